@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// abbreviationClass is the word class SAOL gives abbreviations.
+const abbreviationClass = "förkortning"
+
+// AbbreviationEntry is the record written for an abbreviation: the
+// abbreviation as the headword, what it stands for and its class.
+type AbbreviationEntry struct {
+	Abbreviation string `json:"abbreviation"`
+	Expansion    string `json:"expansion,omitempty"`
+	Class        string `json:"class"`
+}
+
+// expansionPrefix is the "förk. för" a definition of an abbreviation
+// may start with before the expansion.
+var expansionPrefix = regexp.MustCompile(`^(?i:förk(?:\.|ortning)\s+(?:för|av))\s+`)
+
+// parseAbbreviation reads an abbreviation lemma. The expansion is its
+// definition without the "förk. för" in front or the quotes around it.
+func parseAbbreviation(doc *goquery.Document, p SelectorProfile) AbbreviationEntry {
+	headword, _ := p.headword(doc.Selection)
+	entry := AbbreviationEntry{Abbreviation: headword, Class: p.class(doc.Selection)}
+	if p.Definition != "" {
+		expansion := expansionPrefix.ReplaceAllString(cellText(doc.Find(p.Definition).First()), "")
+		entry.Expansion = strings.Trim(expansion, `"'”“‘’ `)
+	}
+	return entry
+}
+
+// WriteAbbreviationsJSON writes abbreviation records to w.
+func WriteAbbreviationsJSON(w io.Writer, entries []AbbreviationEntry) error {
+	return writeVersionedJSON(w, entries)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestParseAbbreviation(t *testing.T) {
+	tests := []struct {
+		html string
+		want AbbreviationEntry
+	}{
+		{`<span class="grundform">t.ex.</span><span class="ordklass">förkortning</span><span class="def">förk. för ”till exempel”</span>`,
+			AbbreviationEntry{Abbreviation: "t.ex.", Expansion: "till exempel", Class: "förkortning"}},
+		{`<span class="grundform">EU</span><span class="ordklass">förkortning</span><span class="def">Europeiska unionen</span>`,
+			AbbreviationEntry{Abbreviation: "EU", Expansion: "Europeiska unionen", Class: "förkortning"}},
+		{`<span class="grundform">kg</span><span class="ordklass">förkortning</span>`,
+			AbbreviationEntry{Abbreviation: "kg", Class: "förkortning"}},
+	}
+	for _, tt := range tests {
+		res, err := extractLemma(context.Background(), LemmaInput{HTML: tt.html}, saolProfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.abbreviation == nil || *res.abbreviation != tt.want {
+			t.Errorf("extractLemma(%s) = %+v, want %+v", tt.html, res.abbreviation, tt.want)
+		}
+	}
+}
+
+func TestWriteAbbreviationsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAbbreviationsJSON(&buf, []AbbreviationEntry{{Abbreviation: "t.ex.", Expansion: "till exempel", Class: "förkortning"}}); err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, "abbreviations.json", buf.Bytes())
+}
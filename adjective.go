@@ -0,0 +1,50 @@
+package main
+
+import "strings"
+
+// AdjectiveSlots are the forms of an adjective by the context SAOL shows
+// them in, stripped of it: pos_en "fin" (en fin + substantiv), pos_ett
+// "fint", pos_plural "fina" (den/det/de fina, also the definite form),
+// komparativ "finare", superlativ "finast" (är finast) and superlativ_best
+// "finaste" (den/det/de finaste).
+type AdjectiveSlots struct {
+	PosEn          string `json:"pos_en,omitempty"`
+	PosEtt         string `json:"pos_ett,omitempty"`
+	PosPlural      string `json:"pos_plural,omitempty"`
+	Komparativ     string `json:"komparativ,omitempty"`
+	Superlativ     string `json:"superlativ,omitempty"`
+	SuperlativBest string `json:"superlativ_best,omitempty"`
+}
+
+// adjectiveSlots fills the slots from the forms grouped by degree, the
+// first form of each shown with the slot's words. It is nil when no form
+// fills a slot, as for the indeclinable "gratis".
+func adjectiveSlots(forms map[string][]Form) *AdjectiveSlots {
+	find := func(degree, led string) string {
+		for _, f := range forms[degree] {
+			form := strings.TrimSuffix(f.Form, attributiveSuffix)
+			if led == "" || strings.HasPrefix(form, led+" ") {
+				return wordForm(form)
+			}
+		}
+		return ""
+	}
+	s := AdjectiveSlots{
+		PosEn:          find("Positiv", "en"),
+		PosEtt:         find("Positiv", "ett"),
+		PosPlural:      find("Positiv", "den/det/de"),
+		Komparativ:     find("Komparativ", ""),
+		Superlativ:     find("Superlativ", "är"),
+		SuperlativBest: find("Superlativ", "den/det/de"),
+	}
+	if s == (AdjectiveSlots{}) {
+		return nil
+	}
+	return &s
+}
+
+// missing returns the slots an adjective with some forms lacks.
+func (s AdjectiveSlots) missing() []string {
+	return missingSlots([]string{"pos_en", "pos_ett", "pos_plural", "komparativ", "superlativ", "superlativ_best"},
+		s.PosEn, s.PosEtt, s.PosPlural, s.Komparativ, s.Superlativ, s.SuperlativBest)
+}
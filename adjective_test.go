@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAdjectiveSlots(t *testing.T) {
+	raw := parseAdjektiv(context.Background(), loadFixture(t, "adjektiv_fin"), saolProfile)
+	got := adjectiveSlots(groupForms("adjektiv", raw))
+	want := AdjectiveSlots{"fin", "fint", "fina", "finare", "finast", "finaste"}
+	if got == nil || *got != want {
+		t.Errorf("adjektiv_fin: slots %+v, want %+v", got, want)
+	}
+
+	raw = parseAdjektiv(context.Background(), loadFixture(t, "adjektiv_gratis"), saolProfile)
+	if got := adjectiveSlots(groupForms("adjektiv", raw)); got != nil {
+		t.Errorf("adjektiv_gratis: slots %+v, want none", got)
+	}
+
+	forms := map[string][]Form{
+		"Positiv":    {{Form: "en stor + substantiv"}, {Form: "ett stort + substantiv"}},
+		"Superlativ": {{Form: "den/det/de största + substantiv"}},
+	}
+	want = AdjectiveSlots{PosEn: "stor", PosEtt: "stort", SuperlativBest: "största"}
+	if got := adjectiveSlots(forms); got == nil || *got != want {
+		t.Errorf("slots %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ankiNoteType is the Anki note type used for one word class: its fields
+// and the function that fills them from a lexicon entry. Definition,
+// Engelska, the English translations, and Ljud, the spoken headword, are
+// always appended as the last fields.
+type ankiNoteType struct {
+	// id identifies the note type to Anki, which keeps the fields of a
+	// note type it has already imported, so it changes whenever the
+	// fields do; ...001 to ...003 were the note types before Engelska
+	// and Ljud.
+	id     int64
+	name   string
+	fields []string
+	values func(e LexiconEntry) []string
+}
+
+// ankiNoteTypes are the word classes that get cards; other classes are
+// left out of the deck.
+var ankiNoteTypes = map[string]ankiNoteType{
+	"substantiv": {
+		id:     1580000000021,
+		name:   "SAOL substantiv",
+		fields: []string{"Obestämd singular", "Genus", "Bestämd singular", "Obestämd plural", "Bestämd plural"},
+		values: func(e LexiconEntry) []string {
+			article := map[string]string{"utrum": "en", "neutrum": "ett"}[e.Gender]
+			return []string{
+				ankiSlot(e, "Nominativ", labelIs("en", "ett")),
+				article,
+				ankiSlot(e, "Nominativ", labelIs("den", "det")),
+				ankiSlot(e, "Nominativ", labelIs("flera")),
+				ankiSlot(e, "Nominativ", labelIs("de")),
+			}
+		},
+	},
+	"verb": {
+		id:     1580000000022,
+		name:   "SAOL verb",
+		fields: []string{"Infinitiv", "Presens", "Preteritum", "Supinum", "Imperativ"},
+		values: func(e LexiconEntry) []string {
+			return []string{
+				e.Headword,
+				ankiSlot(e, "Finita former", tenseIs("presens")),
+				ankiSlot(e, "Finita former", tenseIs("preteritum")),
+				ankiSlot(e, "Infinita former", tenseIs("supinum")),
+				ankiSlot(e, "Finita former", tenseIs("imperativ")),
+			}
+		},
+	},
+	"adjektiv": {
+		id:     1580000000023,
+		name:   "SAOL adjektiv",
+		fields: []string{"Positiv", "Neutrum", "Bestämd/plural", "Komparativ", "Superlativ"},
+		values: func(e LexiconEntry) []string {
+			return []string{
+				e.Headword,
+				ankiSlot(e, "Positiv", ledIs("ett")),
+				ankiSlot(e, "Positiv", ledIs("den/det/de")),
+				ankiSlot(e, "Komparativ", nil),
+				ankiSlot(e, "Superlativ", ledIs("är")),
+			}
+		},
+	},
+}
+
+// ankiDeckID is the ID of the deck every exported card goes into.
+const ankiDeckID = 1580000000000
+
+func labelIs(labels ...string) func(Form) bool {
+	return func(f Form) bool {
+		for _, l := range labels {
+			if f.Label == l {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// tenseIs matches active (or deponent) verb slots of the given tense or mood.
+func tenseIs(tense string) func(Form) bool {
+	return func(f Form) bool {
+		words := strings.Fields(f.Label)
+		return len(words) > 0 && words[0] == tense && !strings.Contains(f.Label, "passiv")
+	}
+}
+
+// ledIs matches adjective slots shown with the given article or verb.
+func ledIs(led string) func(Form) bool {
+	return func(f Form) bool {
+		return strings.HasPrefix(f.Form, led+" ")
+	}
+}
+
+// ankiSlot returns the first slot of section that match accepts (any slot
+// when match is nil) as display text, with its variants after "el.".
+func ankiSlot(e LexiconEntry, section string, match func(Form) bool) string {
+	for _, f := range e.Forms[section] {
+		if match != nil && !match(f) {
+			continue
+		}
+		words := []string{wordForm(f.Form)}
+		for _, v := range f.Variants {
+			words = append(words, wordForm(v))
+		}
+		return strings.Join(words, " el. ")
+	}
+	return ""
+}
+
+var ankiSchema = []string{
+	`CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)`,
+	`CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)`,
+	`CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null)`,
+	`CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null)`,
+	`CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)`,
+	`CREATE INDEX ix_notes_usn on notes (usn)`,
+	`CREATE INDEX ix_cards_usn on cards (usn)`,
+	`CREATE INDEX ix_cards_nid on cards (nid)`,
+	`CREATE INDEX ix_cards_sched on cards (did, queue, due)`,
+	`CREATE INDEX ix_revlog_usn on revlog (usn)`,
+	`CREATE INDEX ix_revlog_cid on revlog (cid)`,
+}
+
+const ankiCSS = `.card { font-family: arial; font-size: 22px; text-align: center; }
+.forms { margin-top: 1em; }
+.def { font-size: 16px; color: #555; }
+.en { font-size: 16px; font-style: italic; }`
+
+// ankiModels returns the "models" JSON of the collection: one note type per
+// word class, each with a single card showing the headword on the front
+// and the principal forms and definition on the back.
+func ankiModels(now int64) map[string]interface{} {
+	models := make(map[string]interface{})
+	for _, nt := range ankiNoteTypes {
+		names := append(append([]string(nil), nt.fields...), "Definition", "Engelska", "Ljud")
+		flds := make([]map[string]interface{}, len(names))
+		var back strings.Builder
+		back.WriteString(`{{FrontSide}}<hr id=answer><div class="forms">`)
+		for i, name := range names {
+			flds[i] = map[string]interface{}{
+				"name": name, "ord": i, "sticky": false, "rtl": false,
+				"font": "Arial", "size": 20, "media": []string{},
+			}
+			if i > 0 && i < len(nt.fields) {
+				fmt.Fprintf(&back, "{{#%s}}<div>%s: {{%s}}</div>{{/%s}}", name, name, name, name)
+			}
+		}
+		back.WriteString(`</div><div class="def">{{Definition}}</div>{{#Engelska}}<div class="en">{{Engelska}}</div>{{/Engelska}}{{Ljud}}`)
+
+		models[strconv.FormatInt(nt.id, 10)] = map[string]interface{}{
+			"id": nt.id, "name": nt.name, "type": 0, "mod": now, "usn": -1,
+			"sortf": 0, "did": ankiDeckID, "flds": flds, "css": ankiCSS,
+			"tmpls": []map[string]interface{}{{
+				"name": "Kort 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+				"qfmt": "{{" + names[0] + "}}",
+				"afmt": back.String(),
+			}},
+			"latexPre":  "\\documentclass[12pt]{article}\n\\begin{document}\n",
+			"latexPost": "\\end{document}",
+			"tags":      []string{}, "vers": []string{},
+			"req": []interface{}{[]interface{}{0, "any", []int{0}}},
+		}
+	}
+	return models
+}
+
+func ankiDecks(now int64) map[string]interface{} {
+	deck := func(id int64, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"id": id, "name": name, "mod": now, "usn": -1, "desc": "",
+			"dyn": 0, "conf": 1, "collapsed": false, "extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0},
+			"lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	return map[string]interface{}{
+		"1":                               deck(1, "Default"),
+		strconv.FormatInt(ankiDeckID, 10): deck(ankiDeckID, "SAOL"),
+	}
+}
+
+var ankiDeckConf = map[string]interface{}{
+	"1": map[string]interface{}{
+		"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60,
+		"autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+		"new": map[string]interface{}{
+			"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500,
+			"order": 1, "perDay": 20, "bury": true, "separate": true,
+		},
+		"rev": map[string]interface{}{
+			"perDay": 100, "ease4": 1.3, "fuzz": 0.05, "maxIvl": 36500,
+			"bury": true, "minSpace": 1, "ivlFct": 1,
+		},
+		"lapse": map[string]interface{}{
+			"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0,
+		},
+	},
+}
+
+// ankiChecksum is the first-field checksum Anki uses to find duplicates.
+func ankiChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// ankiGUID gives each lemma the same note GUID in every export, so a
+// re-imported deck updates notes instead of duplicating them.
+func ankiGUID(id string) string {
+	sum := sha1.Sum([]byte("saol:" + id))
+	return hex.EncodeToString(sum[:8])
+}
+
+// buildAnkiCollection writes entries as a collection.anki2 database at path.
+// Cards are due in the order of entries.
+func buildAnkiCollection(entries []LexiconEntry, path string, now time.Time) (notes int, err error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	for _, stmt := range ankiSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return 0, fmt.Errorf("error creating Anki schema: %w", err)
+		}
+	}
+
+	sec, ms := now.Unix(), now.UnixNano()/int64(time.Millisecond)
+	jsonText := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	conf := map[string]interface{}{
+		"nextPos": 1, "estTimes": true, "activeDecks": []int64{ankiDeckID}, "sortType": "noteFld",
+		"timeLim": 0, "sortBackwards": false, "addToCur": true, "curDeck": ankiDeckID,
+		"newBury": true, "newSpread": 0, "dueCounts": true, "curModel": nil, "collapseTime": 1200,
+	}
+	if _, err := db.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		sec, ms, ms, jsonText(conf), jsonText(ankiModels(sec)), jsonText(ankiDecks(sec)), jsonText(ankiDeckConf)); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, e := range entries {
+		nt, ok := ankiNoteTypes[e.Class]
+		if !ok || e.Headword == "" {
+			continue
+		}
+		values := append(nt.values(e), e.Definition, strings.Join(e.Translations, ", "), ankiSound(e))
+		values[0] = strings.TrimSpace(values[0])
+		if values[0] == "" {
+			values[0] = e.Headword
+		}
+
+		id := ms + int64(notes)
+		if _, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			id, ankiGUID(e.ID), nt.id, sec, " "+e.Class+" ", strings.Join(values, "\x1f"), values[0], ankiChecksum(values[0])); err != nil {
+			return 0, fmt.Errorf("error inserting note for %s: %w", e.ID, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			id, id, ankiDeckID, sec, notes+1); err != nil {
+			return 0, fmt.Errorf("error inserting card for %s: %w", e.ID, err)
+		}
+		notes++
+	}
+	return notes, tx.Commit()
+}
+
+// ankiSound is the Ljud field of an entry: a sound tag playing its audio
+// file, which writeAnki bundles into the package, or "" without audio.
+func ankiSound(e LexiconEntry) string {
+	if e.Audio == "" {
+		return ""
+	}
+	return "[sound:" + filepath.Base(e.Audio) + "]"
+}
+
+// writeAnki writes entries as an Anki package: a zip holding the
+// collection database, the audio files of the entries, stored under their
+// index as Anki expects, and the media map from index to file name.
+func writeAnki(entries []LexiconEntry, filename string) error {
+	dir, err := os.MkdirTemp("", "saol-anki")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	collection := filepath.Join(dir, "collection.anki2")
+	if _, err := buildAnkiCollection(entries, collection, time.Now()); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	if err := addZipFile(zw, "collection.anki2", collection); err != nil {
+		f.Close()
+		return err
+	}
+	media := make(map[string]string)
+	for _, e := range entries {
+		if _, ok := ankiNoteTypes[e.Class]; !ok || e.Audio == "" {
+			continue
+		}
+		index := strconv.Itoa(len(media))
+		if err := addZipFile(zw, index, e.Audio); err != nil {
+			f.Close()
+			return err
+		}
+		media[index] = filepath.Base(e.Audio)
+	}
+	data, err := json.Marshal(media)
+	if err == nil {
+		var w io.Writer
+		if w, err = zw.Create("media"); err == nil {
+			_, err = w.Write(data)
+		}
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func addZipFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
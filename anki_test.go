@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildAnkiCollection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	entries := selectHeadwords(fixtureEntries(t), []string{"man", "knäsätta", "fin", "jag"})
+	n, err := buildAnkiCollection(entries, path, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("got %d notes, want 3 (pronouns have no note type)", n)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT n.flds FROM notes n JOIN cards c ON c.nid = n.id ORDER BY c.due`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var flds string
+		if err := rows.Scan(&flds); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, strings.ReplaceAll(flds, "\x1f", "|"))
+	}
+	want := []string{
+		"man|en|mannen|män|männen|||",
+		"knäsätta|knäsätter|knäsatte|knäsatt|knäsätt|||",
+		"fin|fint|fina|finare|finast|||",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("note fields:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSelectFrequencyRanks(t *testing.T) {
+	// A list without counts ranks by line, as -frequency lists used to.
+	path := filepath.Join(t.TempDir(), "freq.txt")
+	if err := os.WriteFile(path, []byte("och\nen\nbil\n\nhus\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := readFrequencyList(path, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []LexiconEntry{{ID: "hus", Headword: "hus"}, {ID: "bil", Headword: "bil"}, {ID: "en", Headword: "en"}}
+	var got []string
+	for _, e := range selectFrequencyRanks(entries, list, 2, 3) {
+		got = append(got, e.ID)
+	}
+	if strings.Join(got, ",") != "en,bil" {
+		t.Errorf("band 2-3 = %q, want [en bil]", got)
+	}
+	if _, _, err := parseRankRange("3-2"); err == nil {
+		t.Error("band 3-2 accepted")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// articleArchive is a .zip, .tar.gz (.tgz) or .tar of articles: .html files
+// of one article each and .json shards holding arrays of them in the
+// saol_entries.json shape. Members are read straight from the archive,
+// never extracted to disk.
+type articleArchive struct {
+	name string
+	zip  *zip.ReadCloser
+	tar  *tar.Reader
+	file io.ReadCloser
+	gz   *gzip.Reader
+}
+
+// openArticleArchive opens filename, a file or a URL, by its extension. A
+// .zip is read from its central directory at the end, so it has to be a
+// file; tarballs are streamed.
+func openArticleArchive(filename string) (*articleArchive, error) {
+	a := &articleArchive{name: filename}
+	lower := strings.ToLower(filename)
+	if strings.HasSuffix(lower, ".zip") {
+		if isRemoteLocation(filename) {
+			return nil, fmt.Errorf("'%s': a .zip cannot be streamed, download it or use a .tar.gz", filename)
+		}
+		z, err := zip.OpenReader(filename)
+		if err != nil {
+			return nil, err
+		}
+		a.zip = z
+		return a, nil
+	}
+	gzipped := strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+	if !gzipped && !strings.HasSuffix(lower, ".tar") {
+		return nil, fmt.Errorf("unknown archive type '%s', want .zip, .tar.gz, .tgz or .tar", filename)
+	}
+	f, err := openLocation(filename)
+	if err != nil {
+		return nil, err
+	}
+	a.file = f
+	var r io.Reader = f
+	if gzipped {
+		if a.gz, err = gzip.NewReader(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		r = a.gz
+	}
+	a.tar = tar.NewReader(r)
+	return a, nil
+}
+
+// Close closes the archive file.
+func (a *articleArchive) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+	if a.gz != nil {
+		a.gz.Close()
+	}
+	return a.file.Close()
+}
+
+// eachMember calls fn with every regular file of the archive, in archive
+// order, leaving out hidden ones.
+func (a *articleArchive) eachMember(fn func(name string, r io.Reader) error) error {
+	hidden := func(name string) bool {
+		for _, part := range strings.Split(name, "/") {
+			if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+				return true
+			}
+		}
+		return false
+	}
+	if a.zip != nil {
+		for _, f := range a.zip.File {
+			if !f.Mode().IsRegular() || hidden(f.Name) {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+			err = fn(f.Name, r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for {
+		h, err := a.tar.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg || hidden(h.Name) {
+			continue
+		}
+		if err := fn(h.Name, a.tar); err != nil {
+			return err
+		}
+	}
+}
+
+// articles hands out the articles of the archive in archive order, those
+// of a shard in the order of its array. Each is named after its member, a
+// shard's as "shard.json[i]". An article that cannot be read comes with
+// Err set; a broken shard ends with one such article, and a broken archive
+// with one for the rest of it. It stops early when ctx is cancelled.
+func (a *articleArchive) articles(ctx context.Context) <-chan htmlFile {
+	files := make(chan htmlFile)
+	send := func(f htmlFile) error {
+		select {
+		case files <- f:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	go func() {
+		defer close(files)
+		err := a.eachMember(func(name string, r io.Reader) error {
+			switch strings.ToLower(path.Ext(name)) {
+			case ".html", ".htm":
+				data, err := io.ReadAll(r)
+				if err != nil {
+					return send(htmlFile{Path: name, Err: fmt.Errorf("%s: %w", name, err)})
+				}
+				return send(htmlFile{Path: name, HTML: string(data)})
+			case ".json":
+				return sendShard(name, r, send)
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			send(htmlFile{Path: a.name, Err: err})
+		}
+	}()
+	return files
+}
+
+// sendShard sends the articles of a JSON array shard one at a time, as
+// they are decoded.
+func sendShard(name string, r io.Reader, send func(htmlFile) error) error {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		if err == nil {
+			err = fmt.Errorf("not a JSON array")
+		}
+		return send(htmlFile{Path: name, Err: err})
+	}
+	for i := 0; decoder.More(); i++ {
+		var entry InputEntry
+		member := fmt.Sprintf("%s[%d]", name, i)
+		if err := decoder.Decode(&entry); err != nil {
+			return send(htmlFile{Path: member, Err: fmt.Errorf("%s: %w", member, err)})
+		}
+		if err := send(htmlFile{Path: member, HTML: entry.HTML}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// archiveMembers are written to the test archives in this order.
+var archiveMembers = []struct{ name, body string }{
+	{"pages/b.html", "<div>b</div>"},
+	{"pages/a.htm", "<div>a</div>"},
+	{"README.txt", "not an article"},
+	{"pages/.hidden.html", "<div>hidden</div>"},
+	{"shards/1.json", `[{"html":"<div>s0</div>"},{"html":"<div>s1</div>"}]`},
+}
+
+func writeTestZip(t *testing.T, filename string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, m := range archiveMembers {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(m.body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestTarGz(t *testing.T, filename string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "pages/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, m := range archiveMembers {
+		if err := tw.WriteHeader(&tar.Header{Name: m.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(m.body))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(m.body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveArticles(t *testing.T) {
+	want := []htmlFile{
+		{Path: "pages/b.html", HTML: "<div>b</div>"},
+		{Path: "pages/a.htm", HTML: "<div>a</div>"},
+		{Path: "shards/1.json[0]", HTML: "<div>s0</div>"},
+		{Path: "shards/1.json[1]", HTML: "<div>s1</div>"},
+	}
+	dir := t.TempDir()
+	for name, write := range map[string]func(*testing.T, string){"corpus.zip": writeTestZip, "corpus.tar.gz": writeTestTarGz} {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(dir, name)
+			write(t, filename)
+			a, err := openArticleArchive(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer a.Close()
+			var got []htmlFile
+			for f := range a.articles(context.Background()) {
+				got = append(got, f)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("articles = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestArchiveBrokenShard(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "corpus.zip")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("1.json")
+	w.Write([]byte(`[{"html":"<div>ok</div>"},{"html":`))
+	w, _ = zw.Create("2.html")
+	w.Write([]byte("<div>2</div>"))
+	zw.Close()
+	f.Close()
+
+	a, err := openArticleArchive(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	var paths []string
+	var failed []string
+	for f := range a.articles(context.Background()) {
+		paths = append(paths, f.Path)
+		if f.Err != nil {
+			failed = append(failed, f.Path)
+		}
+	}
+	if want := []string{"1.json[0]", "1.json[1]", "2.html"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("articles = %q, want %q", paths, want)
+	}
+	if want := []string{"1.json[1]"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed articles = %q, want %q", failed, want)
+	}
+}
+
+func TestOpenArticleArchiveUnknownType(t *testing.T) {
+	if _, err := openArticleArchive("corpus.rar"); err == nil {
+		t.Error("openArticleArchive(corpus.rar) succeeded")
+	}
+}
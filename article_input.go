@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// errFlattenedInput is returned for flatten or extract output given where
+// the scraped articles belong.
+var errFlattenedInput = errors.New("this is flatten or extract output, not scraped articles; flatten reads saol_entries.json, extract reads flattened_lemmas.json")
+
+// errArticleInput is returned for scraped articles given where flattened
+// lemmas belong.
+var errArticleInput = errors.New("these are scraped articles, not flattened lemmas; run flatten on them first")
+
+// articleReader reads the articles of a saol_entries.json one at a time.
+// Scrape writes an array of articles; an object of articles keyed by
+// anything, a headword or a URL, as other scrapers write them, is read in
+// file order all the same. The shape is told from the first token.
+type articleReader struct {
+	dec   *json.Decoder
+	keyed bool
+	first bool
+	n     int
+}
+
+// newArticleReader reads the opening delimiter of the articles in r.
+func newArticleReader(r io.Reader) (*articleReader, error) {
+	a := &articleReader{dec: json.NewDecoder(r)}
+	tok, err := a.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('['):
+		return a, nil
+	case json.Delim('{'):
+	default:
+		return nil, fmt.Errorf("want an array or an object of articles, got %v", tok)
+	}
+	a.keyed = true
+	if !a.dec.More() {
+		return a, nil
+	}
+	key, err := a.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if key == "schemaVersion" || key == "entries" {
+		return nil, errFlattenedInput
+	}
+	a.first = true
+	return a, nil
+}
+
+// More reports whether there is another article.
+func (a *articleReader) More() bool {
+	return a.dec.More()
+}
+
+// Next returns the next article as it is in the file. The first one is
+// checked for the familyID every flattened lemma has.
+func (a *articleReader) Next() (json.RawMessage, error) {
+	if a.keyed && !a.first {
+		if _, err := a.dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	a.first = false
+	var raw json.RawMessage
+	if err := a.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	a.n++
+	if a.n == 1 {
+		var probe struct {
+			FamilyID int `json:"familyID"`
+		}
+		if json.Unmarshal(raw, &probe) == nil && probe.FamilyID != 0 {
+			return nil, errFlattenedInput
+		}
+	}
+	return raw, nil
+}
+
+// Close reads the closing delimiter of the articles.
+func (a *articleReader) Close() error {
+	want := json.Delim(']')
+	if a.keyed {
+		want = '}'
+	}
+	tok, err := a.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("want %v at the end of the articles, got %v", want, tok)
+	}
+	return nil
+}
+
+// isArticleKey reports whether key, the first key of an object of lemmas,
+// is not the decimal key of a flattened lemma but that of an article.
+func isArticleKey(key json.Token) bool {
+	s, ok := key.(string)
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(s)
+	return err != nil && s != "schemaVersion" && s != "entries"
+}
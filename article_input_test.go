@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArticleReader(t *testing.T) {
+	for _, input := range []string{
+		`[{"html": "<div>bil</div>"}, {"html": "<div>hus</div>"}]`,
+		`{"bil": {"html": "<div>bil</div>"}, "hus": {"html": "<div>hus</div>"}}`,
+	} {
+		a, err := newArticleReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("newArticleReader(%s): %v", input, err)
+		}
+		var got []string
+		for a.More() {
+			raw, err := a.Next()
+			if err != nil {
+				t.Fatalf("Next(%s): %v", input, err)
+			}
+			got = append(got, string(raw))
+		}
+		if err := a.Close(); err != nil {
+			t.Errorf("Close(%s): %v", input, err)
+		}
+		want := []string{`{"html": "<div>bil</div>"}`, `{"html": "<div>hus</div>"}`}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("articles of %s = %q, want %q", input, got, want)
+		}
+	}
+
+	// Flattened lemmas, in any of the shapes flatten has written, are refused.
+	for _, input := range []string{
+		`{"schemaVersion": 2, "entries": []}`,
+		`[{"key": 1, "html": "", "familyID": 1}]`,
+		`{"1": {"html": "", "familyID": 1}}`,
+	} {
+		a, err := newArticleReader(strings.NewReader(input))
+		if err == nil {
+			_, err = a.Next()
+		}
+		if !errors.Is(err, errFlattenedInput) {
+			t.Errorf("articles of %s: %v, want errFlattenedInput", input, err)
+		}
+	}
+
+	if _, err := newArticleReader(strings.NewReader(`"articles"`)); err == nil {
+		t.Error("newArticleReader of a string succeeded")
+	}
+}
+
+func TestForEachLemmaRefusesArticles(t *testing.T) {
+	for _, input := range []string{
+		`[{"html": "<div>bil</div>"}]`,
+		`{"bil": {"html": "<div>bil</div>"}}`,
+	} {
+		err := ForEachLemma(strings.NewReader(input), func(Lemma) error { return nil })
+		if !errors.Is(err, errArticleInput) {
+			t.Errorf("ForEachLemma(%s) = %v, want errArticleInput", input, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ttsBackend speaks a text, for the audio of headwords.
+type ttsBackend interface {
+	// Check reports whether the backend can be used at all.
+	Check(ctx context.Context) error
+	// Synthesize writes the audio of text to w.
+	Synthesize(ctx context.Context, text string, w io.Writer) error
+}
+
+// commandTTS runs a local TTS program, e.g. "espeak-ng -v sv -w {out}
+// {text}". {text} in its arguments is replaced by the text and {out} by a
+// file to write the audio to; without {out} the audio is read from its
+// standard output. Text starting with "-", such as the suffix headword
+// "-aktig", is passed after "--" so it is not taken for an option, which
+// needs {text} to be the last argument.
+type commandTTS struct {
+	args []string
+}
+
+func newCommandTTS(command string) (commandTTS, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return commandTTS{}, errors.New("empty TTS command")
+	}
+	return commandTTS{args}, nil
+}
+
+func (c commandTTS) Check(ctx context.Context) error {
+	_, err := exec.LookPath(c.args[0])
+	return err
+}
+
+func (c commandTTS) Synthesize(ctx context.Context, text string, w io.Writer) error {
+	var out string
+	args := make([]string, 0, len(c.args)+1)
+	for i, a := range c.args {
+		if a == "{text}" && strings.HasPrefix(text, "-") {
+			if i != len(c.args)-1 {
+				return fmt.Errorf("%q starts with '-', which needs {text} to be the last argument of the TTS command", text)
+			}
+			args = append(args, "--")
+		}
+		if strings.Contains(a, "{out}") && out == "" {
+			f, err := os.CreateTemp("", "saol-tts")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			out = f.Name()
+			defer os.Remove(out)
+		}
+		args = append(args, strings.ReplaceAll(strings.ReplaceAll(a, "{text}", text), "{out}", out))
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if out == "" {
+		_, err := w.Write(stdout.Bytes())
+		return err
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// httpTTS fetches audio from an HTTP TTS API: a GET of its URL template
+// with {text} replaced by the query-escaped text, e.g.
+// "http://localhost:5002/api/tts?text={text}".
+type httpTTS struct {
+	template string
+	client   *http.Client
+}
+
+func newHTTPTTS(template string) httpTTS {
+	return httpTTS{template, &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Check fetches the audio of a short word, so a server that is down or
+// rejects the request is caught before every headword fails on it.
+func (h httpTTS) Check(ctx context.Context) error {
+	return h.Synthesize(ctx, "ja", io.Discard)
+}
+
+func (h httpTTS) Synthesize(ctx context.Context, text string, w io.Writer) error {
+	u := strings.ReplaceAll(h.template, "{text}", url.QueryEscape(text))
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", scrapeUserAgent)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// audioFileName is the name of the audio file of a lemma: its ID, with any
+// slash replaced, and ext.
+func audioFileName(id, ext string) string {
+	return strings.ReplaceAll(id, "/", "_") + "." + ext
+}
+
+// audioEnricher stores the audio of each headword in dir and records the
+// file in the entry's Audio field. Files already in dir are reused, so a
+// rerun only synthesizes the headwords that are new.
+type audioEnricher struct {
+	backend ttsBackend
+	dir     string
+	ext     string
+}
+
+func (a audioEnricher) Name() string { return "audio" }
+
+func (a audioEnricher) Check(ctx context.Context) error {
+	if err := createOutputDir(a.dir); err != nil {
+		return err
+	}
+	return a.backend.Check(ctx)
+}
+
+func (a audioEnricher) Missing(e *LexiconEntry) bool { return e.Audio == "" }
+
+func (a audioEnricher) Enrich(ctx context.Context, e *LexiconEntry) error {
+	path := filepath.Join(a.dir, audioFileName(e.ID, a.ext))
+	if _, err := os.Stat(path); err == nil {
+		e.Audio = path
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := a.backend.Synthesize(ctx, e.Headword, &buf); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return fmt.Errorf("no audio for %q", e.Headword)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	e.Audio = path
+	return nil
+}
+
+// newAudioEnricher returns the audio enricher for a TTS command or URL
+// template, whichever is set, or nil when neither is.
+func newAudioEnricher(command, urlTemplate, dir, ext string) (Enricher, error) {
+	var backend ttsBackend
+	switch {
+	case command != "" && urlTemplate != "":
+		return nil, errors.New("set a TTS command or a TTS URL, not both")
+	case command != "":
+		c, err := newCommandTTS(command)
+		if err != nil {
+			return nil, err
+		}
+		backend = c
+	case urlTemplate != "":
+		backend = newHTTPTTS(urlTemplate)
+	default:
+		return nil, nil
+	}
+	return audioEnricher{backend, dir, strings.TrimPrefix(ext, ".")}, nil
+}
+
+// attachAudio sets the Audio field of the entries that have a file in dir,
+// as named by audioFileName with any extension, and returns how many do.
+func attachAudio(entries []LexiconEntry, dir string) (int, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	byBase := make(map[string]string, len(files))
+	for _, f := range files {
+		if !f.IsDir() {
+			name := f.Name()
+			byBase[strings.TrimSuffix(name, filepath.Ext(name))] = filepath.Join(dir, name)
+		}
+	}
+	n := 0
+	for i := range entries {
+		if path, ok := byBase[strings.TrimSuffix(audioFileName(entries[i].ID, ""), ".")]; ok {
+			entries[i].Audio = path
+			n++
+		}
+	}
+	return n, nil
+}
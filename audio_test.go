@@ -0,0 +1,170 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTTS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("text") == "fel" {
+			http.Error(w, "no voice", http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, "audio:"+r.URL.Query().Get("text"))
+	}))
+	defer srv.Close()
+
+	tts := newHTTPTTS(srv.URL + "/tts?text={text}")
+	if err := tts.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tts.Synthesize(context.Background(), "gå ut", &buf); err != nil || buf.String() != "audio:gå ut" {
+		t.Errorf("Synthesize = %q, %v", buf.String(), err)
+	}
+	if err := tts.Synthesize(context.Background(), "fel", io.Discard); err == nil {
+		t.Error("server error accepted")
+	}
+}
+
+func TestCommandTTS(t *testing.T) {
+	tts, err := newCommandTTS("echo {text}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tts.Check(context.Background()); err != nil {
+		t.Skip("no echo:", err)
+	}
+	var buf bytes.Buffer
+	if err := tts.Synthesize(context.Background(), "bil", &buf); err != nil || buf.String() != "bil\n" {
+		t.Errorf("Synthesize = %q, %v", buf.String(), err)
+	}
+
+	// A suffix headword is not an option.
+	buf.Reset()
+	if err := tts.Synthesize(context.Background(), "-aktig", &buf); err != nil || buf.String() != "-- -aktig\n" {
+		t.Errorf("Synthesize(-aktig) = %q, %v, want it after --", buf.String(), err)
+	}
+	if notLast, _ := newCommandTTS("echo {text} -n"); notLast.Synthesize(context.Background(), "-aktig", io.Discard) == nil {
+		t.Error("-aktig passed before another argument")
+	}
+
+	if _, err := newCommandTTS(" "); err == nil {
+		t.Error("empty command accepted")
+	}
+	if _, err := newAudioEnricher("echo", "http://localhost/", "audio", "mp3"); err == nil {
+		t.Error("both backends accepted")
+	}
+}
+
+type fakeTTS struct{ calls int }
+
+func (f *fakeTTS) Check(ctx context.Context) error { return nil }
+
+func (f *fakeTTS) Synthesize(ctx context.Context, text string, w io.Writer) error {
+	f.calls++
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+func TestAudioEnricher(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "audio")
+	tts := &fakeTTS{}
+	enricher := audioEnricher{tts, dir, "mp3"}
+	entries := []LexiconEntry{{ID: "bil", Headword: "bil"}, {ID: "val_2", Headword: "val"}}
+	if degraded := enrichLexicon(context.Background(), entries, []Enricher{enricher}, false, time.Second); len(degraded) != 0 {
+		t.Fatalf("degraded: %v", degraded)
+	}
+	if entries[1].Audio != filepath.Join(dir, "val_2.mp3") {
+		t.Errorf("audio %q", entries[1].Audio)
+	}
+	if data, err := os.ReadFile(entries[1].Audio); err != nil || string(data) != "val" {
+		t.Errorf("audio file %q, %v", data, err)
+	}
+
+	entries[0].Audio = ""
+	enrichLexicon(context.Background(), entries, []Enricher{enricher}, true, time.Second)
+	if tts.calls != 2 || entries[0].Audio == "" {
+		t.Errorf("rerun synthesized again: %d calls, audio %q", tts.calls, entries[0].Audio)
+	}
+
+	fresh := []LexiconEntry{{ID: "bil"}, {ID: "hus"}}
+	if n, err := attachAudio(fresh, dir); err != nil || n != 1 || fresh[0].Audio != filepath.Join(dir, "bil.mp3") || fresh[1].Audio != "" {
+		t.Errorf("attachAudio = %d, %v: %q, %q", n, err, fresh[0].Audio, fresh[1].Audio)
+	}
+}
+
+func TestAnkiAudio(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "man.mp3")
+	if err := os.WriteFile(audio, []byte("mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries := selectHeadwords(fixtureEntries(t), []string{"man", "fin"})
+	entries[0].Audio = audio
+	if got := ankiSound(entries[0]); got != "[sound:man.mp3]" {
+		t.Errorf("ankiSound = %q", got)
+	}
+
+	pkg := filepath.Join(dir, "saol.apkg")
+	if err := writeAnki(entries, pkg); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		if f.Name == "collection.anki2" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+	var media map[string]string
+	if err := json.Unmarshal([]byte(files["media"]), &media); err != nil {
+		t.Fatal(err)
+	}
+	if len(media) != 1 || media["0"] != "man.mp3" || files["0"] != "mp3" {
+		t.Errorf("media %v, files %v", media, files)
+	}
+}
+
+func TestStarDictAudio(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "bil.mp3")
+	if err := os.WriteFile(audio, []byte("mp3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries := selectHeadwords(fixtureEntries(t), []string{"bil"})
+	entries[0].Audio = audio
+	out := filepath.Join(dir, "stardict")
+	if err := writeStarDict(entries, out); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(out, "res", "bil.mp3")); err != nil || string(data) != "mp3" {
+		t.Errorf("res/bil.mp3 = %q, %v", data, err)
+	}
+	dict, err := os.ReadFile(filepath.Join(out, "saol.dict"))
+	if err != nil || !strings.Contains(string(dict), `<audio controls src="bil.mp3"></audio>`) {
+		t.Errorf("article without audio: %q, %v", dict, err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The benchmarks run over the fixtures in testdata, which stand in for a
+// dump: the lemma fixtures for the parsers and the filter, the article
+// fixtures, repeated, for the flatten worker pool. Compare runs with
+// benchstat, e.g.
+//
+//	go test -run '^$' -bench . -count 10 > new.txt
+
+// sampleArticles returns n articles made from the article fixtures.
+func sampleArticles(b *testing.B, n int) []InputEntry {
+	b.Helper()
+	names := fixtureNames(b, "article_")
+	articles := make([]InputEntry, n)
+	for i := range articles {
+		articles[i] = InputEntry{HTML: readFixture(b, names[i%len(names)])}
+	}
+	return articles
+}
+
+// sampleFlattened returns a flattened lemma file of the lemma fixtures,
+// without cached classes, repeated n times.
+func sampleFlattened(b *testing.B, n int) []byte {
+	b.Helper()
+	var lemmas []LemmaOutput
+	for i := 0; i < n; i++ {
+		for _, name := range fixtureNames(b, "") {
+			lemmas = append(lemmas, LemmaOutput{Key: len(lemmas) + 1, HTML: readFixture(b, name), FamilyID: i + 1, Source: "saol"})
+		}
+	}
+	data, err := json.Marshal(lemmas)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+func BenchmarkParsers(b *testing.B) {
+	for _, name := range fixtureNames(b, "") {
+		if strings.HasPrefix(name, "article_") || strings.HasPrefix(name, "so_") {
+			continue
+		}
+		doc := loadFixture(b, name)
+		parse, ok := parserFor(saolProfile.class(doc.Selection))
+		if !ok {
+			continue
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parse(context.Background(), doc, saolProfile)
+			}
+		})
+	}
+}
+
+func BenchmarkWorkerPool(b *testing.B) {
+	articles := sampleArticles(b, 200)
+	profiles := allProfiles()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jobs := make(chan Job, channelBufferSize)
+		results := make(chan Result, channelBufferSize)
+		var wg sync.WaitGroup
+		for w := 1; w <= runtime.NumCPU(); w++ {
+			wg.Add(1)
+			go worker(context.Background(), w, profiles, 0, jobs, results, &wg)
+		}
+		reorder := newReorderBuffer(reorderWindow)
+		go func() {
+			for j, article := range articles {
+				reorder.reserve(context.Background())
+				jobs <- Job{Index: j, Data: article}
+			}
+			close(jobs)
+			wg.Wait()
+			close(results)
+		}()
+
+		for res := range results {
+			reorder.push(res, func(Result) {})
+		}
+	}
+}
+
+func BenchmarkFilterLemmas(b *testing.B) {
+	data := sampleFlattened(b, 50)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := FilterLemmas(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// browsePageSize is how many lemmas the result list shows at a time.
+const browsePageSize = 20
+
+// browser is the state of the browse terminal interface: the last search,
+// the page of its results on screen and the lemma opened from it, if any.
+// It reads a command per line, so it needs no terminal library and works
+// over a pipe as well.
+type browser struct {
+	store   *memStore
+	query   string
+	results []LexiconEntry
+	page    int
+	open    *LexiconEntry
+	message string
+}
+
+// search replaces the results with the lemmas having a form that begins
+// with query, closest first, or failing that a form within two edits of
+// it. A query starting with "/" is a regular expression a headword or form
+// must match instead.
+func (b *browser) search(query string) {
+	b.query, b.page, b.open, b.message = query, 0, nil, ""
+	b.results = nil
+	if re, ok := strings.CutPrefix(query, "/"); ok {
+		s, err := newLemmaSearch(re, false, true, []string{searchHeadword, searchForm}, nil)
+		if err != nil {
+			b.message = err.Error()
+			return
+		}
+		for _, e := range b.store.entries {
+			if _, ok := s.match(e); ok {
+				b.results = append(b.results, e)
+			}
+		}
+		return
+	}
+	candidates := lookupForms(b.store, query, lookupQuery{Prefix: true}, 0)
+	if len(candidates) == 0 {
+		candidates = lookupForms(b.store, query, lookupQuery{Fuzzy: 2}, 0)
+		if len(candidates) > 0 {
+			b.message = "no form begins with " + query + ", showing near misses"
+		}
+	}
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		for _, id := range c.Lemmas {
+			if !seen[id] {
+				seen[id] = true
+				b.results = append(b.results, b.store.entries[b.store.byID[id]])
+			}
+		}
+	}
+}
+
+// pages is the number of pages of results, at least one.
+func (b *browser) pages() int {
+	return max(1, (len(b.results)+browsePageSize-1)/browsePageSize)
+}
+
+// handle carries out a command line and reports whether to quit: a word
+// searches, a number opens that lemma of the list, :n and :p page, an
+// empty line goes back to the list and :q quits.
+func (b *browser) handle(line string) (quit bool) {
+	line = strings.TrimSpace(line)
+	b.message = ""
+	switch line {
+	case ":q":
+		return true
+	case "":
+		b.open = nil
+	case ":n":
+		if b.page+1 < b.pages() {
+			b.page++
+		}
+		b.open = nil
+	case ":p":
+		if b.page > 0 {
+			b.page--
+		}
+		b.open = nil
+	case ":h", "?":
+		b.message = "word: search   /regexp: match headwords and forms   number: open   :n :p: page   enter: back   :q: quit"
+	default:
+		if n, err := strconv.Atoi(line); err == nil {
+			if n < 1 || n > len(b.results) {
+				b.message = fmt.Sprintf("no lemma %d", n)
+				return false
+			}
+			b.open = &b.results[n-1]
+			return false
+		}
+		b.search(line)
+	}
+	return false
+}
+
+// render draws the screen: the search line, then the open lemma's
+// inflection table or the page of results, then any message.
+func (b *browser) render(w io.Writer) {
+	fmt.Fprintf(w, "search: %s", b.query)
+	if b.query != "" {
+		fmt.Fprintf(w, "  (%d lemmas, page %d/%d)", len(b.results), b.page+1, b.pages())
+	}
+	fmt.Fprint(w, "\n\n")
+	if b.open != nil {
+		fmt.Fprint(w, entryText(*b.open))
+	} else {
+		start := b.page * browsePageSize
+		end := min(start+browsePageSize, len(b.results))
+		for i := start; i < end; i++ {
+			e := b.results[i]
+			fmt.Fprintf(w, "%3d  %s%s  %-12s %s\n", i+1, e.Headword, strings.Repeat(" ", max(0, 20-utf8.RuneCountInString(e.Headword))), e.Class, truncateText(e.Definition, 50))
+		}
+	}
+	if b.message != "" {
+		fmt.Fprintf(w, "\n%s\n", b.message)
+	}
+	fmt.Fprint(w, "\n> ")
+}
+
+// truncateText cuts s to at most n runes, marking a cut with an ellipsis.
+func truncateText(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// browse runs the interface, reading commands from in until :q or the end
+// of input. clear is written before each screen.
+func (b *browser) browse(in io.Reader, out io.Writer, clear string) error {
+	bw := bufio.NewWriter(out)
+	scanner := bufio.NewScanner(in)
+	for {
+		bw.WriteString(clear)
+		b.render(bw)
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		if b.handle(scanner.Text()) {
+			return nil
+		}
+	}
+}
+
+// runBrowse lets a linguist search the lexicon and look at the inflection
+// tables the parsers made of it, without opening the JSON files.
+func runBrowse(args []string) {
+	flags := flag.NewFlagSet("browse", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to browse")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool browse [-in file] [word]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	b := &browser{store: newMemStore(entries), message: "type a word to search, ? for help"}
+	if flags.NArg() > 0 {
+		b.search(strings.Join(flags.Args(), " "))
+	}
+	clear := ""
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		clear = "\x1b[H\x1b[2J"
+	}
+	if err := b.browse(os.Stdin, os.Stdout, clear); err != nil {
+		fatal("could not browse", "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func browseEntries() []LexiconEntry {
+	return []LexiconEntry{
+		{ID: "bil", Headword: "bil", Class: "substantiv", Definition: "motorfordon", Forms: map[string][]Form{
+			"Nominativ": {{Form: "en bil", Label: "singular obestämd"}, {Form: "bilen", Label: "singular bestämd"}},
+			"Genitiv":   {{Form: "bils", Label: "singular obestämd"}},
+		}},
+		{ID: "bila", Headword: "bila", Class: "verb"},
+		{ID: "sil", Headword: "sil", Class: "substantiv"},
+	}
+}
+
+func TestEntryText(t *testing.T) {
+	want := `bil  substantiv
+motorfordon
+
+Nominativ
+  en bil  singular obestämd
+  bilen   singular bestämd
+
+Genitiv
+  bils    singular obestämd
+`
+	if got := entryText(browseEntries()[0]); got != want {
+		t.Errorf("entryText:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBrowser(t *testing.T) {
+	b := &browser{store: newMemStore(browseEntries())}
+	b.handle("bil")
+	if len(b.results) != 2 || b.results[0].ID != "bil" || b.results[1].ID != "bila" {
+		t.Fatalf("search bil = %v", b.results)
+	}
+	b.handle("2")
+	if b.open == nil || b.open.ID != "bila" {
+		t.Fatalf("open 2 = %v", b.open)
+	}
+	b.handle("")
+	if b.open != nil {
+		t.Error("enter did not go back to the list")
+	}
+	b.handle("9")
+	if b.open != nil || !strings.Contains(b.message, "no lemma 9") {
+		t.Errorf("open 9: open %v, message %q", b.open, b.message)
+	}
+	b.handle("bli")
+	if len(b.results) == 0 || b.results[0].ID != "bil" || b.message == "" {
+		t.Errorf("near misses of bli = %v, message %q", b.results, b.message)
+	}
+	b.handle("/.il")
+	if len(b.results) != 2 || b.results[0].ID != "bil" || b.results[1].ID != "sil" {
+		t.Errorf("/.il = %v", b.results)
+	}
+	if !b.handle(":q") {
+		t.Error(":q did not quit")
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	b := &browser{store: newMemStore(browseEntries())}
+	var out bytes.Buffer
+	if err := b.browse(strings.NewReader("bil\n1\n"), &out, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"search: bil  (2 lemmas, page 1/1)", "  1  bil ", "  bilen   singular bestämd"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	if got := truncateText("motorfordon", 6); got != "motor…" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateText("bil", 6); got != "bil" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CheckIssue is an inconsistency between the outputs of two stages.
+type CheckIssue struct {
+	Check   string `json:"check"`
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+// slotCounts is how many slots the paradigm of each class with a slot
+// model has: NounSlots, AdjectiveSlots and the PrincipalParts of verbs.
+var slotCounts = map[string]int{"substantiv": 8, "adjektiv": 6, "verb": 4}
+
+// checkedEntry is what check reads of an entry of a class file.
+type checkedEntry struct {
+	Inflection     string            `json:"inflection"`
+	PluralOnly     bool              `json:"pluralOnly"`
+	Uncountable    bool              `json:"uncountable"`
+	Defective      []string          `json:"defective"`
+	Slots          map[string]string `json:"slots"`
+	PrincipalParts map[string]string `json:"principalParts"`
+	Forms          json.RawMessage   `json:"forms"`
+}
+
+// formCount counts the forms of an entry, a list for nouns and lists by
+// section for the other classes.
+func (e checkedEntry) formCount() int {
+	var list []json.RawMessage
+	if json.Unmarshal(e.Forms, &list) == nil {
+		return len(list)
+	}
+	var sections map[string][]json.RawMessage
+	json.Unmarshal(e.Forms, &sections)
+	n := 0
+	for _, forms := range sections {
+		n += len(forms)
+	}
+	return n
+}
+
+// checkSlots reports the entries of a class file whose filled and
+// defective slots do not add up to the paradigm of the class, and those
+// with no forms that are not marked "ingen böjning".
+func checkSlots(class, file string, entries []checkedEntry) []CheckIssue {
+	var issues []CheckIssue
+	for i, e := range entries {
+		if e.Inflection == "none" {
+			continue
+		}
+		if e.formCount() == 0 {
+			issues = append(issues, CheckIssue{"slots", file, fmt.Sprintf("entry %d has no forms and is not marked ingen böjning", i)})
+			continue
+		}
+		want, ok := slotCounts[class]
+		if !ok {
+			continue
+		}
+		slots := e.Slots
+		if class == "verb" {
+			slots = e.PrincipalParts
+		}
+		if len(slots) == 0 {
+			issues = append(issues, CheckIssue{"slots", file, fmt.Sprintf("entry %d has forms but none fills a slot", i)})
+			continue
+		}
+		if e.PluralOnly || e.Uncountable {
+			want /= 2
+		}
+		filled := 0
+		for _, form := range slots {
+			if form != "" {
+				filled++
+			}
+		}
+		if filled+len(e.Defective) != want {
+			issues = append(issues, CheckIssue{"slots", file, fmt.Sprintf("entry %d has %d slots and %d defective, want %d in all", i, filled, len(e.Defective), want)})
+		}
+	}
+	return issues
+}
+
+// flattenedFamilies returns the familyID of every lemma in the flattened
+// lemmas file.
+func flattenedFamilies(filename string) (map[int]bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	families := make(map[int]bool)
+	err = ForEachLemma(f, func(l Lemma) error {
+		families[l.FamilyID] = true
+		return nil
+	})
+	return families, err
+}
+
+// referencedFamilies returns the familyIDs a JSON file refers to: the
+// values of every "familyID" field and every "families" list in it.
+func referencedFamilies(data []byte) ([]int, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var ids []int
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				switch n := value.(type) {
+				case float64:
+					if key == "familyID" {
+						ids = append(ids, int(n))
+					}
+				case []interface{}:
+					if key == "families" {
+						for _, id := range n {
+							if id, ok := id.(float64); ok {
+								ids = append(ids, int(id))
+							}
+						}
+					}
+				}
+				walk(value)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// checkStages cross-checks flatten and extract output: every class file in
+// dir has as many entries as extract should have written, and those have
+// the slots of their class; and every familyID in refFiles, such as
+// lexicon.json or senses.json, is a lemma of the flattened lemmas. The
+// expected counts are those the extract manifest records, or without one
+// (manifest "") the lemmas of each parsed class in the flattened lemmas,
+// less those extract quarantined. That assumes extract ran without -only,
+// -exclude, -sample or a lemma range; with those, give the manifest.
+// A missing quarantine file is no quarantine, and one older than the class
+// files is left over from an earlier run. Extract output written with
+// -combined is not read.
+func checkStages(flattened, quarantine, manifest, dir string, refFiles []string) ([]CheckIssue, error) {
+	var expected map[string]int
+	var err error
+	if manifest != "" {
+		expected, err = manifestCounts(manifest)
+	} else {
+		expected, err = flattenedCounts(flattened, quarantine, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []CheckIssue
+	for _, class := range parsedClasses() {
+		file := filepath.Join(dir, outputFor(class).file)
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			issues = append(issues, CheckIssue{"lemmas", file, fmt.Sprintf("missing, want the %d %s lemmas", expected[class], class)})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, raw, err := readVersionedEntries(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		var entries []checkedEntry
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if len(entries) != expected[class] {
+			issues = append(issues, CheckIssue{"lemmas", file, fmt.Sprintf("%d entries, want %d %s lemmas", len(entries), expected[class], class)})
+		}
+		issues = append(issues, checkSlots(class, file, entries)...)
+	}
+
+	if len(refFiles) > 0 {
+		families, err := flattenedFamilies(flattened)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range refFiles {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			ids, err := referencedFamilies(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			unknown := make(map[int]bool)
+			for _, id := range ids {
+				if !families[id] && !unknown[id] {
+					unknown[id] = true
+					issues = append(issues, CheckIssue{"families", file, fmt.Sprintf("familyID %d is not in %s", id, flattened)})
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// manifestCounts reads the lemmas written per class from an extract
+// manifest.
+func manifestCounts(filename string) (map[string]int, error) {
+	data, err := readLocation(filename)
+	if err != nil {
+		return nil, err
+	}
+	var m extractManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error decoding manifest '%s': %w", filename, err)
+	}
+	if err := checkSchemaVersion(m.SchemaVersion); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return m.Counts, nil
+}
+
+// flattenedCounts counts the lemmas of each parsed class in the flattened
+// lemmas, less those in quarantine if it is at least as new as the class
+// files in dir.
+func flattenedCounts(flattened, quarantine, dir string) (map[string]int, error) {
+	f, err := os.Open(flattened)
+	if err != nil {
+		return nil, err
+	}
+	filtered, err := FilterLemmas(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	expected := make(map[string]int)
+	for _, l := range filtered {
+		class, err := Lemma{LemmaInput: l}.WordClass()
+		if err != nil {
+			return nil, err
+		}
+		expected[class]++
+	}
+
+	info, err := os.Stat(quarantine)
+	if errors.Is(err, fs.ErrNotExist) {
+		return expected, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, class := range parsedClasses() {
+		file := filepath.Join(dir, outputFor(class).file)
+		if out, err := os.Stat(file); err == nil && info.ModTime().Before(out.ModTime()) {
+			slog.Warn("ignoring a quarantine older than the class files", "file", quarantine, "classFile", file)
+			return expected, nil
+		}
+	}
+	data, err := os.ReadFile(quarantine)
+	if err != nil {
+		return nil, err
+	}
+	_, raw, err := readVersionedEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", quarantine, err)
+	}
+	var quarantined []LemmaInput
+	if err := json.Unmarshal(raw, &quarantined); err != nil {
+		return nil, fmt.Errorf("%s: %w", quarantine, err)
+	}
+	for _, l := range quarantined {
+		if class, err := (Lemma{LemmaInput: l}).WordClass(); err == nil {
+			expected[class]--
+		}
+	}
+	return expected, nil
+}
+
+// runCheck cross-validates the output of flatten, extract and the stages
+// after them, and exits with status 1 when they disagree.
+func runCheck(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas, the flatten output")
+	dir := flags.String("dir", ".", "directory of the extract output, one file per class")
+	quarantine := flags.String("quarantine", "quarantined_lemmas.json", "lemmas extract quarantined, if any; ignored if older than the class files")
+	manifest := flags.String("manifest", "", "extract manifest (extract -manifest) to take the expected lemma counts from, instead of counting the flattened lemmas less -quarantine")
+	format := flags.String("format", "text", "output format: text, or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool check [-in file] [-dir dir] [-quarantine file | -manifest file] [-format text|json] [file referring to familyIDs...]")
+		fmt.Fprintln(os.Stderr, "e.g. saoltool check lexicon.json senses.json relations.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	issues, err := checkStages(*in, *quarantine, *manifest, *dir, flags.Args())
+	if err != nil {
+		fatal("could not check", "err", err)
+	}
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, issues)
+	} else {
+		err = writeCheckText(os.Stdout, issues)
+	}
+	if err != nil {
+		fatal("could not write check issues", "err", err)
+	}
+	if len(issues) > 0 {
+		slog.Warn("stages disagree", "issues", len(issues))
+		os.Exit(1)
+	}
+	slog.Info("stages agree", "in", *in, "dir", *dir)
+}
+
+// writeCheckText writes a line per issue: the check, the file and the
+// message, separated by tabs.
+func writeCheckText(w io.Writer, issues []CheckIssue) error {
+	bw := bufio.NewWriter(w)
+	for _, i := range issues {
+		fmt.Fprintf(bw, "%s\t%s\t%s\n", i.Check, i.File, strings.TrimSpace(i.Message))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeStages writes the fixtures named as flattened lemmas, families 1,
+// 2, ..., and extracts them into dir as extract would.
+func writeStages(t *testing.T, dir string, names ...string) string {
+	t.Helper()
+	var lemmas []LemmaOutput
+	parsed := make(map[string][][]string)
+	for i, name := range names {
+		html := readFixture(t, name)
+		lemmas = append(lemmas, LemmaOutput{Key: i + 1, HTML: html, FamilyID: i + 1})
+		res, err := extractLemma(context.Background(), LemmaInput{HTML: html, FamilyID: i + 1}, saolProfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed[res.class] = append(parsed[res.class], res.forms)
+	}
+	flattened := filepath.Join(dir, "flattened_lemmas.json")
+	if err := saveFile(flattened, func(w io.Writer) error { return writeVersionedJSON(w, lemmas) }); err != nil {
+		t.Fatal(err)
+	}
+	for _, class := range parsedClasses() {
+		out := outputFor(class)
+		if err := saveFile(filepath.Join(dir, out.file), func(w io.Writer) error { return out.write(w, parsed[class], false) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return flattened
+}
+
+func TestCheckStages(t *testing.T) {
+	dir := t.TempDir()
+	flattened := writeStages(t, dir, "substantiv_bil", "verb_knasatta", "adjektiv_fin", "adjektiv_gratis")
+	lexicon := filepath.Join(dir, "lexicon.json")
+	if err := os.WriteFile(lexicon, []byte(`[{"id": "bil", "familyID": 1}, {"id": "fin", "familyID": 3}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := checkStages(flattened, filepath.Join(dir, "quarantined_lemmas.json"), "", dir, []string{lexicon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("consistent stages flagged: %+v", issues)
+	}
+
+	// A lemma missing from verbs.json, a noun whose slots do not add up
+	// and a familyID flatten never wrote.
+	verbs := filepath.Join(dir, outputFor("verb").file)
+	nouns := filepath.Join(dir, outputFor("substantiv").file)
+	os.WriteFile(verbs, []byte(`{"schemaVersion": 2, "entries": []}`), 0644)
+	os.WriteFile(nouns, []byte(`{"schemaVersion": 2, "entries": [{"class": "substantiv", "slots": {"sg_indef_nom": "bil"}, "forms": [{"form": "bil"}]}]}`), 0644)
+	os.WriteFile(lexicon, []byte(`{"schemaVersion": 2, "entries": [{"id": "bil", "familyID": 9}], "families": [1, 8]}`), 0644)
+	issues, err = checkStages(flattened, filepath.Join(dir, "quarantined_lemmas.json"), "", dir, []string{lexicon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, i := range issues {
+		got = append(got, i.Check+" "+filepath.Base(i.File))
+	}
+	want := []string{"slots nouns.json", "lemmas verbs.json", "families lexicon.json", "families lexicon.json"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues %q, want %q: %+v", got, want, issues)
+	}
+
+	// Quarantined lemmas are not expected in the class files.
+	quarantine := filepath.Join(dir, "quarantined_lemmas.json")
+	if err := saveQuarantine(quarantine, []LemmaInput{{HTML: readFixture(t, "verb_knasatta"), FamilyID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	issues, err = checkStages(flattened, quarantine, "", dir, nil)
+	if err != nil || len(issues) != 1 || issues[0].Check != "slots" {
+		t.Errorf("with quarantine: %+v, %v", issues, err)
+	}
+
+	// A quarantine from an earlier run is ignored.
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(quarantine, old, old)
+	issues, err = checkStages(flattened, quarantine, "", dir, nil)
+	if err != nil || len(issues) != 2 || issues[1].Check != "lemmas" {
+		t.Errorf("with a stale quarantine: %+v, %v", issues, err)
+	}
+
+	// The manifest's counts win over the flattened lemmas, as extract
+	// -only, -exclude and -sample leave lemmas out.
+	manifest := filepath.Join(dir, "extract_manifest.json")
+	counts := map[string]int{"substantiv": 1, "adjektiv": 2}
+	if err := saveExtractManifest(manifest, flattened, counts, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	issues, err = checkStages(flattened, quarantine, manifest, dir, nil)
+	if err != nil || len(issues) != 1 || issues[0].Check != "slots" {
+		t.Errorf("with a manifest: %+v, %v", issues, err)
+	}
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
-	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -19,6 +24,7 @@ const (
 	outputFile      = "flattened_lemmas.json"
 	numWorkers      = 0
 	channelBufferSize = 100
+	reorderWindow   = 1000
 )
 
 
@@ -33,17 +39,163 @@ type Job struct {
 
 type Result struct {
 	Index      int
+	Source     string
 	LemmaHTMLs []string
+	// Classes and Headwords are those of the lemmas in LemmaHTMLs.
+	Classes    []string
+	Headwords  []string
 	Error      error
+	// Quarantined is the article when it failed or ran past the per-entry
+	// deadline, for retry to have another go at.
+	Quarantined *InputEntry
+	// EncodingProblems are those of the article's HTML; its lemmas are
+	// normalized to NFC whatever they are.
+	EncodingProblems []string
+	// Articles is how many article blocks the entry held; the lemmas of
+	// all of them are in LemmaHTMLs.
+	Articles int
 }
 
+// lemmas returns the lemmas of a flattened article, without keys.
+func (res Result) lemmas() []LemmaOutput {
+	lemmas := make([]LemmaOutput, len(res.LemmaHTMLs))
+	for i, html := range res.LemmaHTMLs {
+		lemmas[i] = LemmaOutput{
+			HTML:     html,
+			FamilyID: res.Index + 1,
+			Source:   res.Source,
+			Class:    res.Classes[i],
+			Headword: res.Headwords[i],
+		}
+	}
+	return lemmas
+}
+
+// quarantinedArticle is one article in the flatten quarantine file: the
+// article as it was read, its index in the input and why it failed.
+type quarantinedArticle struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+	InputEntry
+}
+
+// LemmaOutput is one element of the flattened_lemmas.json array: the HTML
+// of a lemma, its key (its position in the file, from 1), the family ID of
+// the article it came from (its position in the input, from 1, whatever
+// failed before it) and the selector profile it was split out with.
+// Class and Headword are read while splitting, so the later stages can
+// filter lemmas without parsing their HTML again. DuplicateOf is the key of
+// an identical lemma earlier in the file, with -dedup link.
 type LemmaOutput struct {
+	Key      int    `json:"key"`
 	HTML     string `json:"html"`
 	FamilyID int    `json:"familyID"`
+	Source   string `json:"source,omitempty"`
+	Class    string `json:"class,omitempty"`
+	Headword string `json:"headword,omitempty"`
+
+	DuplicateOf int `json:"duplicateOf,omitempty"`
 }
 
-func main() {
-	log.Println("Starting JSON HTML processing for flattened lemmas...")
+// runFlatten splits every SAOL article in -in, inputFile by default, into
+// its lemmas and writes them, tagged with the index of the article they
+// came from, to -out, outputFile by default; either may be a URL.
+// The selector profile of each article is detected unless -dictionary
+// names one; every lemma records the profile it was split out with.
+// Articles that fail or take longer than -entry-timeout are written to
+// -quarantine instead, for retry, and an interrupt stops the run without writing outputFile.
+// With -incremental, articles unchanged since the last run keep the lemmas
+// they had then and only the others are split. With -dedup, lemmas
+// identical to an earlier one are dropped or linked to it. Lemmas are
+// written as soon as every article before theirs is done, so memory use is
+// bounded by reorderWindow articles, not by the dump size. -offset, -limit
+// and -index-range split only a stretch of the articles, under the family
+// IDs they would have in a full run.
+// With -html-dir the articles are the .html files of a directory tree
+// instead, one per file, in path order, and with -archive the members of
+// an archive, .html files and JSON shards, in archive order. With -csv
+// they are the rows of a CSV or TSV file, the HTML in -html-column.
+func runFlatten(args []string) {
+	flags := flag.NewFlagSet("flatten", flag.ExitOnError)
+	inPath := flags.String("in", inputFile, "scraped articles to flatten, a file or an http(s)://, s3:// or gs:// URL")
+	outPath := flags.String("out", outputFile, "where to write the flattened lemmas, a file or a URL")
+	dictionary := flags.String("dictionary", "auto", "selector profile of the articles in "+inputFile+": saol, so, one from -profiles, or auto to detect it per article")
+	profilesFile := flags.String("profiles", "", "JSON file of extra selector profiles, for a redesigned site")
+	entryTimeout := flags.Duration("entry-timeout", 0, "give up on an article after this long and quarantine it (0 for no limit)")
+	quarantineFile := flags.String("quarantine", "quarantined_entries.json", "where to write articles that failed or ran past -entry-timeout, for retry")
+	dedup := flags.String("dedup", dedupOff, "lemmas repeated under several articles: off, drop the later copies, or link them to the first with duplicateOf")
+	dedupReport := flags.String("dedup-report", "duplicate_lemmas.json", "where -dedup reports the copies it found")
+	incremental := flags.Bool("incremental", false, "reuse the lemmas of the articles unchanged since the last run, from "+outputFile+" and -state")
+	stateFile := flags.String("state", "flatten_state.json", "where to keep the article hashes -incremental compares against")
+	encodingReport := flags.String("encoding-report", "encoding_report.json", "where to list the articles with invalid UTF-8, mojibake or text not in NFC")
+	archiveFile := flags.String("archive", "", "read the articles from a .zip, .tar.gz or .tar of .html files and JSON array shards, in archive order, instead of "+inputFile)
+	csvFile := flags.String("csv", "", "read the articles from the rows of a CSV file, or a TSV file by its .tsv extension, instead of "+inputFile)
+	htmlColumn := flags.String("html-column", "html", "the -csv column holding the HTML: its name in the header row, or for a file without one its number, from 1")
+	htmlDir := flags.String("html-dir", "", "read the articles from the .html files under this directory, one article per file in path order, instead of "+inputFile)
+	gapsFile := flags.String("gaps", "family_gaps.json", "where to list the familyIDs left without lemmas, by articles that failed, were quarantined or were empty")
+	perf := addPerfFlags(flags)
+	span := addSpanFlags(flags)
+	flags.Parse(args)
+	defer perf.start()()
+	inputs := 0
+	for _, in := range []string{*archiveFile, *csvFile, *htmlDir} {
+		if in != "" {
+			inputs++
+		}
+	}
+	if inputs > 1 {
+		fatal("give one of -archive, -csv and -html-dir")
+	}
+
+	deduper, err := newLemmaDeduper(*dedup)
+	if err != nil {
+		fatal("invalid -dedup", "err", err)
+	}
+	start, end, err := span.bounds()
+	if err != nil {
+		fatal("invalid article range", "err", err)
+	}
+	if *incremental && span.set() {
+		fatal("-incremental needs every article; leave out -offset, -limit and -index-range")
+	}
+	var previous map[string]Result
+	if *incremental {
+		if *dedup == dedupDrop {
+			fatal("-incremental needs every lemma of the last run; use -dedup link instead of drop")
+		}
+		if previous, err = loadPreviousFlatten(*stateFile, *outPath); err != nil {
+			fatal("could not load the last run for -incremental", "err", err)
+		}
+		slog.Info("loaded the last run", "articles", len(previous))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *profilesFile != "" {
+		if err := loadSelectorProfiles(*profilesFile); err != nil {
+			fatal("could not load selector profiles", "file", *profilesFile, "err", err)
+		}
+	}
+	profiles := allProfiles()
+	if *dictionary != "auto" {
+		profile, err := profileFor(*dictionary)
+		if err != nil {
+			fatal("unknown -dictionary", "err", err)
+		}
+		profiles = []SelectorProfile{profile}
+	}
+
+	switch {
+	case *archiveFile != "":
+		slog.Info("flattening articles", "archive", *archiveFile)
+	case *csvFile != "":
+		slog.Info("flattening articles", "csv", *csvFile, "column", *htmlColumn)
+	case *htmlDir != "":
+		slog.Info("flattening articles", "dir", *htmlDir)
+	default:
+		slog.Info("flattening articles", "file", *inPath)
+	}
 
 	workers := numWorkers
 	if workers <= 0 {
@@ -52,159 +204,401 @@ func main() {
 			workers = 1
 		}
 	}
-	log.Printf("Using %d worker goroutines", workers)
-
-	file, err := os.Open(inputFile)
-	if err != nil {
-		log.Fatalf("Error opening input file '%s'. Error: %v", inputFile, err)
+	slog.Debug("starting workers", "workers", workers)
+
+	var htmlFiles []string
+	var archive *articleArchive
+	var table *csvArticles
+	var file io.ReadCloser
+	switch {
+	case *archiveFile != "":
+		if archive, err = openArticleArchive(*archiveFile); err != nil {
+			fatal("could not open input archive", "file", *archiveFile, "err", err)
+		}
+		defer archive.Close()
+	case *csvFile != "":
+		if table, err = openCSVArticles(*csvFile, *htmlColumn); err != nil {
+			fatal("could not open input table", "file", *csvFile, "err", err)
+		}
+		defer table.Close()
+	case *htmlDir != "":
+		if htmlFiles, err = walkHTMLDir(ctx, *htmlDir, workers); err != nil {
+			fatal("could not walk input directory", "dir", *htmlDir, "err", err)
+		}
+		slog.Info("found article files", "files", len(htmlFiles), "dir", *htmlDir)
+	default:
+		if file, err = openLocation(*inPath); err != nil {
+			fatal("could not open input", "file", *inPath, "err", err)
+		}
+		defer file.Close()
 	}
-	defer file.Close()
 
-	outFile, err := os.Create(outputFile)
+	// A file is written next to the output and renamed over it when done;
+	// an upload is only completed when done.
+	tmpFile := *outPath + ".tmp"
+	if isRemoteLocation(*outPath) {
+		tmpFile = *outPath
+	} else {
+		defer os.Remove(tmpFile)
+	}
+	outFile, err := createLocation(tmpFile)
 	if err != nil {
-		log.Fatalf("Error creating output file '%s': %v", outputFile, err)
+		fatal("could not create output", "file", tmpFile, "err", err)
 	}
-	defer outFile.Close()
+	out := newLemmaArrayWriter(outFile)
 
 	jobs := make(chan Job, channelBufferSize)
 	results := make(chan Result, channelBufferSize)
+	reorder := newReorderBuffer(reorderWindow)
+	reorder.next = start
 	var wg sync.WaitGroup
 
-	log.Println("Launching workers...")
 	for w := 1; w <= workers; w++ {
 		wg.Add(1)
-		go worker(w, jobs, results, &wg)
+		go worker(ctx, w, profiles, *entryTimeout, jobs, results, &wg)
 	}
 
 	var collectorWg sync.WaitGroup
-	collectedResults := make([]Result, 0)
+	articles := 0
+	quarantined := make([]quarantinedArticle, 0)
+	var encodingIssues []encodingIssue
+	var gaps []familyGap
+	multiArticles := 0
+	var flattened []int
+	var writeErr error
 	collectorWg.Add(1)
 	go func() {
 		defer collectorWg.Done()
 		for res := range results {
-			if res.Error != nil {
-				log.Printf("Worker Error (Original Index %d): %v. Skipping this entry.", res.Index, res.Error)
-				continue
-			}
-			collectedResults = append(collectedResults, res)
+			reorder.push(res, func(res Result) {
+				entriesProcessed.inc("stage", "flatten")
+				if gap, ok := resultGap(res); ok {
+					gaps = append(gaps, gap)
+				}
+				if res.Quarantined != nil {
+					entryErrors.inc("stage", "flatten")
+					slog.Warn("quarantining article", "index", res.Index, "err", res.Error)
+					quarantined = append(quarantined, quarantinedArticle{Index: res.Index, Reason: res.Error.Error(), InputEntry: *res.Quarantined})
+					return
+				}
+				if res.Error != nil {
+					entryErrors.inc("stage", "flatten")
+					slog.Warn("skipping article", "index", res.Index, "err", res.Error)
+					return
+				}
+				articles++
+				flattened = append(flattened, res.Index)
+				if len(res.EncodingProblems) > 0 {
+					encodingIssues = append(encodingIssues, encodingIssue{Index: res.Index, Headwords: res.Headwords, Problems: res.EncodingProblems})
+				}
+				if res.Articles > 1 {
+					multiArticles++
+					slog.Debug("entry holds several articles", "index", res.Index, "articles", res.Articles, "headwords", res.Headwords)
+				}
+				for _, entry := range res.lemmas() {
+					lemmasByClass.inc("stage", "flatten", "class", entry.Class)
+					if err := deduper.write(out, entry); err != nil && writeErr == nil {
+						writeErr = err
+					}
+				}
+			})
 		}
-		log.Println("Result collection finished.")
+		slog.Debug("result collection finished")
 	}()
 
-	log.Println("Reading input JSON and dispatching jobs...")
-	decoder := json.NewDecoder(file)
-	token, err := decoder.Token()
-	if err != nil {
-		log.Fatalf("Error reading initial JSON token: %v", err)
-	}
-	if delim, ok := token.(json.Delim); !ok || delim != '[' {
-		log.Fatalf("Expected JSON array start '[', but got: %T %v", token, token)
-	}
-
+	slog.Debug("dispatching jobs")
 	index := 0
-	for decoder.More() {
-		var entry InputEntry
-		err := decoder.Decode(&entry)
+	reused := 0
+	var hashes []string
+	pastEnd := false
+	dispatch := func(entry InputEntry) {
+		hash := hashArticle(entry.HTML)
+		hashes = append(hashes, hash)
+		if res, ok := previous[hash]; ok {
+			res.Index = index
+			results <- res
+			reused++
+			return
+		}
+		jobs <- Job{Index: index, Data: entry}
+	}
+	if archive != nil || table != nil || *htmlDir != "" {
+		readCtx, stopReading := context.WithCancel(ctx)
+		var files <-chan htmlFile
+		switch {
+		case archive != nil:
+			files = archive.articles(readCtx)
+		case table != nil:
+			files = table.articles(readCtx)
+		default:
+			if end >= 0 && end < len(htmlFiles) {
+				htmlFiles, pastEnd = htmlFiles[:end], true
+			}
+			for ; index < start && index < len(htmlFiles); index++ {
+				hashes = append(hashes, "")
+			}
+			files = readHTMLFiles(readCtx, htmlFiles[index:], workers)
+		}
+		for f := range files {
+			if end >= 0 && index >= end {
+				pastEnd = true
+				break
+			}
+			if index < start {
+				hashes = append(hashes, "")
+				index++
+				continue
+			}
+			if reorder.reserve(ctx) != nil {
+				break
+			}
+			if f.Err != nil {
+				results <- Result{Index: index, Error: f.Err}
+				hashes = append(hashes, "")
+			} else {
+				dispatch(InputEntry{HTML: f.HTML})
+			}
+			index++
+		}
+		stopReading()
+		if pastEnd {
+			slog.Info("stopped at the end of the article range", "index", index)
+		}
+	} else {
+		input, err := newArticleReader(file)
 		if err != nil {
-			if err == io.EOF {
-				log.Println("Reached end of JSON stream unexpectedly inside array.")
+			fatal("could not read input", "file", *inPath, "err", err)
+		}
+
+		for input.More() {
+			if end >= 0 && index >= end {
+				pastEnd = true
 				break
 			}
-			log.Printf("Error decoding JSON object at index %d: %v. Skipping.", index, err)
-			var raw json.RawMessage
-			_ = decoder.Decode(&raw)
+			if index < start {
+				if _, err := input.Next(); err != nil {
+					if errors.Is(err, errFlattenedInput) {
+						fatal("could not read input", "file", *inPath, "err", err)
+					}
+					slog.Warn("could not skip article", "file", *inPath, "index", index, "err", err)
+					break
+				}
+				hashes = append(hashes, "")
+				index++
+				continue
+			}
+			if reorder.reserve(ctx) != nil {
+				break
+			}
+			var entry InputEntry
+			raw, err := input.Next()
+			if err == nil {
+				err = json.Unmarshal(raw, &entry)
+			}
+			if err != nil {
+				if errors.Is(err, errFlattenedInput) {
+					fatal("could not read input", "file", *inPath, "err", err)
+				}
+				if err == io.EOF {
+					slog.Warn("input ends inside the array", "file", *inPath, "index", index)
+					results <- Result{Index: index, Error: err}
+					hashes = append(hashes, "")
+					break
+				}
+				results <- Result{Index: index, Error: fmt.Errorf("error decoding JSON object: %w", err)}
+				hashes = append(hashes, "")
+				index++
+				continue
+			}
+			dispatch(entry)
 			index++
-			continue
 		}
-		jobs <- Job{Index: index, Data: entry}
-		index++
+		if pastEnd {
+			slog.Info("stopped at the end of the article range", "index", index)
+		} else if err := input.Close(); err != nil {
+			slog.Warn("could not read the end of the input", "file", *inPath, "err", err)
+		} else {
+			slog.Debug("finished reading input")
+		}
 	}
-
-	token, err = decoder.Token()
-	if err != nil && err != io.EOF {
-		log.Printf("Warning: Error reading final JSON token: %v", err)
-	} else if delim, ok := token.(json.Delim); ok && delim == ']' {
-		log.Println("Finished reading JSON array.")
-	} else if token != nil {
-		log.Printf("Warning: Expected JSON array end ']', but got: %T %v", token, token)
+	if *incremental {
+		slog.Info("reused unchanged articles", "reused", reused, "split", index-reused)
 	}
 
 	close(jobs)
-	log.Println("All jobs dispatched. Waiting for workers...")
+	slog.Debug("all jobs dispatched, waiting for workers")
 
 	wg.Wait()
-	log.Println("All workers finished.")
 
 	close(results)
-	log.Println("Results channel closed. Waiting for collector...")
+	slog.Debug("all workers finished, waiting for collector")
 
 	collectorWg.Wait()
-	log.Println("Collector finished.")
 
-	log.Println("Processing collected results into final format...")
+	if err := ctx.Err(); err != nil {
+		outFile.Abort()
+		os.Remove(tmpFile)
+		fatal("flatten cancelled, output not written", "file", *outPath, "err", err)
+	}
+	if len(quarantined) > 0 {
+		if err := saveQuarantine(*quarantineFile, quarantined); err != nil {
+			fatal("could not save quarantine", "file", *quarantineFile, "err", err)
+		}
+		slog.Info("quarantined articles; have another go with retry", "articles", len(quarantined), "file", *quarantineFile)
+	}
 
-	sort.Slice(collectedResults, func(i, j int) bool {
-		return collectedResults[i].Index < collectedResults[j].Index
-	})
+	if err := saveFamilyGaps(*gapsFile, gaps); err != nil {
+		fatal("could not save family gaps", "file", *gapsFile, "err", err)
+	}
+	if len(gaps) > 0 {
+		slog.Info("left familyIDs without lemmas; the IDs after them keep the positions of their articles", "gaps", len(gaps), "file", *gapsFile)
+	}
 
-	finalOutput := make(map[int]LemmaOutput)
-	outputKey := 1
-	totalLemmasProcessed := 0
-	for _, res := range collectedResults {
-		familyID := res.Index + 1
-		for _, lemmaHTML := range res.LemmaHTMLs {
-			entry := LemmaOutput{
-				HTML:     lemmaHTML,
-				FamilyID: familyID,
-			}
-			finalOutput[outputKey] = entry
-			outputKey++
-			totalLemmasProcessed++
+	if len(encodingIssues) > 0 {
+		if err := saveEncodingReport(*encodingReport, encodingIssues); err != nil {
+			fatal("could not save encoding report", "file", *encodingReport, "err", err)
+		}
+		slog.Warn("found encoding problems; the lemmas were normalized to NFC, but check the report", "articles", len(encodingIssues), "file", *encodingReport)
+	}
+
+	if multiArticles > 0 {
+		slog.Info("split entries holding several articles; their lemmas share a familyID", "entries", multiArticles)
+	}
+
+	if len(deduper.report) > 0 {
+		if err := deduper.saveReport(*dedupReport); err != nil {
+			fatal("could not save dedup report", "file", *dedupReport, "err", err)
 		}
+		slog.Info("found duplicate lemmas", "lemmas", len(deduper.report), "dedup", *dedup, "file", *dedupReport)
 	}
-	log.Printf("Prepared final map with %d individual lemma entries.", totalLemmasProcessed)
 
-	log.Println("Writing output JSON file...")
-	encoder := json.NewEncoder(outFile)
-	encoder.SetIndent("", "  ")
-	err = encoder.Encode(finalOutput)
+	if writeErr == nil {
+		writeErr = out.close()
+	}
+	if writeErr != nil {
+		outFile.Abort()
+	} else {
+		writeErr = outFile.Close()
+	}
+	if writeErr == nil && tmpFile != *outPath {
+		writeErr = os.Rename(tmpFile, *outPath)
+	}
+	if writeErr != nil {
+		fatal("could not write output", "file", *outPath, "err", writeErr)
+	}
+	if err := saveFlattenState(*stateFile, hashes, flattened); err != nil {
+		fatal("could not save flatten state", "file", *stateFile, "err", err)
+	}
+
+	slog.Info("flattened articles", "articles", articles, "lemmas", out.n, "file", *outPath)
+}
+
+// lemmaArrayWriter writes flattened_lemmas.json one LemmaOutput at a time,
+// as the reorder buffer hands the lemmas out, numbering them from 1.
+type lemmaArrayWriter struct {
+	w *bufio.Writer
+	n int
+}
+
+func newLemmaArrayWriter(w io.Writer) *lemmaArrayWriter {
+	return &lemmaArrayWriter{w: bufio.NewWriter(w)}
+}
+
+// write gives entry the next key and appends it to the array.
+func (a *lemmaArrayWriter) write(entry LemmaOutput) error {
+	a.n++
+	entry.Key = a.n
+	data, err := json.MarshalIndent(entry, "    ", "  ")
 	if err != nil {
-		log.Fatalf("Error encoding final JSON output: %v", err)
+		return err
 	}
+	sep := ",\n    "
+	if a.n == 1 {
+		sep = fmt.Sprintf("{\n  \"schemaVersion\": %d,\n  \"entries\": [\n    ", outputSchemaVersion)
+	}
+	a.w.WriteString(sep)
+	_, err = a.w.Write(data)
+	return err
+}
 
-	log.Printf("Successfully processed %d original entries resulting in %d lemma entries, saved to '%s'.", len(collectedResults), totalLemmasProcessed, outputFile)
+// close ends the array and the envelope and flushes them; the output is
+// what writeVersionedJSON would have written.
+func (a *lemmaArrayWriter) close() error {
+	if a.n == 0 {
+		fmt.Fprintf(a.w, "{\n  \"schemaVersion\": %d,\n  \"entries\": []\n}\n", outputSchemaVersion)
+	} else {
+		a.w.WriteString("\n  ]\n}\n")
+	}
+	return a.w.Flush()
 }
 
-func worker(id int, jobs <-chan Job, results chan<- Result, wg *sync.WaitGroup) {
+// worker splits articles into lemmas with the first of profiles that
+// matches each article. An article none of them matches, or that takes
+// longer than entryTimeout (if set), comes back with an error and
+// quarantined.
+// Once ctx is done the remaining jobs are answered with its error.
+func worker(ctx context.Context, id int, profiles []SelectorProfile, entryTimeout time.Duration, jobs <-chan Job, results chan<- Result, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for job := range jobs {
-		doc, err := goquery.NewDocumentFromReader(strings.NewReader(job.Data.HTML))
-		if err != nil {
-			results <- Result{Index: job.Index, Error: fmt.Errorf("failed to parse HTML: %w", err)}
+		if err := ctx.Err(); err != nil {
+			results <- Result{Index: job.Index, Error: err}
 			continue
 		}
-
-		articleSelection := doc.Find("div.article")
-		if articleSelection.Length() == 0 {
-			results <- Result{Index: job.Index, LemmaHTMLs: []string{}}
-			continue
+		start := time.Now()
+		res, err := withEntryDeadline(ctx, entryTimeout, func(ctx context.Context) (Result, error) {
+			return splitArticle(ctx, id, profiles, job), nil
+		})
+		parseDuration.observe(time.Since(start), "stage", "flatten")
+		if err != nil {
+			res = Result{Index: job.Index, Error: err}
 		}
+		if res.Error != nil && ctx.Err() == nil {
+			res.Quarantined = &job.Data
+		}
+		results <- res
+	}
+}
 
-		lemmaSelection := articleSelection.First().Find("div.lemma")
-		lemmasHTML := make([]string, 0, lemmaSelection.Length())
+// splitArticle returns the lemma HTMLs of one article.
+func splitArticle(ctx context.Context, id int, profiles []SelectorProfile, job Job) Result {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(job.Data.HTML))
+	if err != nil {
+		return Result{Index: job.Index, Error: fmt.Errorf("failed to parse HTML: %w", err)}
+	}
 
-	
-		lemmaSelection.Each(func(i int, s *goquery.Selection) { 
-			html, err := s.Html()
-			if err != nil {
-				log.Printf("Worker %d: Error getting HTML for a lemma within original index %d: %v. Skipping lemma.", id, job.Index, err)
-				return 
-			}
-			lemmasHTML = append(lemmasHTML, html)
-		})
+	hasArticle := false
+	for _, p := range profiles {
+		hasArticle = hasArticle || doc.Find(p.Article).Length() > 0
+	}
+	if !hasArticle {
+		return Result{Index: job.Index, LemmaHTMLs: []string{}}
+	}
 
-		
-		results <- Result{Index: job.Index, LemmaHTMLs: lemmasHTML}
+	profile, err := detectProfile(doc, profiles)
+	if err != nil {
+		return Result{Index: job.Index, LemmaHTMLs: []string{}, Error: err}
 	}
+
+	articleSelection := doc.Find(profile.Article)
+	lemmaSelection := articleSelection.Find(profile.Lemma)
+	res := Result{Index: job.Index, Source: profile.Name, LemmaHTMLs: make([]string, 0, lemmaSelection.Length()), EncodingProblems: encodingProblems(job.Data.HTML), Articles: articleSelection.Length()}
+
+	lemmaSelection.EachWithBreak(func(i int, s *goquery.Selection) bool {
+		html, err := s.Html()
+		if err != nil {
+			slog.Warn("skipping lemma without HTML", "worker", id, "index", job.Index, "err", err)
+			return true
+		}
+		headword, _ := profile.headword(s)
+		res.LemmaHTMLs = append(res.LemmaHTMLs, nfc(html))
+		res.Classes = append(res.Classes, profile.class(s))
+		res.Headwords = append(res.Headwords, headword)
+		return ctx.Err() == nil
+	})
+	if err := ctx.Err(); err != nil {
+		return Result{Index: job.Index, Error: err}
+	}
+
+	return res
 }
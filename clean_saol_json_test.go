@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// runWorker feeds a single SAOL job through worker and returns its result.
+func runWorker(index int, html string) Result {
+	return runLayoutWorker(index, html, selectorProfiles["saol"])
+}
+
+// runLayoutWorker is runWorker for articles in the given profile.
+func runLayoutWorker(index int, html string, profile SelectorProfile) Result {
+	jobs := make(chan Job, 1)
+	results := make(chan Result, 1)
+	var wg sync.WaitGroup
+
+	jobs <- Job{Index: index, Data: InputEntry{HTML: html}}
+	close(jobs)
+
+	wg.Add(1)
+	worker(context.Background(), 1, []SelectorProfile{profile}, 0, jobs, results, &wg)
+	wg.Wait()
+	return <-results
+}
+
+func TestWorker(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		wantLemmas int
+	}{
+		{"article_bil", 2},
+		{"article_empty", 0},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			res := runWorker(i, readFixture(t, tt.fixture))
+			if res.Error != nil {
+				t.Fatalf("unexpected error: %v", res.Error)
+			}
+			if res.Index != i {
+				t.Errorf("Index = %d, want %d", res.Index, i)
+			}
+			if len(res.LemmaHTMLs) != tt.wantLemmas {
+				t.Errorf("got %d lemmas, want %d", len(res.LemmaHTMLs), tt.wantLemmas)
+			}
+			checkGolden(t, tt.fixture, res.LemmaHTMLs)
+		})
+	}
+}
+
+func TestWorkerCachesClassAndHeadword(t *testing.T) {
+	res := runWorker(0, readFixture(t, "article_bil"))
+	if !reflect.DeepEqual(res.Classes, []string{"substantiv", "verb"}) || !reflect.DeepEqual(res.Headwords, []string{"bil", "bila"}) {
+		t.Errorf("classes %q, headwords %q", res.Classes, res.Headwords)
+	}
+}
+
+func TestWorkerSplitsEveryArticle(t *testing.T) {
+	html := readFixture(t, "article_bil") + `<div class="article"><div class="lemma"><span class="grundform">bilen</span> <span class="ordklass">substantiv</span></div></div>`
+	res := runWorker(0, html)
+	if res.Articles != 2 || !reflect.DeepEqual(res.Headwords, []string{"bil", "bila", "bilen"}) {
+		t.Errorf("articles %d, headwords %q, want 2 and bil, bila, bilen", res.Articles, res.Headwords)
+	}
+}
+
+func TestLemmaArrayWriter(t *testing.T) {
+	lemmas := []LemmaOutput{
+		{HTML: "<p>a</p>", FamilyID: 1, Source: "saol", Class: "verb", Headword: "a"},
+		{HTML: "<p>b</p>", FamilyID: 3, Source: "so"},
+	}
+	for _, n := range []int{0, 2} {
+		var buf bytes.Buffer
+		out := newLemmaArrayWriter(&buf)
+		for _, l := range lemmas[:n] {
+			if err := out.write(l); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := out.close(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := make([]LemmaOutput, n)
+		for i := range want {
+			want[i] = lemmas[i]
+			want[i].Key = i + 1
+		}
+		var wantJSON bytes.Buffer
+		writeVersionedJSON(&wantJSON, want)
+		if got := buf.String(); got != wantJSON.String()+"\n" {
+			t.Errorf("%d lemmas: wrote\n%s\nwant\n%s", n, got, wantJSON.String())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// The orders -sort puts lemmas in: that of the input, or Swedish
+// alphabetical order, with å, ä and ö after z and case and accents only
+// breaking ties.
+const (
+	sortInput   = "input"
+	sortSwedish = "sv"
+)
+
+func addSortFlag(flags *flag.FlagSet) *string {
+	return flags.String("sort", sortInput, "order of the lemmas: input, or sv for Swedish alphabetical order (å, ä, ö after z)")
+}
+
+// checkSortOrder reports a -sort value that is not an order.
+func checkSortOrder(order string) error {
+	if order != sortInput && order != sortSwedish {
+		return fmt.Errorf("unknown sort order %q, want %s or %s", order, sortInput, sortSwedish)
+	}
+	return nil
+}
+
+// sortEntriesSwedish sorts entries by headword in Swedish collation, then
+// by homograph number; equal lemmas keep their order.
+func sortEntriesSwedish(entries []LexiconEntry) {
+	c := collate.New(language.Swedish)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if cmp := c.CompareString(entries[i].Headword, entries[j].Headword); cmp != 0 {
+			return cmp < 0
+		}
+		return entries[i].Homograph < entries[j].Homograph
+	})
+}
+
+// sortLemmasSwedish sorts flattened lemmas by headword in Swedish
+// collation. Lemmas from files flattened before the headword was cached
+// have it read from their HTML.
+func sortLemmasSwedish(lemmas []LemmaInput) {
+	keys := make([]string, len(lemmas))
+	for i, l := range lemmas {
+		keys[i], _ = Lemma{LemmaInput: l}.HeadwordText()
+	}
+	idx := make([]int, len(lemmas))
+	for i := range idx {
+		idx[i] = i
+	}
+	c := collate.New(language.Swedish)
+	sort.SliceStable(idx, func(i, j int) bool { return c.CompareString(keys[idx[i]], keys[idx[j]]) < 0 })
+	sorted := make([]LemmaInput, len(lemmas))
+	for i, j := range idx {
+		sorted[i] = lemmas[j]
+	}
+	copy(lemmas, sorted)
+}
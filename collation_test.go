@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortEntriesSwedish(t *testing.T) {
+	entries := []LexiconEntry{
+		{ID: "ö", Headword: "ö"},
+		{ID: "val_2", Headword: "val", Homograph: 2},
+		{ID: "ål", Headword: "ål"},
+		{ID: "zon", Headword: "zon"},
+		{ID: "äng", Headword: "äng"},
+		{ID: "Anna", Headword: "Anna"},
+		{ID: "val_1", Headword: "val", Homograph: 1},
+		{ID: "abc", Headword: "abc"},
+	}
+	sortEntriesSwedish(entries)
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.ID)
+	}
+	if want := []string{"abc", "Anna", "val_1", "val_2", "zon", "ål", "äng", "ö"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted = %q, want %q", got, want)
+	}
+}
+
+func TestSortLemmasSwedish(t *testing.T) {
+	lemmas := []LemmaInput{
+		{Headword: "örn", FamilyID: 1},
+		{HTML: `<span class="grundform">ära</span>`, FamilyID: 2},
+		{Headword: "ånga", FamilyID: 3},
+		{Headword: "zebra", FamilyID: 4},
+	}
+	sortLemmasSwedish(lemmas)
+	var got []int
+	for _, l := range lemmas {
+		got = append(got, l.FamilyID)
+	}
+	if want := []int{4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sorted family IDs = %v, want %v", got, want)
+	}
+}
+
+func TestCheckSortOrder(t *testing.T) {
+	for _, order := range []string{sortInput, sortSwedish} {
+		if err := checkSortOrder(order); err != nil {
+			t.Error(err)
+		}
+	}
+	if checkSortOrder("en") == nil {
+		t.Error("checkSortOrder(en): want an error")
+	}
+}
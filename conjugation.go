@@ -0,0 +1,100 @@
+package main
+
+import "strings"
+
+// PrincipalParts are the forms a Swedish verb is learnt by, all active
+// (or, for a deponent like "hoppas", the -s forms).
+type PrincipalParts struct {
+	Infinitive string `json:"infinitive,omitempty"`
+	Present    string `json:"present,omitempty"`
+	Preterite  string `json:"preterite,omitempty"`
+	Supine     string `json:"supine,omitempty"`
+}
+
+func (p PrincipalParts) complete() bool {
+	return p.Infinitive != "" && p.Present != "" && p.Preterite != "" && p.Supine != ""
+}
+
+// missing returns the principal parts a verb with some of them lacks.
+func (p PrincipalParts) missing() []string {
+	return missingSlots([]string{"infinitive", "present", "preterite", "supine"}, p.Infinitive, p.Present, p.Preterite, p.Supine)
+}
+
+// principalParts picks the principal parts out of the grouped forms of a
+// verb, the first form of each labelled "<tense> aktiv" or, in the
+// tables of deponents, just "<tense>". Variants are left out.
+func principalParts(forms map[string][]Form) PrincipalParts {
+	find := func(section, tense string) string {
+		for _, f := range forms[section] {
+			if f.Label == tense+" aktiv" || f.Label == tense {
+				return f.Form
+			}
+		}
+		return ""
+	}
+	return PrincipalParts{
+		Infinitive: find("Infinita former", "infinitiv"),
+		Present:    find("Finita former", "presens"),
+		Preterite:  find("Finita former", "preteritum"),
+		Supine:     find("Infinita former", "supinum"),
+	}
+}
+
+// irregularVerbs are the verbs the grammars list as irregular whose
+// principal parts would otherwise pass for one of the groups (vara, var,
+// varit looks strong; ha, hade, haft weak).
+var irregularVerbs = map[string]bool{
+	"vara": true, "ha": true, "heta": true, "kunna": true, "vilja": true,
+	"skola": true, "veta": true, "säga": true, "lägga": true, "göra": true,
+}
+
+// conjugationGroup classifies a verb by its principal parts into the four
+// conjugations of Swedish grammar, "1" (simma, simmade, simmat), "2" (köra,
+// körde, kört; läsa, läste, läst), "3" (bo, bodde, bott) and "4", the
+// strong verbs (skriva, skrev, skrivit), or "irregular" (göra, gjorde,
+// gjort; gå, gick, gått). It is "" without all four parts.
+func conjugationGroup(p PrincipalParts) string {
+	if !p.complete() {
+		return ""
+	}
+	inf, pret, sup := p.Infinitive, p.Preterite, p.Supine
+	if strings.HasSuffix(inf, "s") && strings.HasSuffix(pret, "s") && strings.HasSuffix(sup, "s") {
+		// A deponent is conjugated like its active counterpart.
+		inf, pret, sup = inf[:len(inf)-1], pret[:len(pret)-1], sup[:len(sup)-1]
+	}
+	if irregularVerbs[inf] {
+		return "irregular"
+	}
+
+	if !strings.HasSuffix(inf, "a") {
+		if pret == inf+"dde" && sup == inf+"tt" {
+			return "3"
+		}
+		return "irregular"
+	}
+	stem := strings.TrimSuffix(inf, "a")
+	short := shortenedStem(stem)
+	weak := false
+	for _, ending := range []string{"de", "te", "dde", "tte"} {
+		weak = weak || pret == short+ending
+	}
+	switch {
+	case pret == stem+"ade" && sup == stem+"at":
+		return "1"
+	case weak && (sup == short+"t" || sup == short+"tt"):
+		return "2"
+	case strings.HasSuffix(sup, "it") && !strings.HasSuffix(pret, "de") && !strings.HasSuffix(pret, "te"):
+		return "4"
+	}
+	return "irregular"
+}
+
+// shortenedStem drops the second of a doubled final consonant, as the
+// preterite and supine do (känna, kände, känt), or else a final d or t,
+// which they merge with their ending (använda, använde, använt).
+func shortenedStem(stem string) string {
+	if n := len(stem); n >= 2 && stem[n-1] == stem[n-2] {
+		return stem[:n-1]
+	}
+	return strings.TrimRight(stem, "dt")
+}
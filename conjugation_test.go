@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConjugationGroup(t *testing.T) {
+	tests := []struct {
+		parts PrincipalParts
+		want  string
+	}{
+		{PrincipalParts{"simma", "simmar", "simmade", "simmat"}, "1"},
+		{PrincipalParts{"hoppas", "hoppas", "hoppades", "hoppats"}, "1"},
+		{PrincipalParts{"köra", "kör", "körde", "kört"}, "2"},
+		{PrincipalParts{"läsa", "läser", "läste", "läst"}, "2"},
+		{PrincipalParts{"känna", "känner", "kände", "känt"}, "2"},
+		{PrincipalParts{"använda", "använder", "använde", "använt"}, "2"},
+		{PrincipalParts{"möta", "möter", "mötte", "mött"}, "2"},
+		{PrincipalParts{"trivas", "trivs", "trivdes", "trivts"}, "2"},
+		{PrincipalParts{"bo", "bor", "bodde", "bott"}, "3"},
+		{PrincipalParts{"skriva", "skriver", "skrev", "skrivit"}, "4"},
+		{PrincipalParts{"finnas", "finns", "fanns", "funnits"}, "4"},
+		{PrincipalParts{"komma", "kommer", "kom", "kommit"}, "4"},
+		{PrincipalParts{"vara", "är", "var", "varit"}, "irregular"},
+		{PrincipalParts{"ha", "har", "hade", "haft"}, "irregular"},
+		{PrincipalParts{"gå", "går", "gick", "gått"}, "irregular"},
+		{PrincipalParts{"knäsätta", "knäsätter", "knäsatte", "knäsatt"}, "irregular"},
+		{PrincipalParts{"simma", "simmar", "", "simmat"}, ""},
+	}
+	for _, tt := range tests {
+		if got := conjugationGroup(tt.parts); got != tt.want {
+			t.Errorf("conjugationGroup(%v) = %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
+
+func TestPrincipalParts(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		want        PrincipalParts
+		conjugation string
+	}{
+		{"verb_simma", PrincipalParts{"simma", "simmar", "simmade", "simmat"}, "1"},
+		{"verb_hoppas", PrincipalParts{"hoppas", "hoppas", "hoppades", "hoppats"}, "1"},
+		{"verb_komma_ihag", PrincipalParts{"komma", "kommer", "kom", "kommit"}, "4"},
+		{"verb_angra_sig", PrincipalParts{"ångra", "ångrar", "ångrade", "ångrat"}, "1"},
+		{"verb_knasatta", PrincipalParts{"knäsätta", "knäsätter", "knäsatte", "knäsatt"}, "irregular"},
+	}
+	for _, tt := range tests {
+		raw := parseVerbForms(context.Background(), loadFixture(t, tt.fixture), saolProfile)
+		particle, reflexive := verbParticles(raw)
+		parts := principalParts(groupForms("verb", stripVerbParticles(raw, particle, reflexive)))
+		if parts != tt.want {
+			t.Errorf("%s: principal parts %+v, want %+v", tt.fixture, parts, tt.want)
+		}
+		if got := conjugationGroup(parts); got != tt.conjugation {
+			t.Errorf("%s: conjugation %q, want %q", tt.fixture, got, tt.conjugation)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxCoverageExamples is how many lemmas a dropped-row group lists.
+const maxCoverageExamples = 5
+
+// coverageReport records, over an extract run, how often each selector of
+// a profile matched and which table rows no parser branch took, say a noun
+// row with three cells. Without it such rows vanish from the output
+// without a trace.
+type coverageReport struct {
+	mu        sync.Mutex
+	lemmas    int
+	selectors map[selectorKey]*SelectorCoverage
+	consumed  int
+	dropped   map[droppedKey]*DroppedRows
+}
+
+type selectorKey struct{ profile, field string }
+
+type droppedKey struct {
+	class, section string
+	cells          int
+}
+
+// SelectorCoverage is how often one selector matched: Matches elements in
+// total, in Lemmas of the lemmas parsed with its profile. Missing is the
+// number of those lemmas it matched nothing in.
+type SelectorCoverage struct {
+	Profile  string `json:"profile"`
+	Field    string `json:"field"`
+	Selector string `json:"selector"`
+	Matches  int    `json:"matches"`
+	Lemmas   int    `json:"lemmas"`
+	Missing  int    `json:"missing"`
+}
+
+// DroppedRows counts the table rows of one shape the parser of Class did
+// not consume, with a few of the lemmas they were in.
+type DroppedRows struct {
+	Class    string          `json:"class"`
+	Section  string          `json:"section"`
+	Cells    int             `json:"cells"`
+	Rows     int             `json:"rows"`
+	Examples []DroppedSample `json:"examples"`
+}
+
+// DroppedSample is one dropped row: the lemma and the row's cell text.
+type DroppedSample struct {
+	FamilyID int    `json:"familyID"`
+	Headword string `json:"headword"`
+	Text     string `json:"text"`
+}
+
+// CoverageSummary is the report coverageReport writes.
+type CoverageSummary struct {
+	Lemmas       int                `json:"lemmas"`
+	ConsumedRows int                `json:"consumedRows"`
+	DroppedRows  int                `json:"droppedRows"`
+	Selectors    []SelectorCoverage `json:"selectors"`
+	Dropped      []DroppedRows      `json:"dropped"`
+}
+
+func newCoverageReport() *coverageReport {
+	return &coverageReport{
+		selectors: make(map[selectorKey]*SelectorCoverage),
+		dropped:   make(map[droppedKey]*DroppedRows),
+	}
+}
+
+// profileSelectors lists the selectors of p that are run on a lemma, by
+// their field names in a profiles file.
+func profileSelectors(p SelectorProfile) [][2]string {
+	var out [][2]string
+	for _, s := range [][2]string{
+		{"headword", p.Headword}, {"homograph", p.Homograph}, {"class", p.Class},
+		{"definition", p.Definition}, {"paradigm", p.Paradigm}, {"tableRow", p.TableRow},
+		{"sectionHeader", p.SectionHeader}, {"inflection", p.Inflection}, {"note", p.Note},
+		{"sense", p.Sense}, {"example", p.Example}, {"usage", p.Usage},
+		{"domain", p.Domain}, {"reference", p.Reference}, {"pronunciation", p.Pronunciation},
+	} {
+		if s[1] != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// lemmaCoverage records the rows of one lemma into its report.
+type lemmaCoverage struct {
+	report   *coverageReport
+	familyID int
+	headword string
+	class    string
+}
+
+type coverageKey struct{}
+
+// withCoverage returns ctx carrying report, for extractLemma to record
+// into; a nil report leaves ctx as it is.
+func withCoverage(ctx context.Context, report *coverageReport) context.Context {
+	if report == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, coverageKey{}, report)
+}
+
+// startLemma counts the selector matches in doc and returns ctx set up
+// for the parser's rows to be recorded against lemma. It does nothing
+// unless ctx carries a report.
+func startLemma(ctx context.Context, doc *goquery.Document, p SelectorProfile, lemma LemmaInput, class string) context.Context {
+	report, _ := ctx.Value(coverageKey{}).(*coverageReport)
+	if report == nil {
+		return ctx
+	}
+	report.mu.Lock()
+	report.lemmas++
+	for _, s := range profileSelectors(p) {
+		key := selectorKey{p.Name, s[0]}
+		c := report.selectors[key]
+		if c == nil {
+			c = &SelectorCoverage{Profile: p.Name, Field: s[0], Selector: s[1]}
+			report.selectors[key] = c
+		}
+		n := doc.Find(s[1]).Length()
+		c.Matches += n
+		c.Lemmas++
+		if n == 0 {
+			c.Missing++
+		}
+	}
+	report.mu.Unlock()
+
+	headword, _ := p.headword(doc.Selection)
+	return context.WithValue(ctx, coverageKey{}, &lemmaCoverage{report: report, familyID: lemma.FamilyID, headword: headword, class: class})
+}
+
+// recordRow records a table row of the lemma ctx was set up for by
+// startLemma, and whether its parser consumed it.
+func recordRow(ctx context.Context, section string, tds *goquery.Selection, consumed bool) {
+	lc, _ := ctx.Value(coverageKey{}).(*lemmaCoverage)
+	if lc == nil {
+		return
+	}
+	r := lc.report
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if consumed {
+		r.consumed++
+		return
+	}
+	key := droppedKey{lc.class, section, tds.Length()}
+	d := r.dropped[key]
+	if d == nil {
+		d = &DroppedRows{Class: lc.class, Section: section, Cells: tds.Length()}
+		r.dropped[key] = d
+	}
+	d.Rows++
+	if len(d.Examples) < maxCoverageExamples {
+		var cells []string
+		tds.Each(func(_ int, td *goquery.Selection) { cells = append(cells, cellText(td)) })
+		d.Examples = append(d.Examples, DroppedSample{FamilyID: lc.familyID, Headword: lc.headword, Text: strings.Join(cells, " | ")})
+	}
+}
+
+// summary returns the report with the selectors in profile and field
+// order and the dropped rows most frequent first.
+func (r *coverageReport) summary() CoverageSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := CoverageSummary{Lemmas: r.lemmas, ConsumedRows: r.consumed, Selectors: []SelectorCoverage{}, Dropped: []DroppedRows{}}
+	for _, c := range r.selectors {
+		s.Selectors = append(s.Selectors, *c)
+	}
+	sort.Slice(s.Selectors, func(i, j int) bool {
+		a, b := s.Selectors[i], s.Selectors[j]
+		if a.Profile != b.Profile {
+			return a.Profile < b.Profile
+		}
+		return a.Field < b.Field
+	})
+	for _, d := range r.dropped {
+		s.DroppedRows += d.Rows
+		s.Dropped = append(s.Dropped, *d)
+	}
+	sort.Slice(s.Dropped, func(i, j int) bool {
+		a, b := s.Dropped[i], s.Dropped[j]
+		if a.Rows != b.Rows {
+			return a.Rows > b.Rows
+		}
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		if a.Section != b.Section {
+			return a.Section < b.Section
+		}
+		return a.Cells < b.Cells
+	})
+	return s
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCoverageReport(t *testing.T) {
+	report := newCoverageReport()
+	ctx := withCoverage(context.Background(), report)
+
+	bil := readFixture(t, "substantiv_bil")
+	extra := strings.Replace(bil, "</table>", `<tr><td>a</td><td>b</td><td>c</td></tr></table>`, 1)
+	for _, html := range []string{bil, extra} {
+		if _, err := extractLemma(ctx, LemmaInput{HTML: html, FamilyID: 7}, saolProfile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := report.summary()
+	if s.Lemmas != 2 || s.ConsumedRows != 16 || s.DroppedRows != 1 {
+		t.Fatalf("summary = %d lemmas, %d consumed, %d dropped rows, want 2, 16, 1", s.Lemmas, s.ConsumedRows, s.DroppedRows)
+	}
+	d := s.Dropped[0]
+	if d.Class != "substantiv" || d.Cells != 3 || len(d.Examples) != 1 || d.Examples[0].Headword != "bil" || d.Examples[0].Text != "a | b | c" {
+		t.Errorf("dropped = %+v", d)
+	}
+	for _, c := range s.Selectors {
+		switch c.Field {
+		case "headword":
+			if c.Matches != 2 || c.Missing != 0 {
+				t.Errorf("headword selector = %+v", c)
+			}
+		case "definition":
+			if c.Lemmas != 2 {
+				t.Errorf("definition selector = %+v", c)
+			}
+		}
+	}
+}
+
+func TestCoverageOff(t *testing.T) {
+	// Without a report, rows are parsed as before and nothing is recorded.
+	res, err := extractLemma(context.Background(), LemmaInput{HTML: readFixture(t, "substantiv_bil")}, saolProfile)
+	if err != nil || len(res.forms) != 8 {
+		t.Errorf("extractLemma(bil) = %v, %v", res.forms, err)
+	}
+}
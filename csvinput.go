@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// csvArticles is a CSV or TSV file of articles, one per row, the HTML in
+// one of its columns and anything else, an ID or a URL, in the others.
+type csvArticles struct {
+	name   string
+	file   io.ReadCloser
+	r      *csv.Reader
+	column int
+}
+
+// openCSVArticles opens a .csv, or with a .tsv extension a tab-separated
+// file, from disk or a URL, and finds its HTML column: by name in the header row, or for a
+// file without one by number, counting from 1.
+func openCSVArticles(filename, column string) (*csvArticles, error) {
+	f, err := openLocation(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &csvArticles{name: filename, file: f, r: csv.NewReader(f)}
+	c.r.FieldsPerRecord = -1
+	if strings.EqualFold(filepath.Ext(filename), ".tsv") {
+		c.r.Comma = '\t'
+		c.r.LazyQuotes = true
+	}
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 {
+			f.Close()
+			return nil, fmt.Errorf("-html-column %d: columns count from 1", n)
+		}
+		c.column = n - 1
+		return c, nil
+	}
+	header, err := c.r.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: reading the header row: %w", filename, err)
+	}
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), column) {
+			c.column = i
+			return c, nil
+		}
+	}
+	f.Close()
+	return nil, fmt.Errorf("%s: no column %q in the header row %q", filename, column, header)
+}
+
+// Close closes the file.
+func (c *csvArticles) Close() error {
+	return c.file.Close()
+}
+
+// articles hands out the rows of the file in order, each named after its
+// line. A row that cannot be parsed or has no HTML column comes with Err
+// set; an error reading the file ends the articles with one such row. It
+// stops early when ctx is cancelled.
+func (c *csvArticles) articles(ctx context.Context) <-chan htmlFile {
+	files := make(chan htmlFile)
+	go func() {
+		defer close(files)
+		for {
+			record, err := c.r.Read()
+			if err == io.EOF {
+				return
+			}
+			// A quoted field can span lines, so the line a row starts on
+			// is the reader's to tell.
+			f := htmlFile{Path: c.name}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				f.Path = fmt.Sprintf("%s:%d", c.name, parseErr.StartLine)
+			} else if err == nil {
+				line, _ := c.r.FieldPos(0)
+				f.Path = fmt.Sprintf("%s:%d", c.name, line)
+			}
+			switch {
+			case parseErr != nil:
+				f.Err = err
+			case err != nil:
+				f.Err = fmt.Errorf("%s: %w", f.Path, err)
+			case c.column >= len(record):
+				f.Err = fmt.Errorf("%s: %d columns, no column %d", f.Path, len(record), c.column+1)
+			default:
+				f.HTML = record[c.column]
+			}
+			select {
+			case files <- f:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil && parseErr == nil {
+				return
+			}
+		}
+	}()
+	return files
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readCSVArticlesForTest(t *testing.T, name, content, column string) []htmlFile {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := openCSVArticles(filename, column)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var files []htmlFile
+	for f := range c.articles(context.Background()) {
+		f.Path = filepath.Base(f.Path)
+		files = append(files, f)
+	}
+	return files
+}
+
+func TestCSVArticles(t *testing.T) {
+	csvContent := "\ufeffid,HTML,url\n" +
+		"1,\"<div class=\"\"lemma\"\">bil</div>\",https://svenska.se/?sok=bil\n" +
+		"2,\"<div>hus\n</div>\",https://svenska.se/?sok=hus\n" +
+		"3,<div>fin</div>,https://svenska.se/?sok=fin\n"
+	got := readCSVArticlesForTest(t, "export.csv", csvContent, "html")
+	// The row after the one spanning lines 3 and 4 starts on line 5.
+	want := []htmlFile{
+		{Path: "export.csv:2", HTML: `<div class="lemma">bil</div>`},
+		{Path: "export.csv:3", HTML: "<div>hus\n</div>"},
+		{Path: "export.csv:5", HTML: "<div>fin</div>"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("csv articles = %+v, want %+v", got, want)
+	}
+
+	// A TSV without a header row, the HTML in the second column; the
+	// short row is an error but the rows after it are read.
+	tsvContent := "a\t<div>\"bil\"</div>\nb\nc\t<div>hus</div>\n"
+	got = readCSVArticlesForTest(t, "export.tsv", tsvContent, "2")
+	if len(got) != 3 || got[0].HTML != `<div>"bil"</div>` || got[1].Err == nil || got[2].HTML != "<div>hus</div>" {
+		t.Errorf("tsv articles = %+v", got)
+	}
+}
+
+func TestOpenCSVArticlesErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "export.csv")
+	if err := os.WriteFile(filename, []byte("id,body\n1,<div/>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range []string{"html", "0"} {
+		if c, err := openCSVArticles(filename, column); err == nil {
+			c.Close()
+			t.Errorf("openCSVArticles(-html-column %s) succeeded", column)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// withEntryDeadline runs fn on one entry with a context that expires after
+// timeout, or without a deadline when timeout is 0. Should fn not return in
+// time it is left to notice its cancelled context on its own, and the
+// context's error is returned at once, so one pathological entry cannot
+// stall a whole run.
+func withEntryDeadline[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn(ctx)
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// saveQuarantine writes the entries that ran past their deadline, in the
+// shape they were read in, so they can be inspected or retried on their own.
+func saveQuarantine(filename string, entries interface{}) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, entries) })
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithEntryDeadline(t *testing.T) {
+	n, err := withEntryDeadline(context.Background(), time.Second, func(ctx context.Context) (int, error) {
+		return 42, nil
+	})
+	if n != 42 || err != nil {
+		t.Errorf("fast entry = %d, %v; want 42, nil", n, err)
+	}
+
+	// A parser that only stops once cancelled must not hold up the caller
+	// past the deadline.
+	start := time.Now()
+	_, err = withEntryDeadline(context.Background(), 10*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return 0, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("slow entry error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("slow entry held the caller for %v", elapsed)
+	}
+}
+
+func TestParsersStopWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if forms := parseVerbForms(ctx, loadFixture(t, "verb_knasatta"), saolProfile); len(forms) != 0 {
+		t.Errorf("cancelled parser returned %d forms", len(forms))
+	}
+
+	lemmas := filepath.Join(t.TempDir(), "flattened_lemmas.json")
+	if err := ioutil.WriteFile(lemmas, []byte(`{"1": {"html": "<span class=\"ordklass\">verb</span>", "familyID": 1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FilterLemmasByOrdklass(ctx, lemmas); !errors.Is(err, context.Canceled) {
+		t.Errorf("FilterLemmasByOrdklass error = %v, want context canceled", err)
+	}
+}
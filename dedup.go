@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dedup modes of flatten: SAOL repeats some lemma blocks, the same
+// headword with the same table, under more than one article.
+const (
+	dedupOff  = "off"  // write every lemma as it is
+	dedupDrop = "drop" // write only the first copy of a lemma
+	dedupLink = "link" // write every copy, the later ones with duplicateOf
+)
+
+// duplicateLemma is one line of the dedup report: a copy of the lemma with
+// key DuplicateOf. Key is the copy's own key, 0 when the copy was dropped.
+type duplicateLemma struct {
+	Key         int    `json:"key,omitempty"`
+	FamilyID    int    `json:"familyID"`
+	Headword    string `json:"headword,omitempty"`
+	DuplicateOf int    `json:"duplicateOf"`
+	Hash        string `json:"hash"`
+}
+
+// lemmaDeduper finds the lemmas whose HTML, whitespace aside, has been
+// written before, by its SHA-256.
+type lemmaDeduper struct {
+	mode   string
+	seen   map[string]int
+	report []duplicateLemma
+}
+
+func newLemmaDeduper(mode string) (*lemmaDeduper, error) {
+	switch mode {
+	case dedupOff, dedupDrop, dedupLink:
+	default:
+		return nil, fmt.Errorf("unknown dedup mode %q, want %q, %q or %q", mode, dedupOff, dedupDrop, dedupLink)
+	}
+	return &lemmaDeduper{mode: mode, seen: make(map[string]int)}, nil
+}
+
+// write passes entry on to out unless it is a copy to drop, setting its
+// DuplicateOf when it is a copy to link, and reports every copy.
+func (d *lemmaDeduper) write(out *lemmaArrayWriter, entry LemmaOutput) error {
+	if d.mode == dedupOff {
+		return out.write(entry)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(entry.HTML), " ")))
+	hash := hex.EncodeToString(sum[:])
+	first, dup := d.seen[hash]
+	if dup && d.mode == dedupDrop {
+		d.report = append(d.report, duplicateLemma{FamilyID: entry.FamilyID, Headword: entry.Headword, DuplicateOf: first, Hash: hash})
+		return nil
+	}
+	if dup {
+		entry.DuplicateOf = first
+	}
+	if err := out.write(entry); err != nil {
+		return err
+	}
+	if dup {
+		d.report = append(d.report, duplicateLemma{Key: out.n, FamilyID: entry.FamilyID, Headword: entry.Headword, DuplicateOf: first, Hash: hash})
+	} else {
+		d.seen[hash] = out.n
+	}
+	return nil
+}
+
+// saveReport writes the copies found, in the order they were written.
+func (d *lemmaDeduper) saveReport(filename string) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, d.report) })
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLemmaDeduper(t *testing.T) {
+	lemmas := []LemmaOutput{
+		{HTML: "<p>bil</p>", FamilyID: 1, Headword: "bil"},
+		{HTML: "<p>bila</p>", FamilyID: 1, Headword: "bila"},
+		{HTML: "<p>bil</p>\n", FamilyID: 2, Headword: "bil"},
+	}
+	tests := []struct {
+		mode       string
+		keys       []int
+		duplicates []duplicateLemma
+	}{
+		{dedupOff, []int{0, 0, 0}, nil},
+		{dedupDrop, []int{0, 0}, []duplicateLemma{{FamilyID: 2, Headword: "bil", DuplicateOf: 1}}},
+		{dedupLink, []int{0, 0, 1}, []duplicateLemma{{Key: 3, FamilyID: 2, Headword: "bil", DuplicateOf: 1}}},
+	}
+	for _, tt := range tests {
+		d, err := newLemmaDeduper(tt.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		out := newLemmaArrayWriter(&buf)
+		for _, l := range lemmas {
+			if err := d.write(out, l); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out.close()
+
+		var links []int
+		err = ForEachLemma(&buf, func(l Lemma) error {
+			links = append(links, l.DuplicateOf)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(links, tt.keys) {
+			t.Errorf("%s: duplicateOf %v, want %v", tt.mode, links, tt.keys)
+		}
+		for i := range d.report {
+			d.report[i].Hash = ""
+		}
+		if !reflect.DeepEqual(d.report, tt.duplicates) {
+			t.Errorf("%s: report %+v, want %+v", tt.mode, d.report, tt.duplicates)
+		}
+	}
+
+	if _, err := newLemmaDeduper("merge"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
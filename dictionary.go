@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// SelectorProfile describes where a svenska.se dictionary keeps the parts
+// of an entry, as CSS selectors. SAOL articles hold one div.lemma per lemma
+// with an inflection table; Svensk ordbok (SO) articles hold div.superlemma
+// blocks whose inflected forms are listed inline in .bojning. A site
+// redesign only needs a new profile, loaded with -profiles.
+type SelectorProfile struct {
+	Name          string `json:"name"`
+	Article       string `json:"article"`             // one article of a search result page
+	Lemma         string `json:"lemma"`               // lemma blocks inside Article, split out by flatten
+	Headword      string `json:"headword"`            // may hold the homograph number as <sup>
+	Homograph     string `json:"homograph,omitempty"` // homograph number given on its own
+	Class         string `json:"class"`
+	Definition    string `json:"definition,omitempty"`
+	Paradigm      string `json:"paradigm,omitempty"`
+	TableRow      string `json:"tableRow,omitempty"`      // rows of the inflection table
+	SectionHeader string `json:"sectionHeader,omitempty"` // header cell starting a table section
+	Inflection    string `json:"inflection,omitempty"`    // inline list of inflected forms; empty for table layouts
+	Note          string `json:"note,omitempty"`          // inflection note, "ingen böjning" for an indeclinable lemma
+	Sense         string `json:"sense,omitempty"`         // one sense of the lemma, holding its Definition and Examples
+	Example       string `json:"example,omitempty"`       // usage example inside a Sense
+	Usage         string `json:"usage,omitempty"`         // usage or register label, "vard." or "åld.", of the lemma or a Sense
+	Domain        string `json:"domain,omitempty"`        // subject-field label, "med." or "jur."
+	Reference     string `json:"reference,omitempty"`     // cross-reference to another lemma, after "se", "jfr" or "äv."
+	Pronunciation string `json:"pronunciation,omitempty"` // pronunciation hint, often in brackets
+}
+
+var selectorProfiles = map[string]SelectorProfile{
+	"saol": {
+		Name: "saol", Article: "div.article", Lemma: "div.lemma", Headword: ".grundform", Homograph: ".homonr",
+		Class: ".ordklass", Definition: ".def", Paradigm: ".bojningsklass",
+		TableRow: ".tabell tr", SectionHeader: "th.ordformth", Note: ".bojning",
+		Sense: ".lexem", Example: ".syntex", Usage: ".stilruta", Domain: ".fack", Reference: "a.hanvisning", Pronunciation: ".uttal",
+	},
+	"so": {
+		Name: "so", Article: "div.article", Lemma: "div.superlemma", Headword: ".orto", Homograph: ".homonr",
+		Class: ".ordklass", Definition: ".def", Inflection: ".bojning", Note: ".bojning",
+		Sense: ".kbetydelse", Example: ".syntex", Usage: ".stilruta", Domain: ".fack", Reference: "a.hanvisning", Pronunciation: ".uttal",
+	},
+}
+
+// soInflectionSection is the section SO's inline forms are grouped under.
+const soInflectionSection = "Böjning"
+
+// profileNames lists the known selector profiles.
+func profileNames() []string {
+	names := make([]string, 0, len(selectorProfiles))
+	for name := range selectorProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileFor returns the selector profile called name; lemmas flattened
+// before profiles were recorded have none and are SAOL.
+func profileFor(name string) (SelectorProfile, error) {
+	if name == "" {
+		name = "saol"
+	}
+	p, ok := selectorProfiles[name]
+	if !ok {
+		return SelectorProfile{}, fmt.Errorf("unknown selector profile %q, want one of: %s (or load it with -profiles)", name, strings.Join(profileNames(), ", "))
+	}
+	return p, nil
+}
+
+// loadSelectorProfiles adds the profiles in a JSON array file to the known
+// ones, replacing a built-in profile of the same name.
+func loadSelectorProfiles(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var profiles []SelectorProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("error decoding selector profiles from '%s': %w", filename, err)
+	}
+	for _, p := range profiles {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		selectorProfiles[p.Name] = p
+	}
+	return nil
+}
+
+func (p SelectorProfile) validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("selector profile without a name")
+	case p.Article == "" || p.Lemma == "" || p.Headword == "" || p.Class == "":
+		return fmt.Errorf("selector profile %q needs article, lemma, headword and class selectors", p.Name)
+	case p.Inflection == "" && (p.TableRow == "" || p.SectionHeader == ""):
+		return fmt.Errorf("selector profile %q needs either an inflection selector or tableRow and sectionHeader", p.Name)
+	}
+	return nil
+}
+
+// detectProfile returns the first of candidates whose article and lemma
+// selectors match doc with a headword in the first lemma. The error names
+// every profile tried, since no match usually means the site changed its
+// markup and every lemma would otherwise be dropped without a word.
+func detectProfile(doc *goquery.Document, candidates []SelectorProfile) (SelectorProfile, error) {
+	var tried []string
+	for _, p := range candidates {
+		lemma := doc.Find(p.Article).First().Find(p.Lemma).First()
+		if lemma.Find(p.Headword).Length() > 0 {
+			return p, nil
+		}
+		tried = append(tried, fmt.Sprintf("%s (%s %s %s)", p.Name, p.Article, p.Lemma, p.Headword))
+	}
+	return SelectorProfile{}, fmt.Errorf("no selector profile matches the article, tried %s; describe the new markup in a -profiles file", strings.Join(tried, ", "))
+}
+
+// elementType is the element name in front of a class or ID selector.
+var elementType = regexp.MustCompile(`(^|[\s>+~,])[a-zA-Z][a-zA-Z0-9]*([.#])`)
+
+// relaxed returns p with the element names dropped from its article and
+// lemma selectors ("div.article" becomes ".article"), for retrying
+// articles whose markup changed the element but kept the class.
+func (p SelectorProfile) relaxed() SelectorProfile {
+	p.Article = elementType.ReplaceAllString(p.Article, "$1$2")
+	p.Lemma = elementType.ReplaceAllString(p.Lemma, "$1$2")
+	return p
+}
+
+// allProfiles returns every known profile, in name order.
+func allProfiles() []SelectorProfile {
+	var out []SelectorProfile
+	for _, name := range profileNames() {
+		out = append(out, selectorProfiles[name])
+	}
+	return out
+}
+
+// headword returns the headword of a lemma and its homograph number, 0
+// when the headword has no homographs. Homographs are marked with a
+// superscript number (²val), given either on its own or as a <sup> inside
+// the headword. Hyphenation marks are left out; see hyphenation.
+func (p SelectorProfile) headword(lemma *goquery.Selection) (headword string, homograph int) {
+	grundform := lemma.Find(p.Headword).First().Clone()
+	var number string
+	if p.Homograph != "" {
+		number = lemma.Find(p.Homograph).First().Text()
+	}
+	if sup := grundform.Find("sup"); sup.Length() > 0 {
+		if number == "" {
+			number = sup.First().Text()
+		}
+		sup.Remove()
+	}
+	homograph, _ = strconv.Atoi(strings.TrimSpace(number))
+	return nfc(strings.TrimSpace(stripStress(stripHyphenation(grundform.Text())))), homograph
+}
+
+// class returns the word class of a lemma.
+func (p SelectorProfile) class(lemma *goquery.Selection) string {
+	return nfc(strings.TrimSpace(lemma.Find(p.Class).First().Text()))
+}
+
+// indeclinable reports whether the dictionary marks a lemma "ingen
+// böjning" instead of giving its forms.
+func (p SelectorProfile) indeclinable(lemma *goquery.Selection) bool {
+	if p.Note == "" {
+		return false
+	}
+	return strings.TrimSpace(lemma.Find(p.Note).First().Text()) == noInflection
+}
+
+// eachTableRow calls fn with the data cells of every row of the profile's
+// inflection tables and the label of the section the row is in; fn reports
+// whether it took a form from the row, for extract -coverage. Rows of a
+// lemma's second and later tables get the section tagged with the table
+// number ("Nominativ#2", see tagTable), so their forms are not mistaken
+// for the first table's. It stops early, leaving the parser with what it
+// has so far, once ctx is done.
+func eachTableRow(ctx context.Context, doc *goquery.Document, p SelectorProfile, fn func(section string, tds *goquery.Selection) bool) {
+	if p.TableRow == "" {
+		return
+	}
+	section := ""
+	table, tables := (*html.Node)(nil), 0
+	doc.Find(p.TableRow).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if t := s.Closest("table"); t.Length() > 0 && t.Get(0) != table {
+			table = t.Get(0)
+			tables++
+		}
+		if th := s.Find(p.SectionHeader); th.Length() == 1 {
+			section = sectionLabel(th)
+			return true
+		}
+		tds := s.Find("td")
+		recordRow(ctx, section, tds, fn(tagTable(section, tables), tds))
+		return true
+	})
+}
+
+// tableTag separates the table number from the section label in parser
+// results of a lemma's second and later tables.
+const tableTag = "#"
+
+// tagTable returns section tagged with table, the 1-based number of the
+// table it is in; the first table's sections stay as they are.
+func tagTable(section string, table int) string {
+	if table < 2 {
+		return section
+	}
+	return section + tableTag + strconv.Itoa(table)
+}
+
+// splitTable splits a section tagged by tagTable into the label and the
+// table number, 0 for the first table.
+func splitTable(section string) (string, int) {
+	if i := strings.LastIndex(section, tableTag); i >= 0 {
+		if n, err := strconv.Atoi(section[i+len(tableTag):]); err == nil && n > 1 {
+			return section[:i], n
+		}
+	}
+	return section, 0
+}
+
+// tableCount returns how many tables the parser results of a lemma came
+// from.
+func tableCount(tagged []string) int {
+	count := 0
+	for _, t := range tagged {
+		if last := strings.LastIndex(t, "-"); last >= 0 {
+			_, table := splitTable(t[last+1:])
+			count = max(count, table, 1)
+		}
+	}
+	return count
+}
+
+// parseInlineForms returns the forms listed in the profile's inflection
+// element ("bilen bilar", or comma separated), tagged "form-Böjning" like
+// the table parsers' output.
+func parseInlineForms(doc *goquery.Document, p SelectorProfile) []string {
+	text := nfc(doc.Find(p.Inflection).First().Text())
+	var forms []string
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\t' }) {
+		forms = append(forms, f+"-"+soInflectionSection)
+	}
+	return forms
+}
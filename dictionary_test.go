@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSOLexiconEntry(t *testing.T) {
+	entry, ok, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: readFixture(t, "so_bil"), FamilyID: 1, Source: "so"})
+	if err != nil || !ok {
+		t.Fatalf("newLexiconEntry = %v, %v", ok, err)
+	}
+	checkGolden(t, "so_bil_entry", entry)
+
+	if _, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: readFixture(t, "so_bil"), Source: "nso"}); err == nil {
+		t.Error("unknown source accepted")
+	}
+}
+
+func TestSOWorker(t *testing.T) {
+	res := runLayoutWorker(0, readFixture(t, "article_so_bil"), selectorProfiles["so"])
+	if res.Error != nil || len(res.LemmaHTMLs) != 2 {
+		t.Fatalf("got %d lemmas, %v; want 2", len(res.LemmaHTMLs), res.Error)
+	}
+
+	// The SAOL profile does not match an SO article, and says so.
+	if res := runWorker(0, readFixture(t, "article_so_bil")); len(res.LemmaHTMLs) != 0 || res.Error == nil {
+		t.Errorf("SAOL profile on an SO article: %d lemmas, error %v", len(res.LemmaHTMLs), res.Error)
+	}
+}
+
+func TestDetectProfile(t *testing.T) {
+	for fixture, want := range map[string]string{"article_bil": "saol", "article_so_bil": "so"} {
+		res := runDetectWorker(readFixture(t, fixture))
+		if res.Error != nil || res.Source != want {
+			t.Errorf("%s: detected %q, %v; want %q", fixture, res.Source, res.Error, want)
+		}
+	}
+
+	redesigned := `<div class="article"><div class="entry"><span class="hw">bil</span></div></div>`
+	if res := runDetectWorker(redesigned); res.Error == nil || !strings.Contains(res.Error.Error(), "no selector profile matches") {
+		t.Errorf("redesigned article: error %v, want no selector profile matches", res.Error)
+	}
+
+	profiles := filepath.Join(t.TempDir(), "profiles.json")
+	config := `[{"name": "saol2", "article": "div.article", "lemma": "div.entry", "headword": ".hw", "class": ".pos", "tableRow": "table.forms tr", "sectionHeader": "th"}]`
+	if err := ioutil.WriteFile(profiles, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadSelectorProfiles(profiles); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(selectorProfiles, "saol2")
+	if res := runDetectWorker(redesigned); res.Error != nil || res.Source != "saol2" || len(res.LemmaHTMLs) != 1 {
+		t.Errorf("with the saol2 profile: %+v", res)
+	}
+
+	incomplete := filepath.Join(t.TempDir(), "incomplete.json")
+	if err := ioutil.WriteFile(incomplete, []byte(`[{"name": "x", "article": "div", "lemma": "div", "headword": "b", "class": "i"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadSelectorProfiles(incomplete); err == nil {
+		t.Error("profile without table or inflection selectors accepted")
+	}
+}
+
+// runDetectWorker feeds an article through a worker detecting the profile
+// among all known ones, as flatten does by default.
+func runDetectWorker(html string) Result {
+	jobs := make(chan Job, 1)
+	results := make(chan Result, 1)
+	var wg sync.WaitGroup
+
+	jobs <- Job{Data: InputEntry{HTML: html}}
+	close(jobs)
+
+	wg.Add(1)
+	worker(context.Background(), 1, allProfiles(), 0, jobs, results, &wg)
+	wg.Wait()
+	return <-results
+}
+
+func TestRelaxedProfile(t *testing.T) {
+	p := SelectorProfile{Article: "div.article", Lemma: "section > div.lemma, p#x"}.relaxed()
+	if p.Article != ".article" || p.Lemma != "section > .lemma, #x" {
+		t.Errorf("relaxed to %q and %q", p.Article, p.Lemma)
+	}
+}
+
+func TestSeveralTables(t *testing.T) {
+	bil := readFixture(t, "substantiv_bil")
+	second := `<table class="tabell"><tr><th class="ordformth" colspan="2"><i>Nominativ</i></th></tr><tr><td class="ordform">bilarne</td><td class="ledtext">de</td></tr></table>`
+	in := LemmaInput{HTML: strings.Replace(bil, "</table>", "</table>"+second, 1)}
+
+	res, err := extractLemma(context.Background(), in, saolProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tableCount(res.forms); got != 2 {
+		t.Errorf("tableCount = %d, want 2", got)
+	}
+	noun := newNounEntry(res.forms)
+	last := noun.Forms[len(noun.Forms)-1]
+	if last.Form != "bilarne" || last.Case != "Nominativ" || last.Table != 2 || noun.Forms[0].Table != 0 {
+		t.Errorf("second table form = %+v, first table = %d", last, noun.Forms[0].Table)
+	}
+
+	entry, _, err := newLexiconEntry(context.Background(), "1", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forms := entry.Forms["Nominativ"]
+	if len(forms) != 5 || forms[4].Table != 2 {
+		t.Errorf("Nominativ = %+v", forms)
+	}
+}
+
+func TestSplitTable(t *testing.T) {
+	for _, tt := range []struct {
+		section string
+		label   string
+		table   int
+	}{
+		{"Nominativ", "Nominativ", 0},
+		{tagTable("Nominativ", 1), "Nominativ", 0},
+		{tagTable("Finita former", 3), "Finita former", 3},
+		{"Nr #x", "Nr #x", 0},
+	} {
+		if label, table := splitTable(tt.section); label != tt.label || table != tt.table {
+			t.Errorf("splitTable(%q) = %q, %d, want %q, %d", tt.section, label, table, tt.label, tt.table)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lexiconDiff is what changed between two snapshots of the lexicon, say
+// two editions of SAOL, with lemmas matched by their stable ID.
+type lexiconDiff struct {
+	Old     string        `json:"old"`
+	New     string        `json:"new"`
+	Added   []diffLemma   `json:"added"`
+	Removed []diffLemma   `json:"removed"`
+	Changed []lemmaChange `json:"changed"`
+}
+
+// diffLemma names a lemma only one of the snapshots has.
+type diffLemma struct {
+	ID       string `json:"id"`
+	Headword string `json:"headword"`
+	Class    string `json:"class"`
+}
+
+// lemmaChange is a lemma both snapshots have that differs in its
+// dictionary fields or its forms. A form whose spelling changed shows up
+// as removed and added.
+type lemmaChange struct {
+	diffLemma
+	Fields       []fieldChange `json:"fields,omitempty"`
+	AddedForms   []diffForm    `json:"addedForms,omitempty"`
+	RemovedForms []diffForm    `json:"removedForms,omitempty"`
+}
+
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// diffForm is one form slot of a lemma: the section of its table, its
+// label, if any, and the form with its variants.
+type diffForm struct {
+	Section  string   `json:"section"`
+	Label    string   `json:"label,omitempty"`
+	Form     string   `json:"form"`
+	Variants []string `json:"variants,omitempty"`
+}
+
+func (f diffForm) key() string {
+	return f.Section + "\x00" + f.Label + "\x00" + f.Form + "\x00" + strings.Join(f.Variants, "\x00")
+}
+
+func (f diffForm) String() string {
+	s := f.Section + ": " + f.Form
+	if len(f.Variants) > 0 {
+		s += " el. " + strings.Join(f.Variants, " el. ")
+	}
+	if f.Label != "" {
+		s += " (" + f.Label + ")"
+	}
+	return s
+}
+
+func (d lexiconDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// runDiff compares two lexicons, each an enriched lexicon.json or a
+// flattened_lemmas.json, and reports the lemmas added, removed and changed
+// between them, as text or as JSON.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "report format: text, or json")
+	out := flags.String("out", "", "file to write the report to (default standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool diff [-format text|json] [-out file] <old> <new>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	oldFile, newFile := flags.Arg(0), flags.Arg(1)
+	oldEntries, err := readDiffLexicon(oldFile)
+	if err != nil {
+		fatal("could not read lexicon", "file", oldFile, "err", err)
+	}
+	newEntries, err := readDiffLexicon(newFile)
+	if err != nil {
+		fatal("could not read lexicon", "file", newFile, "err", err)
+	}
+	d := diffLexicons(oldEntries, newEntries)
+	d.Old, d.New = oldFile, newFile
+
+	write := func(w io.Writer) error {
+		if *format == "json" {
+			return writeIndentedJSON(w, d)
+		}
+		return writeDiffText(w, d)
+	}
+	if *out == "" {
+		err = write(os.Stdout)
+	} else {
+		err = saveFile(*out, write)
+	}
+	if err != nil {
+		fatal("could not write diff", "err", err)
+	}
+	slog.Info("compared lexicons", "added", len(d.Added), "removed", len(d.Removed), "changed", len(d.Changed))
+}
+
+// readDiffLexicon reads a lexicon written by enrich or, for any other
+// file, parses the flattened lemmas in it.
+func readDiffLexicon(filename string) ([]LexiconEntry, error) {
+	if entries, err := readLexiconJSON(filename); err == nil && (len(entries) == 0 || entries[0].ID != "") {
+		return entries, nil
+	}
+	return loadLexicon(filename)
+}
+
+// diffLexicons compares old and new by lemma ID. The article family and
+// what enrich adds (frequency, translations) are not compared, as they
+// change between runs without the dictionary changing.
+func diffLexicons(old, new []LexiconEntry) lexiconDiff {
+	d := lexiconDiff{Added: []diffLemma{}, Removed: []diffLemma{}, Changed: []lemmaChange{}}
+	oldByID := make(map[string]LexiconEntry, len(old))
+	for _, e := range old {
+		oldByID[e.ID] = e
+	}
+	newIDs := make(map[string]bool, len(new))
+	for _, e := range new {
+		newIDs[e.ID] = true
+		prev, ok := oldByID[e.ID]
+		if !ok {
+			d.Added = append(d.Added, e.diffLemma())
+			continue
+		}
+		if c, changed := compareLemmas(prev, e); changed {
+			d.Changed = append(d.Changed, c)
+		}
+	}
+	for _, e := range old {
+		if !newIDs[e.ID] {
+			d.Removed = append(d.Removed, e.diffLemma())
+		}
+	}
+
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].ID < d.Added[j].ID })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].ID < d.Removed[j].ID })
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].ID < d.Changed[j].ID })
+	return d
+}
+
+func (e LexiconEntry) diffLemma() diffLemma {
+	return diffLemma{ID: e.ID, Headword: e.Headword, Class: e.Class}
+}
+
+// compareLemmas lists what differs between two versions of a lemma.
+func compareLemmas(old, new LexiconEntry) (c lemmaChange, changed bool) {
+	c.diffLemma = new.diffLemma()
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"class", old.Class, new.Class},
+		{"paradigm", old.Paradigm, new.Paradigm},
+		{"definition", old.Definition, new.Definition},
+		{"gender", old.Gender, new.Gender},
+		{"particle", old.Particle, new.Particle},
+		{"reflexive", strconv.FormatBool(old.Reflexive), strconv.FormatBool(new.Reflexive)},
+	}
+	for _, f := range fields {
+		if f.old != f.new {
+			c.Fields = append(c.Fields, fieldChange{f.name, f.old, f.new})
+		}
+	}
+
+	oldForms, newForms := lemmaDiffForms(old), lemmaDiffForms(new)
+	for key, f := range newForms {
+		if _, ok := oldForms[key]; !ok {
+			c.AddedForms = append(c.AddedForms, f)
+		}
+	}
+	for key, f := range oldForms {
+		if _, ok := newForms[key]; !ok {
+			c.RemovedForms = append(c.RemovedForms, f)
+		}
+	}
+	sortDiffForms(c.AddedForms)
+	sortDiffForms(c.RemovedForms)
+	return c, len(c.Fields) > 0 || len(c.AddedForms) > 0 || len(c.RemovedForms) > 0
+}
+
+// lemmaDiffForms returns the form slots of e by key.
+func lemmaDiffForms(e LexiconEntry) map[string]diffForm {
+	forms := make(map[string]diffForm)
+	for section, slots := range e.Forms {
+		for _, slot := range slots {
+			f := diffForm{Section: section, Label: slot.Label, Form: slot.Form, Variants: slot.Variants}
+			forms[f.key()] = f
+		}
+	}
+	return forms
+}
+
+func sortDiffForms(forms []diffForm) {
+	sort.Slice(forms, func(i, j int) bool { return forms[i].key() < forms[j].key() })
+}
+
+// writeDiffText writes d in the style of a unified diff header followed by
+// one line per added (+), removed (-) and changed (~) lemma, the changes
+// indented under it, and a summary line.
+func writeDiffText(w io.Writer, d lexiconDiff) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "--- %s\n+++ %s\n", d.Old, d.New)
+	for _, l := range d.Removed {
+		fmt.Fprintf(bw, "- %s (%s)\n", l.ID, l.Class)
+	}
+	for _, l := range d.Added {
+		fmt.Fprintf(bw, "+ %s (%s)\n", l.ID, l.Class)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(bw, "~ %s (%s)\n", c.ID, c.Class)
+		for _, f := range c.Fields {
+			fmt.Fprintf(bw, "    %s: %q -> %q\n", f.Field, f.Old, f.New)
+		}
+		for _, f := range c.RemovedForms {
+			fmt.Fprintf(bw, "    - %s\n", f)
+		}
+		for _, f := range c.AddedForms {
+			fmt.Fprintf(bw, "    + %s\n", f)
+		}
+	}
+	if d.empty() {
+		fmt.Fprintln(bw, "no differences")
+	} else {
+		fmt.Fprintf(bw, "%d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffLexicons(t *testing.T) {
+	old := []LexiconEntry{
+		{ID: "bil", Headword: "bil", Class: "substantiv", Paradigm: "2", FamilyID: 1, Forms: map[string][]Form{
+			"Nominativ": {{Form: "bil", Label: "singular obestämd"}, {Form: "bilen", Label: "singular bestämd"}},
+		}},
+		{ID: "val_1", Headword: "val", Homograph: 1, Class: "substantiv"},
+		{ID: "simma", Headword: "simma", Class: "verb", Forms: map[string][]Form{
+			"Finita former": {{Form: "simmade", Label: "preteritum aktiv", Variants: []string{"sam"}}},
+		}},
+	}
+	new := []LexiconEntry{
+		{ID: "bil", Headword: "bil", Class: "substantiv", Paradigm: "2", FamilyID: 7, Frequency: 12, Forms: map[string][]Form{
+			"Nominativ": {{Form: "bil", Label: "singular obestämd"}, {Form: "bilen", Label: "singular bestämd"}},
+		}},
+		{ID: "simma", Headword: "simma", Class: "verb", Definition: "förflytta sig i vatten", Forms: map[string][]Form{
+			"Finita former": {{Form: "simmade", Label: "preteritum aktiv"}},
+		}},
+		{ID: "swisha", Headword: "swisha", Class: "verb"},
+	}
+
+	d := diffLexicons(old, new)
+	if want := []diffLemma{{"swisha", "swisha", "verb"}}; !reflect.DeepEqual(d.Added, want) {
+		t.Errorf("added %+v, want %+v", d.Added, want)
+	}
+	if want := []diffLemma{{"val_1", "val", "substantiv"}}; !reflect.DeepEqual(d.Removed, want) {
+		t.Errorf("removed %+v, want %+v", d.Removed, want)
+	}
+	want := []lemmaChange{{
+		diffLemma:    diffLemma{"simma", "simma", "verb"},
+		Fields:       []fieldChange{{"definition", "", "förflytta sig i vatten"}},
+		AddedForms:   []diffForm{{Section: "Finita former", Label: "preteritum aktiv", Form: "simmade"}},
+		RemovedForms: []diffForm{{Section: "Finita former", Label: "preteritum aktiv", Form: "simmade", Variants: []string{"sam"}}},
+	}}
+	if !reflect.DeepEqual(d.Changed, want) {
+		t.Errorf("changed %+v, want %+v", d.Changed, want)
+	}
+
+	d.Old, d.New = "saol13.json", "saol14.json"
+	var buf bytes.Buffer
+	if err := writeDiffText(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	wantText := `--- saol13.json
++++ saol14.json
+- val_1 (substantiv)
++ swisha (verb)
+~ simma (verb)
+    definition: "" -> "förflytta sig i vatten"
+    - Finita former: simmade el. sam (preteritum aktiv)
+    + Finita former: simmade (preteritum aktiv)
+1 added, 1 removed, 1 changed
+`
+	if buf.String() != wantText {
+		t.Errorf("text report\n%s\nwant\n%s", buf.String(), wantText)
+	}
+
+	buf.Reset()
+	writeDiffText(&buf, diffLexicons(old, old))
+	if got := buf.String(); got != "--- \n+++ \nno differences\n" {
+		t.Errorf("diff with itself: %q", got)
+	}
+}
+
+func TestReadDiffLexicon(t *testing.T) {
+	dir := t.TempDir()
+	lexicon := filepath.Join(dir, "lexicon.json")
+	entries := []LexiconEntry{{ID: "bil", Headword: "bil", Class: "substantiv", Forms: map[string][]Form{}}}
+	if err := saveLexiconJSON(entries, lexicon); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readDiffLexicon(lexicon)
+	if err != nil || !reflect.DeepEqual(got, entries) {
+		t.Errorf("lexicon.json: %+v %v", got, err)
+	}
+
+	flattened := filepath.Join(dir, outputFile)
+	f, err := os.Create(flattened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newLemmaArrayWriter(f)
+	w.write(LemmaOutput{HTML: readFixture(t, "substantiv_bil"), FamilyID: 1, Source: "saol"})
+	w.close()
+	f.Close()
+	got, err = readDiffLexicon(flattened)
+	if err != nil || len(got) != 1 || got[0].ID != "bil" || len(got[0].Forms["Nominativ"]) == 0 {
+		t.Errorf("flattened_lemmas.json: %+v %v", got, err)
+	}
+}
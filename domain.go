@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// domainNames expands the subject-field abbreviations of SAOL and SO to
+// the domain an entry records. A written-out name maps to itself, so
+// "medicin" and "med." give the same domain.
+var domainNames = map[string]string{
+	"med.": "medicin", "jur.": "juridik", "sport.": "sport", "mat.": "matematik",
+	"kem.": "kemi", "fys.": "fysik", "biol.": "biologi", "bot.": "botanik",
+	"zool.": "zoologi", "geol.": "geologi", "astron.": "astronomi", "tekn.": "teknik",
+	"data.": "data", "ekon.": "ekonomi", "mil.": "militärväsen", "sjö.": "sjöfart",
+	"språkv.": "språkvetenskap", "relig.": "religion", "mus.": "musik", "konstv.": "konst",
+	"filos.": "filosofi", "psykol.": "psykologi", "hist.": "historia", "kok.": "matlagning",
+	"jakt.": "jakt", "lantbr.": "lantbruk", "polit.": "politik", "film.": "film",
+}
+
+func init() {
+	for _, name := range domainNames {
+		domainNames[name] = name
+	}
+}
+
+// domainsIn returns the domains of the subject-field labels inside s, in
+// order and without repeats: those in the profile's domain elements and
+// an abbreviation opening a definition, as in "med. inflammation i ...".
+func (p SelectorProfile) domainsIn(s *goquery.Selection) []string {
+	var domains []string
+	seen := make(map[string]bool)
+	add := func(word string) {
+		if domain, ok := domainNames[word]; ok && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	if p.Domain != "" {
+		s.Find(p.Domain).Each(func(_ int, d *goquery.Selection) {
+			for _, word := range labelWords(cellText(d)) {
+				add(word)
+			}
+		})
+	}
+	if p.Definition != "" {
+		s.Find(p.Definition).Each(func(_ int, def *goquery.Selection) {
+			if words := labelWords(cellText(def)); len(words) > 0 && strings.HasSuffix(words[0], ".") {
+				add(words[0])
+			}
+		})
+	}
+	return domains
+}
+
+// filterByDomain keeps the entries in one of domains, given as names or
+// abbreviations ("medicin" or "med.").
+func filterByDomain(entries []LexiconEntry, domains []string) []LexiconEntry {
+	keep := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if name, ok := domainNames[d]; ok {
+			d = name
+		}
+		keep[d] = true
+	}
+	var out []LexiconEntry
+	for _, e := range entries {
+		for _, d := range e.Domains {
+			if keep[d] {
+				out = append(out, e)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestDomains(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		{`<span class="fack">med.</span>`, []string{"medicin"}},
+		{`<span class="fack">jur., ekon.</span><span class="fack">Juridik</span>`, []string{"juridik", "ekonomi"}},
+		{`<span class="def">sport. mål i fotboll</span>`, []string{"sport"}},
+		{`<span class="def">med stor kraft</span>`, nil},
+		{`<span class="fack">okänd.</span>`, nil},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := selectorProfiles["so"].domainsIn(doc.Selection); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("domainsIn(%s) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByDomain(t *testing.T) {
+	html := `<span class="orto">dom</span><span class="ordklass">substantiv</span><span class="bojning">domen domar</span>` +
+		`<div class="kbetydelse"><span class="fack">jur.</span><span class="def">rättens avgörande</span></div>`
+	dom, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: html, Source: "so"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dom.Domains, []string{"juridik"}) || !reflect.DeepEqual(dom.Senses[0].Domains, []string{"juridik"}) {
+		t.Fatalf("domains %q, senses %+v", dom.Domains, dom.Senses)
+	}
+	entries := []LexiconEntry{{ID: "bil"}, dom}
+	for _, domains := range [][]string{{"jur."}, {"sport", " Juridik"}} {
+		if got := filterByDomain(entries, domains); len(got) != 1 || got[0].ID != "dom" {
+			t.Errorf("filterByDomain(%q) = %v, want dom", domains, got)
+		}
+	}
+}
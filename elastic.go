@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// elasticIndex is the index the bulk file targets; create it with
+// mapping.json first: curl -XPUT localhost:9200/saol -H 'Content-Type: application/json' -d @mapping.json
+const elasticIndex = "saol"
+
+// elasticMapping indexes the headword for search-as-you-type, every form
+// as an exact keyword (so "bilarna" finds "bil") and the definition as
+// Swedish text. It works with Elasticsearch 7+ and OpenSearch.
+const elasticMapping = `{
+  "mappings": {
+    "properties": {
+      "id":         {"type": "keyword"},
+      "familyID":   {"type": "integer"},
+      "headword":   {"type": "search_as_you_type"},
+      "homograph":  {"type": "integer"},
+      "class":      {"type": "keyword"},
+      "paradigm":   {"type": "keyword"},
+      "forms":      {"type": "keyword", "fields": {"prefix": {"type": "search_as_you_type"}}},
+      "definition": {"type": "text", "analyzer": "swedish"},
+      "entry":      {"type": "object", "enabled": false}
+    }
+  }
+}
+`
+
+// elasticDoc is the document indexed per lemma. Entry keeps the full
+// lexicon entry for display without being indexed.
+type elasticDoc struct {
+	ID         string       `json:"id"`
+	FamilyID   int          `json:"familyID"`
+	Headword   string       `json:"headword"`
+	Homograph  int          `json:"homograph,omitempty"`
+	Class      string       `json:"class"`
+	Paradigm   string       `json:"paradigm,omitempty"`
+	Forms      []string     `json:"forms"`
+	Definition string       `json:"definition,omitempty"`
+	Entry      LexiconEntry `json:"entry"`
+}
+
+// writeElasticBulk writes mapping.json and bulk.ndjson into dir. The bulk
+// file is a body for POST /_bulk: an index action line followed by the
+// document, per lemma.
+func writeElasticBulk(entries []LexiconEntry, dir string) error {
+	if err := createOutputDir(dir); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "mapping.json"), []byte(elasticMapping), 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, "bulk.ndjson"))
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	type action struct {
+		Index struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		} `json:"index"`
+	}
+	for _, e := range entries {
+		var a action
+		a.Index.Index, a.Index.ID = elasticIndex, e.ID
+		if err = enc.Encode(a); err != nil {
+			break
+		}
+		doc := elasticDoc{
+			ID:         e.ID,
+			FamilyID:   e.FamilyID,
+			Headword:   e.Headword,
+			Homograph:  e.Homograph,
+			Class:      e.Class,
+			Paradigm:   e.Paradigm,
+			Forms:      e.surfaceForms(),
+			Definition: e.Definition,
+			Entry:      e,
+		}
+		if err = enc.Encode(doc); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteElasticBulk(t *testing.T) {
+	dir := t.TempDir()
+	entries := fixtureEntries(t)
+	if err := writeElasticBulk(entries, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var mapping map[string]interface{}
+	data, err := os.ReadFile(filepath.Join(dir, "mapping.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		t.Fatalf("mapping.json is not JSON: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "bulk.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	lines := 0
+	for sc.Scan() {
+		if lines%2 == 0 {
+			var a map[string]map[string]string
+			if err := json.Unmarshal(sc.Bytes(), &a); err != nil || a["index"]["_index"] != elasticIndex || a["index"]["_id"] != entries[lines/2].ID {
+				t.Errorf("line %d: bad action %s", lines+1, sc.Text())
+			}
+		} else {
+			var doc elasticDoc
+			if err := json.Unmarshal(sc.Bytes(), &doc); err != nil || doc.Headword != entries[lines/2].Headword {
+				t.Errorf("line %d: bad document %s", lines+1, sc.Text())
+			}
+		}
+		lines++
+	}
+	if lines != 2*len(entries) {
+		t.Errorf("got %d lines, want %d", lines, 2*len(entries))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// nfc returns s in Unicode normalization form C, so that å, ä and ö are
+// single code points however the page spelled them: a decomposed "a" and
+// combining ring never matches the "å" of another resource.
+func nfc(s string) string {
+	return norm.NFC.String(s)
+}
+
+// mojibake matches UTF-8 decoded as Latin-1 or Windows-1252 and encoded
+// again: "Ã¥" for å, "Ã¤" for ä, "Ã¶" for ö, "â€“" for an en dash.
+var mojibake = regexp.MustCompile(`Ã[\x{80}-\x{BF}\x{152}\x{160}\x{178}\x{2018}-\x{201E}\x{2020}-\x{2022}\x{2026}\x{2030}\x{2039}\x{203A}\x{20AC}\x{2122}]|â€`)
+
+// The encoding problems encodingProblems reports.
+const (
+	problemInvalidUTF8  = "invalid UTF-8"
+	problemReplacement  = "replacement character"
+	problemMojibake     = "mojibake"
+	problemDecomposed   = "not NFC"
+	problemControlChars = "control characters"
+)
+
+// encodingProblems lists what is wrong with the encoding of s: bytes that
+// are not UTF-8, U+FFFD where a decoder already gave up on some, text
+// encoded twice, characters not in NFC (fixed by normalizing) and control
+// characters other than whitespace.
+func encodingProblems(s string) []string {
+	var problems []string
+	if !utf8.ValidString(s) {
+		problems = append(problems, problemInvalidUTF8)
+	}
+	if strings.Contains(s, "\uFFFD") {
+		problems = append(problems, problemReplacement)
+	}
+	if mojibake.MatchString(s) {
+		problems = append(problems, problemMojibake)
+	}
+	if !norm.NFC.IsNormalString(s) {
+		problems = append(problems, problemDecomposed)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0x7f }) >= 0 {
+		problems = append(problems, problemControlChars)
+	}
+	return problems
+}
+
+// encodingIssue is an article flatten found encoding problems in, for the
+// encoding report.
+type encodingIssue struct {
+	Index     int      `json:"index"`
+	Headwords []string `json:"headwords,omitempty"`
+	Problems  []string `json:"problems"`
+}
+
+// saveEncodingReport writes the articles with encoding problems to
+// filename.
+func saveEncodingReport(filename string, issues []encodingIssue) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, issues) })
+}
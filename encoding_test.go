@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestEncodingProblems(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"bilen går på vägen – fort", nil},
+		{"p\xe5 v\xe4gen", []string{problemInvalidUTF8}},
+		{"p\uFFFD", []string{problemReplacement}},
+		{"pÃ¥ vÃ¤gen", []string{problemMojibake}},
+		{"bil â€“ fordon", []string{problemMojibake}},
+		{"pa\u030a", []string{problemDecomposed}},
+		{"bil\x00", []string{problemControlChars}},
+		{"ÅÄÖ och Ã", nil},
+	}
+	for _, tt := range tests {
+		if got := encodingProblems(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("encodingProblems(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCellTextNFC(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<table><tr><td>la\u0308ser  ho\u0308gt</td></tr></table>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cellText(doc.Find("td")), "läser högt"; got != want {
+		t.Errorf("cellText = %q (% x), want %q", got, got, want)
+	}
+}
+
+func TestSplitArticleEncoding(t *testing.T) {
+	html := `<div class="article"><div class="lemma"><span class="grundform">pa` + "\u030a" + `</span><span class="ordklass">preposition</span></div></div>`
+	res := splitArticle(t.Context(), 1, []SelectorProfile{selectorProfiles["saol"]}, Job{Data: InputEntry{HTML: html}})
+	if res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	if !reflect.DeepEqual(res.EncodingProblems, []string{problemDecomposed}) {
+		t.Errorf("EncodingProblems = %q", res.EncodingProblems)
+	}
+	if len(res.Headwords) != 1 || res.Headwords[0] != "på" || !strings.Contains(res.LemmaHTMLs[0], "på") {
+		t.Errorf("lemmas not normalized: %q, %q", res.Headwords, res.LemmaHTMLs)
+	}
+}
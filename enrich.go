@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// maxEnrichFailures is how many entries in a row an enricher may fail on
+// before its source is considered down for the rest of the run.
+const maxEnrichFailures = 20
+
+// Enricher adds optional data from an external source, such as a frequency
+// list, a translation provider or a TTS backend, to lexicon entries.
+// Enrichment is best effort: a source that is unreachable only leaves its
+// fields absent.
+type Enricher interface {
+	// Name identifies the source in the manifest, e.g. "frequency".
+	Name() string
+	// Check reports whether the source can be used at all.
+	Check(ctx context.Context) error
+	// Missing reports whether entry still lacks the fields this source fills.
+	Missing(entry *LexiconEntry) bool
+	// Enrich fills the source's fields on entry.
+	Enrich(ctx context.Context, entry *LexiconEntry) error
+}
+
+// Degradation records a configured enrichment source that could not be
+// used, entirely or for some entries.
+type Degradation struct {
+	Source  string `json:"source"`
+	Reason  string `json:"reason"`
+	Skipped int    `json:"skippedEntries"`
+}
+
+// enrichManifestFile is the default name of the enrich manifest, which
+// migrate and validate recognize it by.
+const enrichManifestFile = "manifest.json"
+
+// Manifest describes one enrich run.
+type Manifest struct {
+	Generated time.Time     `json:"generated"`
+	Entries   int           `json:"entries"`
+	Degraded  []Degradation `json:"degraded,omitempty"`
+}
+
+// enrichLexicon runs every enricher over entries. With fillMissing only
+// entries an enricher reports as Missing are passed to it, which is how a
+// later run fills the gaps an earlier degraded run left.
+func enrichLexicon(ctx context.Context, entries []LexiconEntry, enrichers []Enricher, fillMissing bool, checkTimeout time.Duration) []Degradation {
+	var degraded []Degradation
+
+	for _, e := range enrichers {
+		pending := 0
+		for i := range entries {
+			if !fillMissing || e.Missing(&entries[i]) {
+				pending++
+			}
+		}
+		if pending == 0 {
+			continue
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := e.Check(checkCtx)
+		cancel()
+		if err != nil {
+			slog.Warn("enrichment source is unavailable, continuing without it", "source", e.Name(), "err", err)
+			degraded = append(degraded, Degradation{Source: e.Name(), Reason: err.Error(), Skipped: pending})
+			continue
+		}
+
+		failures, skipped := 0, 0
+		var lastErr error
+		for i := range entries {
+			entry := &entries[i]
+			if fillMissing && !e.Missing(entry) {
+				continue
+			}
+			if failures >= maxEnrichFailures {
+				skipped++
+				continue
+			}
+			if err := e.Enrich(ctx, entry); err != nil {
+				failures++
+				skipped++
+				lastErr = err
+				if failures == maxEnrichFailures {
+					slog.Warn("enrichment source keeps failing, skipping it for the remaining entries", "source", e.Name(), "failures", failures, "err", err)
+				}
+				continue
+			}
+			failures = 0
+		}
+		if skipped > 0 {
+			degraded = append(degraded, Degradation{Source: e.Name(), Reason: lastErr.Error(), Skipped: skipped})
+		}
+		slog.Info("enrichment source done", "source", e.Name(), "skipped", skipped, "pending", pending)
+	}
+	return degraded
+}
+
+// configuredEnrichers returns the enrichment sources enabled on the
+// command line: a frequency list when countsFile is set, Folkets lexikon
+// when folketsFile is, rule-based IPA with ipa, and audio when it is not nil.
+func configuredEnrichers(countsFile string, bandSize int, folketsFile string, ipa bool, audio Enricher) ([]Enricher, error) {
+	var enrichers []Enricher
+	if countsFile != "" {
+		list, err := readFrequencyList(countsFile, bandSize)
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, frequencyEnricher{list})
+	}
+	if folketsFile != "" {
+		lex, err := openFolkets(folketsFile)
+		if err != nil {
+			return nil, err
+		}
+		enrichers = append(enrichers, folketsEnricher{lex})
+	}
+	if ipa {
+		enrichers = append(enrichers, ipaEnricher{})
+	}
+	if audio != nil {
+		enrichers = append(enrichers, audio)
+	}
+	return enrichers, nil
+}
+
+// runEnrich builds (or, with -fill-missing, re-reads) a lexicon file and
+// runs the configured enrichment sources over it.
+func runEnrich(args []string) {
+	flags := flag.NewFlagSet("enrich", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas to build the lexicon from, or with -fill-missing an enriched lexicon")
+	out := flags.String("out", "lexicon.json", "enriched lexicon to write")
+	manifestFile := flags.String("manifest", enrichManifestFile, "manifest to write, recording degraded sources")
+	fillMissing := flags.Bool("fill-missing", false, "only enrich fields an earlier run left absent")
+	checkTimeout := flags.Duration("check-timeout", 10*time.Second, "how long to wait for a source to respond before treating it as unavailable")
+	counts := flags.String("counts", "", "frequency list, word<TAB>count per line, to annotate lemmas and forms with")
+	bandSize := flags.Int("band-size", 1000, "ranks per frequency band")
+	folkets := flags.String("folkets", "", "Folkets lexikon XML (folkets_sv_en_public.xml) to add English translations from")
+	sections := flags.String("sections", "", "JSON file of section labels, aliases and English names to add to or replace the built-in ones")
+	ipa := flags.Bool("ipa", false, "add a rough IPA transcription of every headword, by spelling rules")
+	ttsCommand := flags.String("tts-command", "", `local TTS program to speak each headword with, e.g. "espeak-ng -v sv -w {out} {text}" ({out} omitted: audio on stdout)`)
+	ttsURL := flags.String("tts-url", "", `HTTP TTS API to fetch each headword's audio from, e.g. "http://localhost:5002/api/tts?text={text}"`)
+	audioDir := flags.String("audio-dir", "audio", "directory to store the audio of -tts-command or -tts-url in")
+	audioExt := flags.String("audio-ext", "mp3", "file extension of the audio the TTS backend produces")
+	references := flags.String("references", "", "also write the graph of cross-references (se, jfr, äv.) between lemma IDs to this file, e.g. references.json")
+	keepRaw := flags.String("keep-raw", "", `keep each lemma's source table next to its forms, as "html" or "text"`)
+	order := addSortFlag(flags)
+	flags.Parse(args)
+	if err := checkSortOrder(*order); err != nil {
+		fatal("invalid -sort", "err", err)
+	}
+	if err := checkRawMode(*keepRaw); err != nil {
+		fatal("invalid -keep-raw", "err", err)
+	}
+
+	if *sections != "" {
+		if err := loadSectionConfig(*sections); err != nil {
+			fatal("could not load section labels", "file", *sections, "err", err)
+		}
+	}
+	audio, err := newAudioEnricher(*ttsCommand, *ttsURL, *audioDir, *audioExt)
+	if err != nil {
+		fatal("invalid TTS backend", "err", err)
+	}
+	enrichers, err := configuredEnrichers(*counts, *bandSize, *folkets, *ipa, audio)
+	if err != nil {
+		fatal("could not set up enrichment sources", "err", err)
+	}
+
+	var entries []LexiconEntry
+	if *fillMissing {
+		entries, err = readLexiconJSON(*in)
+	} else {
+		entries, err = loadLexicon(*in, WithRawTables(*keepRaw))
+	}
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+
+	if *order == sortSwedish {
+		sortEntriesSwedish(entries)
+	}
+	degraded := enrichLexicon(context.Background(), entries, enrichers, *fillMissing, *checkTimeout)
+
+	if err := saveLexiconJSON(entries, *out); err != nil {
+		fatal("could not save lexicon", "file", *out, "err", err)
+	}
+	if *references != "" {
+		if err := writeReferences(entries, *references); err != nil {
+			fatal("could not save reference graph", "file", *references, "err", err)
+		}
+	}
+
+	manifest := Manifest{Generated: time.Now().UTC(), Entries: len(entries), Degraded: degraded}
+	if err := saveFile(*manifestFile, func(w io.Writer) error { return writeVersionedJSON(w, manifest) }); err != nil {
+		fatal("could not save manifest", "file", *manifestFile, "err", err)
+	}
+
+	if len(degraded) > 0 {
+		slog.Warn("wrote lexicon with degraded sources; rerun with -fill-missing once they are back", "entries", len(entries), "file", *out, "degraded", len(degraded))
+		return
+	}
+	slog.Info("wrote lexicon", "entries", len(entries), "file", *out)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeEnricher marks the entries it enriched and fails as configured.
+type fakeEnricher struct {
+	down     bool
+	failFrom int // fail every entry from this call on, 0 never
+	calls    int
+	done     map[string]bool
+}
+
+func (f *fakeEnricher) Name() string { return "fake" }
+
+func (f *fakeEnricher) Check(ctx context.Context) error {
+	if f.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (f *fakeEnricher) Missing(e *LexiconEntry) bool { return !f.done[e.ID] }
+
+func (f *fakeEnricher) Enrich(ctx context.Context, e *LexiconEntry) error {
+	f.calls++
+	if f.failFrom > 0 && f.calls >= f.failFrom {
+		return errors.New("timeout")
+	}
+	f.done[e.ID] = true
+	return nil
+}
+
+func TestEnrichLexiconDegrades(t *testing.T) {
+	entries := fixtureEntries(t)
+
+	down := &fakeEnricher{down: true, done: map[string]bool{}}
+	degraded := enrichLexicon(context.Background(), entries, []Enricher{down}, false, time.Second)
+	if len(degraded) != 1 || degraded[0].Skipped != len(entries) || down.calls != 0 {
+		t.Fatalf("unreachable source: degraded = %+v, calls = %d", degraded, down.calls)
+	}
+
+	flaky := &fakeEnricher{failFrom: 3, done: map[string]bool{}}
+	degraded = enrichLexicon(context.Background(), entries, []Enricher{flaky}, false, time.Second)
+	if len(degraded) != 1 || degraded[0].Skipped != len(entries)-2 {
+		t.Fatalf("flaky source: degraded = %+v", degraded)
+	}
+
+	// A later -fill-missing pass only revisits what the flaky run skipped.
+	flaky.failFrom, flaky.calls = 0, 0
+	degraded = enrichLexicon(context.Background(), entries, []Enricher{flaky}, true, time.Second)
+	if len(degraded) != 0 || flaky.calls != len(entries)-2 {
+		t.Fatalf("fill-missing: degraded = %+v, calls = %d", degraded, flaky.calls)
+	}
+}
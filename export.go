@@ -0,0 +1,251 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// exporter writes a lexicon in a format some other tool consumes.
+type exporter struct {
+	// out is the default output path, a file or a directory depending on
+	// the format.
+	out   string
+	write func(entries []LexiconEntry, out string) error
+}
+
+var exporters = map[string]exporter{
+	"spacy":       {out: "spacy", write: writeSpacyLookups},
+	"lexc":        {out: "saol.lexc", write: writeLexc},
+	"anki":        {out: "saol.apkg", write: writeAnki},
+	"stardict":    {out: "stardict", write: writeStarDict},
+	"kindle":      {out: "kindle", write: writeKindle},
+	"parquet":     {out: "forms.parquet", write: writeParquet},
+	"elastic":     {out: "elasticsearch", write: writeElasticBulk},
+	"pb":          {out: "lexicon.pb", write: writeProtobuf},
+	"wordlist":    {out: "wordlist.txt", write: writeWordlist},
+	"relations":   {out: "relations.json", write: writeRelations},
+	"paradigms":   {out: "paradigms.json", write: writeParadigms},
+	"senses":      {out: "senses.json", write: writeSenses},
+	"references":  {out: "references.json", write: writeReferences},
+	"hyphenation": {out: "hyphenation", write: writeHyphenation},
+	"homonyms":    {out: "homonyms.json", write: writeHomonyms},
+}
+
+// exportFormats lists the names of the registered exporters.
+func exportFormats() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runExport parses the flattened lemmas into a lexicon and writes it with
+// the exporter named by -format, or with -pg-dsn loads it into PostgreSQL.
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "output format: "+strings.Join(exportFormats(), ", "))
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas to export")
+	out := flags.String("out", "", "where to write the export (default depends on -format)")
+	classes := flags.String("class", "", "only export these word classes, comma separated, e.g. substantiv,verb")
+	domains := flags.String("domain", "", "only export the lemmas of these subject fields, comma separated, e.g. medicin,jur.")
+	lemmaList := flags.String("lemmas", "", "only export the headwords listed in this file, one per line, in that order")
+	counts := flags.String("counts", "", "frequency list, word<TAB>count per line or one word per line most frequent first: annotate entries with frequency and band and export the most frequent first")
+	flags.StringVar(counts, "frequency", "", "alias of -counts")
+	band := flags.String("band", "", `with -counts, only export the headwords ranked in this range, e.g. "1-1000"`)
+	maxBand := flags.Int("max-band", 0, "with -counts, only export lemmas in frequency bands 1 to this")
+	bandSize := flags.Int("band-size", 1000, "ranks per frequency band")
+	folkets := flags.String("folkets", "", "Folkets lexikon XML: add English translations, for a bilingual export")
+	audioDir := flags.String("audio", "", "directory of headword audio, as enrich -tts-command writes it, to bundle into the anki and stardict exports")
+	where := flags.String("where", "", `only export the lemmas matching this filter, e.g. 'class=verb AND section="Perfekt particip"'`)
+	keepRaw := flags.String("keep-raw", "", `keep each lemma's source table next to its forms, as "html" or "text", in the formats with a place for it`)
+	pgDSN := flags.String("pg-dsn", "", "instead of writing a file, load the lexicon into this empty PostgreSQL database with COPY")
+	sample := addSampleFlags(flags)
+	order := addSortFlag(flags)
+	flags.Parse(args)
+	if err := checkSortOrder(*order); err != nil {
+		fatal("invalid -sort", "err", err)
+	}
+	if err := checkRawMode(*keepRaw); err != nil {
+		fatal("invalid -keep-raw", "err", err)
+	}
+
+	exp, ok := exporters[*format]
+	if !ok && *pgDSN == "" {
+		fatal("unknown -format", "format", *format, "want", strings.Join(exportFormats(), ", "))
+	}
+	if *out == "" {
+		*out = exp.out
+	}
+
+	entries, err := loadLexicon(*in, WithRawTables(*keepRaw))
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	var filter whereExpr
+	if *where != "" {
+		if filter, err = parseWhere(*where); err != nil {
+			fatal("invalid -where", "err", err)
+		}
+	}
+	if *classes != "" {
+		entries = filterByClass(entries, strings.Split(*classes, ","))
+	}
+	if *domains != "" {
+		entries = filterByDomain(entries, strings.Split(*domains, ","))
+	}
+	if *lemmaList != "" {
+		headwords, err := readHeadwordList(*lemmaList)
+		if err != nil {
+			fatal("could not read lemma selection", "file", *lemmaList, "err", err)
+		}
+		entries = selectHeadwords(entries, headwords)
+	}
+	if *band != "" && *counts == "" {
+		fatal("-band needs a frequency list, -counts")
+	}
+	if *counts != "" {
+		list, err := readFrequencyList(*counts, *bandSize)
+		if err != nil {
+			fatal("could not read frequency list", "file", *counts, "err", err)
+		}
+		if *band != "" {
+			from, to, err := parseRankRange(*band)
+			if err != nil {
+				fatal("invalid -band", "err", err)
+			}
+			entries = selectFrequencyRanks(entries, list, from, to)
+		} else {
+			entries = selectFrequencyBands(entries, list, *maxBand)
+		}
+	}
+	if *folkets != "" {
+		lex, err := openFolkets(*folkets)
+		if err != nil {
+			fatal("could not read Folkets lexikon", "file", *folkets, "err", err)
+		}
+		slog.Info("added English translations", "translated", lex.addTranslations(entries), "entries", len(entries))
+	}
+	if *audioDir != "" {
+		n, err := attachAudio(entries, *audioDir)
+		if err != nil {
+			fatal("could not read audio", "dir", *audioDir, "err", err)
+		}
+		slog.Info("attached audio", "withAudio", n, "entries", len(entries))
+	}
+	if filter != nil {
+		entries = filterWhere(entries, filter)
+	}
+	entries = applySample(sample, entries)
+	if *order == sortSwedish {
+		sortEntriesSwedish(entries)
+	}
+	if *pgDSN != "" {
+		if err := bulkLoadPostgres(entries, *pgDSN); err != nil {
+			fatal("could not load the lexicon into PostgreSQL", "err", err)
+		}
+		slog.Info("loaded lexicon into PostgreSQL", "entries", len(entries))
+		return
+	}
+	if err := exp.write(entries, *out); err != nil {
+		fatal("could not write export", "format", *format, "file", *out, "err", err)
+	}
+	slog.Info("wrote export", "format", *format, "entries", len(entries), "file", *out)
+}
+
+// filterByClass keeps the entries whose word class is one of classes.
+func filterByClass(entries []LexiconEntry, classes []string) []LexiconEntry {
+	keep := make(map[string]bool, len(classes))
+	for _, c := range classes {
+		keep[strings.TrimSpace(c)] = true
+	}
+	var out []LexiconEntry
+	for _, e := range entries {
+		if keep[e.Class] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// loadHeadwordFilter reads the allowlist only and the blocklist exclude,
+// either of which may be empty, and returns whether a headword passes
+// both: it is in only, if given, and not in exclude.
+func loadHeadwordFilter(only, exclude string) (func(headword string) bool, error) {
+	var allowed, blocked map[string]bool
+	var err error
+	if only != "" {
+		if allowed, err = readHeadwordSet(only); err != nil {
+			return nil, err
+		}
+	}
+	if exclude != "" {
+		if blocked, err = readHeadwordSet(exclude); err != nil {
+			return nil, err
+		}
+	}
+	return func(headword string) bool {
+		return (allowed == nil || allowed[headword]) && !blocked[headword]
+	}, nil
+}
+
+// readHeadwordSet is readHeadwordList as a set.
+func readHeadwordSet(filename string) (map[string]bool, error) {
+	headwords, err := readHeadwordList(filename)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(headwords))
+	for _, h := range headwords {
+		set[h] = true
+	}
+	return set, nil
+}
+
+// readHeadwordList reads one headword per line. Anything after a tab is
+// ignored, so "headword<TAB>count" frequency lists work as they are.
+func readHeadwordList(filename string) ([]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var headwords []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if tab := strings.IndexByte(line, '\t'); tab >= 0 {
+			line = line[:tab]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			headwords = append(headwords, line)
+		}
+	}
+	return headwords, nil
+}
+
+// selectHeadwords keeps the entries whose headword is in headwords,
+// ordered as in headwords; homographs keep their lexicon order.
+func selectHeadwords(entries []LexiconEntry, headwords []string) []LexiconEntry {
+	rank := make(map[string]int, len(headwords))
+	for i, h := range headwords {
+		if _, ok := rank[h]; !ok {
+			rank[h] = i
+		}
+	}
+	var out []LexiconEntry
+	for _, e := range entries {
+		if _, ok := rank[e.Headword]; ok {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return rank[out[i].Headword] < rank[out[j].Headword] })
+	return out
+}
+
+// createOutputDir makes sure dir exists for exporters that write several files.
+func createOutputDir(dir string) error {
+	return os.MkdirAll(dir, 0755)
+}
@@ -1,82 +1,325 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
-	"io/ioutil"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func main() {
-	inputFile := "flattened_lemmas.json"
+// runExtract filters the flattened lemmas down to the supported word classes
+// and writes the parsed inflection tables per class. Lemmas that take longer
+// than -entry-timeout are left out and written to -quarantine instead. The
+// input and the -dir the outputs go to may be URLs.
+func runExtract(args []string) {
+	flags := flag.NewFlagSet("extract", flag.ExitOnError)
+	in := flags.String("in", outputFile, "flattened lemmas to extract from, a file or an http(s)://, s3:// or gs:// URL")
+	dir := flags.String("dir", ".", "directory, or URL prefix, to write the class files and the other outputs to")
+	out := flags.String("out", combinedFile, "with -combined, the file in -dir to write every class to")
+	spelling := flags.String("spelling", "", `respell the exported forms: "modern" or "historical" (pre-1906)`)
+	ud := flags.Bool("ud", false, "add Universal Dependencies feature bundles (feats) to every form")
+	withUninflected := flags.Bool("uninflected", false, "also write uninflected.json with headword records for "+strings.Join(uninflectedClasses, ", "))
+	withProperNouns := flags.Bool("proper-nouns", false, "also write proper_nouns.json with every "+properNounClass+" and its genitive, e.g. for a gazetteer")
+	withAbbreviations := flags.Bool("abbreviations", false, "also write abbreviations.json with the abbreviation, expansion and class of every "+abbreviationClass)
+	profiles := flags.String("profiles", "", "JSON file of extra selector profiles the lemmas were flattened with")
+	sections := flags.String("sections", "", "JSON file of section labels, aliases and English names to add to or replace the built-in ones")
+	entryTimeout := flags.Duration("entry-timeout", 0, "give up on a lemma after this long and quarantine it (0 for no limit)")
+	quarantineFile := flags.String("quarantine", "", "where to write lemmas that ran past -entry-timeout (default quarantined_lemmas.json in -dir)")
+	combined := flags.Bool("combined", false, "write every class to -out, keyed by class, instead of one file per class")
+	manifestFile := flags.String("manifest", "", "also write a manifest of the files written, with lemma counts, schema version and hashes, e.g. extract_manifest.json")
+	only := flags.String("only", "", "only extract the headwords listed in this file, one per line, e.g. a textbook's vocabulary")
+	exclude := flags.String("exclude", "", "skip the headwords listed in this file, one per line, e.g. stopwords")
+	coverageFile := flags.String("coverage", "", "also write a report of how often each selector matched and of the table rows no parser took, e.g. coverage.json")
+	perf := addPerfFlags(flags)
+	sample := addSampleFlags(flags)
+	span := addSpanFlags(flags)
+	order := addSortFlag(flags)
+	flags.Parse(args)
+	defer perf.start()()
 
-	log.Println("Calling FilterLemmasByOrdklass...")
-	filteredHTMLs, err := FilterLemmasByOrdklass(inputFile)
+	if err := checkSortOrder(*order); err != nil {
+		fatal("invalid -sort", "err", err)
+	}
+
+	start, end, err := span.bounds()
 	if err != nil {
-		log.Fatalf("Function failed: %v", err)
+		fatal("invalid lemma range", "err", err)
 	}
 
-	log.Printf("Successfully filtered lemmas. Number of matching HTML entries: %d", len(filteredHTMLs))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var coverage *coverageReport
+	if *coverageFile != "" {
+		coverage = newCoverageReport()
+	}
 
-	log.Println("First few matching HTMLs:")
+	if *profiles != "" {
+		if err := loadSelectorProfiles(*profiles); err != nil {
+			fatal("could not load selector profiles", "file", *profiles, "err", err)
+		}
+	}
+	if *sections != "" {
+		if err := loadSectionConfig(*sections); err != nil {
+			fatal("could not load section labels", "file", *sections, "err", err)
+		}
+	}
+
+	var respell func(string) string
+	switch *spelling {
+	case "":
+	case "modern":
+		respell = ModernizeSpelling
+	case "historical":
+		respell = HistoricizeSpelling
+	default:
+		fatal("unknown -spelling, want modern or historical", "spelling", *spelling)
+	}
 
-	nouns := [][]string{}
-	verbs := [][]string{}
-	adjectives := [][]string{}
-	for _, html := range filteredHTMLs {
+	inputFile := *in
+	if *quarantineFile == "" {
+		*quarantineFile = joinLocation(*dir, "quarantined_lemmas.json")
+	}
+	if *dir != "." && !isRemoteLocation(*dir) {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			fatal("could not create output directory", "dir", *dir, "err", err)
+		}
+	}
 
-		reader := strings.NewReader(html)
-		doc, err := goquery.NewDocumentFromReader(reader)
+	slog.Debug("filtering lemmas", "file", inputFile)
+	opts := []Option{WithContext(ctx), WithRange(start, end)}
+	if *withUninflected {
+		opts = append(opts, WithExtraClasses(uninflectedClasses...))
+	}
+	if *withAbbreviations {
+		opts = append(opts, WithExtraClasses(abbreviationClass))
+	}
+	if *withProperNouns {
+		opts = append(opts, WithExtraClasses(properNounClass))
+	}
+	if *only != "" || *exclude != "" {
+		keep, err := loadHeadwordFilter(*only, *exclude)
 		if err != nil {
-			log.Fatal(err)
+			fatal("could not read headword list", "err", err)
+		}
+		opts = append(opts, WithHeadwords(keep))
+	}
+	file, err := openLocation(inputFile)
+	if err != nil {
+		fatal("could not open input", "file", inputFile, "err", err)
+	}
+	filtered, err := FilterLemmas(file, opts...)
+	file.Close()
+	if err != nil {
+		fatal("could not filter lemmas", "file", inputFile, "err", err)
+	}
+
+	slog.Info("filtered lemmas", "lemmas", len(filtered))
+	filtered = applySample(sample, filtered)
+	if *order == sortSwedish {
+		sortLemmasSwedish(filtered)
+	}
+
+	parsed := make(map[string][][]string)
+	uninflected := []UninflectedEntry{}
+	abbreviations := []AbbreviationEntry{}
+	properNouns := []ProperNounEntry{}
+	var quarantined []LemmaInput
+	multiTables := 0
+	for _, lemma := range filtered {
+		profile, err := profileFor(lemma.Source)
+		if err != nil {
+			fatal("unknown selector profile", "familyID", lemma.FamilyID, "err", err)
+		}
+
+		start := time.Now()
+		res, err := withEntryDeadline(ctx, *entryTimeout, func(ctx context.Context) (extractedLemma, error) {
+			return extractLemma(withCoverage(ctx, coverage), lemma, profile)
+		})
+		parseDuration.observe(time.Since(start), "stage", "extract")
+		entriesProcessed.inc("stage", "extract")
+		switch {
+		case ctx.Err() != nil:
+			fatal("extract cancelled", "err", ctx.Err())
+		case errors.Is(err, context.DeadlineExceeded):
+			entryErrors.inc("stage", "extract")
+			slog.Warn("lemma ran past the deadline, quarantining it", "familyID", lemma.FamilyID, "timeout", *entryTimeout)
+			quarantined = append(quarantined, lemma)
+			continue
+		case err != nil:
+			fatal("could not parse lemma", "familyID", lemma.FamilyID, "err", err)
+		}
+
+		if res.class != "" {
+			lemmasByClass.inc("stage", "extract", "class", res.class)
+		}
+		if tables := tableCount(res.forms); tables > 1 {
+			multiTables++
+			slog.Debug("lemma has several inflection tables", "familyID", lemma.FamilyID, "headword", lemma.Headword, "tables", tables)
+		}
+		if res.abbreviation != nil {
+			abbreviations = append(abbreviations, *res.abbreviation)
+		} else if res.properNoun != nil {
+			properNouns = append(properNouns, *res.properNoun)
+		} else if res.uninflected != nil {
+			uninflected = append(uninflected, *res.uninflected)
+		} else if res.class != "" {
+			parsed[res.class] = append(parsed[res.class], res.forms)
+		}
+	}
+
+	if multiTables > 0 {
+		slog.Info("parsed lemmas with several inflection tables; forms after the first table carry its number", "lemmas", multiTables)
+	}
+	if coverage != nil {
+		summary := coverage.summary()
+		if err := saveFile(*coverageFile, func(w io.Writer) error { return writeIndentedJSON(w, summary) }); err != nil {
+			fatal("could not save coverage report", "file", *coverageFile, "err", err)
 		}
+		slog.Info("wrote coverage report", "consumedRows", summary.ConsumedRows, "droppedRows", summary.DroppedRows, "file", *coverageFile)
+	}
 
-		switch doc.Find(".ordklass").Text() {
-		case "substantiv":
-			nouns = append(nouns, parseSubstantiv(doc))
-		case "verb":
-			verbs = append(verbs, parseVerbForms(doc))
-		case "adjektiv":
-			adjectives = append(adjectives, parseAdjektiv(doc))
+	if respell != nil {
+		for _, all := range parsed {
+			respellForms(all, respell)
 		}
 	}
 
-	if err := saveAdjectivesJSON(adjectives, "adjectives.json"); err != nil {
-		log.Fatalf("Failed to write adjectives.json: %v", err)
+	counts := make(map[string]int)
+	for _, class := range parsedClasses() {
+		counts[class] = len(parsed[class])
+	}
+	var written []string
+	if *combined {
+		if !*withUninflected {
+			uninflected = nil
+		}
+		file := joinLocation(*dir, *out)
+		err := saveFile(file, func(w io.Writer) error {
+			return writeCombinedJSON(w, parsed, parsedClasses(), *ud, uninflected)
+		})
+		if err != nil {
+			fatal("could not save combined output", "file", file, "err", err)
+		}
+		written = append(written, file)
+	} else {
+		for _, class := range parsedClasses() {
+			out := outputFor(class)
+			file := joinLocation(*dir, out.file)
+			err := saveFile(file, func(w io.Writer) error { return out.write(w, parsed[class], *ud) })
+			if err != nil {
+				fatal("could not save class output", "file", file, "err", err)
+			}
+			written = append(written, file)
+		}
+		if *withUninflected {
+			file := joinLocation(*dir, "uninflected.json")
+			if err := saveFile(file, func(w io.Writer) error { return WriteUninflectedJSON(w, uninflected) }); err != nil {
+				fatal("could not save uninflected lemmas", "file", file, "err", err)
+			}
+			written = append(written, file)
+		}
+	}
+	if *withAbbreviations {
+		file := joinLocation(*dir, "abbreviations.json")
+		if err := saveFile(file, func(w io.Writer) error { return WriteAbbreviationsJSON(w, abbreviations) }); err != nil {
+			fatal("could not save abbreviations", "file", file, "err", err)
+		}
+		written = append(written, file)
+		counts["abbreviations"] = len(abbreviations)
+	}
+	if *withProperNouns {
+		file := joinLocation(*dir, "proper_nouns.json")
+		if err := saveFile(file, func(w io.Writer) error { return WriteProperNounsJSON(w, properNouns) }); err != nil {
+			fatal("could not save proper nouns", "file", file, "err", err)
+		}
+		written = append(written, file)
+		counts["properNouns"] = len(properNouns)
+	}
+	if *withUninflected {
+		counts["uninflected"] = len(uninflected)
 	}
 
-	if err := saveVerbsJSON(verbs, "verbs.json"); err != nil {
-		log.Fatalf("could not save verbs.json: %v", err)
+	// After the class files, so check can tell this quarantine from one an
+	// earlier run left.
+	if len(quarantined) > 0 {
+		if err := saveQuarantine(*quarantineFile, quarantined); err != nil {
+			fatal("could not save quarantine", "file", *quarantineFile, "err", err)
+		}
+		slog.Info("quarantined slow lemmas", "lemmas", len(quarantined), "file", *quarantineFile)
 	}
 
-	for i, verb := range verbs {
-		fmt.Printf("%d: %s\n", i+1, strings.Join(verb, "; "))
+	if *manifestFile != "" {
+		if err := saveExtractManifest(*manifestFile, inputFile, counts, len(quarantined), written); err != nil {
+			fatal("could not save manifest", "file", *manifestFile, "err", err)
+		}
 	}
 }
 
-func parseSubstantiv(doc *goquery.Document) []string {
-	var nouns []string
-	currentCase := ""
+// extractedLemma is what extract got out of one lemma: the parsed forms of
+// a class with a parser, or the record of an uninflected one, an
+// abbreviation or a proper noun.
+type extractedLemma struct {
+	class        string
+	forms        []string
+	uninflected  *UninflectedEntry
+	abbreviation *AbbreviationEntry
+	properNoun   *ProperNounEntry
+}
 
-	doc.Find(".tabell tr").Each(func(_ int, s *goquery.Selection) {
+// extractLemma parses one lemma with the parser registered for its class.
+func extractLemma(ctx context.Context, lemma LemmaInput, profile SelectorProfile) (extractedLemma, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(lemma.HTML))
+	if err != nil {
+		return extractedLemma{}, err
+	}
 
-		if th := s.Find("th.ordformth"); th.Length() == 1 {
-			currentCase = strings.TrimSpace(th.Find("i").Text())
-			return
+	class := profile.class(doc.Selection)
+	ctx = startLemma(ctx, doc, profile, lemma, class)
+	if parse, ok := parserFor(class); ok {
+		if profile.indeclinable(doc.Selection) {
+			return extractedLemma{class: class, forms: []string{noInflection}}, nil
 		}
+		return extractedLemma{class: class, forms: parse(ctx, doc, profile)}, nil
+	}
+	if isUninflected(class) {
+		entry := parseUninflected(doc, profile)
+		return extractedLemma{class: class, uninflected: &entry}, nil
+	}
+	if class == abbreviationClass {
+		entry := parseAbbreviation(doc, profile)
+		return extractedLemma{class: class, abbreviation: &entry}, nil
+	}
+	if class == properNounClass {
+		entry := parseProperNoun(ctx, doc, profile)
+		return extractedLemma{class: class, properNoun: &entry}, nil
+	}
+	return extractedLemma{}, nil
+}
 
-		tds := s.Find("td")
+func parseSubstantiv(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	var nouns []string
+
+	eachTableRow(ctx, doc, p, func(currentCase string, tds *goquery.Selection) bool {
 		if tds.Length() != 2 {
-			return
+			return false
 		}
 
-		nounText := strings.TrimSpace(tds.Eq(0).Text())
+		nounText := cellText(tds.Eq(0))
+		if nounText == "" {
+			return false
+		}
 
-		ledText := strings.TrimSpace(tds.Eq(1).Text())
-		parts := strings.Fields(ledText)
+		parts := strings.Fields(nfc(tds.Eq(1).Text()))
 		var ledWord string
 		if len(parts) > 0 {
 			ledWord = parts[0]
@@ -84,33 +327,126 @@ func parseSubstantiv(doc *goquery.Document) []string {
 
 		entry := fmt.Sprintf("%s-%s-%s", nounText, ledWord, currentCase)
 		nouns = append(nouns, entry)
+		return true
 	})
 
 	return nouns
 }
 
-// parseVerbForms walks one .tabell and returns a []string where each entry
-// is "form-tense voice-Section", e.g. "knäsätter-presens aktiv-Finita former".
-func parseVerbForms(doc *goquery.Document) []string {
-	var forms []string
-	currentSection := ""
+// ledFeatures maps the led word in the second column of a noun table to
+// the gender, definiteness and number of the form next to it.
+var ledFeatures = map[string]NounForm{
+	"en":    {Gender: "utrum", Definiteness: "obestämd", Number: "singular"},
+	"ett":   {Gender: "neutrum", Definiteness: "obestämd", Number: "singular"},
+	"den":   {Gender: "utrum", Definiteness: "bestämd", Number: "singular"},
+	"det":   {Gender: "neutrum", Definiteness: "bestämd", Number: "singular"},
+	"flera": {Definiteness: "obestämd", Number: "plural"},
+	"de":    {Definiteness: "bestämd", Number: "plural"},
+}
+
+// NounForm is one form of a noun with the features its led word encodes.
+type NounForm struct {
+	Form         string   `json:"form"`
+	Variants     []string `json:"variants,omitempty"`
+	Case         string   `json:"case"`
+	Number       string   `json:"number,omitempty"`
+	Gender       string   `json:"gender,omitempty"`
+	Definiteness string   `json:"definiteness,omitempty"`
+	Feats        string   `json:"feats,omitempty"`
+	Table        int      `json:"table,omitempty"`
+}
 
-	doc.Find(".tabell tr").Each(func(_ int, s *goquery.Selection) {
-		if th := s.Find("th.ordformth"); th.Length() == 1 {
-			currentSection = strings.TrimSpace(th.Find("i").Text())
-			return
+// NounEntry is one noun in nouns.json. Gender is taken from the singular
+// led words and also set on the plural forms, whose led does not show it.
+// PluralOnly and Uncountable flag nouns SAOL inflects in one number only,
+// Inflection nouns marked "ingen böjning" and Defective the slots a noun
+// lacks in the numbers it has.
+type NounEntry struct {
+	Class       string     `json:"class"`
+	Gender      string     `json:"gender,omitempty"`
+	Inflection  string     `json:"inflection,omitempty"`
+	PluralOnly  bool       `json:"pluralOnly,omitempty"`
+	Uncountable bool       `json:"uncountable,omitempty"`
+	Defective   []string   `json:"defective,omitempty"`
+	Slots       *NounSlots `json:"slots,omitempty"`
+	Forms       []NounForm `json:"forms"`
+}
+
+// newNounEntry decodes the "form-led-Case" results of parseSubstantiv.
+func newNounEntry(raw []string) NounEntry {
+	entry := NounEntry{Class: "substantiv", Inflection: inflectionOf(raw), Forms: []NounForm{}}
+
+	for _, tagged := range raw {
+		last := strings.LastIndex(tagged, "-")
+		if last < 0 {
+			continue
+		}
+		rest := tagged[:last]
+		nounCase, table := splitTable(tagged[last+1:])
+		nounCase, _ = canonicalSection("substantiv", nounCase)
+
+		led := ""
+		if dash := strings.LastIndex(rest, "-"); dash >= 0 {
+			rest, led = rest[:dash], rest[dash+1:]
+		}
+
+		form := ledFeatures[led]
+		form.Form, form.Variants = splitVariants(rest)
+		form.Case = nounCase
+		form.Table = table
+		if entry.Gender == "" {
+			entry.Gender = form.Gender
+		}
+		entry.Forms = append(entry.Forms, form)
+	}
+
+	for i := range entry.Forms {
+		if entry.Forms[i].Gender == "" {
+			entry.Forms[i].Gender = entry.Gender
 		}
+	}
+	entry.Slots, entry.PluralOnly, entry.Uncountable = nounSlots(entry.Forms)
+	if entry.Slots != nil {
+		entry.Defective = entry.Slots.missing(entry.PluralOnly, entry.Uncountable)
+	}
+	return entry
+}
 
-		tds := s.Find("td")
+// WriteNounsJSON writes parsed nouns to w, each form with the features its
+// led word encodes.
+func WriteNounsJSON(w io.Writer, all [][]string, ud bool) error {
+	out := make([]NounEntry, 0, len(all))
+	for _, raw := range all {
+		entry := newNounEntry(raw)
+		if ud {
+			for i := range entry.Forms {
+				entry.Forms[i].Feats = nounFeats(entry.Forms[i])
+			}
+		}
+		out = append(out, entry)
+	}
+
+	return writeVersionedJSON(w, out)
+}
+
+// parseVerbForms walks one inflection table and returns a []string where each entry
+// is "form-tense voice-Section", e.g. "knäsätter-presens aktiv-Finita former".
+func parseVerbForms(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	var forms []string
+
+	eachTableRow(ctx, doc, p, func(currentSection string, tds *goquery.Selection) bool {
 		if tds.Length() == 0 {
-			return
+			return false
 		}
 
-		formText := strings.TrimSpace(tds.Eq(0).Text())
+		formText := cellText(tds.Eq(0))
+		if formText == "" {
+			return false
+		}
 
 		var tenseVoice string
 		if tds.Length() > 1 {
-			tenseVoice = strings.TrimSpace(tds.Eq(1).Text())
+			tenseVoice = cellText(tds.Eq(1))
 		}
 
 		entry := formText
@@ -120,27 +456,39 @@ func parseVerbForms(doc *goquery.Document) []string {
 		entry += "-" + currentSection
 
 		forms = append(forms, entry)
+		return true
 	})
 
 	return forms
 }
-func saveVerbsJSON(all [][]string, filename string) error {
+
+// WriteVerbsJSON writes parsed verbs to w in the class/forms schema, with
+// the particle and reflexive marker of multi-word verbs split off and the
+// principal parts and conjugation group picked out of the forms.
+func WriteVerbsJSON(w io.Writer, all [][]string, ud bool) error {
 	type verbJSON struct {
-		Class string              `json:"class"`
-		Forms map[string][]string `json:"forms"`
+		Class          string            `json:"class"`
+		Particle       string            `json:"particle,omitempty"`
+		Reflexive      bool              `json:"reflexive,omitempty"`
+		Inflection     string            `json:"inflection,omitempty"`
+		Defective      []string          `json:"defective,omitempty"`
+		PrincipalParts *PrincipalParts   `json:"principalParts,omitempty"`
+		Conjugation    string            `json:"conjugation,omitempty"`
+		Forms          map[string][]Form `json:"forms"`
 	}
 
-	var out []verbJSON
+	out := make([]verbJSON, 0, len(all))
 
 	for _, raw := range all {
+		particle, reflexive := verbParticles(raw)
+		raw = stripVerbParticles(raw, particle, reflexive)
+
 		entry := verbJSON{
-			Class: "verb",
-			Forms: map[string][]string{
-				"Finita former":    {},
-				"Infinita former":  {},
-				"Presens particip": {},
-				"Perfekt particip": {},
-			},
+			Class:      "verb",
+			Particle:   particle,
+			Reflexive:  reflexive,
+			Inflection: inflectionOf(raw),
+			Forms:      emptySections("verb"),
 		}
 
 		for _, tagged := range raw {
@@ -149,69 +497,354 @@ func saveVerbsJSON(all [][]string, filename string) error {
 			if last < 0 {
 				continue
 			}
-			section := tagged[last+1:]
-			fv := tagged[:last]
-			if _, ok := entry.Forms[section]; ok {
-				entry.Forms[section] = append(entry.Forms[section], fv)
+			section, table := splitTable(tagged[last+1:])
+			section, ok := knownSection("verb", section)
+			if ok {
+				f := newForm("verb", tagged[:last])
+				f.Table = table
+				entry.Forms[section] = append(entry.Forms[section], f)
 			}
 		}
+		if parts := principalParts(entry.Forms); parts != (PrincipalParts{}) {
+			entry.PrincipalParts = &parts
+			entry.Conjugation = conjugationGroup(parts)
+			entry.Defective = parts.missing()
+		}
+		if ud {
+			addUDFeats(entry.Class, entry.Forms)
+		}
 		out = append(out, entry)
 	}
 
-	data, err := json.MarshalIndent(out, "", "  ")
-	if err != nil {
-		return err
+	return writeVersionedJSON(w, out)
+}
+
+// verbParticles finds the particle ("komma ihåg") and reflexive marker
+// ("ångra sig") of a verb from its infinitive in the parsed forms.
+func verbParticles(raw []string) (particle string, reflexive bool) {
+	for _, tagged := range raw {
+		if !strings.HasSuffix(tagged, "-Infinita former") {
+			continue
+		}
+		form, rest := splitVerbForm(tagged)
+		if strings.HasPrefix(rest, "-infinitiv") {
+			return splitVerbPhrase(form)
+		}
 	}
-	return ioutil.WriteFile(filename, data, 0644)
+	return "", false
 }
-func parseAdjektiv(doc *goquery.Document) []string {
-	var entries []string
-	currentDegree := ""
 
-	doc.Find(".tabell tr").Each(func(_ int, s *goquery.Selection) {
+// splitVerbForm splits a verb parser result into its form and the
+// "-label-Section" after it at the last two dashes, as groupForms and
+// newForm do, so a hyphenated verb like "e-posta sig" stays whole.
+func splitVerbForm(tagged string) (form, rest string) {
+	last := strings.LastIndex(tagged, "-")
+	if last < 0 {
+		return tagged, ""
+	}
+	if dash := strings.LastIndex(tagged[:last], "-"); dash >= 0 {
+		return tagged[:dash], tagged[dash:]
+	}
+	return tagged[:last], tagged[last:]
+}
 
-		if th := s.Find("th.ordformth"); th.Length() == 1 {
-			currentDegree = strings.TrimSpace(th.Find("i").Text())
-			return
+// splitVerbPhrase splits a multi-word verb into the particle words after
+// the verb and whether "sig" is among them: "ge sig av" → "av", true.
+func splitVerbPhrase(phrase string) (particle string, reflexive bool) {
+	words := strings.Fields(phrase)
+	if len(words) < 2 {
+		return "", false
+	}
+	var particles []string
+	for _, w := range words[1:] {
+		if w == "sig" {
+			reflexive = true
+			continue
+		}
+		particles = append(particles, w)
+	}
+	return strings.Join(particles, " "), reflexive
+}
+
+// stripVerbParticles removes the particle and reflexive words from the
+// form part of each parser result, so "kommer ihåg-presens aktiv-Finita
+// former" becomes "kommer-presens aktiv-Finita former". The first word of
+// a form is always kept, and fused forms like "ihågkommen" are untouched.
+func stripVerbParticles(raw []string, particle string, reflexive bool) []string {
+	if particle == "" && !reflexive {
+		return raw
+	}
+	drop := make(map[string]bool)
+	for _, w := range strings.Fields(particle) {
+		drop[w] = true
+	}
+	if reflexive {
+		drop["sig"] = true
+	}
+
+	stripped := make([]string, len(raw))
+	for i, tagged := range raw {
+		form, rest := splitVerbForm(tagged)
+		words := strings.Fields(form)
+		if len(words) < 2 {
+			stripped[i] = tagged
+			continue
+		}
+		kept := words[:1]
+		for _, w := range words[1:] {
+			if !drop[w] {
+				kept = append(kept, w)
+			}
 		}
+		stripped[i] = strings.Join(kept, " ") + rest
+	}
+	return stripped
+}
+
+func parseAdjektiv(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	var entries []string
 
-		tds := s.Find("td")
+	eachTableRow(ctx, doc, p, func(currentDegree string, tds *goquery.Selection) bool {
 		if tds.Length() != 1 {
-			return
+			return false
 		}
 
-		raw := strings.TrimSpace(tds.Eq(0).Text())
+		raw := cellText(tds.Eq(0))
 
 		parts := strings.SplitN(raw, "+", 2)
 		form := strings.TrimSpace(parts[0])
+		if form == "" {
+			return false
+		}
 
 		entry := fmt.Sprintf("%s-%s", form, currentDegree)
 		entries = append(entries, entry)
+		return true
 	})
 
 	return entries
 }
 
+// parseAdverb walks the comparison table of an adverb, one form per row
+// under Positiv, Komparativ and Superlativ, and returns "form-Degree"
+// entries, e.g. "fortare-Komparativ". Adverbs without a table, most of
+// them, give their headword as the only Positiv form.
+func parseAdverb(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	var entries []string
+
+	eachTableRow(ctx, doc, p, func(currentDegree string, tds *goquery.Selection) bool {
+		if tds.Length() != 1 {
+			return false
+		}
+
+		form := cellText(tds.Eq(0))
+		if form == "" {
+			return false
+		}
+
+		entries = append(entries, fmt.Sprintf("%s-%s", form, currentDegree))
+		return true
+	})
+
+	if len(entries) == 0 {
+		if headword, _ := p.headword(doc.Selection); headword != "" {
+			entries = append(entries, headword+"-Positiv")
+		}
+	}
+	return entries
+}
+
+// parsePronomen walks a pronoun table, where each section is a case
+// (Subjektsform, Objektsform, Possessiv) and rows may add gender/number,
+// and returns "form-features-Section" entries, e.g. "mitt-neutrum-Possessiv".
+// Rows without a feature cell give "form-Section", e.g. "jag-Subjektsform".
+func parsePronomen(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	return parseFeatureRows(ctx, doc, p)
+}
+
+// parseRakneord walks a numeral table with a Grundtal (cardinal) and an
+// Ordningstal (ordinal) section, e.g. "ett-neutrum-Grundtal", "första-Ordningstal".
+func parseRakneord(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	return parseFeatureRows(ctx, doc, p)
+}
+
+// parseFeatureRows reads tables of one form per row with an optional
+// feature cell, as used by pronouns and numerals.
+func parseFeatureRows(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+	var forms []string
+
+	eachTableRow(ctx, doc, p, func(currentCase string, tds *goquery.Selection) bool {
+		if tds.Length() == 0 {
+			return false
+		}
+
+		form := cellText(tds.Eq(0))
+		if form == "" {
+			return false
+		}
+
+		var features string
+		if tds.Length() > 1 {
+			features = cellText(tds.Eq(1))
+		}
+
+		entry := form
+		if features != "" {
+			entry += "-" + features
+		}
+		entry += "-" + currentCase
+
+		forms = append(forms, entry)
+		return true
+	})
+
+	return forms
+}
+
+// WritePronounsJSON writes parsed pronouns to w in the class/forms schema.
+// Pronoun tables differ between lemmas, so unlike verbs and adjectives the
+// sections are whatever the table contained rather than a fixed set.
+func WritePronounsJSON(w io.Writer, all [][]string, ud bool) error {
+	return WriteClassJSON(w, "pronomen", all, ud)
+}
+
+// WriteClassJSON writes the parsed lemmas of class to w in the class/forms
+// schema, with the sections each table contained.
+func WriteClassJSON(w io.Writer, class string, all [][]string, ud bool) error {
+	type classJSON struct {
+		Class      string            `json:"class"`
+		Inflection string            `json:"inflection,omitempty"`
+		Forms      map[string][]Form `json:"forms"`
+	}
+
+	out := make([]classJSON, 0, len(all))
+	for _, raw := range all {
+		entry := classJSON{
+			Class:      class,
+			Inflection: inflectionOf(raw),
+			Forms:      groupForms(class, raw),
+		}
+		if ud {
+			addUDFeats(entry.Class, entry.Forms)
+		}
+		out = append(out, entry)
+	}
+
+	return writeVersionedJSON(w, out)
+}
+
+// classOutput is the file extract writes the lemmas of one word class to.
+type classOutput struct {
+	file  string
+	write func(w io.Writer, all [][]string, ud bool) error
+}
+
+// classOutputs are the files of the built-in parsers' classes.
+var classOutputs = map[string]classOutput{
+	"substantiv": {"nouns.json", WriteNounsJSON},
+	"verb":       {"verbs.json", WriteVerbsJSON},
+	"adjektiv":   {"adjectives.json", WriteAdjectivesJSON},
+	"pronomen":   {"pronouns.json", WritePronounsJSON},
+	"räkneord":   {"numerals.json", WriteNumeralsJSON},
+}
+
+// outputFor returns the output of class; classes registered with
+// RegisterParser beyond the built-in ones go to <class>.json.
+func outputFor(class string) classOutput {
+	if out, ok := classOutputs[class]; ok {
+		return out
+	}
+	return classOutput{class + ".json", func(w io.Writer, all [][]string, ud bool) error {
+		return WriteClassJSON(w, class, all, ud)
+	}}
+}
+
+// NumeralEntry is one räkneord in numerals.json. Cardinal and Ordinal are
+// the first form of the Grundtal and Ordningstal sections.
+type NumeralEntry struct {
+	Class      string            `json:"class"`
+	Inflection string            `json:"inflection,omitempty"`
+	Cardinal   string            `json:"cardinal,omitempty"`
+	Ordinal    string            `json:"ordinal,omitempty"`
+	Forms      map[string][]Form `json:"forms"`
+}
+
+// WriteNumeralsJSON writes parsed numerals to w with their cardinal and
+// ordinal picked out of the inflected forms.
+func WriteNumeralsJSON(w io.Writer, all [][]string, ud bool) error {
+	out := make([]NumeralEntry, 0, len(all))
+	for _, raw := range all {
+		entry := NumeralEntry{Class: "räkneord", Inflection: inflectionOf(raw), Forms: groupForms("räkneord", raw)}
+		if forms := entry.Forms["Grundtal"]; len(forms) > 0 {
+			entry.Cardinal = forms[0].Form
+		}
+		if forms := entry.Forms["Ordningstal"]; len(forms) > 0 {
+			entry.Ordinal = forms[0].Form
+		}
+		if ud {
+			addUDFeats(entry.Class, entry.Forms)
+		}
+		out = append(out, entry)
+	}
+
+	return writeVersionedJSON(w, out)
+}
+
+// uninflectedClasses are the word classes that have no inflection table
+// and are passed through as plain headword records.
+var uninflectedClasses = []string{"preposition", "konjunktion", "subjunktion", "interjektion"}
+
+func isUninflected(class string) bool {
+	for _, c := range uninflectedClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// UninflectedEntry is the record written for a lemma without an inflection table.
+type UninflectedEntry struct {
+	Class      string `json:"class"`
+	Headword   string `json:"headword"`
+	Definition string `json:"definition,omitempty"`
+}
+
+func parseUninflected(doc *goquery.Document, p SelectorProfile) UninflectedEntry {
+	headword, _ := p.headword(doc.Selection)
+	entry := UninflectedEntry{Class: p.class(doc.Selection), Headword: headword}
+	if p.Definition != "" {
+		entry.Definition = cellText(doc.Find(p.Definition).First())
+	}
+	return entry
+}
+
+// WriteUninflectedJSON writes headword records to w.
+func WriteUninflectedJSON(w io.Writer, entries []UninflectedEntry) error {
+	return writeVersionedJSON(w, entries)
+}
+
 // AdjectiveEntry defines the JSON schema without an ID.
 type AdjectiveEntry struct {
-	Class string              `json:"class"`
-	Forms map[string][]string `json:"forms"`
+	Class      string            `json:"class"`
+	Inflection string            `json:"inflection,omitempty"`
+	Defective  []string          `json:"defective,omitempty"`
+	Slots      *AdjectiveSlots   `json:"slots,omitempty"`
+	Forms      map[string][]Form `json:"forms"`
 }
 
-// saveAdjectivesJSON takes a slice of slice-of-strings and writes the JSON file.
-func saveAdjectivesJSON(adjs [][]string, filename string) error {
+// WriteAdjectivesJSON takes a slice of slice-of-strings and writes the JSON to w.
+// With ud set, every form also gets its UD feature bundle.
+func WriteAdjectivesJSON(w io.Writer, adjs [][]string, ud bool) error {
 	// Prepare a slice of entries
 	entries := make([]AdjectiveEntry, len(adjs))
 
 	for i, rawForms := range adjs {
 		// Initialize with fixed degrees
 		entry := AdjectiveEntry{
-			Class: "adjektiv",
-			Forms: map[string][]string{
-				"Positiv":    {},
-				"Komparativ": {},
-				"Superlativ": {},
-			},
+			Class:      "adjektiv",
+			Inflection: inflectionOf(rawForms),
+			Forms:      emptySections("adjektiv"),
 		}
 
 		// Populate based on each "form-Degree" string
@@ -223,80 +856,245 @@ func saveAdjectivesJSON(adjs [][]string, filename string) error {
 				continue
 			}
 			form := tagged[:idx]
-			degree := tagged[idx+1:]
+			degree, table := splitTable(tagged[idx+1:])
+			degree, ok := knownSection("adjektiv", degree)
 
-			// only append if it's one of the three known degrees
-			if _, ok := entry.Forms[degree]; ok {
-				entry.Forms[degree] = append(entry.Forms[degree], form)
+			// only append if it's one of the configured degrees
+			if ok {
+				f := newForm("adjektiv", form)
+				f.Table = table
+				entry.Forms[degree] = append(entry.Forms[degree], f)
 			}
 		}
 
-		entries[i] = entry
-	}
+		if entry.Slots = adjectiveSlots(entry.Forms); entry.Slots != nil {
+			entry.Defective = entry.Slots.missing()
+		}
+		if ud {
+			addUDFeats(entry.Class, entry.Forms)
+		}
 
-	// Marshal to pretty JSON
-	data, err := json.MarshalIndent(entries, "", "  ")
-	if err != nil {
-		return err
+		entries[i] = entry
 	}
 
-	// Write to the given filename
-	return ioutil.WriteFile(filename, data, 0644)
+	return writeVersionedJSON(w, entries)
 }
 
+// LemmaInput is what the later stages read of a flattened lemma. Class
+// and Headword are empty in files flattened before flatten cached them.
+// FilterLemmas skips the copies flatten -dedup link marked with DuplicateOf.
 type LemmaInput struct {
 	HTML     string `json:"html"`
 	FamilyID int    `json:"familyID"`
-}
+	Source   string `json:"source,omitempty"`
+	Class    string `json:"class,omitempty"`
+	Headword string `json:"headword,omitempty"`
 
-func FilterLemmasByOrdklass(filename string) ([]string, error) {
-	allowedOrdklass := map[string]bool{
-		"substantiv": true,
-		"verb":       true,
-		"adjektiv":   true,
-		"adverb":     true,
-	}
-	ordklassSelector := ".ordklass"
+	DuplicateOf int `json:"duplicateOf,omitempty"`
+}
 
-	file, err := os.Open(filename)
+// FilterLemmasByOrdklass is FilterLemmas for the flattened lemmas in filename.
+func FilterLemmasByOrdklass(ctx context.Context, filename string, extraClasses ...string) ([]LemmaInput, error) {
+	file, err := openLocation(filename)
 	if err != nil {
 		return nil, fmt.Errorf("error opening input file '%s': %w", filename, err)
 	}
 	defer file.Close()
+	return FilterLemmas(file, WithContext(ctx), WithExtraClasses(extraClasses...))
+}
 
-	var inputMap map[string]LemmaInput
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&inputMap)
-	if err != nil {
-		return nil, fmt.Errorf("error decoding JSON from '%s': %w", filename, err)
+// filterOptions are the settings of FilterLemmas.
+type filterOptions struct {
+	ctx          context.Context
+	extraClasses []string
+	keepHeadword func(string) bool
+	start, end   int
+}
+
+// Option configures FilterLemmas.
+type Option func(*filterOptions)
+
+// WithContext stops FilterLemmas with ctx's error once ctx is done.
+func WithContext(ctx context.Context) Option {
+	return func(o *filterOptions) { o.ctx = ctx }
+}
+
+// WithExtraClasses also keeps the lemmas of classes, which need no parser.
+func WithExtraClasses(classes ...string) Option {
+	return func(o *filterOptions) { o.extraClasses = append(o.extraClasses, classes...) }
+}
+
+// WithHeadwords only keeps the lemmas whose headword keep returns true for.
+func WithHeadwords(keep func(headword string) bool) Option {
+	return func(o *filterOptions) { o.keepHeadword = keep }
+}
+
+// errPastRange stops FilterLemmas at the end of its range.
+var errPastRange = errors.New("past the end of the range")
+
+// WithRange only keeps the lemmas at 0-based positions start to end of
+// the input, end excluded or -1 for no end, and stops reading at end.
+func WithRange(start, end int) Option {
+	return func(o *filterOptions) { o.start, o.end = start, end }
+}
+
+// FilterLemmas streams the flattened lemmas in r with ForEachLemma and
+// returns, in key order, every lemma whose word class has a registered
+// parser. Each lemma's word class is looked up with the selector profile it
+// was flattened with.
+func FilterLemmas(r io.Reader, opts ...Option) ([]LemmaInput, error) {
+	o := filterOptions{ctx: context.Background(), end: -1}
+	for _, opt := range opts {
+		opt(&o)
 	}
+	ctx := o.ctx
 
-	matchingHTMLs := make([]string, 0)
+	allowedOrdklass := make(map[string]bool)
+	for _, class := range append(parsedClasses(), o.extraClasses...) {
+		allowedOrdklass[class] = true
+	}
 
-	log.Printf("Processing %d entries from %s...", len(inputMap), filename)
+	var matching []Lemma
 	processedCount := 0
-	for key, entry := range inputMap {
+	err := ForEachLemma(r, func(lemma Lemma) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		processedCount++
 		if processedCount%1000 == 0 {
-			log.Printf("...processed %d entries", processedCount)
+			slog.Debug("filtering lemmas", "processed", processedCount)
+		}
+		if o.end >= 0 && processedCount > o.end {
+			return errPastRange
+		}
+		if processedCount <= o.start {
+			return nil
 		}
 
-		reader := strings.NewReader(entry.HTML)
-		doc, err := goquery.NewDocumentFromReader(reader)
+		if _, err := profileFor(lemma.Source); err != nil {
+			return err
+		}
+		if lemma.DuplicateOf != 0 {
+			return nil
+		}
+		class, err := lemma.WordClass()
 		if err != nil {
-
-			log.Printf("Warning: Failed to parse HTML for entry key '%s'. Skipping. Error: %v", key, err)
-			continue
+			slog.Warn("skipping lemma with unparsable HTML", "key", lemma.Key, "err", err)
+			return nil
+		}
+		if !allowedOrdklass[class] {
+			return nil
 		}
+		if o.keepHeadword != nil {
+			headword, err := lemma.HeadwordText()
+			if err != nil {
+				slog.Warn("skipping lemma with unparsable HTML", "key", lemma.Key, "err", err)
+				return nil
+			}
+			if !o.keepHeadword(headword) {
+				return nil
+			}
+		}
+		matching = append(matching, lemma)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPastRange) {
+		return nil, err
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		a, _ := strconv.Atoi(matching[i].Key)
+		b, _ := strconv.Atoi(matching[j].Key)
+		return a < b
+	})
+	slog.Info("filtered lemmas by word class", "processed", processedCount, "matching", len(matching))
 
-		ordklassText := strings.TrimSpace(doc.Find(ordklassSelector).First().Text())
+	inputs := make([]LemmaInput, len(matching))
+	for i, lemma := range matching {
+		inputs[i] = lemma.LemmaInput
+	}
+	return inputs, nil
+}
 
-		if allowedOrdklass[ordklassText] {
+// cellText returns the text of a table cell with runs of whitespace
+// collapsed to single spaces, so markup line breaks never end up in a form,
+// and in NFC.
+func cellText(s *goquery.Selection) string {
+	return nfc(strings.Join(strings.Fields(s.Text()), " "))
+}
 
-			matchingHTMLs = append(matchingHTMLs, entry.HTML)
-		}
+// sectionLabel returns the label of a table header cell. Parser results are
+// split at their last "-" to recover the label, so any dash inside the
+// label itself is turned into a space.
+func sectionLabel(th *goquery.Selection) string {
+	label := strings.ReplaceAll(th.Find("i").Text(), "-", " ")
+	return nfc(strings.Join(strings.Fields(label), " "))
+}
+
+// readFlattenedLemmas decodes the key → lemma map written by the flatten stage.
+func readFlattenedLemmas(filename string) (map[string]LemmaInput, error) {
+	file, err := openLocation(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening input file '%s': %w", filename, err)
+	}
+	defer file.Close()
+
+	inputMap, err := decodeFlattenedLemmas(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return inputMap, nil
+}
+
+// decodeFlattenedLemmas is readFlattenedLemmas for a stream, in either
+// format ForEachLemma reads.
+func decodeFlattenedLemmas(r io.Reader) (map[string]LemmaInput, error) {
+	inputMap := make(map[string]LemmaInput)
+	err := ForEachLemma(r, func(l Lemma) error {
+		inputMap[l.Key] = l.LemmaInput
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	log.Printf("Finished processing. Found %d matching entries.", len(matchingHTMLs))
+	return inputMap, nil
+}
 
-	return matchingHTMLs, nil
+// lemmaKeys returns the keys of a flattened lemma map in numeric order,
+// which is the order of the articles they were split out of.
+func lemmaKeys(inputMap map[string]LemmaInput) []string {
+	keys := make([]string, 0, len(inputMap))
+	for key := range inputMap {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+	return keys
+}
+
+// writeIndentedJSON writes v to w as indented JSON, the format of every
+// file extract writes.
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// saveFile creates filename, a file or a URL, and fills it with write.
+// An upload is only completed when write succeeds.
+func saveFile(filename string, write func(w io.Writer) error) error {
+	f, err := createLocation(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
 }
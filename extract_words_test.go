@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// saolProfile is the profile every fixture lemma is in.
+var saolProfile = selectorProfiles["saol"]
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		fixture string
+		parse   ParserFunc
+	}{
+		{"substantiv_bil", parseSubstantiv},
+		{"substantiv_hus", parseSubstantiv},
+		{"substantiv_man", parseSubstantiv},
+		{"verb_knasatta", parseVerbForms},
+		{"verb_simma", parseVerbForms},
+		{"verb_hoppas", parseVerbForms},
+		{"verb_komma_ihag", parseVerbForms},
+		{"verb_angra_sig", parseVerbForms},
+		{"adjektiv_fin", parseAdjektiv},
+		{"adjektiv_gratis", parseAdjektiv},
+		{"pronomen_jag", parsePronomen},
+		{"rakneord_en", parseRakneord},
+		{"adverb_fort", parseAdverb},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			doc := loadFixture(t, tt.fixture)
+			checkGolden(t, tt.fixture, tt.parse(context.Background(), doc, saolProfile))
+		})
+	}
+}
+
+// fuzzParser checks that parse never panics and that every result still
+// splits into its form and a complete section label at the last "-", which
+// is what WriteVerbsJSON and WriteAdjectivesJSON rely on.
+func fuzzParser(f *testing.F, parse ParserFunc) {
+	for _, name := range fixtureNames(f, "") {
+		f.Add(readFixture(f, name))
+	}
+
+	f.Fuzz(func(t *testing.T, html string) {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+		if err != nil {
+			return
+		}
+
+		labels := map[string]bool{"": true}
+		doc.Find(".tabell th.ordformth").Each(func(_ int, th *goquery.Selection) {
+			labels[sectionLabel(th)] = true
+		})
+
+		for _, entry := range parse(context.Background(), doc, saolProfile) {
+			last := strings.LastIndex(entry, "-")
+			if last <= 0 {
+				t.Fatalf("result %q has no form or no section field", entry)
+			}
+			if section := entry[last+1:]; !labels[section] {
+				t.Fatalf("result %q splits into unknown section %q", entry, section)
+			}
+			if strings.ContainsAny(entry, "\n\t") {
+				t.Fatalf("result %q contains unnormalized whitespace", entry)
+			}
+		}
+	})
+}
+
+func FuzzParseSubstantiv(f *testing.F) { fuzzParser(f, parseSubstantiv) }
+
+func FuzzParseVerbForms(f *testing.F) { fuzzParser(f, parseVerbForms) }
+
+func FuzzParseAdjektiv(f *testing.F) { fuzzParser(f, parseAdjektiv) }
+
+func FuzzParsePronomen(f *testing.F) { fuzzParser(f, parsePronomen) }
+
+func TestParseUninflected(t *testing.T) {
+	doc := loadFixture(t, "preposition_pa")
+	checkGolden(t, "preposition_pa", parseUninflected(doc, saolProfile))
+}
+
+func TestParseAdverbWithoutTable(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<span class="grundform">här</span><span class="ordklass">adverb</span>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseAdverb(context.Background(), doc, saolProfile); len(got) != 1 || got[0] != "här-Positiv" {
+		t.Errorf("parseAdverb(här) = %q, want [här-Positiv]", got)
+	}
+}
+
+func TestVerbParticles(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		particle  string
+		reflexive bool
+		presens   string
+	}{
+		{"verb_knasatta", "", false, "knäsätter-presens aktiv-Finita former"},
+		{"verb_komma_ihag", "ihåg", false, "kommer-presens aktiv-Finita former"},
+		{"verb_angra_sig", "", true, "ångrar-presens aktiv-Finita former"},
+	}
+	for _, tt := range tests {
+		raw := parseVerbForms(context.Background(), loadFixture(t, tt.fixture), saolProfile)
+		particle, reflexive := verbParticles(raw)
+		if particle != tt.particle || reflexive != tt.reflexive {
+			t.Errorf("%s: verbParticles = %q, %v, want %q, %v", tt.fixture, particle, reflexive, tt.particle, tt.reflexive)
+		}
+		if got := stripVerbParticles(raw, particle, reflexive)[0]; got != tt.presens {
+			t.Errorf("%s: stripped presens = %q, want %q", tt.fixture, got, tt.presens)
+		}
+	}
+
+	// A hyphen in the verb itself is not where its label starts.
+	raw := []string{"e-postar sig-presens aktiv-Finita former", "e-posta sig-infinitiv aktiv-Infinita former"}
+	particle, reflexive := verbParticles(raw)
+	if particle != "" || !reflexive {
+		t.Errorf("e-posta sig: verbParticles = %q, %v, want \"\", true", particle, reflexive)
+	}
+	want := []string{"e-postar-presens aktiv-Finita former", "e-posta-infinitiv aktiv-Infinita former"}
+	if got := stripVerbParticles(raw, particle, reflexive); !reflect.DeepEqual(got, want) {
+		t.Errorf("e-posta sig: stripped %q, want %q", got, want)
+	}
+}
+
+func TestNounEntry(t *testing.T) {
+	for _, name := range fixtureNames(t, "substantiv_") {
+		t.Run(name, func(t *testing.T) {
+			raw := parseSubstantiv(context.Background(), loadFixture(t, name), saolProfile)
+			checkGolden(t, name+"_entry", newNounEntry(raw))
+		})
+	}
+}
+
+func TestFilterLemmasFromReader(t *testing.T) {
+	input := `{
+		"2": {"html": "<span class=\"grundform\">på</span><span class=\"ordklass\">preposition</span>", "familyID": 2},
+		"1": {"html": "<span class=\"grundform\">bil</span><span class=\"ordklass\">substantiv</span>", "familyID": 1},
+		"3": {"html": "<span class=\"grundform\">fort</span><span class=\"ordklass\">adverb</span>", "familyID": 3},
+		"4": {"html": "<span class=\"grundform\">t.ex.</span><span class=\"ordklass\">förkortning</span>", "familyID": 4}
+	}`
+
+	lemmas, err := FilterLemmas(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lemmas) != 2 || lemmas[0].FamilyID != 1 || lemmas[1].FamilyID != 3 {
+		t.Errorf("FilterLemmas = %+v, want the noun and the adverb", lemmas)
+	}
+
+	lemmas, err = FilterLemmas(strings.NewReader(input), WithExtraClasses(uninflectedClasses...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lemmas) != 3 || lemmas[0].FamilyID != 1 || lemmas[1].FamilyID != 2 || lemmas[2].FamilyID != 3 {
+		t.Errorf("FilterLemmas with uninflected = %+v, want the noun, the preposition and the adverb in key order", lemmas)
+	}
+
+	lemmas, err = FilterLemmas(strings.NewReader(input), WithExtraClasses(uninflectedClasses...), WithHeadwords(func(h string) bool { return h != "bil" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lemmas) != 2 || lemmas[0].FamilyID != 2 || lemmas[1].FamilyID != 3 {
+		t.Errorf("FilterLemmas without bil = %+v, want the preposition and the adverb", lemmas)
+	}
+
+	var buf bytes.Buffer
+	raw := parseSubstantiv(context.Background(), loadFixture(t, "substantiv_bil"), saolProfile)
+	if err := WriteNounsJSON(&buf, [][]string{raw}, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"form": "bilen"`) {
+		t.Errorf("WriteNounsJSON wrote %s", buf.String())
+	}
+}
+
+func TestLoadHeadwordFilter(t *testing.T) {
+	dir := t.TempDir()
+	only := filepath.Join(dir, "only.txt")
+	exclude := filepath.Join(dir, "exclude.txt")
+	os.WriteFile(only, []byte("bil\nhus\t12\nkatt\n"), 0644)
+	os.WriteFile(exclude, []byte("katt\noch\n"), 0644)
+
+	tests := []struct {
+		only, exclude string
+		want          map[string]bool
+	}{
+		{only, "", map[string]bool{"bil": true, "hus": true, "katt": true, "och": false}},
+		{"", exclude, map[string]bool{"bil": true, "hus": true, "katt": false, "och": false}},
+		{only, exclude, map[string]bool{"bil": true, "hus": true, "katt": false, "och": false}},
+	}
+	for _, tt := range tests {
+		keep, err := loadHeadwordFilter(tt.only, tt.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for headword, want := range tt.want {
+			if got := keep(headword); got != want {
+				t.Errorf("only %q, exclude %q: keep(%q) = %v, want %v", tt.only, tt.exclude, headword, got, want)
+			}
+		}
+	}
+	if _, err := loadHeadwordFilter(filepath.Join(dir, "missing.txt"), ""); err == nil {
+		t.Error("missing list: want an error")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// folketsClasses maps Folkets lexikon word class codes to SAOL word classes.
+var folketsClasses = map[string]string{
+	"nn": "substantiv",
+	"vb": "verb",
+	"jj": "adjektiv",
+	"ab": "adverb",
+	"pn": "pronomen",
+	"rg": "räkneord",
+	"pp": "preposition",
+	"kn": "konjunktion",
+	"sn": "subjunktion",
+	"in": "interjektion",
+}
+
+type folketsWord struct {
+	Value        string `xml:"value,attr"`
+	Class        string `xml:"class,attr"`
+	Translations []struct {
+		Value string `xml:"value,attr"`
+	} `xml:"translation"`
+}
+
+// folketsKey identifies a Folkets headword; class is empty for words
+// Folkets gives no class.
+type folketsKey struct{ headword, class string }
+
+// folketsLexicon holds the English translations of Folkets lexikon
+// (folkets_sv_en_public.xml) by headword and word class.
+type folketsLexicon map[folketsKey][]string
+
+// readFolkets streams the <word> elements of a Folkets lexikon XML file.
+// The | Folkets marks compound boundaries with is removed from headwords.
+func readFolkets(r io.Reader) (folketsLexicon, error) {
+	dec := xml.NewDecoder(r)
+	lex := make(folketsLexicon)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return lex, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading Folkets XML: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "word" {
+			continue
+		}
+		var w folketsWord
+		if err := dec.DecodeElement(&w, &start); err != nil {
+			return nil, fmt.Errorf("error reading Folkets word: %w", err)
+		}
+		key := folketsKey{strings.ReplaceAll(w.Value, "|", ""), folketsClasses[w.Class]}
+		for _, t := range w.Translations {
+			if t.Value = strings.TrimSpace(t.Value); t.Value != "" {
+				lex[key] = appendUnique(lex[key], t.Value)
+			}
+		}
+	}
+}
+
+// openFolkets reads the Folkets lexikon XML file at filename.
+func openFolkets(filename string) (folketsLexicon, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readFolkets(f)
+}
+
+// translations returns the English translations of e: those Folkets lists
+// under the same headword and class, or under the headword alone when
+// Folkets gives it no class.
+func (lex folketsLexicon) translations(e LexiconEntry) []string {
+	if t := lex[folketsKey{e.Headword, e.Class}]; len(t) > 0 {
+		return t
+	}
+	return lex[folketsKey{e.Headword, ""}]
+}
+
+// addTranslations sets the translations of every entry Folkets has and
+// returns how many entries got one.
+func (lex folketsLexicon) addTranslations(entries []LexiconEntry) int {
+	n := 0
+	for i := range entries {
+		if t := lex.translations(entries[i]); len(t) > 0 {
+			entries[i].Translations = t
+			n++
+		}
+	}
+	return n
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, x := range list {
+		if x == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// folketsEnricher is the Enricher for a local copy of Folkets lexikon.
+type folketsEnricher struct {
+	lex folketsLexicon
+}
+
+func (f folketsEnricher) Name() string { return "folkets" }
+
+func (f folketsEnricher) Check(ctx context.Context) error { return nil }
+
+func (f folketsEnricher) Missing(e *LexiconEntry) bool { return len(e.Translations) == 0 }
+
+func (f folketsEnricher) Enrich(ctx context.Context, e *LexiconEntry) error {
+	e.Translations = f.lex.translations(*e)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const folketsSample = `<?xml version="1.0" encoding="UTF-8"?>
+<dictionary source-language="sv" target-language="en">
+<word value="bil" lang="sv" class="nn">
+  <translation value="car"/>
+  <translation value="automobile"/>
+  <paradigm><inflection value="bilen"/><inflection value="bilar"/></paradigm>
+</word>
+<word value="bil|verkstad" lang="sv" class="nn">
+  <translation value="garage"/>
+</word>
+<word value="fin" lang="sv" class="jj">
+  <translation value="fine"/>
+</word>
+<word value="fin" lang="sv" class="nn">
+  <translation value="Finn"/>
+</word>
+<word value="man" lang="sv">
+  <translation value="man"/>
+  <translation value="man"/>
+</word>
+</dictionary>`
+
+func TestFolketsTranslations(t *testing.T) {
+	lex, err := readFolkets(strings.NewReader(folketsSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []LexiconEntry{
+		{Headword: "bil", Class: "substantiv"},
+		{Headword: "bilverkstad", Class: "substantiv"},
+		{Headword: "fin", Class: "adjektiv"},
+		{Headword: "man", Class: "substantiv"},
+		{Headword: "simma", Class: "verb"},
+	}
+	if n := lex.addTranslations(entries); n != 4 {
+		t.Errorf("translated %d entries, want 4", n)
+	}
+	want := [][]string{{"car", "automobile"}, {"garage"}, {"fine"}, {"man"}, nil}
+	for i, e := range entries {
+		if !reflect.DeepEqual(e.Translations, want[i]) {
+			t.Errorf("%s: translations %q, want %q", e.Headword, e.Translations, want[i])
+		}
+	}
+
+	html := entryHTML(entries[0])
+	if !strings.Contains(html, `<i lang="en">car, automobile</i>`) {
+		t.Errorf("entryHTML does not show the translations: %s", html)
+	}
+}
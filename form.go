@@ -0,0 +1,111 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Form is one slot of an inflection table. Label holds what the table
+// says about the slot besides its section (tense and voice for verbs, the
+// led word for nouns); Variants holds alternative forms SAOL gives after
+// the main one, most preferred first. Feats is the Universal Dependencies
+// feature bundle of the slot, filled in by extract -ud, and Frequency its
+// corpus count, filled in from a frequency list. Table is the number of
+// the table the slot is from when a lemma has several, 0 for the first.
+type Form struct {
+	Form      string   `json:"form"`
+	Label     string   `json:"label,omitempty"`
+	Variants  []string `json:"variants,omitempty"`
+	Feats     string   `json:"feats,omitempty"`
+	Frequency int      `json:"frequency,omitempty"`
+	Table     int      `json:"table,omitempty"`
+}
+
+// labelledClasses are the word classes whose parsers append a label after
+// the form ("knäsätter-presens aktiv"); adjective results carry none.
+var labelledClasses = map[string]bool{
+	"substantiv": true,
+	"verb":       true,
+	"pronomen":   true,
+	"räkneord":   true,
+	"egennamn":   true,
+}
+
+// newForm decodes a parser result with its section already removed.
+func newForm(class, value string) Form {
+	var f Form
+	if labelledClasses[class] {
+		if dash := strings.LastIndex(value, "-"); dash >= 0 {
+			value, f.Label = value[:dash], value[dash+1:]
+		}
+	}
+	f.Form, f.Variants = splitVariants(value)
+	return f
+}
+
+// groupForms groups tagged parser results by the section after their last
+// "-", the same way WriteVerbsJSON and WriteAdjectivesJSON do.
+func groupForms(class string, tagged []string) map[string][]Form {
+	forms := make(map[string][]Form)
+	for _, t := range tagged {
+		last := strings.LastIndex(t, "-")
+		if last < 0 {
+			continue
+		}
+		section, table := splitTable(t[last+1:])
+		section, _ = canonicalSection(class, section)
+		f := newForm(class, t[:last])
+		f.Table = table
+		forms[section] = append(forms[section], f)
+	}
+	return forms
+}
+
+var (
+	parenthesized    = regexp.MustCompile(`\(([^()]*)\)`)
+	alternativeWords = regexp.MustCompile(`,?\s+(?:el\.|eller|även)\s+`)
+)
+
+// splitVariants splits a table cell that gives alternative forms, such as
+// "simmade el. sam", "simmat (även summit)" or "mej även mig", into the
+// main form and its variants in the order SAOL lists them.
+func splitVariants(cell string) (form string, variants []string) {
+	var parts []string
+	for _, m := range parenthesized.FindAllStringSubmatch(cell, -1) {
+		parts = append(parts, alternativeWords.Split(" "+m[1], -1)...)
+	}
+	main := parenthesized.ReplaceAllString(cell, " ")
+	parts = append(alternativeWords.Split(main, -1), parts...)
+
+	for _, p := range parts {
+		p = strings.Join(strings.Fields(p), " ")
+		if p == "" {
+			continue
+		}
+		if form == "" {
+			form = p
+			continue
+		}
+		variants = append(variants, p)
+	}
+	return form, variants
+}
+
+// attributivePrefixes and attributiveSuffix are the words SAOL shows
+// adjective and participle forms with ("en fin + substantiv", "är finast").
+var (
+	attributivePrefixes = []string{"den/det/de ", "en ", "ett ", "är "}
+	attributiveSuffix   = " + substantiv"
+)
+
+// wordForm strips the example context from an adjective or participle form,
+// leaving the word itself: "den/det/de fina + substantiv" becomes "fina".
+func wordForm(form string) string {
+	form = strings.TrimSuffix(form, attributiveSuffix)
+	for _, p := range attributivePrefixes {
+		if strings.HasPrefix(form, p) {
+			return form[len(p):]
+		}
+	}
+	return form
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSplitVariants(t *testing.T) {
+	tests := []struct {
+		cell     string
+		form     string
+		variants []string
+	}{
+		{"knäsatte", "knäsatte", nil},
+		{"simmade el. sam", "simmade", []string{"sam"}},
+		{"simmat (även summit)", "simmat", []string{"summit"}},
+		{"mig även mej", "mig", []string{"mej"}},
+		{"sov el. sovit (sovat)", "sov", []string{"sovit", "sovat"}},
+		{"(el. sam)", "sam", nil},
+		{"den/det/de fina", "den/det/de fina", nil},
+	}
+	for _, tt := range tests {
+		form, variants := splitVariants(tt.cell)
+		if form != tt.form || !reflect.DeepEqual(variants, tt.variants) {
+			t.Errorf("splitVariants(%q) = %q, %q, want %q, %q", tt.cell, form, variants, tt.form, tt.variants)
+		}
+	}
+}
+
+func TestGroupForms(t *testing.T) {
+	forms := groupForms("verb", parseVerbForms(context.Background(), loadFixture(t, "verb_simma"), saolProfile))
+	checkGolden(t, "verb_simma_forms", forms)
+}
+
+func TestWordForm(t *testing.T) {
+	for form, want := range map[string]string{
+		"en fin + substantiv":          "fin",
+		"den/det/de fina + substantiv": "fina",
+		"finare + substantiv":          "finare",
+		"är finast":                    "finast",
+		"ett knäsatt + substantiv":     "knäsatt",
+		"knäsatte":                     "knäsatte",
+		"ett":                          "ett",
+	} {
+		if got := wordForm(form); got != want {
+			t.Errorf("wordForm(%q) = %q, want %q", form, got, want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// frequencyList holds corpus counts per word form, read from a
+// "word<TAB>count" list such as a Kelly list or a corpus dump, or from a
+// list of words alone, most frequent first.
+type frequencyList struct {
+	counts map[string]int
+	// sorted holds every count, highest first, to rank a lemma frequency.
+	sorted []int
+	// bandSize is how many ranks make up one frequency band.
+	bandSize int
+	// ranks is the 1-based rank of every word, by count and then by
+	// first appearance in the list.
+	ranks map[string]int
+}
+
+// readFrequencyList reads a "word<TAB>count" list. Counts of a word listed
+// more than once, say in different cases of the same spelling, are added up.
+// A list without counts ranks its words by line; the counts they are
+// annotated with are then the ranks counted from the bottom of the list.
+func readFrequencyList(filename string, bandSize int) (*frequencyList, error) {
+	if bandSize < 1 {
+		return nil, fmt.Errorf("band size must be at least 1, got %d", bandSize)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	type line struct {
+		n     int
+		word  string
+		count string
+	}
+	var lines []line
+	withCounts := 0
+	for n, text := range strings.Split(string(data), "\n") {
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		word, count, ok := strings.Cut(text, "\t")
+		if ok {
+			withCounts++
+		}
+		lines = append(lines, line{n + 1, strings.TrimSpace(word), count})
+	}
+
+	list := &frequencyList{counts: make(map[string]int), bandSize: bandSize, ranks: make(map[string]int)}
+	var order []string
+	for i, l := range lines {
+		count := len(lines) - i
+		if withCounts > 0 {
+			if withCounts < len(lines) && l.count == "" {
+				return nil, fmt.Errorf("%s:%d: want word<TAB>count like the other lines, got %q", filename, l.n, l.word)
+			}
+			count, err = strconv.Atoi(strings.TrimSpace(l.count))
+			if err != nil || count < 0 {
+				return nil, fmt.Errorf("%s:%d: malformed count %q", filename, l.n, l.count)
+			}
+		}
+		if _, ok := list.counts[l.word]; !ok {
+			order = append(order, l.word)
+		}
+		list.counts[l.word] += count
+	}
+	sort.SliceStable(order, func(i, j int) bool { return list.counts[order[i]] > list.counts[order[j]] })
+	for i, word := range order {
+		list.ranks[word] = i + 1
+		list.sorted = append(list.sorted, list.counts[word])
+	}
+	return list, nil
+}
+
+// band returns the frequency band of a count: 1 for the bandSize most
+// frequent words, 2 for the next bandSize and so on; 0 when count is 0.
+func (l *frequencyList) band(count int) int {
+	if count == 0 {
+		return 0
+	}
+	rank := sort.Search(len(l.sorted), func(i int) bool { return l.sorted[i] <= count })
+	return rank/l.bandSize + 1
+}
+
+// annotate sets the corpus count of every slot of e, and the frequency and
+// band of the lemma, which counts each of its distinct surface forms once.
+func (l *frequencyList) annotate(e *LexiconEntry) {
+	for section, slots := range e.Forms {
+		for i := range slots {
+			e.Forms[section][i].Frequency = l.counts[wordForm(slots[i].Form)]
+		}
+	}
+	e.Frequency = 0
+	for _, f := range e.surfaceForms() {
+		e.Frequency += l.counts[f]
+	}
+	e.FrequencyBand = l.band(e.Frequency)
+}
+
+// frequencyEnricher is the Enricher for a local frequency list.
+type frequencyEnricher struct {
+	list *frequencyList
+}
+
+func (f frequencyEnricher) Name() string { return "frequency" }
+
+func (f frequencyEnricher) Check(ctx context.Context) error { return nil }
+
+func (f frequencyEnricher) Missing(e *LexiconEntry) bool { return e.FrequencyBand == 0 }
+
+func (f frequencyEnricher) Enrich(ctx context.Context, e *LexiconEntry) error {
+	f.list.annotate(e)
+	return nil
+}
+
+// selectFrequencyBands annotates entries from list and keeps those in
+// bands 1 to maxBand (all when maxBand is 0), most frequent first.
+func selectFrequencyBands(entries []LexiconEntry, list *frequencyList, maxBand int) []LexiconEntry {
+	var out []LexiconEntry
+	for i := range entries {
+		list.annotate(&entries[i])
+		if b := entries[i].FrequencyBand; maxBand == 0 || b > 0 && b <= maxBand {
+			out = append(out, entries[i])
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Frequency > out[j].Frequency })
+	return out
+}
+
+// selectFrequencyRanks annotates entries from list and keeps those whose
+// headword is ranked from to to (1-based, inclusive) in it, in rank order.
+func selectFrequencyRanks(entries []LexiconEntry, list *frequencyList, from, to int) []LexiconEntry {
+	var out []LexiconEntry
+	for i := range entries {
+		list.annotate(&entries[i])
+		if r := list.ranks[entries[i].Headword]; r >= from && r <= to {
+			out = append(out, entries[i])
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return list.ranks[out[i].Headword] < list.ranks[out[j].Headword] })
+	return out
+}
+
+// parseRankRange reads a -band value, "from-to" ranks counting from 1.
+func parseRankRange(band string) (from, to int, err error) {
+	if _, err := fmt.Sscanf(band, "%d-%d", &from, &to); err != nil || from < 1 || to < from {
+		return 0, 0, fmt.Errorf("malformed -band %q, want e.g. 1-1000", band)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFrequencyList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "freq.txt")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFrequencyBands(t *testing.T) {
+	path := writeFrequencyList(t, "och\t900\nbilen\t40\nbil\t30\nbilar\t20\nhus\t25\nsimma\t5\n")
+	list, err := readFrequencyList(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []LexiconEntry{
+		{ID: "simma", Headword: "simma", Class: "verb"},
+		{ID: "bil", Headword: "bil", Class: "substantiv", Forms: map[string][]Form{
+			"Nominativ": {{Form: "en bil"}, {Form: "bilen"}, {Form: "bilar"}},
+		}},
+		{ID: "hus", Headword: "hus", Class: "substantiv"},
+		{ID: "zon", Headword: "zon", Class: "substantiv"},
+	}
+	got := selectFrequencyBands(entries, list, 2)
+
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+	// bil (90) ranks behind och, hus (25) is rank 4, simma (5) rank 6 is
+	// band 3 and zon has no count at all.
+	if strings.Join(ids, " ") != "bil hus" {
+		t.Fatalf("selected %v, want [bil hus]", ids)
+	}
+	bil := got[0]
+	if bil.Frequency != 90 || bil.FrequencyBand != 1 {
+		t.Errorf("bil: frequency %d band %d, want 90 and 1", bil.Frequency, bil.FrequencyBand)
+	}
+	if f := bil.Forms["Nominativ"][0]; f.Frequency != 30 {
+		t.Errorf("en bil: frequency %d, want 30", f.Frequency)
+	}
+	if got[1].FrequencyBand != 2 || entries[0].FrequencyBand != 3 || entries[3].FrequencyBand != 0 {
+		t.Errorf("bands hus %d simma %d zon %d, want 2, 3 and 0", got[1].FrequencyBand, entries[0].FrequencyBand, entries[3].FrequencyBand)
+	}
+}
+
+func TestReadFrequencyListMalformed(t *testing.T) {
+	for _, content := range []string{"och\t900\nbil\n", "bil\tmånga\n"} {
+		if _, err := readFrequencyList(writeFrequencyList(t, content), 1000); err == nil {
+			t.Errorf("readFrequencyList(%q) succeeded, want an error", content)
+		}
+	}
+}
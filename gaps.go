@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Reasons a familyID has no lemmas.
+const (
+	gapQuarantined = "quarantined"
+	gapFailed      = "failed"
+	gapEmpty       = "empty"
+)
+
+// familyGap is a familyID flatten wrote no lemmas under. Family IDs are
+// the positions of the articles in the input, from 1, so a failed article
+// leaves its ID unused rather than shifting the IDs after it; the gap
+// report says which IDs those are and why.
+type familyGap struct {
+	FamilyID int    `json:"familyID"`
+	Reason   string `json:"reason"`
+	Error    string `json:"error,omitempty"`
+}
+
+// resultGap returns the gap a flatten result leaves, if any.
+func resultGap(res Result) (familyGap, bool) {
+	gap := familyGap{FamilyID: res.Index + 1}
+	if res.Error != nil {
+		gap.Error = res.Error.Error()
+	}
+	switch {
+	case res.Quarantined != nil:
+		gap.Reason = gapQuarantined
+	case res.Error != nil:
+		gap.Reason = gapFailed
+	case len(res.LemmaHTMLs) == 0:
+		gap.Reason = gapEmpty
+	default:
+		return familyGap{}, false
+	}
+	return gap, true
+}
+
+// saveFamilyGaps writes gaps to filename in familyID order.
+func saveFamilyGaps(filename string, gaps []familyGap) error {
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i].FamilyID < gaps[j].FamilyID })
+	if gaps == nil {
+		gaps = []familyGap{}
+	}
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, gaps) })
+}
+
+// readFamilyGaps reads a gap report.
+func readFamilyGaps(filename string) ([]familyGap, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	_, entries, err := readVersionedEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding family gaps '%s': %w", filename, err)
+	}
+	var gaps []familyGap
+	if err := json.Unmarshal(entries, &gaps); err != nil {
+		return nil, fmt.Errorf("error decoding family gaps '%s': %w", filename, err)
+	}
+	return gaps, nil
+}
+
+// fillFamilyGaps removes the families of the retried articles from the gap
+// report in filename; without a report there is nothing to do.
+func fillFamilyGaps(filename string, retried []Result) error {
+	gaps, err := readFamilyGaps(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	filled := make(map[int]bool, len(retried))
+	for _, res := range retried {
+		filled[res.Index+1] = len(res.LemmaHTMLs) > 0
+	}
+	remaining := []familyGap{}
+	for _, g := range gaps {
+		if !filled[g.FamilyID] {
+			remaining = append(remaining, g)
+		}
+	}
+	return saveFamilyGaps(filename, remaining)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResultGap(t *testing.T) {
+	failed := errors.New("no lemmas")
+	tests := []struct {
+		res    Result
+		want   familyGap
+		wantOK bool
+	}{
+		{Result{Index: 0, LemmaHTMLs: []string{"<div/>"}}, familyGap{}, false},
+		{Result{Index: 1, Error: failed, Quarantined: &InputEntry{}}, familyGap{FamilyID: 2, Reason: gapQuarantined, Error: "no lemmas"}, true},
+		{Result{Index: 2, Error: failed}, familyGap{FamilyID: 3, Reason: gapFailed, Error: "no lemmas"}, true},
+		{Result{Index: 3}, familyGap{FamilyID: 4, Reason: gapEmpty}, true},
+	}
+	for _, tt := range tests {
+		got, ok := resultGap(tt.res)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("resultGap(index %d) = %+v, %v, want %+v, %v", tt.res.Index, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFillFamilyGaps(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "family_gaps.json")
+	if err := fillFamilyGaps(file, []Result{{Index: 1}}); err != nil {
+		t.Fatalf("without a report: %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("fillFamilyGaps created %s", file)
+	}
+
+	gaps := []familyGap{{FamilyID: 7, Reason: gapFailed}, {FamilyID: 2, Reason: gapQuarantined}, {FamilyID: 5, Reason: gapQuarantined}}
+	if err := saveFamilyGaps(file, gaps); err != nil {
+		t.Fatal(err)
+	}
+	// Family 2 is filled by the retry; family 5 is retried but still empty.
+	retried := []Result{{Index: 1, LemmaHTMLs: []string{"<div/>"}}, {Index: 4}}
+	if err := fillFamilyGaps(file, retried); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readFamilyGaps(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	checkValid(t, "family_gaps.json", data)
+	want := []familyGap{{FamilyID: 5, Reason: gapQuarantined}, {FamilyID: 7, Reason: gapFailed}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("gaps after retry = %+v, want %+v", got, want)
+	}
+}
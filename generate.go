@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// GeneratedForm is a form GenerateForm found for a feature bundle, with
+// the lemma and the slot of its paradigm it comes from.
+type GeneratedForm struct {
+	ID       string   `json:"id"`
+	Class    string   `json:"class"`
+	Form     string   `json:"form"`
+	Variants []string `json:"variants,omitempty"`
+	Section  string   `json:"section"`
+	Label    string   `json:"label,omitempty"`
+	Feats    string   `json:"feats"`
+}
+
+// featureRequest is a parsed feature bundle: the word class it asks for,
+// if any, and the UD features a form must have.
+type featureRequest struct {
+	class string
+	feats map[string]string
+}
+
+// parseFeatureRequest reads a feature bundle such as
+// "verb+preteritum+passiv" or "substantiv plural bestämd genitiv": the
+// words SAOL labels forms with, a word class and UD features such as
+// "Tense=Past", separated by "+", spaces or commas. A tense without a verb
+// form asks for the finite form, since the participles have tenses too.
+func parseFeatureRequest(spec string) (featureRequest, error) {
+	req := featureRequest{feats: make(map[string]string)}
+	words := strings.FieldsFunc(spec, func(r rune) bool { return r == '+' || r == ',' || r == ' ' })
+	for _, word := range words {
+		if kv := strings.SplitN(word, "=", 2); len(kv) == 2 {
+			req.feats[kv[0]] = kv[1]
+			continue
+		}
+		word = strings.ToLower(word)
+		if feats, ok := udWordFeatures[word]; ok {
+			for _, f := range feats {
+				kv := strings.SplitN(f, "=", 2)
+				req.feats[kv[0]] = kv[1]
+			}
+			continue
+		}
+		if isLexiconClass(word) {
+			req.class = word
+			continue
+		}
+		return featureRequest{}, fmt.Errorf("unknown feature %q, want a word class, a SAOL label such as preteritum or a UD feature such as Tense=Past", word)
+	}
+	if req.feats["Tense"] != "" && req.feats["VerbForm"] == "" {
+		req.feats["VerbForm"] = "Fin"
+	}
+	return req, nil
+}
+
+// isLexiconClass reports whether word names a class of the lexicon.
+func isLexiconClass(word string) bool {
+	for _, class := range lexiconClasses() {
+		if class == word {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a form with the UD features feats satisfies req.
+func (req featureRequest) matches(feats string) bool {
+	have := parseFeats(feats)
+	for k, v := range req.feats {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateForm returns the forms of the lemmas with headword lemma that
+// have every feature in features, the inverse of a form lookup: ("sätta",
+// "verb+preteritum+passiv") gives "sattes". Adjective and participle forms
+// come without the words SAOL shows them with. It is an error for features
+// to hold a word it does not know; no lemma or no such form gives none.
+func GenerateForm(store Store, lemma, features string) ([]GeneratedForm, error) {
+	req, err := parseFeatureRequest(features)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := store.GetLemma(lemma)
+	if err != nil {
+		return nil, err
+	}
+	out := []GeneratedForm{}
+	for _, e := range entries {
+		if req.class != "" && e.Class != req.class {
+			continue
+		}
+		for _, section := range sortedKeys(e.Forms) {
+			for _, f := range e.Forms[section] {
+				feats := slotFeats(e, section, f)
+				if !req.matches(feats) {
+					continue
+				}
+				g := GeneratedForm{ID: e.ID, Class: e.Class, Form: wordForm(f.Form), Section: section, Label: f.Label, Feats: feats}
+				for _, v := range f.Variants {
+					g.Variants = append(g.Variants, wordForm(v))
+				}
+				out = append(out, g)
+			}
+		}
+	}
+	return out, nil
+}
+
+// handleGenerate answers GET /generate/{lemma}?features=verb+preteritum+passiv.
+func handleGenerate(store Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		features := r.URL.Query().Get("features")
+		if features == "" {
+			http.Error(w, "features is required", http.StatusBadRequest)
+			return
+		}
+		forms, err := GenerateForm(store, r.PathValue("lemma"), features)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(forms) == 0 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, forms)
+	}
+}
+
+// runGenerate prints the forms of a lemma with the given features.
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to generate from")
+	format := flags.String("format", "text", "output format: text, one form per line, or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool generate [-in file] [-format text|json] <lemma> <features>")
+		fmt.Fprintln(os.Stderr, `e.g. saoltool generate sätta verb+preteritum+passiv`)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	forms, err := GenerateForm(newMemStore(entries), flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		fatal("could not generate", "lemma", flags.Arg(0), "err", err)
+	}
+	if *format == "json" {
+		if err := writeIndentedJSON(os.Stdout, forms); err != nil {
+			fatal("could not write forms", "err", err)
+		}
+		return
+	}
+	for _, f := range forms {
+		fmt.Println(strings.Join(append([]string{f.Form}, f.Variants...), "\t"))
+	}
+}
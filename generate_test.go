@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateForm(t *testing.T) {
+	store := newMemStore(fixtureEntries(t))
+	tests := []struct {
+		lemma, features string
+		want            []string
+	}{
+		{"knäsätta", "verb+preteritum+passiv", []string{"knäsattes"}},
+		{"knäsätta", "preteritum", []string{"knäsatte", "knäsattes"}},
+		{"knäsätta", "supinum aktiv", []string{"knäsatt"}},
+		{"knäsätta", "perfekt particip neutrum", []string{"knäsatt"}},
+		{"knäsätta", "Mood=Imp", []string{"knäsätt"}},
+		{"bil", "substantiv+plural+bestämd+genitiv", []string{"bilarnas"}},
+		{"fin", "komparativ", []string{"finare"}},
+		{"fin", "superlativ bestämd", []string{"finaste"}},
+		{"bil", "verb+presens", nil},
+		{"nej", "presens", nil},
+	}
+	for _, tt := range tests {
+		forms, err := GenerateForm(store, tt.lemma, tt.features)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.lemma, tt.features, err)
+			continue
+		}
+		var got []string
+		for _, f := range forms {
+			got = append(got, f.Form)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GenerateForm(%q, %q) = %q, want %q", tt.lemma, tt.features, got, tt.want)
+		}
+	}
+
+	if _, err := GenerateForm(store, "bil", "substantiv+dual"); err == nil {
+		t.Error("dual: want an error for an unknown feature")
+	}
+}
+
+func TestServeGenerate(t *testing.T) {
+	mux := newServeMux(newMemStore(fixtureEntries(t)), nil)
+	tests := []struct {
+		path string
+		code int
+	}{
+		{"/generate/knäsätta?features=verb+preteritum+passiv", http.StatusOK},
+		{"/generate/knäsätta?features=verb%2Bpreteritum", http.StatusOK},
+		{"/generate/knäsätta", http.StatusBadRequest},
+		{"/generate/knäsätta?features=dual", http.StatusBadRequest},
+		{"/generate/bil?features=imperativ", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// readFixture returns the contents of testdata/<name>.html.
+func readFixture(t testing.TB, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".html"))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// fixtureNames lists the fixtures in testdata whose name starts with prefix.
+func fixtureNames(t testing.TB, prefix string) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", prefix+"*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = strings.TrimSuffix(filepath.Base(path), ".html")
+	}
+	return names
+}
+
+// loadFixture parses testdata/<name>.html the same way the pipeline parses a lemma.
+func loadFixture(t testing.TB, name string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(readFixture(t, name)))
+	if err != nil {
+		t.Fatalf("parsing fixture %s: %v", name, err)
+	}
+	return doc
+}
+
+// checkGolden compares got, encoded as indented JSON, against
+// testdata/<name>.golden. Run `go test -update` to accept new output.
+func checkGolden(t *testing.T, name string, got interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(got); err != nil {
+		t.Fatalf("encoding result: %v", err)
+	}
+	data := buf.Bytes()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run go test -update to create it)", err)
+	}
+	if string(want) != string(data) {
+		t.Errorf("%s mismatch\n--- got ---\n%s--- want ---\n%s", path, data, want)
+	}
+}
+
+// decodeEntries decodes the entries of an output file into v.
+func decodeEntries(t testing.TB, data []byte, v interface{}) {
+	t.Helper()
+	_, entries, err := readVersionedEntries(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(entries, v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The gRPC service is proto/lexicon.proto's Lexicon. Like the pb export it
+// is encoded with protowire rather than generated code: requests and
+// responses are wireMessages, which wireCodec puts on the wire, so clients
+// generated from the .proto talk to it unchanged.
+
+// wireMessage is a message that encodes itself.
+type wireMessage interface {
+	marshalWire() []byte
+	unmarshalWire(b []byte) error
+}
+
+// pbMessage is an already encoded response message.
+type pbMessage []byte
+
+func (m pbMessage) marshalWire() []byte { return m }
+
+func (m *pbMessage) unmarshalWire(b []byte) error {
+	*m = append((*m)[:0], b...)
+	return nil
+}
+
+type wireCodec struct{}
+
+func (wireCodec) Name() string { return "proto" }
+
+func (wireCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T", v)
+	}
+	return m.marshalWire(), nil
+}
+
+func (wireCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("cannot decode into %T", v)
+	}
+	return m.unmarshalWire(data)
+}
+
+type analyzeFormRequest struct{ form string }
+
+func (r *analyzeFormRequest) marshalWire() []byte { return appendString(nil, 1, r.form) }
+
+func (r *analyzeFormRequest) unmarshalWire(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, v []byte, _ uint64) {
+		if num == 1 {
+			r.form = string(v)
+		}
+	})
+}
+
+type generateRequest struct {
+	lemmaID  string
+	features map[string]string
+}
+
+func (r *generateRequest) marshalWire() []byte {
+	feats := make([]string, 0, len(r.features))
+	for name, value := range r.features {
+		feats = append(feats, name+"="+value)
+	}
+	b := appendString(nil, 1, r.lemmaID)
+	return appendMessage(b, 2, marshalFeatures(strings.Join(feats, "|")))
+}
+
+func (r *generateRequest) unmarshalWire(b []byte) error {
+	var inner error
+	err := consumeFields(b, func(num protowire.Number, v []byte, _ uint64) {
+		switch num {
+		case 1:
+			r.lemmaID = string(v)
+		case 2:
+			r.features, inner = unmarshalFeatures(v)
+		}
+	})
+	if err == nil {
+		err = inner
+	}
+	return err
+}
+
+type searchPrefixRequest struct {
+	prefix string
+	limit  int
+}
+
+func (r *searchPrefixRequest) marshalWire() []byte {
+	return appendInt(appendString(nil, 1, r.prefix), 2, r.limit)
+}
+
+func (r *searchPrefixRequest) unmarshalWire(b []byte) error {
+	return consumeFields(b, func(num protowire.Number, v []byte, n uint64) {
+		switch num {
+		case 1:
+			r.prefix = string(v)
+		case 2:
+			r.limit = int(int32(n))
+		}
+	})
+}
+
+// lexiconService answers the Lexicon RPCs from a Store.
+type lexiconService struct {
+	store Store
+}
+
+// lexiconServer is the handler type of lexiconServiceDesc.
+type lexiconServer interface {
+	analyzeForm(ctx context.Context, req *analyzeFormRequest) (pbMessage, error)
+	generate(ctx context.Context, req *generateRequest) (pbMessage, error)
+	searchPrefix(req *searchPrefixRequest, stream grpc.ServerStream) error
+}
+
+func (s *lexiconService) analyzeForm(ctx context.Context, req *analyzeFormRequest) (pbMessage, error) {
+	entries, err := s.store.SearchForm(req.form)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error reading lexicon: %v", err)
+	}
+	var resp []byte
+	for _, e := range entries {
+		for _, section := range e.sections() {
+			for _, slot := range e.Forms[section] {
+				if !slotHasForm(slot, req.form) {
+					continue
+				}
+				var a []byte
+				a = appendString(a, 1, e.ID)
+				a = appendString(a, 2, e.Headword)
+				a = appendString(a, 3, e.Class)
+				a = appendMessage(a, 4, marshalForm(e, section, slot))
+				resp = appendMessage(resp, 1, a)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// slotHasForm reports whether form is the word of slot or of one of its variants.
+func slotHasForm(slot Form, form string) bool {
+	if wordForm(slot.Form) == form {
+		return true
+	}
+	for _, v := range slot.Variants {
+		if wordForm(v) == form {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *lexiconService) generate(ctx context.Context, req *generateRequest) (pbMessage, error) {
+	e, ok, err := s.store.GetByID(req.lemmaID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error reading lexicon: %v", err)
+	}
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no lemma %q", req.lemmaID)
+	}
+	var resp []byte
+	for _, section := range e.sections() {
+	slots:
+		for _, slot := range e.Forms[section] {
+			feats := parseFeats(slotFeats(e, section, slot))
+			for name, value := range req.features {
+				if feats[name] != value {
+					continue slots
+				}
+			}
+			resp = appendMessage(resp, 1, marshalForm(e, section, slot))
+		}
+	}
+	return resp, nil
+}
+
+func (s *lexiconService) searchPrefix(req *searchPrefixRequest, stream grpc.ServerStream) error {
+	limit := req.limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		return status.Errorf(codes.InvalidArgument, "limit must be at most %d", maxSearchLimit)
+	}
+	entries, err := s.store.SearchPrefix(req.prefix, limit)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error reading lexicon: %v", err)
+	}
+	for _, e := range entries {
+		msg := pbMessage(marshalEntry(e))
+		if err := stream.SendMsg(&msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var lexiconServiceDesc = grpc.ServiceDesc{
+	ServiceName: "saol.Lexicon",
+	HandlerType: (*lexiconServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AnalyzeForm",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(analyzeFormRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				resp, err := srv.(lexiconServer).analyzeForm(ctx, req)
+				return &resp, err
+			},
+		},
+		{
+			MethodName: "Generate",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(generateRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				resp, err := srv.(lexiconServer).generate(ctx, req)
+				return &resp, err
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SearchPrefix",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(searchPrefixRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return srv.(lexiconServer).searchPrefix(req, stream)
+			},
+		},
+	},
+	Metadata: "proto/lexicon.proto",
+}
+
+// newGRPCServer returns a gRPC server with the Lexicon service over store.
+func newGRPCServer(store Store) *grpc.Server {
+	srv := grpc.NewServer(grpc.ForceServerCodec(wireCodec{}))
+	srv.RegisterService(&lexiconServiceDesc, &lexiconService{store: store})
+	return srv
+}
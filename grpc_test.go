@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func dialLexicon(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := newGRPCServer(newMemStore(fixtureEntries(t)))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(wireCodec{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// repeatedStrings collects field num of every message in field outer of b.
+func repeatedStrings(t *testing.T, b []byte, outer, inner protowire.Number) []string {
+	t.Helper()
+	var out []string
+	err := consumeFields(b, func(num protowire.Number, v []byte, _ uint64) {
+		if num != outer {
+			return
+		}
+		consumeFields(v, func(num protowire.Number, v []byte, _ uint64) {
+			if num == inner {
+				out = append(out, string(v))
+			}
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestGRPCLexicon(t *testing.T) {
+	conn := dialLexicon(t)
+	ctx := context.Background()
+
+	var resp pbMessage
+	if err := conn.Invoke(ctx, "/saol.Lexicon/AnalyzeForm", &analyzeFormRequest{form: "sam"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := repeatedStrings(t, resp, 1, 1); len(got) != 1 || got[0] != "simma" {
+		t.Errorf("AnalyzeForm(sam) lemmas = %q", got)
+	}
+
+	req := &generateRequest{lemmaID: "bil", features: map[string]string{"Number": "Plur", "Case": "Nom"}}
+	if err := conn.Invoke(ctx, "/saol.Lexicon/Generate", req, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := repeatedStrings(t, resp, 1, 2); len(got) != 2 || got[0] != "bilar" || got[1] != "bilarna" {
+		t.Errorf("Generate(bil, Nom Plur) = %q", got)
+	}
+
+	err := conn.Invoke(ctx, "/saol.Lexicon/Generate", &generateRequest{lemmaID: "nej"}, &resp)
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Generate(nej) error = %v, want NotFound", err)
+	}
+
+	stream, err := conn.NewStream(ctx, &lexiconServiceDesc.Streams[0], "/saol.Lexicon/SearchPrefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendMsg(&searchPrefixRequest{prefix: "h"}); err != nil {
+		t.Fatal(err)
+	}
+	stream.CloseSend()
+	var headwords []string
+	for {
+		var msg pbMessage
+		if err := stream.RecvMsg(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		consumeFields(msg, func(num protowire.Number, v []byte, _ uint64) {
+			if num == 3 {
+				headwords = append(headwords, string(v))
+			}
+		})
+	}
+	if len(headwords) != 2 || headwords[0] != "hoppas" || headwords[1] != "hus" {
+		t.Errorf("SearchPrefix(h) = %q", headwords)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "io"
+
+// HomonymLemma is one of the lemmas sharing a headword.
+type HomonymLemma struct {
+	ID        string `json:"id"`
+	Class     string `json:"class"`
+	FamilyID  int    `json:"familyID"`
+	Homograph int    `json:"homograph,omitempty"`
+}
+
+// Homonym is a headword with more than one lemma, in different word
+// classes ("springa" the verb and the noun) or different articles, with
+// the classes and families it spans.
+type Homonym struct {
+	Headword string         `json:"headword"`
+	Classes  []string       `json:"classes"`
+	Families []int          `json:"families"`
+	Lemmas   []HomonymLemma `json:"lemmas"`
+}
+
+// homonyms returns the headwords of entries that appear under several
+// word classes or families, in the order of their first lemma. Several
+// lemmas of one class in one article do not make a homonym.
+func homonyms(entries []LexiconEntry) []Homonym {
+	var order []string
+	byHeadword := make(map[string]*Homonym)
+	for _, e := range entries {
+		h, ok := byHeadword[e.Headword]
+		if !ok {
+			h = &Homonym{Headword: e.Headword}
+			byHeadword[e.Headword] = h
+			order = append(order, e.Headword)
+		}
+		h.Lemmas = append(h.Lemmas, HomonymLemma{ID: e.ID, Class: e.Class, FamilyID: e.FamilyID, Homograph: e.Homograph})
+		h.Classes = appendUnique(h.Classes, e.Class)
+		if !containsInt(h.Families, e.FamilyID) {
+			h.Families = append(h.Families, e.FamilyID)
+		}
+	}
+	out := []Homonym{}
+	for _, headword := range order {
+		if h := byHeadword[headword]; len(h.Classes) > 1 || len(h.Families) > 1 {
+			out = append(out, *h)
+		}
+	}
+	return out
+}
+
+func containsInt(list []int, n int) bool {
+	for _, m := range list {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHomonyms writes the homonym report of entries to filename as
+// versioned JSON.
+func writeHomonyms(entries []LexiconEntry, filename string) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, homonyms(entries)) })
+}
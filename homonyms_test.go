@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHomonyms(t *testing.T) {
+	entries := []LexiconEntry{
+		{ID: "springa", Headword: "springa", Class: "verb", FamilyID: 1},
+		{ID: "bil", Headword: "bil", Class: "substantiv", FamilyID: 2},
+		{ID: "springa_2", Headword: "springa", Class: "substantiv", FamilyID: 3, Homograph: 2},
+		{ID: "val_1", Headword: "val", Class: "substantiv", FamilyID: 4, Homograph: 1},
+		{ID: "val_2", Headword: "val", Class: "substantiv", FamilyID: 5, Homograph: 2},
+		{ID: "en", Headword: "en", Class: "räkneord", FamilyID: 6},
+		{ID: "en_2", Headword: "en", Class: "räkneord", FamilyID: 6},
+	}
+	filename := filepath.Join(t.TempDir(), "homonyms.json")
+	if err := writeHomonyms(entries, filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, filename, data)
+
+	var got []Homonym
+	decodeEntries(t, data, &got)
+	want := []Homonym{
+		{Headword: "springa", Classes: []string{"verb", "substantiv"}, Families: []int{1, 3}, Lemmas: []HomonymLemma{
+			{ID: "springa", Class: "verb", FamilyID: 1},
+			{ID: "springa_2", Class: "substantiv", FamilyID: 3, Homograph: 2},
+		}},
+		{Headword: "val", Classes: []string{"substantiv"}, Families: []int{4, 5}, Lemmas: []HomonymLemma{
+			{ID: "val_1", Class: "substantiv", FamilyID: 4, Homograph: 1},
+			{ID: "val_2", Class: "substantiv", FamilyID: 5, Homograph: 2},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("homonyms %+v, want %+v", got, want)
+	}
+}
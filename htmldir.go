@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// htmlFile is an article read from a file of an -html-dir tree or a
+// member of an -archive.
+type htmlFile struct {
+	Path string
+	HTML string
+	Err  error
+}
+
+// isHTMLFile reports whether name is an article file of an -html-dir tree.
+func isHTMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".html" || ext == ".htm"
+}
+
+// walkHTMLDir returns the .html and .htm files under root, reading up to
+// parallel directories at a time. Hidden files and directories are left
+// out. The paths are sorted, so an unchanged tree gives every article the
+// same index, and familyID, on every run.
+func walkHTMLDir(ctx context.Context, root string, parallel int) ([]string, error) {
+	var (
+		mu       sync.Mutex
+		paths    []string
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, parallel)
+	var walk func(dir string)
+	walk = func(dir string) {
+		defer wg.Done()
+		if ctx.Err() != nil {
+			return
+		}
+		sem <- struct{}{}
+		entries, err := os.ReadDir(dir)
+		<-sem
+		var found []string
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			switch {
+			case e.IsDir():
+				wg.Add(1)
+				go walk(path)
+			case e.Type().IsRegular() && isHTMLFile(e.Name()):
+				found = append(found, path)
+			}
+		}
+		mu.Lock()
+		paths = append(paths, found...)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+	wg.Add(1)
+	walk(root)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// readHTMLFiles reads paths, up to readers of them at a time, and hands
+// them out in the order given. It stops early when ctx is cancelled.
+func readHTMLFiles(ctx context.Context, paths []string, readers int) <-chan htmlFile {
+	pending := make(chan chan htmlFile, readers)
+	files := make(chan htmlFile)
+	go func() {
+		defer close(pending)
+		for _, path := range paths {
+			read := make(chan htmlFile, 1)
+			select {
+			case pending <- read:
+			case <-ctx.Done():
+				return
+			}
+			go func(path string) {
+				data, err := os.ReadFile(path)
+				read <- htmlFile{Path: path, HTML: string(data), Err: err}
+			}(path)
+		}
+	}()
+	go func() {
+		defer close(files)
+		for read := range pending {
+			select {
+			case files <- <-read:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return files
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalkHTMLDir(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"b/2.html", "b/10.HTM", "a.html", "a/sub/x.html", "notes.txt", ".cache/y.html", "c/.z.html"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("<div>"+name+"</div>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := walkHTMLDir(context.Background(), root, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range paths {
+		rel, _ := filepath.Rel(root, p)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"a.html", "a/sub/x.html", "b/10.HTM", "b/2.html"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("walkHTMLDir = %q, want %q", got, want)
+	}
+
+	if _, err := walkHTMLDir(context.Background(), filepath.Join(root, "missing"), 2); err == nil {
+		t.Error("walkHTMLDir of a missing directory succeeded")
+	}
+}
+
+func TestReadHTMLFilesInOrder(t *testing.T) {
+	root := t.TempDir()
+	var paths []string
+	for _, name := range []string{"1.html", "2.html", "missing.html", "3.html"} {
+		path := filepath.Join(root, name)
+		if name != "missing.html" {
+			if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		paths = append(paths, path)
+	}
+
+	var got []string
+	for f := range readHTMLFiles(context.Background(), paths, 3) {
+		if f.Path != paths[len(got)] {
+			t.Errorf("file %d is %s, want %s", len(got), f.Path, paths[len(got)])
+		}
+		if f.Err != nil {
+			got = append(got, "error")
+			continue
+		}
+		got = append(got, f.HTML)
+	}
+	want := []string{"1.html", "2.html", "error", "3.html"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readHTMLFiles = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// hyphenMarks are the characters SAOL divides headwords with: the middle
+// dot between syllables, the soft hyphen and the bar at compound joints
+// (bil|verk|stad). Each is a point a word can be hyphenated at.
+const hyphenMarks = "·\u00ad|"
+
+// hyphenPoint separates the parts of a hyphenation in lexicon entries.
+const hyphenPoint = "·"
+
+// stripHyphenation removes the hyphenation marks from a headword.
+func stripHyphenation(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(hyphenMarks, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// hyphenation returns the headword of a lemma with its hyphenation points
+// as "·" (bil·verk·stad), or "" when the dictionary marks none.
+func (p SelectorProfile) hyphenation(lemma *goquery.Selection) string {
+	grundform := lemma.Find(p.Headword).First().Clone()
+	grundform.Find("sup").Remove()
+	text := nfc(strings.TrimSpace(grundform.Text()))
+	if !strings.ContainsAny(text, hyphenMarks) {
+		return ""
+	}
+	var parts []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return strings.ContainsRune(hyphenMarks, r) }) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, hyphenPoint)
+}
+
+// hyphenationPattern turns a hyphenation into a Liang pattern matching
+// just that word, ".bil1verk1stad.", in lower case. ok is false for words
+// patterns cannot hold, those with anything but letters.
+func hyphenationPattern(hyphenation string) (pattern string, ok bool) {
+	var b strings.Builder
+	b.WriteByte('.')
+	for i, part := range strings.Split(strings.ToLower(hyphenation), hyphenPoint) {
+		if part == "" {
+			return "", false
+		}
+		for _, r := range part {
+			if !unicode.IsLetter(r) {
+				return "", false
+			}
+		}
+		if i > 0 {
+			b.WriteByte('1')
+		}
+		b.WriteString(part)
+	}
+	b.WriteByte('.')
+	return b.String(), true
+}
+
+// hyphenationPatterns returns the distinct patterns of the entries'
+// hyphenations, sorted.
+func hyphenationPatterns(entries []LexiconEntry) []string {
+	seen := make(map[string]bool)
+	var patterns []string
+	for _, e := range entries {
+		if e.Hyphenation == "" {
+			continue
+		}
+		if p, ok := hyphenationPattern(e.Hyphenation); ok && !seen[p] {
+			seen[p] = true
+			patterns = append(patterns, p)
+		}
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// writeHyphenation writes the hyphenation points of entries into dir as
+// Swedish patterns for TeX (hyph-sv.tex, \patterns plus \hyphenation
+// exceptions) and for LibreOffice's hyphen library (hyph_sv_SE.dic). The
+// patterns are whole words, so they hyphenate the listed words exactly and
+// leave others to the patterns they are combined with.
+func writeHyphenation(entries []LexiconEntry, dir string) error {
+	if err := createOutputDir(dir); err != nil {
+		return err
+	}
+	patterns := hyphenationPatterns(entries)
+
+	err := saveFile(filepath.Join(dir, "hyph-sv.tex"), func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		fmt.Fprintln(bw, "% Swedish hyphenation patterns from the SAOL headword divisions, written by saoltool.")
+		fmt.Fprintln(bw, `\patterns{`)
+		for _, p := range patterns {
+			fmt.Fprintln(bw, p)
+		}
+		fmt.Fprintln(bw, "}")
+		fmt.Fprintln(bw, `\hyphenation{`)
+		for _, p := range patterns {
+			fmt.Fprintln(bw, strings.NewReplacer(".", "", "1", "-").Replace(p))
+		}
+		fmt.Fprintln(bw, "}")
+		return bw.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	return saveFile(filepath.Join(dir, "hyph_sv_SE.dic"), func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		fmt.Fprintln(bw, "UTF-8")
+		fmt.Fprintln(bw, "LEFTHYPHENMIN 2")
+		fmt.Fprintln(bw, "RIGHTHYPHENMIN 2")
+		for _, p := range patterns {
+			fmt.Fprintln(bw, p)
+		}
+		return bw.Flush()
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHyphenation(t *testing.T) {
+	html := `<span class="grundform">bil|verk·stad</span><span class="ordklass">substantiv</span>`
+	entry, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: html})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Headword != "bilverkstad" || entry.ID != "bilverkstad" || entry.Hyphenation != "bil·verk·stad" {
+		t.Errorf("headword %q, ID %q, hyphenation %q", entry.Headword, entry.ID, entry.Hyphenation)
+	}
+
+	entry, _, err = newLexiconEntry(context.Background(), "1", LemmaInput{HTML: readFixture(t, "substantiv_bil")})
+	if err != nil || entry.Hyphenation != "" {
+		t.Errorf("bil: hyphenation %q (%v), want none", entry.Hyphenation, err)
+	}
+}
+
+func TestHyphenationPattern(t *testing.T) {
+	tests := []struct {
+		hyphenation, want string
+		ok                bool
+	}{
+		{"bil·verk·stad", ".bil1verk1stad.", true},
+		{"Ös·ter·sund", ".ös1ter1sund.", true},
+		{"e-post·a", "", false},
+		{"t.ex.", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := hyphenationPattern(tt.hyphenation); got != tt.want || ok != tt.ok {
+			t.Errorf("hyphenationPattern(%q) = %q, %v, want %q, %v", tt.hyphenation, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWriteHyphenation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hyphenation")
+	entries := []LexiconEntry{{Hyphenation: "bil·verk·stad"}, {Hyphenation: "ka·nin"}, {Headword: "bil"}, {Hyphenation: "ka·nin"}}
+	if err := writeHyphenation(entries, dir); err != nil {
+		t.Fatal(err)
+	}
+	tex, err := os.ReadFile(filepath.Join(dir, "hyph-sv.tex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tex), "\\patterns{\n.bil1verk1stad.\n.ka1nin.\n}") || !strings.Contains(string(tex), "\\hyphenation{\nbil-verk-stad\nka-nin\n}") {
+		t.Errorf("hyph-sv.tex:\n%s", tex)
+	}
+	dic, err := os.ReadFile(filepath.Join(dir, "hyph_sv_SE.dic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(dic), "UTF-8\n") || !strings.HasSuffix(string(dic), ".bil1verk1stad.\n.ka1nin.\n") {
+		t.Errorf("hyph_sv_SE.dic:\n%s", dic)
+	}
+}
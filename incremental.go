@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+)
+
+// The flatten state is a JSON array with the SHA-256 of every article of
+// the last run's saol_entries.json, in order, or "" for the articles that
+// did not make it into its flattened_lemmas.json (quarantined, failed).
+// flatten -incremental reuses the lemmas of the articles whose hash is in
+// it instead of splitting them again.
+
+// hashArticle returns the hash the flatten state keeps of an article.
+func hashArticle(html string) string {
+	sum := sha256.Sum256([]byte(html))
+	return hex.EncodeToString(sum[:])
+}
+
+// saveFlattenState writes the state of a run; hashes holds the hash of
+// every article by index and flattened the indexes that were written.
+func saveFlattenState(filename string, hashes []string, flattened []int) error {
+	state := make([]string, len(hashes))
+	for _, index := range flattened {
+		state[index] = hashes[index]
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// loadPreviousFlatten reads the state of the last run and the
+// flattened_lemmas.json it wrote back into the results of its articles,
+// keyed by article hash. The results have no Index; they are reused under
+// whatever index the article has now. Without a state file or without the
+// output, as on the first run, there is nothing to reuse.
+func loadPreviousFlatten(stateFile, lemmaFile string) (map[string]Result, error) {
+	data, err := ioutil.ReadFile(stateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]Result{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading flatten state: %w", err)
+	}
+	var hashes []string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return nil, fmt.Errorf("error decoding flatten state '%s': %w", stateFile, err)
+	}
+
+	// An article that was in the dump twice is reused from its first copy.
+	previous := make(map[string]Result, len(hashes))
+	first := make(map[string]int, len(hashes))
+	for i, hash := range hashes {
+		if _, ok := previous[hash]; hash != "" && !ok {
+			previous[hash] = Result{LemmaHTMLs: []string{}}
+			first[hash] = i + 1
+		}
+	}
+
+	file, err := openLocation(lemmaFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]Result{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening previous output: %w", err)
+	}
+	defer file.Close()
+	err = ForEachLemma(file, func(l Lemma) error {
+		if l.FamilyID < 1 || l.FamilyID > len(hashes) || hashes[l.FamilyID-1] == "" {
+			return fmt.Errorf("lemma %s is from article %d, which is not in the flatten state", l.Key, l.FamilyID)
+		}
+		hash := hashes[l.FamilyID-1]
+		if first[hash] != l.FamilyID {
+			return nil
+		}
+		res := previous[hash]
+		res.Source = l.Source
+		res.LemmaHTMLs = append(res.LemmaHTMLs, l.HTML)
+		res.Classes = append(res.Classes, l.Class)
+		res.Headwords = append(res.Headwords, l.Headword)
+		previous[hash] = res
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading previous output '%s': %w", lemmaFile, err)
+	}
+	return previous, nil
+}
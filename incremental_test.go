@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPreviousFlatten(t *testing.T) {
+	dir := t.TempDir()
+	lemmaFile := filepath.Join(dir, "flattened_lemmas.json")
+	stateFile := filepath.Join(dir, "flatten_state.json")
+
+	bil, hus := hashArticle("<bil>"), hashArticle("<hus>")
+	// Article 2 failed, article 4 is a second copy of article 1.
+	if err := saveFlattenState(stateFile, []string{bil, hashArticle("<x>"), hus, bil}, []int{0, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	lemmas := `[
+		{"key": 1, "html": "bil", "familyID": 1, "source": "saol", "class": "substantiv", "headword": "bil"},
+		{"key": 2, "html": "bila", "familyID": 1, "source": "saol", "class": "verb", "headword": "bila"},
+		{"key": 3, "html": "bil", "familyID": 4, "source": "saol", "class": "substantiv", "headword": "bil"}
+	]`
+	if err := os.WriteFile(lemmaFile, []byte(lemmas), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := loadPreviousFlatten(stateFile, lemmaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Result{
+		bil: {Source: "saol", LemmaHTMLs: []string{"bil", "bila"}, Classes: []string{"substantiv", "verb"}, Headwords: []string{"bil", "bila"}},
+		hus: {LemmaHTMLs: []string{}},
+	}
+	if !reflect.DeepEqual(previous, want) {
+		t.Errorf("got %+v, want %+v", previous, want)
+	}
+
+	if err := saveFlattenState(stateFile, []string{bil}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPreviousFlatten(stateFile, lemmaFile); err == nil {
+		t.Error("lemmas of articles missing from the state were accepted")
+	}
+
+	// The first run has neither file; a run whose output was removed has
+	// only the state.
+	for _, files := range [][2]string{{filepath.Join(dir, "none.json"), lemmaFile}, {stateFile, filepath.Join(dir, "none.json")}} {
+		previous, err := loadPreviousFlatten(files[0], files[1])
+		if err != nil || len(previous) != 0 {
+			t.Errorf("without %v: %v, %v; want nothing to reuse", files, previous, err)
+		}
+	}
+}
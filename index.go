@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+// The index file is a byte trie over every lookup key of the lexicon,
+// next to the entries as JSON, laid out to be read in place from a memory
+// mapping. All integers are little-endian.
+//
+//	header   magic "SAOLIDX1", then uint64 record count, record table
+//	         offset, trie offset and root node offset
+//	records  the entries as JSON, in entryLess order
+//	table    uint64 offset of each record and one past the last
+//	trie     nodes, children before their parent: uint32 child count n,
+//	         n label bytes in order, n uint32 child offsets from the trie
+//	         start, uint32 value count m and m uint32 record numbers
+//
+// A key is a namespace byte and NUL followed by the headword ("h"), a
+// surface form ("f"), the ID ("i") or the class ("c") of the entry.
+const indexMagic = "SAOLIDX1"
+
+const indexHeaderSize = len(indexMagic) + 4*8
+
+const (
+	indexHeadword = 'h'
+	indexForm     = 'f'
+	indexID       = 'i'
+	indexClass    = 'c'
+)
+
+// indexKey is the key of s in namespace.
+func indexKey(namespace byte, s string) string {
+	return string([]byte{namespace, 0}) + s
+}
+
+// trieNode is a node of the trie while it is built.
+type trieNode struct {
+	labels   []byte
+	children []*trieNode
+	values   []uint32
+}
+
+// child returns the child of n labelled b, adding it if create is set.
+func (n *trieNode) child(b byte, create bool) *trieNode {
+	i := sort.Search(len(n.labels), func(i int) bool { return n.labels[i] >= b })
+	if i < len(n.labels) && n.labels[i] == b {
+		return n.children[i]
+	}
+	if !create {
+		return nil
+	}
+	c := &trieNode{}
+	n.labels = append(n.labels[:i], append([]byte{b}, n.labels[i:]...)...)
+	n.children = append(n.children[:i], append([]*trieNode{c}, n.children[i:]...)...)
+	return c
+}
+
+// add adds value to key. Values are added in increasing order, once each.
+func (n *trieNode) add(key string, value uint32) {
+	for i := 0; i < len(key); i++ {
+		n = n.child(key[i], true)
+	}
+	if len(n.values) == 0 || n.values[len(n.values)-1] != value {
+		n.values = append(n.values, value)
+	}
+}
+
+// write appends the subtree at n to buf, children first, and returns the
+// offset of n.
+func (n *trieNode) write(buf *bytes.Buffer) (uint32, error) {
+	offsets := make([]uint32, len(n.children))
+	for i, c := range n.children {
+		off, err := c.write(buf)
+		if err != nil {
+			return 0, err
+		}
+		offsets[i] = off
+	}
+	if buf.Len() > 1<<32-1 {
+		return 0, errors.New("index trie larger than 4 GiB")
+	}
+	off := uint32(buf.Len())
+	binary.Write(buf, binary.LittleEndian, uint32(len(n.labels)))
+	buf.Write(n.labels)
+	binary.Write(buf, binary.LittleEndian, offsets)
+	binary.Write(buf, binary.LittleEndian, uint32(len(n.values)))
+	binary.Write(buf, binary.LittleEndian, n.values)
+	return off, nil
+}
+
+// writeIndex writes entries to w as an index file.
+func writeIndex(w io.Writer, entries []LexiconEntry) error {
+	sorted := append([]LexiconEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return entryLess(sorted[i], sorted[j]) })
+
+	var records bytes.Buffer
+	offsets := make([]uint64, 0, len(sorted)+1)
+	root := &trieNode{}
+	for i, e := range sorted {
+		offsets = append(offsets, uint64(indexHeaderSize+records.Len()))
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		records.Write(data)
+
+		n := uint32(i)
+		root.add(indexKey(indexHeadword, e.Headword), n)
+		root.add(indexKey(indexID, e.ID), n)
+		root.add(indexKey(indexClass, e.Class), n)
+		for _, f := range e.surfaceForms() {
+			root.add(indexKey(indexForm, f), n)
+		}
+	}
+	offsets = append(offsets, uint64(indexHeaderSize+records.Len()))
+
+	var trie bytes.Buffer
+	rootOff, err := root.write(&trie)
+	if err != nil {
+		return err
+	}
+	tableOff := uint64(indexHeaderSize + records.Len())
+	trieOff := tableOff + uint64(8*len(offsets))
+
+	bw := &errWriter{w: w}
+	bw.write([]byte(indexMagic))
+	bw.write(binary.LittleEndian.AppendUint64(nil, uint64(len(sorted))))
+	bw.write(binary.LittleEndian.AppendUint64(nil, tableOff))
+	bw.write(binary.LittleEndian.AppendUint64(nil, trieOff))
+	bw.write(binary.LittleEndian.AppendUint64(nil, trieOff+uint64(rootOff)))
+	bw.write(records.Bytes())
+	for _, off := range offsets {
+		bw.write(binary.LittleEndian.AppendUint64(nil, off))
+	}
+	bw.write(trie.Bytes())
+	return bw.err
+}
+
+// errWriter keeps the first error of a run of writes.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) write(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
+
+// indexStore answers lookups from an index file read in place, decoding
+// only the entries a lookup returns.
+type indexStore struct {
+	data     []byte
+	records  uint64
+	tableOff uint64
+	trieOff  uint64
+	rootOff  uint64
+	close    func() error
+}
+
+// openIndexStore maps the index file filename. When it does not exist
+// and lexiconFile is set, it is built from the flattened lemmas first.
+func openIndexStore(filename, lexiconFile string) (*indexStore, error) {
+	if _, err := os.Stat(filename); os.IsNotExist(err) && lexiconFile != "" {
+		entries, err := loadLexicon(lexiconFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := saveFile(filename, func(w io.Writer) error { return writeIndex(w, entries) }); err != nil {
+			return nil, err
+		}
+		slog.Info("built index", "file", filename, "entries", len(entries))
+	}
+	data, closer, err := mapFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newIndexStore(data)
+	if err != nil {
+		closer()
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	s.close = closer
+	return s, nil
+}
+
+// newIndexStore reads the header of an index file in data.
+func newIndexStore(data []byte) (*indexStore, error) {
+	if len(data) < indexHeaderSize || string(data[:len(indexMagic)]) != indexMagic {
+		return nil, errors.New("not a saoltool index file")
+	}
+	u := func(i int) uint64 { return binary.LittleEndian.Uint64(data[len(indexMagic)+8*i:]) }
+	s := &indexStore{data: data, records: u(0), tableOff: u(1), trieOff: u(2), rootOff: u(3), close: func() error { return nil }}
+	size := uint64(len(data))
+	if s.records >= size/8 || s.tableOff > size-8*(s.records+1) || s.trieOff > size || s.rootOff > size-4 {
+		return nil, errors.New("truncated index file")
+	}
+	return s, nil
+}
+
+// errCorruptIndex reports an offset or count in an index file that points
+// outside it.
+var errCorruptIndex = errors.New("corrupt index file")
+
+// span returns the n bytes at off, or errCorruptIndex if they run past the
+// end of the file.
+func (s *indexStore) span(off, n uint64) ([]byte, error) {
+	size := uint64(len(s.data))
+	if off > size || n > size-off {
+		return nil, fmt.Errorf("%w: %d bytes at offset %d of %d", errCorruptIndex, n, off, size)
+	}
+	return s.data[off : off+n], nil
+}
+
+func (s *indexStore) u32(off uint64) (uint32, error) {
+	b, err := s.span(off, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// lookup returns the record numbers stored under key.
+func (s *indexStore) lookup(key string) ([]uint32, error) {
+	node, ok, err := s.find(key)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return s.values(node)
+}
+
+// node reads the labels and child offsets of the node at off.
+func (s *indexStore) node(off uint64) (labels []byte, children uint64, err error) {
+	n, err := s.u32(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if labels, err = s.span(off+4, uint64(n)); err != nil {
+		return nil, 0, err
+	}
+	children = off + 4 + uint64(n)
+	if _, err := s.span(children, 4*uint64(n)); err != nil {
+		return nil, 0, err
+	}
+	return labels, children, nil
+}
+
+// child returns the offset of child j of a node whose child offsets start
+// at children.
+func (s *indexStore) child(children uint64, j int) (uint64, error) {
+	rel, err := s.u32(children + 4*uint64(j))
+	if err != nil {
+		return 0, err
+	}
+	return s.trieOff + uint64(rel), nil
+}
+
+// find walks the trie from the root along key and returns the offset of
+// the node it ends at.
+func (s *indexStore) find(key string) (uint64, bool, error) {
+	node := s.rootOff
+	for i := 0; i < len(key); i++ {
+		labels, children, err := s.node(node)
+		if err != nil {
+			return 0, false, err
+		}
+		j := sort.Search(len(labels), func(j int) bool { return labels[j] >= key[i] })
+		if j == len(labels) || labels[j] != key[i] {
+			return 0, false, nil
+		}
+		if node, err = s.child(children, j); err != nil {
+			return 0, false, err
+		}
+	}
+	return node, true, nil
+}
+
+// values returns the record numbers of the node at off.
+func (s *indexStore) values(off uint64) ([]uint32, error) {
+	labels, children, err := s.node(off)
+	if err != nil {
+		return nil, err
+	}
+	off = children + 4*uint64(len(labels))
+	m, err := s.u32(off)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.span(off+4, 4*uint64(m))
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint32, m)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return values, nil
+}
+
+// walk calls fn with the key and record numbers of the node at off, whose
+// key is key, and of every node below it, in key order, until fn returns
+// false. The key passed to fn is only valid until it returns.
+func (s *indexStore) walk(off uint64, key []byte, fn func(key []byte, values []uint32) bool) (bool, error) {
+	values, err := s.values(off)
+	if err != nil {
+		return false, err
+	}
+	if !fn(key, values) {
+		return false, nil
+	}
+	labels, children, err := s.node(off)
+	if err != nil {
+		return false, err
+	}
+	for j, b := range labels {
+		child, err := s.child(children, j)
+		if err != nil {
+			return false, err
+		}
+		if more, err := s.walk(child, append(key, b), fn); !more || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// entry decodes record i.
+func (s *indexStore) entry(i uint32) (LexiconEntry, error) {
+	if uint64(i) >= s.records {
+		return LexiconEntry{}, fmt.Errorf("index record %d out of range", i)
+	}
+	b, err := s.span(s.tableOff+8*uint64(i), 16)
+	if err != nil {
+		return LexiconEntry{}, err
+	}
+	start, end := binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])
+	if start > end {
+		return LexiconEntry{}, fmt.Errorf("%w: record %d ends before it starts", errCorruptIndex, i)
+	}
+	record, err := s.span(start, end-start)
+	if err != nil {
+		return LexiconEntry{}, err
+	}
+	var e LexiconEntry
+	err = json.Unmarshal(record, &e)
+	return e, err
+}
+
+func (s *indexStore) pick(idx []uint32) ([]LexiconEntry, error) {
+	out := make([]LexiconEntry, len(idx))
+	for i, n := range idx {
+		var err error
+		if out[i], err = s.entry(n); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (s *indexStore) GetLemma(headword string) ([]LexiconEntry, error) {
+	idx, err := s.lookup(indexKey(indexHeadword, headword))
+	if err != nil {
+		return nil, err
+	}
+	return s.pick(idx)
+}
+
+func (s *indexStore) GetByID(id string) (LexiconEntry, bool, error) {
+	idx, err := s.lookup(indexKey(indexID, id))
+	if len(idx) == 0 || err != nil {
+		return LexiconEntry{}, false, err
+	}
+	e, err := s.entry(idx[0])
+	return e, err == nil, err
+}
+
+func (s *indexStore) SearchForm(form string) ([]LexiconEntry, error) {
+	idx, err := s.lookup(indexKey(indexForm, form))
+	if err != nil {
+		return nil, err
+	}
+	return s.pick(idx)
+}
+
+func (s *indexStore) SearchPrefix(prefix string, limit int) ([]LexiconEntry, error) {
+	node, ok, err := s.find(indexKey(indexHeadword, prefix))
+	if !ok || err != nil {
+		return nil, err
+	}
+	var idx []uint32
+	_, err = s.walk(node, nil, func(_ []byte, values []uint32) bool {
+		for _, v := range values {
+			if len(idx) == limit {
+				return false
+			}
+			idx = append(idx, v)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pick(idx)
+}
+
+func (s *indexStore) ListByClass(class string, offset, limit int) ([]LexiconEntry, error) {
+	idx, err := s.lookup(indexKey(indexClass, class))
+	if offset >= len(idx) || err != nil {
+		return nil, err
+	}
+	idx = idx[offset:]
+	if limit < len(idx) {
+		idx = idx[:limit]
+	}
+	return s.pick(idx)
+}
+
+func (s *indexStore) Close() error { return s.close() }
+
+// runIndex builds the index file serve -store index reads.
+func runIndex(args []string) {
+	flags := flag.NewFlagSet("index", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to index")
+	out := flags.String("out", "lexicon.idx", "index file to write")
+	flags.Parse(args)
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	if err := saveFile(*out, func(w io.Writer) error { return writeIndex(w, entries) }); err != nil {
+		fatal("could not write index", "file", *out, "err", err)
+	}
+	slog.Info("wrote index", "file", *out, "entries", len(entries))
+}
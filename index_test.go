@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestIndex(t *testing.T, entries []LexiconEntry) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "lexicon.idx")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := writeIndex(f, entries); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestIndexStore(t *testing.T) {
+	entries := []LexiconEntry{
+		{ID: "bila", Headword: "bila", Class: "verb"},
+		{ID: "bil", Headword: "bil", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "bilen"}}}},
+		{ID: "val_2", Headword: "val", Homograph: 2, Class: "substantiv"},
+		{ID: "val_1", Headword: "val", Homograph: 1, Class: "substantiv"},
+		{ID: "bå", Headword: "bå", Class: "interjektion"},
+	}
+	s, err := openIndexStore(writeTestIndex(t, entries), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ids := func(got []LexiconEntry, err error) []string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range got {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	if got, want := ids(s.SearchPrefix("b", 10)), []string{"bil", "bila", "bå"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchPrefix(b) = %q, want %q", got, want)
+	}
+	if got, want := ids(s.SearchPrefix("", 2)), []string{"bil", "bila"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchPrefix(\"\", 2) = %q, want %q", got, want)
+	}
+	if got := ids(s.SearchPrefix("x", 10)); got != nil {
+		t.Errorf("SearchPrefix(x) = %q, want none", got)
+	}
+	if got, want := ids(s.GetLemma("val")), []string{"val_1", "val_2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetLemma(val) = %q, want %q", got, want)
+	}
+	if got, want := ids(s.SearchForm("bilen")), []string{"bil"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchForm(bilen) = %q, want %q", got, want)
+	}
+	if got := ids(s.SearchForm("bi")); got != nil {
+		t.Errorf("SearchForm(bi) = %q, want none: a prefix of a form is not a form", got)
+	}
+	if got, want := ids(s.ListByClass("substantiv", 0, 10)), []string{"bil", "val_1", "val_2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListByClass(substantiv) = %q, want %q", got, want)
+	}
+	e, ok, err := s.GetByID("bil")
+	if err != nil || !ok || !reflect.DeepEqual(e, entries[1]) {
+		t.Errorf("GetByID(bil) = %+v, %v, %v", e, ok, err)
+	}
+}
+
+func TestIndexStoreRejectsOtherFiles(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "lexicon.idx")
+	for _, data := range []string{"", "[]", indexMagic + "\xff\xff\xff\xff\xff\xff\xff\x00"} {
+		os.WriteFile(filename, []byte(data), 0644)
+		if s, err := openIndexStore(filename, ""); err == nil {
+			s.Close()
+			t.Errorf("%q: want an error", data)
+		}
+	}
+	if _, err := openIndexStore(filepath.Join(t.TempDir(), "missing.idx"), ""); err == nil {
+		t.Error("missing file: want an error")
+	}
+}
+
+func TestIndexStoreCorrupt(t *testing.T) {
+	// Overwriting any byte past the header with 0xff must give errors or
+	// wrong answers, never a panic.
+	data, err := os.ReadFile(writeTestIndex(t, []LexiconEntry{
+		{ID: "bil", Headword: "bil", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "bilen"}}}},
+		{ID: "bila", Headword: "bila", Class: "verb"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := indexHeaderSize; i < len(data); i++ {
+		corrupt := append([]byte(nil), data...)
+		corrupt[i] = 0xff
+		s, err := newIndexStore(corrupt)
+		if err != nil {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("byte %d: %v", i, r)
+				}
+			}()
+			s.SearchPrefix("", 10)
+			s.GetLemma("bil")
+			s.GetByID("bila")
+			s.SearchForm("bilen")
+			s.ListByClass("verb", 0, 10)
+		}()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+// noInflection is the note SAOL and SO give an indeclinable lemma in place
+// of its forms. extract passes it on as the lemma's only parsed form; with
+// no "-Section" tag it is skipped wherever forms are grouped.
+const noInflection = "ingen böjning"
+
+// inflectionNone is the inflection of an entry marked "ingen böjning".
+// Consumers tell it from an entry whose table was missing or could not be
+// parsed, which has no forms and no inflection.
+const inflectionNone = "none"
+
+// inflectionOf returns inflectionNone for the parsed forms of an
+// indeclinable lemma and "" otherwise.
+func inflectionOf(raw []string) string {
+	if len(raw) == 1 && raw[0] == noInflection {
+		return inflectionNone
+	}
+	return ""
+}
+
+// missingSlots returns the names of the slots that are empty, nil when
+// every slot or none is filled: a paradigm with gaps is defective, one
+// without any forms has no data.
+func missingSlots(names []string, values ...string) []string {
+	var missing []string
+	for i, v := range values {
+		if v == "" {
+			missing = append(missing, names[i])
+		}
+	}
+	if len(missing) == len(values) {
+		return nil
+	}
+	return missing
+}
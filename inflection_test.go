@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExtractIndeclinable(t *testing.T) {
+	res, err := extractLemma(context.Background(), LemmaInput{HTML: readFixture(t, "adjektiv_gratis")}, saolProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.class != "adjektiv" || !reflect.DeepEqual(res.forms, []string{noInflection}) {
+		t.Fatalf("gratis: %+v", res)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAdjectivesJSON(&buf, [][]string{res.forms, nil}, false); err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, "adjectives.json", buf.Bytes())
+	var entries []AdjectiveEntry
+	decodeEntries(t, buf.Bytes(), &entries)
+	if entries[0].Inflection != inflectionNone || len(entries[0].Forms["Positiv"]) > 0 {
+		t.Errorf("gratis: %+v, want inflection none and no forms", entries[0])
+	}
+	if entries[1].Inflection != "" || entries[1].Defective != nil {
+		t.Errorf("no table: %+v, want neither inflection nor defective", entries[1])
+	}
+
+	res, _ = extractLemma(context.Background(), LemmaInput{HTML: readFixture(t, "adjektiv_fin")}, saolProfile)
+	if inflectionOf(res.forms) != "" || len(res.forms) != 6 {
+		t.Errorf("fin: %q", res.forms)
+	}
+}
+
+func TestDefective(t *testing.T) {
+	var buf bytes.Buffer
+	WriteVerbsJSON(&buf, [][]string{{"simma-infinitiv aktiv-Infinita former", "simmar-presens aktiv-Finita former"}}, false)
+	checkValid(t, "verbs.json", buf.Bytes())
+	var verbs []struct{ Defective []string }
+	decodeEntries(t, buf.Bytes(), &verbs)
+	if want := []string{"preterite", "supine"}; !reflect.DeepEqual(verbs[0].Defective, want) {
+		t.Errorf("verb defective %q, want %q", verbs[0].Defective, want)
+	}
+
+	noun := newNounEntry([]string{"mjölk-en-Nominativ", "mjölken-den-Nominativ"})
+	if want := []string{"sg_indef_gen", "sg_def_gen"}; !reflect.DeepEqual(noun.Defective, want) {
+		t.Errorf("noun defective %q, want %q", noun.Defective, want)
+	}
+	if noun := newNounEntry([]string{noInflection}); noun.Inflection != inflectionNone || noun.Defective != nil {
+		t.Errorf("indeclinable noun %+v", noun)
+	}
+
+	if got := missingSlots([]string{"a", "b"}, "", ""); got != nil {
+		t.Errorf("no forms: defective %q, want none", got)
+	}
+}
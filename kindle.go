@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// kindleIdxNS is the namespace kindlegen expects the idx: and mbp: tags in.
+const kindleIdxNS = "https://kindlegen.s3.amazonaws.com/AmazonKindlePublishingGuidelines.pdf"
+
+const kindleOPF = `<?xml version="1.0" encoding="utf-8"?>
+<package unique-identifier="uid" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <metadata>
+    <dc-metadata>
+      <dc:Identifier id="uid">saol</dc:Identifier>
+      <dc:Title>SAOL</dc:Title>
+      <dc:Language>sv</dc:Language>
+    </dc-metadata>
+    <x-metadata>
+      <DictionaryInLanguage>sv</DictionaryInLanguage>
+      <DictionaryOutLanguage>sv</DictionaryOutLanguage>
+      <DefaultLookupIndex>default</DefaultLookupIndex>
+    </x-metadata>
+  </metadata>
+  <manifest>
+    <item id="dictionary" href="saol.html" media-type="text/x-oeb1-document"/>
+  </manifest>
+  <spine>
+    <itemref idref="dictionary"/>
+  </spine>
+</package>
+`
+
+// kindleHTML renders entries as the content file of a Kindle dictionary.
+// Every lemma is an idx:entry whose idx:infl lists its inflected forms, so
+// looking up "bilarna" on the device finds "bil".
+func kindleHTML(entries []LexiconEntry) string {
+	sorted := make([]LexiconEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Headword != "" {
+			sorted = append(sorted, e)
+		}
+	}
+	c := collate.New(language.Swedish)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return c.CompareString(sorted[i].Headword, sorted[j].Headword) < 0
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<html xmlns:idx="%s" xmlns:mbp="%s">`+"\n", kindleIdxNS, kindleIdxNS)
+	b.WriteString(`<head><meta http-equiv="Content-Type" content="text/html; charset=utf-8"></head>` + "\n")
+	b.WriteString("<body>\n<mbp:frameset>\n")
+	for _, e := range sorted {
+		b.WriteString(`<idx:entry name="default" scriptable="yes" spell="yes">` + "\n")
+		fmt.Fprintf(&b, `<idx:orth value="%s">`, html.EscapeString(e.Headword))
+		var iforms []string
+		for _, f := range e.surfaceForms() {
+			if f != e.Headword {
+				iforms = append(iforms, fmt.Sprintf(`<idx:iform value="%s"/>`, html.EscapeString(f)))
+			}
+		}
+		if len(iforms) > 0 {
+			b.WriteString("<idx:infl>" + strings.Join(iforms, "") + "</idx:infl>")
+		}
+		b.WriteString("</idx:orth>\n")
+		b.WriteString(entryHTML(e))
+		b.WriteString("\n</idx:entry>\n<hr/>\n")
+	}
+	b.WriteString("</mbp:frameset>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// writeKindle writes the kindlegen source of entries into dir: saol.opf
+// and the saol.html it points to.
+func writeKindle(entries []LexiconEntry, dir string) error {
+	if err := createOutputDir(dir); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "saol.opf"), []byte(kindleOPF), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "saol.html"), []byte(kindleHTML(entries)), 0644)
+}
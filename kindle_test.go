@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKindleHTML(t *testing.T) {
+	got := kindleHTML(fixtureEntries(t))
+
+	for _, want := range []string{
+		`<idx:orth value="bil"><idx:infl>`,
+		`<idx:iform value="bilarna"/>`,
+		`<idx:iform value="sam"/>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Kindle HTML lacks %s", want)
+		}
+	}
+	if strings.Index(got, `value="ångra sig"`) < strings.Index(got, `value="val"`) {
+		t.Error("entries are not in Swedish alphabetical order")
+	}
+}
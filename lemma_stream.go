@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Lemma is one flattened lemma as ForEachLemma hands it out: its key in
+// the flattened file and what flatten wrote for it.
+type Lemma struct {
+	Key string
+	LemmaInput
+}
+
+// Document parses the lemma's HTML.
+func (l Lemma) Document() (*goquery.Document, error) {
+	return goquery.NewDocumentFromReader(strings.NewReader(l.HTML))
+}
+
+// HeadwordText returns the headword flatten cached for the lemma, or for
+// older files reads it from the HTML with the lemma's selector profile.
+func (l Lemma) HeadwordText() (string, error) {
+	if l.Headword != "" {
+		return l.Headword, nil
+	}
+	profile, err := profileFor(l.Source)
+	if err != nil {
+		return "", err
+	}
+	doc, err := l.Document()
+	if err != nil {
+		return "", err
+	}
+	headword, _ := profile.headword(doc.Selection)
+	return headword, nil
+}
+
+// WordClass returns the word class flatten cached for the lemma, or for
+// older files reads it from the HTML with the lemma's selector profile.
+// A plain class selector is matched with sniffClassText, without parsing
+// the whole lemma.
+func (l Lemma) WordClass() (string, error) {
+	if l.Class != "" {
+		return l.Class, nil
+	}
+	profile, err := profileFor(l.Source)
+	if err != nil {
+		return "", err
+	}
+	if class, ok := sniffClassText(strings.NewReader(l.HTML), profile.Class); ok {
+		return strings.TrimSpace(class), nil
+	}
+	doc, err := l.Document()
+	if err != nil {
+		return "", err
+	}
+	return profile.class(doc.Selection), nil
+}
+
+// ForEachLemma decodes the flattened lemmas in r one at a time, in file
+// order, and calls fn with each, so only one lemma is held in memory at a
+// time. It stops at the first error fn returns and returns that error.
+//
+// flattened_lemmas.json is the versioned envelope with an array of
+// LemmaOutput ordered by key under "entries". Schema version 1 files are
+// the bare array, or before that an object of the same records keyed by
+// the decimal key, without the key field; ForEachLemma reads all three,
+// telling them apart by the first token, and refuses scraped articles
+// with errArticleInput.
+func ForEachLemma(r io.Reader, fn func(Lemma) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("error decoding flattened lemmas: %w", err)
+	}
+	switch tok {
+	case json.Delim('['):
+		return forEachLemmaIn(dec, false, nil, fn)
+	case json.Delim('{'):
+	default:
+		return fmt.Errorf("error decoding flattened lemmas: want an array or an object, got %v", tok)
+	}
+
+	if !dec.More() {
+		_, err := dec.Token()
+		return err
+	}
+	tok, err = dec.Token()
+	if err != nil {
+		return fmt.Errorf("error decoding flattened lemmas: %w", err)
+	}
+	if _, err := strconv.Atoi(fmt.Sprint(tok)); err == nil {
+		return forEachLemmaIn(dec, true, tok, fn)
+	}
+	if isArticleKey(tok) {
+		return fmt.Errorf("error decoding flattened lemmas: %w", errArticleInput)
+	}
+	entries := false
+	for {
+		switch tok {
+		case "schemaVersion":
+			var version int
+			if err := dec.Decode(&version); err != nil {
+				return fmt.Errorf("error decoding flattened lemmas: malformed schemaVersion: %w", err)
+			}
+			if err := checkSchemaVersion(version); err != nil {
+				return fmt.Errorf("error decoding flattened lemmas: %w", err)
+			}
+		case "entries":
+			if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+				return fmt.Errorf("error decoding flattened lemmas: want an array of entries")
+			}
+			if err := forEachLemmaIn(dec, false, nil, fn); err != nil {
+				return err
+			}
+			entries = true
+		default:
+			return fmt.Errorf("error decoding flattened lemmas: unexpected %q", tok)
+		}
+		if !dec.More() {
+			break
+		}
+		if tok, err = dec.Token(); err != nil {
+			return fmt.Errorf("error decoding flattened lemmas: %w", err)
+		}
+	}
+	if !entries {
+		return fmt.Errorf("error decoding flattened lemmas: no entries")
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("error decoding flattened lemmas: %w", err)
+	}
+	return nil
+}
+
+// forEachLemmaIn decodes the rest of an array of lemmas, or of the old
+// keyed object whose first key was already read as key, from dec up to
+// and including its closing delimiter. An array whose first element has
+// neither key nor familyID holds scraped articles instead.
+func forEachLemmaIn(dec *json.Decoder, keyed bool, key json.Token, fn func(Lemma) error) error {
+	for first := true; ; first = false {
+		var out LemmaOutput
+		if keyed && key == nil && dec.More() {
+			var err error
+			if key, err = dec.Token(); err != nil {
+				return fmt.Errorf("error decoding flattened lemmas: %w", err)
+			}
+		}
+		if keyed && key != nil {
+			var err error
+			if out.Key, err = strconv.Atoi(key.(string)); err != nil {
+				return fmt.Errorf("error decoding flattened lemmas: malformed key %q", key)
+			}
+			key = nil
+		} else if !dec.More() {
+			break
+		}
+		if err := dec.Decode(&out); err != nil {
+			return fmt.Errorf("error decoding flattened lemma after key %d: %w", out.Key, err)
+		}
+		if first && !keyed && out.Key == 0 && out.FamilyID == 0 {
+			return fmt.Errorf("error decoding flattened lemmas: %w", errArticleInput)
+		}
+		lemma := Lemma{
+			Key:        strconv.Itoa(out.Key),
+			LemmaInput: LemmaInput{HTML: out.HTML, FamilyID: out.FamilyID, Source: out.Source, Class: out.Class, Headword: out.Headword, DuplicateOf: out.DuplicateOf},
+		}
+		if err := fn(lemma); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("error decoding flattened lemmas: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestForEachLemma(t *testing.T) {
+	input := `[
+		{"key": 1, "html": "<span class=\"grundform\">bil</span><span class=\"ordklass\">substantiv</span>", "familyID": 1},
+		{"key": 2, "html": "<span class=\"grundform\">på</span><span class=\"ordklass\">preposition</span>", "familyID": 2},
+		{"key": 10, "html": "<span class=\"orto\">bila</span><span class=\"ordklass\">verb</span>", "familyID": 4, "source": "so"}
+	]`
+	// The map keyed by decimal keys flatten wrote before the array.
+	legacy := `{
+		"1": {"html": "<span class=\"grundform\">bil</span><span class=\"ordklass\">substantiv</span>", "familyID": 1},
+		"10": {"html": "<span class=\"orto\">bila</span><span class=\"ordklass\">verb</span>", "familyID": 4, "source": "so"},
+		"2": {"html": "<span class=\"grundform\">på</span><span class=\"ordklass\">preposition</span>", "familyID": 2}
+	}`
+
+	versioned := `{"schemaVersion": 2, "entries": ` + input + `}`
+
+	for in, want := range map[string]string{
+		versioned: "1:substantiv 2:preposition 10:verb",
+		input:     "1:substantiv 2:preposition 10:verb",
+		legacy:    "1:substantiv 10:verb 2:preposition",
+	} {
+		var got []string
+		err := ForEachLemma(strings.NewReader(in), func(l Lemma) error {
+			class, err := l.WordClass()
+			if err != nil {
+				return err
+			}
+			got = append(got, l.Key+":"+class)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("lemmas %q, want %q", got, want)
+		}
+	}
+
+	// A class cached by flatten is used without looking at the HTML.
+	if class, err := (Lemma{LemmaInput: LemmaInput{HTML: "<p>", Class: "verb"}}).WordClass(); class != "verb" || err != nil {
+		t.Errorf("cached WordClass = %q, %v", class, err)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := ForEachLemma(strings.NewReader(input), func(Lemma) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("ForEachLemma after stop: %v with %d calls, want stop after 1", err, calls)
+	}
+
+	bad := []string{`"lemmas"`, `{"1": {"html": 3}}`, `{"x": {}}`, `[{}`,
+		`{"schemaVersion": 2}`, `{"schemaVersion": 3, "entries": []}`, `{"schemaVersion": 2, "entries": {}}`}
+	for _, bad := range bad {
+		if err := ForEachLemma(strings.NewReader(bad), func(Lemma) error { return nil }); err == nil {
+			t.Errorf("ForEachLemma(%s) succeeded", bad)
+		}
+	}
+}
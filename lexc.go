@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// lexcClass is how one word class appears in the lexc lexicon: the sublexicon
+// its stems are listed in and the part-of-speech tag of its analyses.
+type lexcClass struct {
+	lexicon string
+	tag     string
+}
+
+var lexcClasses = map[string]lexcClass{
+	"substantiv": {"Nouns", "+NOUN"},
+	"verb":       {"Verbs", "+VERB"},
+	"adjektiv":   {"Adjectives", "+ADJ"},
+	"pronomen":   {"Pronouns", "+PRON"},
+	"räkneord":   {"Numerals", "+NUM"},
+}
+
+// lexcPair is one continuation line: the analysis suffix (rest of the lemma
+// plus tags) over the surface suffix, both following the shared stem.
+type lexcPair struct {
+	upper, lower string
+}
+
+// lexcParadigm is a continuation class shared by every lemma whose forms
+// split into the same suffix pairs.
+type lexcParadigm struct {
+	name  string
+	pairs []lexcPair
+}
+
+// lexcStem is one lemma in a class sublexicon.
+type lexcStem struct {
+	stem, paradigm string
+}
+
+// lexcLexicon renders entries as an HFST/Foma lexc source. Each lemma is
+// cut into the longest stem its forms share and a continuation class of
+// suffixes; lemmas whose suffixes and tags come out identical share one
+// class, named after the first of them. Tags are the part of speech
+// followed by the UD features of the form. Multiword lemmas are left out.
+func lexcLexicon(entries []LexiconEntry) string {
+	symbols := make(map[string]bool)
+	paradigms := make(map[string]*lexcParadigm)
+	var order []*lexcParadigm
+	stems := make(map[string][]lexcStem)
+
+	for _, e := range entries {
+		class, ok := lexcClasses[e.Class]
+		if !ok || e.Headword == "" || strings.Contains(e.Headword, " ") {
+			continue
+		}
+		symbols[class.tag] = true
+
+		type analysis struct{ form, tags string }
+		var analyses []analysis
+		sections := make([]string, 0, len(e.Forms))
+		for section := range e.Forms {
+			sections = append(sections, section)
+		}
+		sort.Strings(sections)
+		for _, section := range sections {
+			for _, slot := range e.Forms[section] {
+				tags := class.tag
+				if feats := slotFeats(e, section, slot); feats != "" {
+					for _, f := range strings.Split(feats, "|") {
+						symbols["+"+f] = true
+						tags += "+" + f
+					}
+				}
+				for _, form := range append([]string{slot.Form}, slot.Variants...) {
+					if form = wordForm(form); form != "" && !strings.Contains(form, " ") {
+						analyses = append(analyses, analysis{form, tags})
+					}
+				}
+			}
+		}
+		if len(analyses) == 0 {
+			analyses = append(analyses, analysis{e.Headword, class.tag})
+		}
+
+		stem := e.Headword
+		for _, a := range analyses {
+			stem = commonPrefix(stem, a.form)
+		}
+		var pairs []lexcPair
+		seen := make(map[lexcPair]bool)
+		for _, a := range analyses {
+			p := lexcPair{upper: e.Headword[len(stem):] + a.tags, lower: a.form[len(stem):]}
+			if !seen[p] {
+				seen[p] = true
+				pairs = append(pairs, p)
+			}
+		}
+
+		var key strings.Builder
+		for _, p := range pairs {
+			key.WriteString(p.upper + "\x00" + p.lower + "\x00")
+		}
+		paradigm, ok := paradigms[key.String()]
+		if !ok {
+			paradigm = &lexcParadigm{name: e.Class + "_" + e.ID, pairs: pairs}
+			paradigms[key.String()] = paradigm
+			order = append(order, paradigm)
+		}
+		stems[e.Class] = append(stems[e.Class], lexcStem{stem: stem, paradigm: paradigm.name})
+	}
+
+	var b strings.Builder
+	b.WriteString("Multichar_Symbols\n")
+	names := make([]string, 0, len(symbols))
+	for s := range symbols {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+	for _, s := range names {
+		fmt.Fprintf(&b, "%s\n", lexcEscape(s))
+	}
+
+	classes := make([]string, 0, len(stems))
+	for class := range stems {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	b.WriteString("\nLEXICON Root\n")
+	for _, class := range classes {
+		fmt.Fprintf(&b, "%s ;\n", lexcClasses[class].lexicon)
+	}
+	for _, class := range classes {
+		fmt.Fprintf(&b, "\nLEXICON %s\n", lexcClasses[class].lexicon)
+		for _, s := range stems[class] {
+			fmt.Fprintf(&b, "%s %s ;\n", lexcSide(s.stem), s.paradigm)
+		}
+	}
+	for _, p := range order {
+		fmt.Fprintf(&b, "\nLEXICON %s\n", p.name)
+		for _, pair := range p.pairs {
+			fmt.Fprintf(&b, "%s:%s # ;\n", lexcSide(pair.upper), lexcSide(pair.lower))
+		}
+	}
+	return b.String()
+}
+
+// commonPrefix returns the longest common prefix of a and b, whole runes only.
+func commonPrefix(a, b string) string {
+	n := 0
+	for _, r := range a {
+		if !strings.HasPrefix(b[n:], string(r)) {
+			break
+		}
+		n += len(string(r))
+	}
+	return a[:n]
+}
+
+// lexcEscape escapes the characters lexc treats specially.
+func lexcEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("!%;:<>0 \t", r) {
+			b.WriteByte('%')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// lexcSide escapes one side of an entry, writing the empty string as 0.
+func lexcSide(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return lexcEscape(s)
+}
+
+// writeLexc writes the lexc source of entries to filename.
+func writeLexc(entries []LexiconEntry, filename string) error {
+	return ioutil.WriteFile(filename, []byte(lexcLexicon(entries)), 0644)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexcLexicon(t *testing.T) {
+	checkGolden(t, "lexc", strings.Split(lexcLexicon(fixtureEntries(t)), "\n"))
+}
+
+func TestLexcEscape(t *testing.T) {
+	for s, want := range map[string]string{
+		"bil":         "bil",
+		"10:e":        "1%0%:e",
+		"+Case=Nom":   "+Case=Nom",
+		"sankt%hans!": "sankt%%hans%!",
+	} {
+		if got := lexcEscape(s); got != want {
+			t.Errorf("lexcEscape(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
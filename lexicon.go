@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// LexiconEntry is one parsed lemma in the same class/forms shape as
+// verbs.json and adjectives.json, plus what identifies it: a stable ID
+// made from the headword and homograph number, the article family and
+// the headword itself. Paradigm is SAOL's inflection class (böjningsklass)
+// code, which is only unique within a word class. Source names the
+// dictionary the entry comes from, "saol" or "so". Frequency and
+// FrequencyBand are filled in from a corpus frequency list, if one is given,
+// and Translations, the English equivalents, from Folkets lexikon. Raw is
+// the source table, kept with -keep-raw for checking the forms against.
+// Senses are the lemma's meanings, Definition being that of the first, and
+// Labels the usage labels ("vardagligt", "ålderdomligt") of the lemma and
+// any of its senses, Domains their subject fields ("medicin", "juridik").
+// References are its cross-references to other lemmas and Hyphenation its
+// headword divided where it may be hyphenated, "bil·verk·stad".
+// Pronunciation is the dictionary's pronunciation hint and IPA a rough
+// transcription by rule, added by enrich -ipa. Audio is the file of the
+// spoken headword, from enrich -tts-command or -tts-url.
+type LexiconEntry struct {
+	ID            string            `json:"id"`
+	FamilyID      int               `json:"familyID"`
+	Headword      string            `json:"headword"`
+	Homograph     int               `json:"homograph,omitempty"`
+	Class         string            `json:"class"`
+	Paradigm      string            `json:"paradigm,omitempty"`
+	Definition    string            `json:"definition,omitempty"`
+	Gender        string            `json:"gender,omitempty"`
+	Particle      string            `json:"particle,omitempty"`
+	Reflexive     bool              `json:"reflexive,omitempty"`
+	Source        string            `json:"source,omitempty"`
+	Senses        []Sense           `json:"senses,omitempty"`
+	Labels        []string          `json:"labels,omitempty"`
+	Domains       []string          `json:"domains,omitempty"`
+	References    []Reference       `json:"references,omitempty"`
+	Hyphenation   string            `json:"hyphenation,omitempty"`
+	Pronunciation string            `json:"pronunciation,omitempty"`
+	Forms         map[string][]Form `json:"forms"`
+
+	Frequency     int      `json:"frequency,omitempty"`
+	FrequencyBand int      `json:"frequencyBand,omitempty"`
+	Translations  []string `json:"translations,omitempty"`
+	IPA           string   `json:"ipa,omitempty"`
+	Audio         string   `json:"audio,omitempty"`
+	Raw           string   `json:"raw,omitempty"`
+}
+
+// lexiconClasses returns the word classes that make it into the lexicon:
+// those with a registered parser, then the uninflected ones.
+func lexiconClasses() []string {
+	return append(parsedClasses(), uninflectedClasses...)
+}
+
+// parseClassForms runs the registered table parser for class. Uninflected
+// classes have no forms but are still ok; ok is false for any other class.
+func parseClassForms(ctx context.Context, class string, doc *goquery.Document, p SelectorProfile) (forms []string, ok bool) {
+	if parse, ok := parserFor(class); ok {
+		return parse(ctx, doc, p), true
+	}
+	return nil, isUninflected(class)
+}
+
+// lemmaHeadword returns the headword of a SAOL lemma and its homograph
+// number, 0 when the headword has no homographs. SAOL marks homographs with
+// a superscript number (²val), given either as .homonr or as a <sup> inside
+// the .grundform.
+func lemmaHeadword(doc *goquery.Document) (headword string, homograph int) {
+	return selectorProfiles["saol"].headword(doc.Selection)
+}
+
+// lemmaID is the stable ID of a lemma: its headword, suffixed with the
+// homograph number when there is one ("val_2").
+func lemmaID(headword string, homograph int) string {
+	if homograph > 0 {
+		return headword + "_" + strconv.Itoa(homograph)
+	}
+	return headword
+}
+
+// LexiconOption configures how lemmas are parsed into lexicon entries.
+type LexiconOption func(*lexiconOptions)
+
+type lexiconOptions struct {
+	rawMode string
+}
+
+// WithRawTables keeps each entry's source table in Raw, as HTML or text
+// (rawHTML, rawText); an empty mode keeps nothing.
+func WithRawTables(mode string) LexiconOption {
+	return func(o *lexiconOptions) { o.rawMode = mode }
+}
+
+// newLexiconEntry parses one flattened lemma with the selector profile it
+// was flattened with. ok is false when the lemma belongs to a word class without a
+// parser. The ID falls back to key for lemmas without a headword.
+func newLexiconEntry(ctx context.Context, key string, in LemmaInput, opts ...LexiconOption) (entry LexiconEntry, ok bool, err error) {
+	var o lexiconOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	profile, err := profileFor(in.Source)
+	if err != nil {
+		return LexiconEntry{}, false, err
+	}
+	if _, parsed := parserFor(in.Class); in.Class != "" && !parsed && !isUninflected(in.Class) {
+		return LexiconEntry{}, false, nil
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(in.HTML))
+	if err != nil {
+		return LexiconEntry{}, false, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	class := profile.class(doc.Selection)
+	tagged, ok := parseClassForms(ctx, class, doc, profile)
+	if !ok {
+		return LexiconEntry{}, false, nil
+	}
+	if profile.Inflection != "" {
+		tagged = parseInlineForms(doc, profile)
+	}
+
+	headword, homograph := profile.headword(doc.Selection)
+	entry = LexiconEntry{
+		ID:        key,
+		FamilyID:  in.FamilyID,
+		Headword:  headword,
+		Homograph: homograph,
+		Class:     class,
+		Source:    in.Source,
+	}
+	if profile.Paradigm != "" {
+		entry.Paradigm = cellText(doc.Find(profile.Paradigm).First())
+	}
+	if profile.Definition != "" {
+		entry.Definition = cellText(doc.Find(profile.Definition).First())
+	}
+	entry.Senses = profile.senses(doc.Selection)
+	entry.Labels = profile.usageLabelsIn(doc.Selection)
+	entry.Domains = profile.domainsIn(doc.Selection)
+	entry.References = profile.references(doc.Selection)
+	entry.Hyphenation = profile.hyphenation(doc.Selection)
+	entry.Pronunciation = profile.pronunciation(doc.Selection)
+	if headword != "" {
+		entry.ID = lemmaID(headword, homograph)
+	}
+	switch class {
+	case "substantiv":
+		entry.Gender = newNounEntry(tagged).Gender
+	case "verb":
+		entry.Particle, entry.Reflexive = splitVerbPhrase(entry.Headword)
+		tagged = stripVerbParticles(tagged, entry.Particle, entry.Reflexive)
+	}
+	entry.Forms = groupForms(class, tagged)
+	if o.rawMode != "" {
+		if entry.Raw, err = rawTable(doc, profile, o.rawMode); err != nil {
+			return LexiconEntry{}, false, err
+		}
+	}
+	return entry, true, nil
+}
+
+// surfaceForms returns the distinct surface forms of e, headword included,
+// with adjective and participle forms reduced to the word itself.
+func (e LexiconEntry) surfaceForms() []string {
+	seen := map[string]bool{e.Headword: true}
+	forms := []string{e.Headword}
+	for _, slots := range e.Forms {
+		for _, slot := range slots {
+			for _, f := range append([]string{slot.Form}, slot.Variants...) {
+				f = wordForm(f)
+				if f != "" && !seen[f] {
+					seen[f] = true
+					forms = append(forms, f)
+				}
+			}
+		}
+	}
+	return forms
+}
+
+// loadLexicon parses every lemma of a supported word class in a flattened
+// lemma file, in key order. Should two lemmas still end up with the same
+// ID, say an unnumbered homograph, the later one gets its key appended.
+func loadLexicon(filename string, opts ...LexiconOption) ([]LexiconEntry, error) {
+	inputMap, err := readFlattenedLemmas(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LexiconEntry, 0, len(inputMap))
+	seen := make(map[string]bool, len(inputMap))
+	for _, key := range lemmaKeys(inputMap) {
+		entry, ok, err := newLexiconEntry(context.Background(), key, inputMap[key], opts...)
+		if err != nil {
+			slog.Warn("skipping unparsable lemma", "key", key, "err", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if seen[entry.ID] {
+			slog.Warn("lemma has the same ID as an earlier lemma", "key", key, "id", entry.ID)
+			entry.ID += "_" + key
+		}
+		seen[entry.ID] = true
+		entries = append(entries, entry)
+	}
+
+	slog.Info("loaded lexicon", "entries", len(entries), "file", filename)
+	return entries, nil
+}
+
+// saveLexiconJSON writes entries as one indented, versioned JSON array.
+func saveLexiconJSON(entries []LexiconEntry, filename string) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, entries) })
+}
+
+// readLexiconJSON reads a lexicon written by saveLexiconJSON, or by a
+// saoltool before schema version 2.
+func readLexiconJSON(filename string) ([]LexiconEntry, error) {
+	data, err := readLocation(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lexicon file '%s': %w", filename, err)
+	}
+	_, raw, err := readVersionedEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding JSON from '%s': %w", filename, err)
+	}
+	var entries []LexiconEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding JSON from '%s': %w", filename, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLemmaHeadword(t *testing.T) {
+	tests := []struct {
+		html      string
+		headword  string
+		homograph int
+		id        string
+	}{
+		{`<span class="grundform">bil</span>`, "bil", 0, "bil"},
+		{`<span class="homonr">2</span><span class="grundform">val</span>`, "val", 2, "val_2"},
+		{`<span class="grundform"><sup>1</sup>val</span>`, "val", 1, "val_1"},
+	}
+	for _, tt := range tests {
+		entry, ok, err := newLexiconEntry(context.Background(), "7", LemmaInput{HTML: tt.html + `<span class="ordklass">substantiv</span>`})
+		if err != nil || !ok {
+			t.Fatalf("%s: ok = %v, err = %v", tt.html, ok, err)
+		}
+		if entry.Headword != tt.headword || entry.Homograph != tt.homograph || entry.ID != tt.id {
+			t.Errorf("%s: got %q, %d, ID %q, want %q, %d, ID %q", tt.html,
+				entry.Headword, entry.Homograph, entry.ID, tt.headword, tt.homograph, tt.id)
+		}
+	}
+}
+
+func TestLexiconEntryParadigm(t *testing.T) {
+	tests := []struct{ fixture, paradigm string }{
+		{"substantiv_bil", "2"},
+		{"verb_knasatta", "4"},
+		{"adjektiv_fin", ""},
+	}
+	for _, tt := range tests {
+		entry, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: readFixture(t, tt.fixture)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Paradigm != tt.paradigm {
+			t.Errorf("%s: Paradigm = %q, want %q", tt.fixture, entry.Paradigm, tt.paradigm)
+		}
+	}
+}
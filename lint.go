@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LintIssue is a paradigm that breaks a rule of Swedish morphophonology,
+// more likely a parsing error than a real exception.
+type LintIssue struct {
+	ID      string `json:"id"`
+	Class   string `json:"class"`
+	Rule    string `json:"rule"`
+	Form    string `json:"form"`
+	Message string `json:"message"`
+}
+
+// lintRule checks the paradigms of one word class. check returns the
+// offending form and why it is suspicious, or "" when the entry passes.
+type lintRule struct {
+	name  string
+	class string
+	check func(e LexiconEntry) (form, message string)
+}
+
+// presensWithoutR are the verbs, most but not all of them modal, whose
+// presens does not end in -r: kan, ska, vill, måste, må, törs, vet, torde.
+var presensWithoutR = map[string]bool{
+	"kunna": true, "skola": true, "vilja": true, "måste": true, "må": true, "töras": true,
+	"veta": true, "torde": true,
+}
+
+// lintRules are the checks lint runs, in the order it reports them.
+var lintRules = []lintRule{
+	{"or-plural", "substantiv", func(e LexiconEntry) (string, string) {
+		for _, pl := range slotForms(e, "Nominativ", labelIs("flera")) {
+			if !strings.HasSuffix(pl, "or") {
+				continue
+			}
+			stem := strings.TrimSuffix(e.Headword, "a")
+			if pl != stem+"or" && pl != e.Headword+"r" {
+				return pl, "plural in -or without a singular in -a (flicka, flickor)"
+			}
+		}
+		return "", ""
+	}},
+	{"definite-singular", "substantiv", func(e LexiconEntry) (string, string) {
+		for _, f := range slotForms(e, "Nominativ", labelIs("den", "det")) {
+			if !strings.HasSuffix(f, "n") && !strings.HasSuffix(f, "t") {
+				return f, "definite singular not in -n or -t (bilen, huset)"
+			}
+		}
+		return "", ""
+	}},
+	{"definite-plural", "substantiv", func(e LexiconEntry) (string, string) {
+		for _, f := range slotForms(e, "Nominativ", labelIs("de")) {
+			if !strings.HasSuffix(f, "na") && !strings.HasSuffix(f, "en") {
+				return f, "definite plural not in -na or -en (bilarna, husen)"
+			}
+		}
+		return "", ""
+	}},
+	{"supinum", "verb", func(e LexiconEntry) (string, string) {
+		for _, f := range slotForms(e, "Infinita former", tenseIs("supinum")) {
+			if !strings.HasSuffix(f, "t") && !(strings.HasSuffix(f, "ts") && strings.HasSuffix(e.Headword, "s")) {
+				return f, "supinum not in -t or -tt (ångrat, knäsatt)"
+			}
+		}
+		return "", ""
+	}},
+	{"presens", "verb", func(e LexiconEntry) (string, string) {
+		if presensWithoutR[e.Headword] {
+			return "", ""
+		}
+		for _, f := range slotForms(e, "Finita former", tenseIs("presens")) {
+			if !strings.HasSuffix(f, "r") && !strings.HasSuffix(f, "s") {
+				return f, "presens not in -r (knäsätter), or -s for a deponent verb"
+			}
+		}
+		return "", ""
+	}},
+	{"neuter-positive", "adjektiv", func(e LexiconEntry) (string, string) {
+		for _, f := range slotForms(e, "Positiv", ledIs("ett")) {
+			if !strings.HasSuffix(f, "t") && f != e.Headword {
+				return f, "neuter not in -t (fint), nor the same as the headword (bra)"
+			}
+		}
+		return "", ""
+	}},
+	{"comparative", "adjektiv", func(e LexiconEntry) (string, string) {
+		for _, f := range slotForms(e, "Komparativ", nil) {
+			if !strings.HasSuffix(f, "re") && !strings.HasPrefix(f, "mer ") {
+				return f, "komparativ not in -re or -are (större, finare), nor with mer"
+			}
+		}
+		return "", ""
+	}},
+	{"superlative", "adjektiv", func(e LexiconEntry) (string, string) {
+		for _, f := range slotForms(e, "Superlativ", nil) {
+			if !strings.HasSuffix(f, "st") && !strings.HasSuffix(f, "sta") && !strings.HasSuffix(f, "ste") && !strings.HasPrefix(f, "mest ") {
+				return f, "superlativ not in -st or -ast (störst, finast), nor with mest"
+			}
+		}
+		return "", ""
+	}},
+}
+
+// slotForms returns the forms, variants included and without the words
+// SAOL shows them with, of the slots of section that match accepts (every
+// slot when match is nil).
+func slotForms(e LexiconEntry, section string, match func(Form) bool) []string {
+	var forms []string
+	for _, f := range e.Forms[section] {
+		if match != nil && !match(f) {
+			continue
+		}
+		for _, w := range append([]string{f.Form}, f.Variants...) {
+			if w = wordForm(w); w != "" && w != "–" {
+				forms = append(forms, w)
+			}
+		}
+	}
+	return forms
+}
+
+// lintEntries runs every rule over entries and returns the issues found,
+// in entry order.
+func lintEntries(entries []LexiconEntry) []LintIssue {
+	var issues []LintIssue
+	for _, e := range entries {
+		for _, r := range lintRules {
+			if r.class != e.Class {
+				continue
+			}
+			if form, msg := r.check(e); msg != "" {
+				issues = append(issues, LintIssue{ID: e.ID, Class: e.Class, Rule: r.name, Form: form, Message: msg})
+			}
+		}
+	}
+	return issues
+}
+
+// runLint checks the paradigms of a lexicon against morphophonological
+// rules and lists the suspicious ones. It exits with status 1 when any are
+// found.
+func runLint(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to check")
+	format := flags.String("format", "text", "output format: text, or json")
+	flags.Parse(args)
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	issues := lintEntries(entries)
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, issues)
+	} else {
+		err = writeLintText(os.Stdout, issues)
+	}
+	if err != nil {
+		fatal("could not write lint issues", "err", err)
+	}
+	if len(issues) > 0 {
+		slog.Warn("found suspicious paradigms", "issues", len(issues), "entries", len(entries))
+		os.Exit(1)
+	}
+	slog.Info("no suspicious paradigms", "entries", len(entries))
+}
+
+// writeLintText writes a line per issue: the lemma ID, the rule, the form
+// and the message, separated by tabs.
+func writeLintText(w io.Writer, issues []LintIssue) error {
+	bw := bufio.NewWriter(w)
+	for _, i := range issues {
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\n", i.ID, i.Rule, i.Form, i.Message)
+	}
+	return bw.Flush()
+}
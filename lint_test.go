@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLintFixtures(t *testing.T) {
+	if issues := lintEntries(fixtureEntries(t)); len(issues) != 0 {
+		t.Errorf("well-formed fixtures flagged: %+v", issues)
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	noun := func(id string, slots ...Form) LexiconEntry {
+		return LexiconEntry{ID: id, Headword: id, Class: "substantiv", Forms: map[string][]Form{"Nominativ": slots}}
+	}
+	entries := []LexiconEntry{
+		noun("flicka", Form{Form: "flickor", Label: "flera"}),
+		noun("ko", Form{Form: "kor", Label: "flera"}),
+		noun("bil", Form{Form: "flickor", Label: "flera"}, Form{Form: "bilar", Label: "den"}),
+		{ID: "ta", Headword: "ta", Class: "verb", Forms: map[string][]Form{
+			"Infinita former": {{Form: "tagit", Label: "supinum"}, {Form: "tas", Label: "supinum passiv"}},
+			"Finita former":   {{Form: "tog", Label: "presens"}},
+		}},
+		{ID: "kunna", Headword: "kunna", Class: "verb", Forms: map[string][]Form{
+			"Finita former": {{Form: "kan", Label: "presens"}},
+		}},
+		{ID: "veta", Headword: "veta", Class: "verb", Forms: map[string][]Form{
+			"Finita former": {{Form: "vet", Label: "presens"}},
+		}},
+		{ID: "stor", Headword: "stor", Class: "adjektiv", Forms: map[string][]Form{
+			"Positiv":    {{Form: "ett stort"}},
+			"Komparativ": {{Form: "störst"}},
+			"Superlativ": {{Form: "är större"}},
+		}},
+	}
+	var got []string
+	for _, i := range lintEntries(entries) {
+		got = append(got, i.ID+" "+i.Rule+" "+i.Form)
+	}
+	want := []string{
+		"bil or-plural flickor",
+		"bil definite-singular bilar",
+		"ta presens tog",
+		"stor comparative störst",
+		"stor superlative större",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("issues %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("issue %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
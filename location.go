@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Input and output paths may be URLs as well as files, so the pipeline
+// can run on a dump in the cloud without copying it to disk first:
+//
+//	https://host/path   read with GET, written with a chunked PUT
+//	s3://bucket/key     Amazon S3 or, with AWS_ENDPOINT_URL, a compatible
+//	                    store; signed with AWS_ACCESS_KEY_ID,
+//	                    AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN when set,
+//	                    in AWS_REGION (default us-east-1)
+//	gs://bucket/object  Google Cloud Storage, authorized with the OAuth
+//	                    token in GOOGLE_OAUTH_ACCESS_TOKEN when set
+//
+// Both are streamed: reading starts with the first bytes of the response
+// and writing uploads as the output is written, S3 in s3PartSize parts.
+
+// locationClient makes the requests for URL locations. It has no overall
+// timeout, which would cut off a long download of a whole dump; stalls
+// are caught by stallGuard instead.
+var locationClient = http.DefaultClient
+
+// locationStallTimeout is how long a request may wait for its response,
+// or one read or write of its body may block, before it is given up. An
+// S3 request sends its part, at most s3PartSize, within that time too.
+var locationStallTimeout = 2 * time.Minute
+
+// stallGuard calls cancel unless the returned stop func is called within
+// locationStallTimeout. It is armed around each blocking call only, so a
+// slow consumer of a download, or a slow producer of an upload, does not
+// count as a stall.
+func stallGuard(cancel context.CancelFunc) (stop func()) {
+	t := time.AfterFunc(locationStallTimeout, cancel)
+	return func() { t.Stop() }
+}
+
+// joinLocation names a file in dir, a directory or a URL prefix.
+func joinLocation(dir, name string) string {
+	switch {
+	case dir == "" || dir == ".":
+		return name
+	case isRemoteLocation(dir):
+		return strings.TrimSuffix(dir, "/") + "/" + name
+	}
+	return filepath.Join(dir, name)
+}
+
+// s3PartSize is the size of the parts of an S3 multipart upload, which
+// are held in memory one at a time; an output that fits in one part is
+// uploaded with a single PUT.
+var s3PartSize = 8 << 20
+
+// gcsEndpoint is the base URL of the Cloud Storage APIs.
+var gcsEndpoint = "https://storage.googleapis.com"
+
+// isRemoteLocation reports whether name is a URL rather than a file.
+func isRemoteLocation(name string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://", "gs://"} {
+		if strings.HasPrefix(name, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// openLocation opens a file or URL for reading.
+func openLocation(name string) (io.ReadCloser, error) {
+	if !isRemoteLocation(name) {
+		return os.Open(name)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := locationRequest(ctx, http.MethodGet, name, nil, nil, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	stop := stallGuard(cancel)
+	resp, err := locationClient.Do(req)
+	stop()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := locationStatus(name, resp); err != nil {
+		cancel()
+		return nil, err
+	}
+	return &guardedBody{body: resp.Body, cancel: cancel}, nil
+}
+
+// guardedBody is the body of a download, given up when a read stalls.
+type guardedBody struct {
+	body   io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *guardedBody) Read(p []byte) (int, error) {
+	defer stallGuard(b.cancel)()
+	return b.body.Read(p)
+}
+
+func (b *guardedBody) Close() error {
+	err := b.body.Close()
+	b.cancel()
+	return err
+}
+
+// readLocation reads the whole of a file or URL.
+func readLocation(name string) ([]byte, error) {
+	r, err := openLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// locationWriter is an output being written to a file or URL. Close
+// finishes it, and for a URL returns the error of the upload; Abort gives
+// it up instead, without completing an upload.
+type locationWriter interface {
+	io.WriteCloser
+	Abort()
+}
+
+// fileWriter is a locationWriter for a file; Abort just closes it.
+type fileWriter struct{ *os.File }
+
+func (f fileWriter) Abort() { f.Close() }
+
+// createLocation creates a file or URL for writing.
+func createLocation(name string) (locationWriter, error) {
+	if !isRemoteLocation(name) {
+		f, err := os.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		return fileWriter{f}, nil
+	}
+	if strings.HasPrefix(name, "s3://") {
+		if _, _, err := splitBucketURL(name); err != nil {
+			return nil, err
+		}
+		return &s3Writer{name: name}, nil
+	}
+	return newPipeUpload(name)
+}
+
+// pipeUpload streams what is written to it as the body of one chunked
+// upload request, made as soon as the writer is created.
+type pipeUpload struct {
+	pw     *io.PipeWriter
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func newPipeUpload(name string) (*pipeUpload, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	method := http.MethodPut
+	if strings.HasPrefix(name, "gs://") {
+		method = http.MethodPost
+	}
+	req, err := locationRequest(ctx, method, name, nil, pr, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	u := &pipeUpload{pw: pw, cancel: cancel, done: make(chan error, 1)}
+	go func() {
+		resp, err := locationClient.Do(req)
+		if err == nil {
+			err = locationStatus(name, resp)
+			resp.Body.Close()
+		}
+		pr.CloseWithError(err)
+		u.done <- err
+	}()
+	return u, nil
+}
+
+func (u *pipeUpload) Write(p []byte) (int, error) {
+	defer stallGuard(u.cancel)()
+	return u.pw.Write(p)
+}
+
+func (u *pipeUpload) Close() error {
+	u.pw.Close()
+	stop := stallGuard(u.cancel)
+	err := <-u.done
+	stop()
+	u.cancel()
+	return err
+}
+
+func (u *pipeUpload) Abort() {
+	u.cancel()
+	u.pw.CloseWithError(context.Canceled)
+	<-u.done
+}
+
+// s3Writer uploads to S3 a part at a time, starting a multipart upload
+// once the output outgrows its first part.
+type s3Writer struct {
+	name     string
+	buf      bytes.Buffer
+	uploadID string
+	etags    []string
+	err      error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buf.Write(p)
+	for w.buf.Len() >= s3PartSize {
+		if w.uploadID == "" {
+			var result struct {
+				UploadID string `xml:"UploadId"`
+			}
+			if _, w.err = w.do(http.MethodPost, url.Values{"uploads": {""}}, nil, &result); w.err != nil {
+				return 0, w.err
+			}
+			w.uploadID = result.UploadID
+		}
+		if w.err = w.uploadPart(w.buf.Next(s3PartSize)); w.err != nil {
+			w.Abort()
+			return 0, w.err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *s3Writer) uploadPart(part []byte) error {
+	query := url.Values{"partNumber": {strconv.Itoa(len(w.etags) + 1)}, "uploadId": {w.uploadID}}
+	header, err := w.do(http.MethodPut, query, part, nil)
+	if err != nil {
+		return err
+	}
+	w.etags = append(w.etags, header.Get("ETag"))
+	return nil
+}
+
+func (w *s3Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.uploadID == "" {
+		_, w.err = w.do(http.MethodPut, nil, w.buf.Bytes(), nil)
+		return w.err
+	}
+	if w.buf.Len() > 0 {
+		if w.err = w.uploadPart(w.buf.Bytes()); w.err != nil {
+			w.Abort()
+			return w.err
+		}
+	}
+	var complete bytes.Buffer
+	complete.WriteString("<CompleteMultipartUpload>")
+	for i, etag := range w.etags {
+		fmt.Fprintf(&complete, "<Part><PartNumber>%d</PartNumber><ETag>", i+1)
+		xml.EscapeText(&complete, []byte(etag))
+		complete.WriteString("</ETag></Part>")
+	}
+	complete.WriteString("</CompleteMultipartUpload>")
+	_, w.err = w.do(http.MethodPost, url.Values{"uploadId": {w.uploadID}}, complete.Bytes(), nil)
+	return w.err
+}
+
+// Abort drops a multipart upload and the parts uploaded so far.
+func (w *s3Writer) Abort() {
+	if w.uploadID != "" {
+		w.do(http.MethodDelete, url.Values{"uploadId": {w.uploadID}}, nil, nil)
+		w.uploadID = ""
+	}
+	if w.err == nil {
+		w.err = context.Canceled
+	}
+}
+
+// do makes a request on the object, given up if it stalls, and decodes
+// its XML response into result, unless result is nil. It returns the
+// response headers.
+func (w *s3Writer) do(method string, query url.Values, body []byte, result interface{}) (http.Header, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := locationRequest(ctx, method, w.name, query, bytes.NewReader(body), body)
+	if err != nil {
+		return nil, err
+	}
+	defer stallGuard(cancel)()
+	resp, err := locationClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", w.name, err)
+	}
+	defer resp.Body.Close()
+	if err := locationStatus(w.name, resp); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return resp.Header, nil
+	}
+	return resp.Header, xml.NewDecoder(resp.Body).Decode(result)
+}
+
+// locationRequest builds a request on an http(s) URL, or on the HTTP
+// endpoint of an s3:// or gs:// one; a POST to a gs:// URL uploads the
+// object. S3 requests carry the whole of payload, by which they are
+// signed; other bodies are sent chunked.
+func locationRequest(ctx context.Context, method, name string, query url.Values, body io.Reader, payload []byte) (*http.Request, error) {
+	target := name
+	s3, gs := strings.HasPrefix(name, "s3://"), strings.HasPrefix(name, "gs://")
+	if s3 || gs {
+		bucket, key, err := splitBucketURL(name)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case s3:
+			target = s3ObjectURL(bucket, key)
+		case method == http.MethodPost:
+			target = gcsEndpoint + "/upload/storage/v1/b/" + url.PathEscape(bucket) + "/o"
+			query = url.Values{"uploadType": {"media"}, "name": {key}}
+		default:
+			target = gcsEndpoint + "/" + bucket + "/" + s3EscapePath(key)
+		}
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case s3:
+		req.ContentLength = int64(len(payload))
+		if len(payload) == 0 {
+			req.Body = http.NoBody
+		}
+		signS3(req, payload, time.Now())
+	case body != nil:
+		req.ContentLength = -1
+	}
+	if gs {
+		authorizeGCS(req)
+	}
+	return req, nil
+}
+
+// splitBucketURL splits s3://bucket/key or gs://bucket/object.
+func splitBucketURL(name string) (bucket, key string, err error) {
+	rest := name[strings.Index(name, "://")+3:]
+	bucket, key, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("'%s' is not a bucket/object URL", name)
+	}
+	return bucket, key, nil
+}
+
+// s3ObjectURL is the HTTP URL of an S3 object: path style under
+// AWS_ENDPOINT_URL, for S3-compatible stores, else virtual-hosted style
+// on Amazon's endpoint for AWS_REGION.
+func s3ObjectURL(bucket, key string) string {
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + s3EscapePath(key)
+	}
+	return "https://" + bucket + ".s3." + s3Region() + ".amazonaws.com/" + s3EscapePath(key)
+}
+
+func s3Region() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+// authorizeGCS adds the OAuth token of GOOGLE_OAUTH_ACCESS_TOKEN, if set.
+func authorizeGCS(req *http.Request) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// locationStatus turns an unsuccessful response into an error quoting
+// the start of its body.
+func locationStatus(name string, resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s: %s", name, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// s3EscapePath escapes a key the way Signature Version 4 expects it:
+// everything but unreserved characters and "/".
+func s3EscapePath(key string) string {
+	return strings.ReplaceAll(s3Escape(key), "%2F", "/")
+}
+
+func s3Escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// signS3 signs req with AWS Signature Version 4 and the credentials in
+// the environment, covering its Host, the headers already set and the
+// SHA-256 of payload. Without credentials the request goes unsigned, for
+// public buckets.
+func signS3(req *http.Request, payload []byte, now time.Time) {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return
+	}
+	now = now.UTC()
+	amzDate, day := now.Format("20060102T150405Z"), now.Format("20060102")
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{req.Method, path, strings.Join(params, "&"), canonicalHeaders.String(), signedHeaders, hex.EncodeToString(payloadHash[:])}, "\n")
+	scope := day + "/" + s3Region() + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{day, s3Region(), "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, hex.EncodeToString(key)))
+}
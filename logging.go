@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Log records carry the same attribute names throughout, so a log
+// collector can index them: stage (the command), worker, index (of an
+// article in saol_entries.json), key (of a lemma in flattened_lemmas.json),
+// file and err.
+
+// setupLogging makes slog's default logger write to w at level ("debug",
+// "info", "warn" or "error") in format, "text" or "json", with the stage
+// attribute set to stage. The log package writes through it as well.
+func setupLogging(w io.Writer, level, format, stage string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level %q, want debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q, want text or json", format)
+	}
+	slog.SetDefault(slog.New(h).With("stage", stage))
+	return nil
+}
+
+// fatalHooks run before fatal exits, newest first, for what deferred
+// calls would otherwise have finished, such as profiles.
+var (
+	fatalMu    sync.Mutex
+	fatalHooks []func()
+)
+
+// onFatal registers fn to run before fatal exits.
+func onFatal(fn func()) {
+	fatalMu.Lock()
+	defer fatalMu.Unlock()
+	fatalHooks = append(fatalHooks, fn)
+}
+
+// fatal logs msg and its attributes as an error, runs the onFatal hooks
+// and exits, the slog counterpart of log.Fatalf. A hook that calls fatal
+// itself exits at once.
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	fatalMu.Lock()
+	hooks := fatalHooks
+	fatalHooks = nil
+	fatalMu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var buf bytes.Buffer
+	if err := setupLogging(&buf, "warn", "json", "flatten"); err != nil {
+		t.Fatal(err)
+	}
+	slog.Info("not written")
+	slog.Warn("skipping article", "index", 3)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	if record["level"] != "WARN" || record["msg"] != "skipping article" || record["stage"] != "flatten" || record["index"] != 3.0 {
+		t.Errorf("record %v", record)
+	}
+
+	if err := setupLogging(&buf, "loud", "text", "flatten"); err == nil {
+		t.Error("unknown level accepted")
+	}
+	if err := setupLogging(&buf, "info", "xml", "flatten"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+// TestFatalRunsHooks calls fatal in a copy of the test binary, which
+// should run the onFatal hooks, newest first, before it exits with 1.
+func TestFatalRunsHooks(t *testing.T) {
+	if out := os.Getenv("SAOLTOOL_FATAL_OUT"); out != "" {
+		onFatal(func() { appendLine(out, "first") })
+		onFatal(func() { appendLine(out, "second") })
+		fatal("giving up")
+		return
+	}
+	out := filepath.Join(t.TempDir(), "hooks.txt")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalRunsHooks$")
+	cmd.Env = append(os.Environ(), "SAOLTOOL_FATAL_OUT="+out)
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); !ok || exit.ExitCode() != 1 {
+		t.Fatalf("fatal exited with %v, want status 1", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "second\nfirst\n" {
+		t.Errorf("hooks ran as %q, want second, then first", data)
+	}
+}
+
+func appendLine(filename, line string) {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(line + "\n")
+}
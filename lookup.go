@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lookupCandidate is a form matching a lookup and the lemmas it is a form
+// of. Distance is the edit distance to the query for a fuzzy lookup and the
+// number of letters the form adds to it for a prefix or suffix lookup.
+type lookupCandidate struct {
+	Form     string   `json:"form"`
+	Distance int      `json:"distance"`
+	Lemmas   []string `json:"lemmas"`
+}
+
+// lookupQuery says how forms are matched against a query: exactly, as its
+// extensions (prefix), as words ending in it (suffix) or within Fuzzy edits.
+type lookupQuery struct {
+	Prefix bool
+	Suffix bool
+	Fuzzy  int
+}
+
+// match returns the distance of form to query and whether it matches.
+func (q lookupQuery) match(query, form string) (int, bool) {
+	switch {
+	case q.Prefix:
+		return utf8.RuneCountInString(form) - utf8.RuneCountInString(query), strings.HasPrefix(form, query)
+	case q.Suffix:
+		return utf8.RuneCountInString(form) - utf8.RuneCountInString(query), strings.HasSuffix(form, query)
+	case q.Fuzzy > 0:
+		d := damerauLevenshtein(query, form, q.Fuzzy)
+		return d, d <= q.Fuzzy
+	}
+	return 0, form == query
+}
+
+// lookupForms returns the forms of the lexicon in s matching query, closest
+// first and then in alphabetical order, at most n of them (all when n is 0).
+// Case is ignored.
+func lookupForms(s *memStore, query string, q lookupQuery, n int) []lookupCandidate {
+	query = strings.ToLower(query)
+	byForm := make(map[string]*lookupCandidate)
+	for form, idx := range s.byForm {
+		d, ok := q.match(query, strings.ToLower(form))
+		if !ok {
+			continue
+		}
+		c, seen := byForm[form]
+		if !seen {
+			c = &lookupCandidate{Form: form, Distance: d}
+			byForm[form] = c
+		}
+		for _, i := range idx {
+			c.Lemmas = append(c.Lemmas, s.entries[i].ID)
+		}
+	}
+	candidates := make([]lookupCandidate, 0, len(byForm))
+	for _, c := range byForm {
+		candidates = append(candidates, *c)
+	}
+	return rankCandidates(candidates, n)
+}
+
+// rankCandidates sorts candidates closest first and then alphabetically
+// and keeps the first n (all when n is 0).
+func rankCandidates(candidates []lookupCandidate, n int) []lookupCandidate {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].Form < candidates[j].Form
+	})
+	if n > 0 && len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// lookupIndexForms is lookupForms for an index file. Exact and prefix
+// lookups walk the trie below the query in lower case, capitalised and in
+// capitals; suffix and fuzzy lookups scan every form. Only the lemmas of
+// the candidates kept are read.
+func lookupIndexForms(s *indexStore, query string, q lookupQuery, n int) ([]lookupCandidate, error) {
+	query = strings.ToLower(query)
+	roots := []string{""}
+	if !q.Suffix && q.Fuzzy == 0 {
+		roots = []string{query}
+		if r, size := utf8.DecodeRuneInString(query); unicode.IsLower(r) {
+			roots = append(roots, string(unicode.ToUpper(r))+query[size:])
+		}
+		if upper := strings.ToUpper(query); upper != roots[len(roots)-1] {
+			roots = append(roots, upper)
+		}
+	}
+
+	candidates := []lookupCandidate{}
+	records := make(map[string][]uint32)
+	for _, root := range roots {
+		node, ok, err := s.find(indexKey(indexForm, root))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		_, err = s.walk(node, []byte(root), func(key []byte, values []uint32) bool {
+			if form := string(key); len(values) > 0 && records[form] == nil {
+				if d, ok := q.match(query, strings.ToLower(form)); ok {
+					candidates = append(candidates, lookupCandidate{Form: form, Distance: d})
+					records[form] = values
+				}
+			}
+			return q.Prefix || q.Suffix || q.Fuzzy > 0
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	candidates = rankCandidates(candidates, n)
+	for i := range candidates {
+		entries, err := s.pick(records[candidates[i].Form])
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			candidates[i].Lemmas = append(candidates[i].Lemmas, e.ID)
+		}
+	}
+	return candidates, nil
+}
+
+// damerauLevenshtein returns the optimal string alignment distance between
+// a and b in runes: insertions, deletions, substitutions and swaps of two
+// adjacent letters each cost one. It gives up once the distance is
+// certain to exceed max and returns max+1.
+func damerauLevenshtein(a, b string, max int) int {
+	s, t := []rune(a), []rune(b)
+	if d := len(s) - len(t); d > max || -d > max {
+		return max + 1
+	}
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(t)], max+1)
+}
+
+// runLookup lists the forms of a lexicon matching a word, for a
+// did-you-mean in a dictionary interface.
+func runLookup(args []string) {
+	flags := flag.NewFlagSet("lookup", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to look up in")
+	index := flags.String("index", "", "look forms up in this index file (see saoltool index) instead of parsing -in, which builds it if missing")
+	prefix := flags.Bool("prefix", false, "match the forms beginning with the word")
+	suffix := flags.Bool("suffix", false, "match the forms ending in the word")
+	fuzzy := flags.Int("fuzzy", 0, "match the forms within this many edits of the word, adjacent swaps included")
+	n := flags.Int("n", 10, "candidates to list, best first (0 for all)")
+	format := flags.String("format", "text", "output format: text, or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool lookup [-in file | -index file] [-prefix | -suffix | -fuzzy N] [-n 10] [-format text|json] <word>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	q := lookupQuery{Prefix: *prefix, Suffix: *suffix, Fuzzy: *fuzzy}
+	if err := q.validate(); err != nil {
+		fatal("invalid lookup", "err", err)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	var candidates []lookupCandidate
+	if *index != "" {
+		s, err := openIndexStore(*index, *in)
+		if err != nil {
+			fatal("could not open index", "file", *index, "err", err)
+		}
+		candidates, err = lookupIndexForms(s, flags.Arg(0), q, *n)
+		s.Close()
+		if err != nil {
+			fatal("could not look up in index", "file", *index, "err", err)
+		}
+	} else {
+		entries, err := readDiffLexicon(*in)
+		if err != nil {
+			fatal("could not read lexicon", "file", *in, "err", err)
+		}
+		candidates = lookupForms(newMemStore(entries), flags.Arg(0), q, *n)
+	}
+	var err error
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, candidates)
+	} else {
+		err = writeLookupText(os.Stdout, candidates)
+	}
+	if err != nil {
+		fatal("could not write candidates", "err", err)
+	}
+}
+
+// validate reports a lookup asking for more than one kind of match.
+func (q lookupQuery) validate() error {
+	modes := 0
+	for _, on := range []bool{q.Prefix, q.Suffix, q.Fuzzy != 0} {
+		if on {
+			modes++
+		}
+	}
+	switch {
+	case q.Fuzzy < 0:
+		return errors.New("-fuzzy must not be negative")
+	case modes > 1:
+		return errors.New("-prefix, -suffix and -fuzzy exclude each other")
+	}
+	return nil
+}
+
+// writeLookupText writes a line per candidate: the form, its distance and
+// its lemmas, separated by tabs.
+func writeLookupText(w io.Writer, candidates []lookupCandidate) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range candidates {
+		fmt.Fprintf(bw, "%s\t%d\t%s\n", c.Form, c.Distance, strings.Join(c.Lemmas, " "))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDamerauLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"bil", "bil", 2, 0},
+		{"bil", "bill", 2, 1},
+		{"bil", "bli", 2, 1},       // swap
+		{"häst", "hast", 2, 1},     // runes, not bytes
+		{"stol", "stolarna", 2, 3}, // gives up
+		{"kaffe", "kafee", 2, 1},
+		{"", "ab", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := damerauLevenshtein(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("damerauLevenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestLookupForms(t *testing.T) {
+	s := newMemStore([]LexiconEntry{
+		{ID: "bil", Headword: "bil", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "bilen"}, {Form: "bilar"}}}},
+		{ID: "bila", Headword: "bila", Class: "verb", Forms: map[string][]Form{"Aktiv": {{Form: "bilar"}}}},
+		{ID: "sil", Headword: "sil", Class: "substantiv"},
+		{ID: "personbil", Headword: "personbil", Class: "substantiv"},
+		{ID: "Bilbao", Headword: "Bilbao", Class: "egennamn"},
+	})
+	tests := []struct {
+		name  string
+		query string
+		q     lookupQuery
+		n     int
+		want  []lookupCandidate
+	}{
+		{"exact", "Bilar", lookupQuery{}, 0, []lookupCandidate{{"bilar", 0, []string{"bil", "bila"}}}},
+		{"prefix", "bil", lookupQuery{Prefix: true}, 0, []lookupCandidate{
+			{"bil", 0, []string{"bil"}}, {"bila", 1, []string{"bila"}},
+			{"bilar", 2, []string{"bil", "bila"}}, {"bilen", 2, []string{"bil"}},
+			{"Bilbao", 3, []string{"Bilbao"}},
+		}},
+		{"suffix", "bil", lookupQuery{Suffix: true}, 0, []lookupCandidate{
+			{"bil", 0, []string{"bil"}}, {"personbil", 6, []string{"personbil"}},
+		}},
+		{"fuzzy", "bli", lookupQuery{Fuzzy: 1}, 0, []lookupCandidate{
+			{"bil", 1, []string{"bil"}},
+		}},
+		{"fuzzy limit", "bil", lookupQuery{Fuzzy: 1}, 2, []lookupCandidate{
+			{"bil", 0, []string{"bil"}}, {"bila", 1, []string{"bila"}},
+		}},
+	}
+	index, err := openIndexStore(writeTestIndex(t, s.entries), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+	for _, tt := range tests {
+		if got := lookupForms(s, tt.query, tt.q, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: lookupForms(%q) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+		got, err := lookupIndexForms(index, tt.query, tt.q, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: lookupIndexForms(%q) = %v, want %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+	if got := lookupForms(s, "xyz", lookupQuery{Fuzzy: 1}, 0); len(got) != 0 {
+		t.Errorf("lookupForms(xyz) = %v, want none", got)
+	}
+	if got, err := lookupIndexForms(index, "xyz", lookupQuery{Prefix: true}, 0); len(got) != 0 || err != nil {
+		t.Errorf("lookupIndexForms(xyz) = %v, %v, want none", got, err)
+	}
+}
+
+func TestLookupQueryValidate(t *testing.T) {
+	for _, q := range []lookupQuery{{Prefix: true, Suffix: true}, {Prefix: true, Fuzzy: 1}, {Fuzzy: -1}} {
+		if q.validate() == nil {
+			t.Errorf("%+v: want an error", q)
+		}
+	}
+	if err := (lookupQuery{Fuzzy: 2}).validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteLookupText(t *testing.T) {
+	var buf bytes.Buffer
+	writeLookupText(&buf, []lookupCandidate{{"bilar", 1, []string{"bil", "bila"}}})
+	if got, want := buf.String(), "bilar\t1\tbil bila\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// saoltool runs the SAOL processing pipeline one stage at a time:
+//
+//	saoltool scrape    wordlist -> saol_entries.json, fetched from svenska.se
+//	saoltool flatten   saol_entries.json -> flattened_lemmas.json
+//	saoltool extract   flattened_lemmas.json -> one JSON file per word class
+//	saoltool enrich    flattened_lemmas.json -> lexicon.json, manifest.json
+//	saoltool export    flattened_lemmas.json -> a lexicon for another tool (-format)
+//	saoltool retry     quarantined_entries.json -> flattened_lemmas.json
+//	saoltool split     saol_entries.json -> shards/shard-NNN/saol_entries.json
+//	saoltool merge     shards/shard-NNN/*.json -> flattened_lemmas.json, nouns.json, ...
+//	saoltool saldo     flattened_lemmas.json + saldom.xml -> saldo_crosswalk.json
+//	saoltool serve     flattened_lemmas.json or a database -> REST API on :8080
+//	saoltool diff      old lexicon + new lexicon -> added, removed and changed lemmas
+//	saoltool migrate   output files of an older saoltool -> the current schema version
+//	saoltool validate  any output file -> its errors against schema/*.schema.json
+//
+// -log-level and -log-format, before the command, apply to every stage.
+func main() {
+	global := flag.NewFlagSet("saoltool", flag.ExitOnError)
+	global.Usage = usage
+	logLevel := global.String("log-level", "info", "least severe log records to write: debug, info, warn or error")
+	logFormat := global.String("log-format", "text", "log record format: text, or json for log collectors")
+	global.Parse(os.Args[1:])
+	args := global.Args()
+	if len(args) < 1 {
+		usage()
+		os.Exit(2)
+	}
+	if err := setupLogging(os.Stderr, *logLevel, *logFormat, args[0]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "scrape":
+		runScrape(args[1:])
+	case "flatten":
+		runFlatten(args[1:])
+	case "extract":
+		runExtract(args[1:])
+	case "enrich":
+		runEnrich(args[1:])
+	case "export":
+		runExport(args[1:])
+	case "retry":
+		runRetry(args[1:])
+	case "split":
+		runSplit(args[1:])
+	case "merge":
+		runMerge(args[1:])
+	case "saldo":
+		runSaldo(args[1:])
+	case "serve":
+		runServe(args[1:])
+	case "diff":
+		runDiff(args[1:])
+	case "migrate":
+		runMigrate(args[1:])
+	case "validate":
+		runValidate(args[1:])
+	case "lint":
+		runLint(args[1:])
+	case "check":
+		runCheck(args[1:])
+	case "segment":
+		runSegment(args[1:])
+	case "generate":
+		runGenerate(args[1:])
+	case "tag":
+		runTag(args[1:])
+	case "predict":
+		runPredict(args[1:])
+	case "index":
+		runIndex(args[1:])
+	case "lookup":
+		runLookup(args[1:])
+	case "suggest":
+		runSuggest(args[1:])
+	case "search":
+		runSearch(args[1:])
+	case "browse":
+		runBrowse(args[1:])
+	case "conjugate", "decline":
+		runTable(args[0], args[1:])
+	case "rhymes":
+		runRhymes(args[1:])
+	case "random":
+		runRandom(args[1:])
+	case "report":
+		runReport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: saoltool [-log-level debug|info|warn|error] [-log-format text|json] <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  scrape    fetch SAOL articles from svenska.se into saol_entries.json")
+	fmt.Fprintln(os.Stderr, "  flatten   split saol_entries.json into flattened_lemmas.json")
+	fmt.Fprintln(os.Stderr, "  extract   parse flattened_lemmas.json into per-class JSON files")
+	fmt.Fprintln(os.Stderr, "  enrich    build lexicon.json and add data from optional external sources")
+	fmt.Fprintln(os.Stderr, "  export    write the lexicon for another tool, e.g. -format spacy")
+	fmt.Fprintln(os.Stderr, "  retry     split the articles flatten quarantined again and merge them back in")
+	fmt.Fprintln(os.Stderr, "  split     partition saol_entries.json into shards to flatten and extract separately")
+	fmt.Fprintln(os.Stderr, "  merge     combine the outputs of the shards, renumbering keys and family IDs")
+	fmt.Fprintln(os.Stderr, "  saldo     align the lexicon with SALDO and report differing inflections")
+	fmt.Fprintln(os.Stderr, "  serve     serve the lexicon over HTTP: /lemma/{word}, /form/{form}, /paradigm/{id}, /search?q=")
+	fmt.Fprintln(os.Stderr, "  diff      report the lemmas and forms added, removed and changed between two lexicons")
+	fmt.Fprintln(os.Stderr, "  migrate   upgrade output files written by an older saoltool to the current schema version")
+	fmt.Fprintln(os.Stderr, "  validate  check output files against their JSON Schema in schema/")
+	fmt.Fprintln(os.Stderr, "  lint      flag paradigms that break Swedish morphophonology, likely parsing errors")
+	fmt.Fprintln(os.Stderr, "  check     cross-check flatten, extract and later output: lemma counts, slots, familyIDs")
+	fmt.Fprintln(os.Stderr, "  segment   split compounds into lemmas: järnvägsstation -> järnväg+s+station")
+	fmt.Fprintln(os.Stderr, "  generate  print the forms of a lemma with given features: sätta verb+preteritum+passiv")
+	fmt.Fprintln(os.Stderr, "  tag       lemmatize and tag running text, one token per line in CoNLL-U")
+	fmt.Fprintln(os.Stderr, "  predict   guess the inflection of a word the lexicon lacks from its paradigms")
+	fmt.Fprintln(os.Stderr, "  index     build the trie index file serve -store index maps")
+	fmt.Fprintln(os.Stderr, "  lookup    list the forms matching a word exactly, by -prefix, -suffix or -fuzzy N edits")
+	fmt.Fprintln(os.Stderr, "  suggest   rank spelling corrections of a word by typing distance and frequency")
+	fmt.Fprintln(os.Stderr, "  search    stream the lemmas whose headword, forms or definition match a -pattern or -glob as NDJSON")
+	fmt.Fprintln(os.Stderr, "  browse    search the lexicon and view inflection tables in the terminal")
+	fmt.Fprintln(os.Stderr, "  conjugate print the full inflection table of a lemma as text, markdown or html; also decline")
+	fmt.Fprintln(os.Stderr, "  rhymes    list the forms sharing the longest ending with a word, by class and syllable count")
+	fmt.Fprintln(os.Stderr, "  random    print random lemmas, e.g. -class adjektiv -n 10, for flashcards and quizzes")
+	fmt.Fprintln(os.Stderr, "  report    write an HTML page of parsed lemmas next to their source tables, dropped rows highlighted")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// combinedFile is where extract -combined writes every class.
+const combinedFile = "classes.json"
+
+// extractManifest describes one extract run: what it read, how many
+// lemmas of each class it wrote and the files it wrote them to.
+type extractManifest struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	Generated     time.Time      `json:"generated"`
+	Source        manifestFile   `json:"source"`
+	Counts        map[string]int `json:"counts"`
+	Quarantined   int            `json:"quarantined,omitempty"`
+	Artifacts     []manifestFile `json:"artifacts"`
+}
+
+// manifestFile identifies a file by name, size and SHA-256.
+type manifestFile struct {
+	File   string `json:"file"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// describeFile hashes filename, a file or a URL, for a manifest.
+func describeFile(filename string) (manifestFile, error) {
+	f, err := openLocation(filename)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	return manifestFile{File: filename, Bytes: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// saveExtractManifest describes source and the artifacts extract wrote in
+// filename.
+func saveExtractManifest(filename, source string, counts map[string]int, quarantined int, artifacts []string) error {
+	m := extractManifest{
+		SchemaVersion: outputSchemaVersion,
+		Generated:     time.Now().UTC(),
+		Counts:        counts,
+		Quarantined:   quarantined,
+		Artifacts:     []manifestFile{},
+	}
+	var err error
+	if m.Source, err = describeFile(source); err != nil {
+		return err
+	}
+	for _, name := range artifacts {
+		desc, err := describeFile(name)
+		if err != nil {
+			return err
+		}
+		m.Artifacts = append(m.Artifacts, desc)
+	}
+	return saveFile(filename, func(w io.Writer) error { return writeIndentedJSON(w, m) })
+}
+
+// writeCombinedJSON writes the output of every class as one versioned
+// object, the entries of each keyed by class under "classes", in the shape
+// of the class's own file.
+func writeCombinedJSON(w io.Writer, parsed map[string][][]string, classes []string, ud bool, uninflected []UninflectedEntry) error {
+	combined := make(map[string]json.RawMessage, len(classes)+1)
+	for _, class := range classes {
+		var buf bytes.Buffer
+		if err := outputFor(class).write(&buf, parsed[class], ud); err != nil {
+			return err
+		}
+		_, entries, err := readVersionedEntries(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		combined[class] = entries
+	}
+	if uninflected != nil {
+		data, err := json.Marshal(uninflected)
+		if err != nil {
+			return err
+		}
+		combined["uninflected"] = data
+	}
+	return writeIndentedJSON(w, combinedOutput{SchemaVersion: outputSchemaVersion, Classes: combined})
+}
+
+// combinedOutput is classes.json.
+type combinedOutput struct {
+	SchemaVersion int                        `json:"schemaVersion"`
+	Classes       map[string]json.RawMessage `json:"classes"`
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCombinedJSON(t *testing.T) {
+	parsed := make(map[string][][]string)
+	for _, name := range []string{"substantiv_bil", "verb_simma", "adjektiv_fin", "pronomen_jag", "rakneord_en"} {
+		doc := loadFixture(t, name)
+		class := saolProfile.class(doc.Selection)
+		parse, _ := parserFor(class)
+		parsed[class] = append(parsed[class], parse(context.Background(), doc, saolProfile))
+	}
+	uninflected := []UninflectedEntry{parseUninflected(loadFixture(t, "preposition_pa"), saolProfile)}
+
+	var buf bytes.Buffer
+	if err := writeCombinedJSON(&buf, parsed, parsedClasses(), false, uninflected); err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, combinedFile, buf.Bytes())
+
+	// Each class is what its own file would hold.
+	var combined combinedOutput
+	if err := json.Unmarshal(buf.Bytes(), &combined); err != nil {
+		t.Fatal(err)
+	}
+	var verbs bytes.Buffer
+	WriteVerbsJSON(&verbs, parsed["verb"], false)
+	_, verbEntries, _ := readVersionedEntries(verbs.Bytes())
+	if got, want := compactJSON(t, combined.Classes["verb"]), compactJSON(t, verbEntries); got != want {
+		t.Errorf("verb in %s:\n%s\nwant\n%s", combinedFile, got, want)
+	}
+	if _, ok := combined.Classes["uninflected"]; !ok {
+		t.Error("no uninflected lemmas")
+	}
+
+	buf.Reset()
+	writeCombinedJSON(&buf, parsed, parsedClasses(), false, nil)
+	combined = combinedOutput{}
+	json.Unmarshal(buf.Bytes(), &combined)
+	if _, ok := combined.Classes["uninflected"]; ok {
+		t.Error("uninflected lemmas without -uninflected")
+	}
+}
+
+func compactJSON(t *testing.T, data []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestSaveExtractManifest(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, outputFile)
+	verbs := filepath.Join(dir, "verbs.json")
+	os.WriteFile(source, []byte("[]\n"), 0644)
+	os.WriteFile(verbs, []byte("[]"), 0644)
+
+	filename := filepath.Join(dir, "extract_manifest.json")
+	counts := map[string]int{"verb": 0, "substantiv": 0}
+	if err := saveExtractManifest(filename, source, counts, 2, []string{verbs}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, filename, data)
+
+	var m extractManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	wantSource := manifestFile{source, 3, "37517e5f3dc66819f61f5a7bb8ace1921282415f10551d2defa5c3eb0985b570"}
+	if m.Source != wantSource {
+		t.Errorf("source %+v, want %+v", m.Source, wantSource)
+	}
+	if m.SchemaVersion != outputSchemaVersion || m.Quarantined != 2 || !reflect.DeepEqual(m.Counts, counts) {
+		t.Errorf("manifest %+v", m)
+	}
+	if len(m.Artifacts) != 1 || m.Artifacts[0].File != verbs || m.Artifacts[0].Bytes != 2 {
+		t.Errorf("artifacts %+v", m.Artifacts)
+	}
+
+	if err := saveExtractManifest(filename, filepath.Join(dir, "missing.json"), counts, 0, nil); err == nil {
+		t.Error("want an error for a missing source")
+	}
+}
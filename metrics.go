@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The pipeline metrics, served in the Prometheus text format on
+// -metrics-addr while flatten or extract runs. Every series has a stage
+// label, "flatten" or "extract".
+var (
+	entriesProcessed = newMetricCounter("saoltool_entries_processed_total", "Articles (flatten) or lemmas (extract) done with, failed ones included.")
+	entryErrors      = newMetricCounter("saoltool_entry_errors_total", "Articles or lemmas that failed or were quarantined.")
+	lemmasByClass    = newMetricCounter("saoltool_lemmas_total", "Lemmas written, by word class.")
+	parseDuration    = newMetricHistogram("saoltool_parse_duration_seconds", "Time to split an article or parse a lemma.",
+		[]float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5})
+
+	allMetrics = []metric{entriesProcessed, entryErrors, lemmasByClass, parseDuration}
+)
+
+type metric interface {
+	writeTo(w io.Writer)
+}
+
+// metricLabels renders label name/value pairs as Prometheus writes them
+// between the braces of a series.
+func metricLabels(pairs []string) string {
+	labels := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, pairs[i]+"="+strconv.Quote(pairs[i+1]))
+	}
+	return strings.Join(labels, ",")
+}
+
+// sortedKeys returns the keys of m in order, so the output is stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// metricCounter is a counter with one series per set of labels.
+type metricCounter struct {
+	name, help string
+
+	mu     sync.Mutex
+	series map[string]float64
+}
+
+func newMetricCounter(name, help string) *metricCounter {
+	return &metricCounter{name: name, help: help, series: make(map[string]float64)}
+}
+
+// inc adds one to the series of the label name/value pairs.
+func (c *metricCounter) inc(labels ...string) {
+	key := metricLabels(labels)
+	c.mu.Lock()
+	c.series[key]++
+	c.mu.Unlock()
+}
+
+func (c *metricCounter) writeTo(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.series) {
+		fmt.Fprintf(w, "%s{%s} %g\n", c.name, key, c.series[key])
+	}
+}
+
+// metricHistogram is a histogram with one series per set of labels.
+type metricHistogram struct {
+	name, help string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func newMetricHistogram(name, help string, buckets []float64) *metricHistogram {
+	return &metricHistogram{name: name, help: help, buckets: buckets, series: make(map[string]*histogramSeries)}
+}
+
+// observe records d in the series of the label name/value pairs.
+func (h *metricHistogram) observe(d time.Duration, labels ...string) {
+	key := metricLabels(labels)
+	v := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.series[key]
+	if s == nil {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *metricHistogram) writeTo(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		sep := ""
+		if key != "" {
+			sep = ","
+		}
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s%sle=%q} %d\n", h.name, key, sep, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", h.name, key, sep, s.count)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", h.name, key, s.sum)
+		fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, key, s.count)
+	}
+}
+
+// metricsHandler serves allMetrics in the Prometheus text format.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, m := range allMetrics {
+		m.writeTo(w)
+	}
+}
+
+// serveMetrics serves /metrics on addr in the background for as long as
+// the process runs.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metricsHandler)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Warn("metrics server stopped", "addr", addr, "err", err)
+		}
+	}()
+	slog.Info("serving metrics", "url", "http://"+addr+"/metrics")
+}
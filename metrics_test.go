@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsText(t *testing.T) {
+	c := newMetricCounter("test_lemmas_total", "Lemmas.")
+	c.inc("stage", "extract", "class", "verb")
+	c.inc("stage", "extract", "class", "verb")
+	c.inc("stage", "extract", "class", "räkneord")
+
+	h := newMetricHistogram("test_duration_seconds", "Time.", []float64{.01, .1})
+	h.observe(5*time.Millisecond, "stage", "flatten")
+	h.observe(50*time.Millisecond, "stage", "flatten")
+	h.observe(time.Second, "stage", "flatten")
+
+	var buf bytes.Buffer
+	c.writeTo(&buf)
+	h.writeTo(&buf)
+	want := `# HELP test_lemmas_total Lemmas.
+# TYPE test_lemmas_total counter
+test_lemmas_total{stage="extract",class="räkneord"} 1
+test_lemmas_total{stage="extract",class="verb"} 2
+# HELP test_duration_seconds Time.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{stage="flatten",le="0.01"} 1
+test_duration_seconds_bucket{stage="flatten",le="0.1"} 2
+test_duration_seconds_bucket{stage="flatten",le="+Inf"} 3
+test_duration_seconds_sum{stage="flatten"} 1.055
+test_duration_seconds_count{stage="flatten"} 3
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	entriesProcessed.inc("stage", "flatten")
+	rec := httptest.NewRecorder()
+	metricsHandler(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type %q", ct)
+	}
+	for _, m := range []string{"saoltool_entries_processed_total{stage=\"flatten\"}", "# TYPE saoltool_parse_duration_seconds histogram"} {
+		if !strings.Contains(rec.Body.String(), m) {
+			t.Errorf("no %s in\n%s", m, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "io/ioutil"
+
+// mapFile reads filename into memory where there is no mmap.
+func mapFile(filename string) (data []byte, close func() error, err error) {
+	data, err = ioutil.ReadFile(filename)
+	return data, func() error { return nil }, err
+}
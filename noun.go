@@ -0,0 +1,69 @@
+package main
+
+// NounSlots is the paradigm of a noun as the eight slots of the grammars,
+// singular and plural by indefinite and definite, each in the nominative
+// and the genitive: sg_indef_nom "bil", sg_def_gen "bilens", pl_def_nom
+// "bilarna" and so on. A slot is empty when SAOL has no form for it.
+type NounSlots struct {
+	SgIndefNom string `json:"sg_indef_nom,omitempty"`
+	SgIndefGen string `json:"sg_indef_gen,omitempty"`
+	SgDefNom   string `json:"sg_def_nom,omitempty"`
+	SgDefGen   string `json:"sg_def_gen,omitempty"`
+	PlIndefNom string `json:"pl_indef_nom,omitempty"`
+	PlIndefGen string `json:"pl_indef_gen,omitempty"`
+	PlDefNom   string `json:"pl_def_nom,omitempty"`
+	PlDefGen   string `json:"pl_def_gen,omitempty"`
+}
+
+// slot returns the slot for number, definiteness and case, nil for
+// features outside the paradigm.
+func (s *NounSlots) slot(number, definiteness, nounCase string) *string {
+	slots := map[[3]string]*string{
+		{"singular", "obestämd", "Nominativ"}: &s.SgIndefNom,
+		{"singular", "obestämd", "Genitiv"}:   &s.SgIndefGen,
+		{"singular", "bestämd", "Nominativ"}:  &s.SgDefNom,
+		{"singular", "bestämd", "Genitiv"}:    &s.SgDefGen,
+		{"plural", "obestämd", "Nominativ"}:   &s.PlIndefNom,
+		{"plural", "obestämd", "Genitiv"}:     &s.PlIndefGen,
+		{"plural", "bestämd", "Nominativ"}:    &s.PlDefNom,
+		{"plural", "bestämd", "Genitiv"}:      &s.PlDefGen,
+	}
+	return slots[[3]string{number, definiteness, nounCase}]
+}
+
+// nounSlots fills the slots from the forms of a noun, the first form of
+// each, and tells whether it has only plural forms (glasögon) or only
+// singular ones (mjölk). It returns nil slots when no form fills one.
+func nounSlots(forms []NounForm) (slots *NounSlots, pluralOnly, uncountable bool) {
+	var s NounSlots
+	singular, plural := false, false
+	for _, f := range forms {
+		p := s.slot(f.Number, f.Definiteness, f.Case)
+		if p == nil {
+			continue
+		}
+		singular = singular || f.Number == "singular"
+		plural = plural || f.Number == "plural"
+		if *p == "" {
+			*p = f.Form
+		}
+	}
+	if !singular && !plural {
+		return nil, false, false
+	}
+	return &s, !singular, !plural
+}
+
+// missing returns the slots a noun with some forms lacks, leaving out the
+// number a plural-only or uncountable noun has no forms in.
+func (s NounSlots) missing(pluralOnly, uncountable bool) []string {
+	names := []string{"sg_indef_nom", "sg_indef_gen", "sg_def_nom", "sg_def_gen", "pl_indef_nom", "pl_indef_gen", "pl_def_nom", "pl_def_gen"}
+	values := []string{s.SgIndefNom, s.SgIndefGen, s.SgDefNom, s.SgDefGen, s.PlIndefNom, s.PlIndefGen, s.PlDefNom, s.PlDefGen}
+	switch {
+	case pluralOnly:
+		names, values = names[4:], values[4:]
+	case uncountable:
+		names, values = names[:4], values[:4]
+	}
+	return missingSlots(names, values...)
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestNounSlots(t *testing.T) {
+	bil := NounSlots{"bil", "bils", "bilen", "bilens", "bilar", "bilars", "bilarna", "bilarnas"}
+	tests := []struct {
+		name                    string
+		raw                     []string
+		want                    *NounSlots
+		pluralOnly, uncountable bool
+	}{
+		{"bil", []string{
+			"bil-en-Nominativ", "bilen-den-Nominativ", "bilar-flera-Nominativ", "bilarna-de-Nominativ",
+			"bils-en-Genitiv", "bilens-den-Genitiv", "bilars-flera-Genitiv", "bilarnas-de-Genitiv",
+		}, &bil, false, false},
+		{"glasögon", []string{"glasögon-flera-Nominativ", "glasögonen-de-Nominativ"},
+			&NounSlots{PlIndefNom: "glasögon", PlDefNom: "glasögonen"}, true, false},
+		{"mjölk", []string{"mjölk-en-Nominativ", "mjölken-den-Nominativ", "mjölks-en-Genitiv"},
+			&NounSlots{SgIndefNom: "mjölk", SgIndefGen: "mjölks", SgDefNom: "mjölken"}, false, true},
+		{"unlabelled", []string{"x-Nominativ"}, nil, false, false},
+	}
+	for _, tt := range tests {
+		e := newNounEntry(tt.raw)
+		if (e.Slots == nil) != (tt.want == nil) || e.Slots != nil && *e.Slots != *tt.want {
+			t.Errorf("%s: slots %+v, want %+v", tt.name, e.Slots, tt.want)
+		}
+		if e.PluralOnly != tt.pluralOnly || e.Uncountable != tt.uncountable {
+			t.Errorf("%s: pluralOnly %v, uncountable %v, want %v, %v", tt.name, e.PluralOnly, e.Uncountable, tt.pluralOnly, tt.uncountable)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// inflectionParadigm is a group of lemmas whose tables follow the same
+// suffix pattern: cut each lemma into the longest stem its headword and
+// forms share, and what remains of them is the same for every member.
+// The stem of a member and the suffixes give back its forms, and a word
+// outside the lexicon ending like a member's headword can be inflected by
+// analogy.
+type inflectionParadigm struct {
+	ID      string           `json:"id"`
+	Class   string           `json:"class"`
+	Lemma   string           `json:"lemma"` // suffix of the headword
+	Slots   []paradigmSlot   `json:"slots"`
+	Members []paradigmMember `json:"members"`
+}
+
+// paradigmSlot is one form slot of a paradigm as the suffix after the stem.
+type paradigmSlot struct {
+	Section  string   `json:"section"`
+	Label    string   `json:"label,omitempty"`
+	Suffix   string   `json:"suffix"`
+	Variants []string `json:"variants,omitempty"`
+}
+
+// paradigmMember is a lemma of a paradigm and its stem.
+type paradigmMember struct {
+	ID   string `json:"id"`
+	Stem string `json:"stem"`
+}
+
+// paradigmStem returns the longest prefix the headword and every form of
+// e, variants included and adjective forms reduced to the word, share.
+func paradigmStem(e LexiconEntry) string {
+	stem := e.Headword
+	for _, section := range sortedKeys(e.Forms) {
+		for _, f := range e.Forms[section] {
+			for _, form := range append([]string{f.Form}, f.Variants...) {
+				stem = commonPrefix(stem, wordForm(form))
+			}
+		}
+	}
+	return stem
+}
+
+// paradigmSlots returns the forms of e as suffixes after stem, sections in
+// order and slots in table order.
+func paradigmSlots(e LexiconEntry, stem string) []paradigmSlot {
+	var slots []paradigmSlot
+	for _, section := range sortedKeys(e.Forms) {
+		for _, f := range e.Forms[section] {
+			slot := paradigmSlot{Section: section, Label: f.Label, Suffix: wordForm(f.Form)[len(stem):]}
+			for _, v := range f.Variants {
+				slot.Variants = append(slot.Variants, wordForm(v)[len(stem):])
+			}
+			slots = append(slots, slot)
+		}
+	}
+	return slots
+}
+
+// paradigmKey identifies a suffix pattern within a word class.
+func paradigmKey(class, lemma string, slots []paradigmSlot) string {
+	var key strings.Builder
+	key.WriteString(class + "\x00" + lemma + "\x00")
+	for _, s := range slots {
+		key.WriteString(s.Section + "\x00" + s.Label + "\x00" + s.Suffix + "\x00" + strings.Join(s.Variants, "\x01") + "\x00")
+	}
+	return key.String()
+}
+
+// clusterParadigms groups the inflected lemmas of entries by suffix
+// pattern, in the order the patterns first appear. A paradigm is named,
+// like a lexc continuation class, after its class and first member:
+// "substantiv_bil". Lemmas without forms or with a multiword headword are
+// left out.
+func clusterParadigms(entries []LexiconEntry) []*inflectionParadigm {
+	byKey := make(map[string]*inflectionParadigm)
+	var paradigms []*inflectionParadigm
+	for _, e := range entries {
+		if len(e.Forms) == 0 || e.Headword == "" || strings.Contains(e.Headword, " ") {
+			continue
+		}
+		stem := paradigmStem(e)
+		lemma := e.Headword[len(stem):]
+		slots := paradigmSlots(e, stem)
+		key := paradigmKey(e.Class, lemma, slots)
+		p, ok := byKey[key]
+		if !ok {
+			p = &inflectionParadigm{ID: e.Class + "_" + e.ID, Class: e.Class, Lemma: lemma, Slots: slots}
+			byKey[key] = p
+			paradigms = append(paradigms, p)
+		}
+		p.Members = append(p.Members, paradigmMember{ID: e.ID, Stem: stem})
+	}
+	return paradigms
+}
+
+// writeParadigms writes the paradigms of entries to filename as versioned
+// JSON.
+func writeParadigms(entries []LexiconEntry, filename string) error {
+	return saveFile(filename, func(w io.Writer) error {
+		paradigms := clusterParadigms(entries)
+		if paradigms == nil {
+			paradigms = []*inflectionParadigm{}
+		}
+		return writeVersionedJSON(w, paradigms)
+	})
+}
+
+// inflect gives the forms of p for a stem, grouped by section as in a
+// lexicon entry.
+func (p *inflectionParadigm) inflect(stem string) map[string][]Form {
+	forms := make(map[string][]Form)
+	for _, s := range p.Slots {
+		f := Form{Form: stem + s.Suffix, Label: s.Label}
+		for _, v := range s.Variants {
+			f.Variants = append(f.Variants, stem+v)
+		}
+		forms[s.Section] = append(forms[s.Section], f)
+	}
+	return forms
+}
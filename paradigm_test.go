@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClusterParadigms(t *testing.T) {
+	noun := func(id, sg, def, pl string) LexiconEntry {
+		return LexiconEntry{ID: id, Headword: sg, Class: "substantiv", Forms: map[string][]Form{
+			"Nominativ": {{Form: sg, Label: "en"}, {Form: def, Label: "den"}, {Form: pl, Label: "flera"}},
+		}}
+	}
+	entries := []LexiconEntry{
+		noun("bil", "bil", "bilen", "bilar"),
+		noun("man", "man", "mannen", "män"),
+		noun("stol", "stol", "stolen", "stolar"),
+		noun("gubbe", "gubbe", "gubben", "gubbar"),
+		noun("pojke", "pojke", "pojken", "pojkar"),
+		{ID: "på", Headword: "på", Class: "preposition"},
+		{ID: "ge_upp", Headword: "ge upp", Class: "verb", Forms: map[string][]Form{"Finita former": {{Form: "ger upp"}}}},
+	}
+	paradigms := clusterParadigms(entries)
+
+	var ids []string
+	for _, p := range paradigms {
+		ids = append(ids, p.ID)
+	}
+	if len(paradigms) != 3 {
+		t.Fatalf("paradigms %q, want substantiv_bil, substantiv_man and substantiv_gubbe", ids)
+	}
+	bil := paradigms[0]
+	if bil.ID != "substantiv_bil" || bil.Lemma != "" || len(bil.Members) != 2 || bil.Members[1] != (paradigmMember{"stol", "stol"}) {
+		t.Errorf("bil paradigm %+v", bil)
+	}
+	if got := bil.Slots[2]; !reflect.DeepEqual(got, paradigmSlot{Section: "Nominativ", Label: "flera", Suffix: "ar"}) {
+		t.Errorf("bil plural slot %+v", got)
+	}
+	if man := paradigms[1]; man.Lemma != "an" || man.Members[0].Stem != "m" || man.Slots[2].Suffix != "än" {
+		t.Errorf("man paradigm %+v", man)
+	}
+	if gubbe := paradigms[2]; gubbe.Lemma != "e" || len(gubbe.Members) != 2 || gubbe.Members[1].Stem != "pojk" {
+		t.Errorf("gubbe paradigm %+v", gubbe)
+	}
+}
+
+func TestWriteParadigms(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "paradigms.json")
+	for _, entries := range [][]LexiconEntry{fixtureEntries(t), nil} {
+		if err := writeParadigms(entries, filename); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkValid(t, filename, data)
+	}
+}
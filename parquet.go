@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/parquet-go/parquet-go"
+)
+
+// formRow is one row of the Parquet export: a single surface form with its
+// lemma and grammatical features. Features the form does not have are null.
+type formRow struct {
+	LemmaID      string `parquet:"lemma_id"`
+	FamilyID     int64  `parquet:"family_id"`
+	Headword     string `parquet:"headword"`
+	Class        string `parquet:"class,dict"`
+	Section      string `parquet:"section,dict"`
+	Label        string `parquet:"label,optional,dict"`
+	Form         string `parquet:"form"`
+	Variant      bool   `parquet:"variant"`
+	Case         string `parquet:"case,optional,dict"`
+	Number       string `parquet:"number,optional,dict"`
+	Gender       string `parquet:"gender,optional,dict"`
+	Definiteness string `parquet:"definiteness,optional,dict"`
+	Tense        string `parquet:"tense,optional,dict"`
+	Voice        string `parquet:"voice,optional,dict"`
+	Mood         string `parquet:"mood,optional,dict"`
+	VerbForm     string `parquet:"verb_form,optional,dict"`
+	Degree       string `parquet:"degree,optional,dict"`
+}
+
+// formRows flattens entries into one row per form, variants included and
+// marked as such. Feature columns hold UD values (Pres, Cmp, Def, ...).
+func formRows(entries []LexiconEntry) []formRow {
+	var rows []formRow
+	for _, e := range entries {
+		for _, section := range e.sections() {
+			for _, slot := range e.Forms[section] {
+				feats := parseFeats(slotFeats(e, section, slot))
+				row := formRow{
+					LemmaID:      e.ID,
+					FamilyID:     int64(e.FamilyID),
+					Headword:     e.Headword,
+					Class:        e.Class,
+					Section:      section,
+					Label:        slot.Label,
+					Case:         feats["Case"],
+					Number:       feats["Number"],
+					Gender:       feats["Gender"],
+					Definiteness: feats["Definite"],
+					Tense:        feats["Tense"],
+					Voice:        feats["Voice"],
+					Mood:         feats["Mood"],
+					VerbForm:     feats["VerbForm"],
+					Degree:       feats["Degree"],
+				}
+				for i, form := range append([]string{slot.Form}, slot.Variants...) {
+					row.Form, row.Variant = wordForm(form), i > 0
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// writeParquet writes the forms of entries to filename as a Parquet file,
+// ready for DuckDB (SELECT * FROM 'forms.parquet') or pandas.read_parquet.
+func writeParquet(entries []LexiconEntry, filename string) error {
+	return parquet.WriteFile(filename, formRows(entries))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestWriteParquet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forms.parquet")
+	entries := fixtureEntries(t)
+	if err := writeParquet(entries, path); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := parquet.ReadFile[formRow](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(formRows(entries)); len(rows) != want {
+		t.Fatalf("read %d rows, want %d", len(rows), want)
+	}
+
+	var sam, finare *formRow
+	for i := range rows {
+		switch rows[i].Form {
+		case "sam":
+			sam = &rows[i]
+		case "finare":
+			finare = &rows[i]
+		}
+	}
+	if sam == nil || !sam.Variant || sam.Headword != "simma" || sam.Tense != "Past" || sam.Voice != "Act" {
+		t.Errorf("sam row = %+v", sam)
+	}
+	if finare == nil || finare.Degree != "Cmp" || finare.Tense != "" || finare.Section != "Komparativ" {
+		t.Errorf("finare row = %+v", finare)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ParserFunc parses the inflection table of one lemma, located with the
+// profile's selectors, into "form[-label]-Section" strings. A parser
+// should return what it has once ctx is done.
+type ParserFunc func(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string
+
+var (
+	parsersMu   sync.RWMutex
+	parsers     = make(map[string]ParserFunc)
+	parserOrder []string // registration order, for listing classes
+)
+
+func init() {
+	RegisterParser("substantiv", parseSubstantiv)
+	RegisterParser("verb", parseVerbForms)
+	RegisterParser("adjektiv", parseAdjektiv)
+	RegisterParser("pronomen", parsePronomen)
+	RegisterParser("räkneord", parseRakneord)
+	RegisterParser("adverb", parseAdverb)
+}
+
+// RegisterParser makes fn the table parser for the word class named as
+// SAOL's .ordklass gives it. Registering a class again replaces its parser,
+// which is how a custom table layout overrides a built-in one. Lemmas of a
+// class without a parser are skipped by extract and the lexicon.
+func RegisterParser(class string, fn ParserFunc) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	if _, ok := parsers[class]; !ok {
+		parserOrder = append(parserOrder, class)
+	}
+	parsers[class] = fn
+}
+
+// parserFor returns the registered parser of class.
+func parserFor(class string) (ParserFunc, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	fn, ok := parsers[class]
+	return fn, ok
+}
+
+// parsedClasses returns the classes with a registered parser, in
+// registration order.
+func parsedClasses() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	return append([]string(nil), parserOrder...)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// withParser registers fn for class for the rest of the test.
+func withParser(t *testing.T, class string, fn ParserFunc) {
+	t.Helper()
+	old, had := parserFor(class)
+	order := parsedClasses()
+	RegisterParser(class, fn)
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		defer parsersMu.Unlock()
+		if had {
+			parsers[class] = old
+		} else {
+			delete(parsers, class)
+		}
+		parserOrder = order
+	})
+}
+
+func TestRegisterParser(t *testing.T) {
+	withParser(t, "adverb", func(ctx context.Context, doc *goquery.Document, p SelectorProfile) []string {
+		headword, _ := p.headword(doc.Selection)
+		return []string{headword + "-Positiv"}
+	})
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		`<span class="grundform">fort</span> <span class="ordklass">adverb</span>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forms, ok := parseClassForms(context.Background(), "adverb", doc, saolProfile)
+	if !ok || !reflect.DeepEqual(forms, []string{"fort-Positiv"}) {
+		t.Errorf("parseClassForms(adverb) = %q, %v", forms, ok)
+	}
+	if classes := lexiconClasses(); classes[5] != "adverb" {
+		t.Errorf("lexiconClasses = %q, want adverb after the built-in classes", classes)
+	}
+	if out := outputFor("adverb"); out.file != "adverb.json" {
+		t.Errorf("adverbs go to %s, want adverb.json", out.file)
+	}
+
+	// Registering a built-in class again replaces its parser.
+	withParser(t, "substantiv", func(context.Context, *goquery.Document, SelectorProfile) []string { return nil })
+	if forms, ok := parseClassForms(context.Background(), "substantiv", loadFixture(t, "substantiv_bil"), saolProfile); !ok || forms != nil {
+		t.Errorf("replaced substantiv parser not used: %q", forms)
+	}
+	if _, ok := parseClassForms(context.Background(), "preposition", doc, saolProfile); !ok {
+		t.Error("uninflected class not ok")
+	}
+	if _, ok := parseClassForms(context.Background(), "artikel", doc, saolProfile); ok {
+		t.Error("class without a parser ok")
+	}
+}
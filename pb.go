@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The pb export is encoded by hand with protowire against
+// proto/lexicon.proto, which keeps protoc out of the build. Field numbers
+// here must match the schema.
+
+// appendString appends a string field, skipped when empty as in proto3.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendInt(b []byte, num protowire.Number, n int) []byte {
+	if n == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(n)))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// pbFeatureFields are the UD features of the Features message, by field
+// number starting at 1; Poss is the bool field after them.
+var pbFeatureFields = []string{"Case", "Number", "Gender", "Definite", "Tense", "Voice", "Mood", "VerbForm", "Degree", "NumType"}
+
+const pbPossField = 11
+
+// marshalFeatures encodes a UD feature bundle as a Features message.
+func marshalFeatures(feats string) []byte {
+	f := parseFeats(feats)
+	var b []byte
+	for i, name := range pbFeatureFields {
+		b = appendString(b, protowire.Number(i+1), f[name])
+	}
+	return appendBool(b, pbPossField, f["Poss"] == "Yes")
+}
+
+// unmarshalFeatures decodes a Features message into a UD feature map.
+func unmarshalFeatures(b []byte) (map[string]string, error) {
+	feats := make(map[string]string)
+	err := consumeFields(b, func(num protowire.Number, v []byte, n uint64) {
+		switch {
+		case num == pbPossField && n == 1:
+			feats["Poss"] = "Yes"
+		case num >= 1 && int(num) <= len(pbFeatureFields) && v != nil:
+			feats[pbFeatureFields[num-1]] = string(v)
+		}
+	})
+	return feats, err
+}
+
+// consumeFields calls fn for every field of a message with its value:
+// v for length-delimited fields, n for varints. Other wire types are
+// skipped.
+func consumeFields(b []byte, fn func(num protowire.Number, v []byte, n uint64)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, v, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fn(num, nil, v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+// marshalEntry encodes e as a LexicalEntry message, forms in table order.
+func marshalEntry(e LexiconEntry) []byte {
+	var b []byte
+	b = appendString(b, 1, e.ID)
+	b = appendInt(b, 2, e.FamilyID)
+	b = appendString(b, 3, e.Headword)
+	b = appendInt(b, 4, e.Homograph)
+	b = appendString(b, 5, e.Class)
+	b = appendString(b, 6, e.Paradigm)
+	b = appendString(b, 7, e.Definition)
+	b = appendString(b, 8, e.Gender)
+	b = appendString(b, 9, e.Particle)
+	b = appendBool(b, 10, e.Reflexive)
+	for _, section := range e.sections() {
+		for _, slot := range e.Forms[section] {
+			b = appendMessage(b, 11, marshalForm(e, section, slot))
+		}
+	}
+	return b
+}
+
+// marshalForm encodes one form slot of e as a Form message.
+func marshalForm(e LexiconEntry, section string, slot Form) []byte {
+	var f []byte
+	f = appendString(f, 1, section)
+	f = appendString(f, 2, slot.Form)
+	f = appendString(f, 3, slot.Label)
+	for _, v := range slot.Variants {
+		f = protowire.AppendTag(f, 4, protowire.BytesType)
+		f = protowire.AppendString(f, v)
+	}
+	if feats := marshalFeatures(slotFeats(e, section, slot)); len(feats) > 0 {
+		f = appendMessage(f, 5, feats)
+	}
+	return f
+}
+
+// writeProtobuf writes entries to filename as a stream of length-delimited
+// LexicalEntry messages.
+func writeProtobuf(entries []LexiconEntry, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, e := range entries {
+		msg := marshalEntry(e)
+		if _, err = w.Write(protowire.AppendVarint(nil, uint64(len(msg)))); err != nil {
+			break
+		}
+		if _, err = w.Write(msg); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
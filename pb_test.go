@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// pbFields decodes the top-level fields of one message into their raw
+// values: strings and nested messages as []byte, varints as uint64.
+func pbFields(t *testing.T, msg []byte) map[protowire.Number][]interface{} {
+	t.Helper()
+	fields := make(map[protowire.Number][]interface{})
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			t.Fatal(protowire.ParseError(n))
+		}
+		msg = msg[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(msg)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fields[num] = append(fields[num], v)
+			msg = msg[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(msg)
+			if n < 0 {
+				t.Fatal(protowire.ParseError(n))
+			}
+			fields[num] = append(fields[num], v)
+			msg = msg[n:]
+		default:
+			t.Fatalf("unexpected wire type %v", typ)
+		}
+	}
+	return fields
+}
+
+func TestWriteProtobuf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lexicon.pb")
+	entries := fixtureEntries(t)
+	if err := writeProtobuf(entries, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs [][]byte
+	for len(data) > 0 {
+		size, n := protowire.ConsumeVarint(data)
+		if n < 0 || uint64(len(data)-n) < size {
+			t.Fatal("truncated stream")
+		}
+		msgs = append(msgs, data[n:n+int(size)])
+		data = data[n+int(size):]
+	}
+	if len(msgs) != len(entries) {
+		t.Fatalf("got %d messages, want %d", len(msgs), len(entries))
+	}
+
+	for i, e := range entries {
+		if e.ID != "simma" {
+			continue
+		}
+		fields := pbFields(t, msgs[i])
+		if got := string(fields[3][0].([]byte)); got != "simma" {
+			t.Errorf("headword = %q", got)
+		}
+		for _, raw := range fields[11] {
+			form := pbFields(t, raw.([]byte))
+			if string(form[2][0].([]byte)) != "simmade" {
+				continue
+			}
+			if len(form[4]) != 1 || string(form[4][0].([]byte)) != "sam" {
+				t.Errorf("simmade variants = %q", form[4])
+			}
+			feats := pbFields(t, form[5][0].([]byte))
+			if string(feats[5][0].([]byte)) != "Past" {
+				t.Errorf("simmade tense = %q", feats[5])
+			}
+			return
+		}
+		t.Fatal("no simmade form")
+	}
+	t.Fatal("no simma entry")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync/atomic"
+)
+
+// perfFlags are the profiling and monitoring flags shared by the pipeline
+// stages. The profiles are for `go tool pprof`, the trace for `go tool
+// trace` and the metrics for Prometheus.
+type perfFlags struct {
+	cpuProfile  *string
+	memProfile  *string
+	trace       *string
+	metricsAddr *string
+}
+
+func addPerfFlags(flags *flag.FlagSet) *perfFlags {
+	return &perfFlags{
+		cpuProfile:  flags.String("cpuprofile", "", "write a CPU profile to this file"),
+		memProfile:  flags.String("memprofile", "", "write a heap profile to this file when the stage is done"),
+		trace:       flags.String("trace", "", "write an execution trace to this file"),
+		metricsAddr: flags.String("metrics-addr", "", "serve Prometheus metrics on this address, e.g. :9100"),
+	}
+}
+
+// start starts the CPU profile, the trace and the metrics server that were
+// asked for. The returned function stops them and writes the heap profile;
+// call it once the stage is done. A stage ending in fatal calls it too, so
+// its profiles are complete up to the failure.
+func (p *perfFlags) start() (stop func()) {
+	if *p.metricsAddr != "" {
+		serveMetrics(*p.metricsAddr)
+	}
+	var stops []func()
+	if *p.cpuProfile != "" {
+		f := createProfile(*p.cpuProfile)
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fatal("could not start CPU profile", "err", err)
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		})
+	}
+	if *p.trace != "" {
+		f := createProfile(*p.trace)
+		if err := trace.Start(f); err != nil {
+			fatal("could not start trace", "err", err)
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+
+	var stopped atomic.Bool
+	stop = func() {
+		if !stopped.CompareAndSwap(false, true) {
+			return
+		}
+		for _, stop := range stops {
+			stop()
+		}
+		if *p.memProfile != "" {
+			f := createProfile(*p.memProfile)
+			defer f.Close()
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				slog.Warn("could not write heap profile", "file", *p.memProfile, "err", err)
+			}
+		}
+	}
+	onFatal(stop)
+	return stop
+}
+
+func createProfile(filename string) *os.File {
+	f, err := os.Create(filename)
+	if err != nil {
+		fatal("could not create profile", "file", filename, "err", err)
+	}
+	return f
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// pgBulkTables are created before the load. Constraints and indexes come
+// after it, in pgBulkConstraints, so COPY does not have to maintain them
+// row by row. The tables are those the postgres store reads, plus the
+// families lemmas belong to.
+var pgBulkTables = []string{
+	`CREATE TABLE families (
+		id INTEGER PRIMARY KEY
+	)`,
+	`CREATE TABLE lemmas (
+		id        TEXT PRIMARY KEY,
+		family_id INTEGER NOT NULL,
+		headword  TEXT NOT NULL COLLATE "C",
+		class     TEXT NOT NULL,
+		entry     TEXT NOT NULL
+	)`,
+	`CREATE TABLE lemma_forms (
+		lemma_id TEXT NOT NULL,
+		form     TEXT NOT NULL
+	)`,
+}
+
+var pgBulkConstraints = []string{
+	`ALTER TABLE lemmas ADD CONSTRAINT lemmas_family_id_fkey FOREIGN KEY (family_id) REFERENCES families(id)`,
+	`ALTER TABLE lemma_forms ADD CONSTRAINT lemma_forms_lemma_id_fkey FOREIGN KEY (lemma_id) REFERENCES lemmas(id)`,
+	`CREATE INDEX lemmas_headword ON lemmas(headword)`,
+	`CREATE INDEX lemmas_class ON lemmas(class)`,
+	`CREATE INDEX lemma_forms_form ON lemma_forms(form)`,
+}
+
+// bulkLoadPostgres creates the lexicon schema in the empty database at dsn
+// and streams entries into it with COPY, all in one transaction.
+func bulkLoadPostgres(entries []LexiconEntry, dsn string) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return fmt.Errorf("error opening postgres database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range pgBulkTables {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("error creating schema (the database must not hold a lexicon yet): %w", err)
+		}
+	}
+
+	families := make(map[int]bool)
+	var familyRows [][]interface{}
+	lemmaRows := make([][]interface{}, 0, len(entries))
+	var formRows [][]interface{}
+	for _, e := range entries {
+		if !families[e.FamilyID] {
+			families[e.FamilyID] = true
+			familyRows = append(familyRows, []interface{}{e.FamilyID})
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		lemmaRows = append(lemmaRows, []interface{}{e.ID, e.FamilyID, e.Headword, e.Class, string(data)})
+		for _, f := range e.surfaceForms() {
+			formRows = append(formRows, []interface{}{e.ID, f})
+		}
+	}
+
+	if err := copyRows(tx, "families", []string{"id"}, familyRows); err != nil {
+		return err
+	}
+	if err := copyRows(tx, "lemmas", []string{"id", "family_id", "headword", "class", "entry"}, lemmaRows); err != nil {
+		return err
+	}
+	if err := copyRows(tx, "lemma_forms", []string{"lemma_id", "form"}, formRows); err != nil {
+		return err
+	}
+
+	for _, stmt := range pgBulkConstraints {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("error adding constraints: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// copyRows streams rows into table with COPY FROM STDIN.
+func copyRows(tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return fmt.Errorf("error copying into %s: %w", table, err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("error copying into %s: %w", table, err)
+	}
+	return stmt.Close()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestBulkLoadPostgres needs an empty scratch database, e.g.
+// SAOL_TEST_POSTGRES=postgres://localhost/saol_test?sslmode=disable.
+func TestBulkLoadPostgres(t *testing.T) {
+	dsn := os.Getenv("SAOL_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("SAOL_TEST_POSTGRES not set")
+	}
+	entries := fixtureEntries(t)
+	if err := bulkLoadPostgres(entries, dsn); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := openStore("postgres", dsn, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.SearchForm("bilarna")
+	if err != nil || len(got) != 1 || got[0].ID != "bil" {
+		t.Errorf("SearchForm(bilarna) = %v, %v", got, err)
+	}
+	if err := bulkLoadPostgres(entries, dsn); err == nil {
+		t.Error("second load into the same database succeeded")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// paradigmPrediction is a paradigm a word outside the lexicon may follow,
+// with the forms it would have and how sure predict is of it.
+type paradigmPrediction struct {
+	Paradigm   string            `json:"paradigm"`
+	Confidence float64           `json:"confidence"`
+	Analogies  []string          `json:"analogies"` // members ending most like the word
+	Forms      map[string][]Form `json:"forms"`
+}
+
+// maxAnalogies is how many members a prediction names as its analogies.
+const maxAnalogies = 3
+
+// predictParadigms guesses the paradigm of word, an unseen lemma of class,
+// by analogy with the lemmas of the lexicon. Each member of a paradigm
+// whose lemma suffix the word ends in votes for it with the square of the
+// letters its headword and the word end in alike, if that is more than the
+// lemma suffix; the confidence of a paradigm is its share of the votes.
+// The n best predictions come first, all of them when n is 0.
+func predictParadigms(paradigms []*inflectionParadigm, word, class string, n int) []paradigmPrediction {
+	type candidate struct {
+		p         *inflectionParadigm
+		votes     float64
+		analogies []paradigmMember
+		shared    map[string]int
+	}
+	var candidates []*candidate
+	total := 0.0
+	for _, p := range paradigms {
+		if p.Class != class || !strings.HasSuffix(word, p.Lemma) || len(word) == len(p.Lemma) {
+			continue
+		}
+		c := &candidate{p: p, shared: make(map[string]int)}
+		for _, m := range p.Members {
+			shared := len([]rune(commonSuffix(word, m.Stem+p.Lemma)))
+			if shared <= len([]rune(p.Lemma)) {
+				continue
+			}
+			c.votes += float64(shared * shared)
+			c.analogies = append(c.analogies, m)
+			c.shared[m.ID] = shared
+		}
+		if c.votes > 0 {
+			total += c.votes
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].votes > candidates[j].votes })
+	if n > 0 && len(candidates) > n {
+		candidates = candidates[:n]
+	}
+
+	predictions := make([]paradigmPrediction, 0, len(candidates))
+	for _, c := range candidates {
+		sort.SliceStable(c.analogies, func(i, j int) bool { return c.shared[c.analogies[i].ID] > c.shared[c.analogies[j].ID] })
+		pred := paradigmPrediction{
+			Paradigm:   c.p.ID,
+			Confidence: math.Round(c.votes/total*1000) / 1000,
+			Analogies:  []string{},
+			Forms:      c.p.inflect(strings.TrimSuffix(word, c.p.Lemma)),
+		}
+		for i, m := range c.analogies {
+			if i == maxAnalogies {
+				break
+			}
+			pred.Analogies = append(pred.Analogies, m.ID)
+		}
+		predictions = append(predictions, pred)
+	}
+	return predictions
+}
+
+// commonSuffix returns the longest suffix a and b share, in whole runes.
+func commonSuffix(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	n := 0
+	for n < len(ra) && n < len(rb) && ra[len(ra)-1-n] == rb[len(rb)-1-n] {
+		n++
+	}
+	return string(ra[len(ra)-n:])
+}
+
+// runPredict prints the likely inflection tables of a word the lexicon
+// does not have.
+func runPredict(args []string) {
+	flags := flag.NewFlagSet("predict", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to learn the paradigms from")
+	n := flags.Int("n", 3, "predictions to list, most likely first (0 for all)")
+	format := flags.String("format", "text", "output format: text, or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool predict [-in file] [-n 3] [-format text|json] <word> <class>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+	word, class := flags.Arg(0), flags.Arg(1)
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	predictions := predictParadigms(clusterParadigms(entries), word, class, *n)
+	if len(predictions) == 0 {
+		fatal("no paradigm of the class ends like the word", "word", word, "class", class)
+	}
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, predictions)
+	} else {
+		err = writePredictionsText(os.Stdout, predictions)
+	}
+	if err != nil {
+		fatal("could not write predictions", "err", err)
+	}
+}
+
+// writePredictionsText writes each prediction as a heading with its
+// confidence and analogies, then its forms a section per line.
+func writePredictionsText(w io.Writer, predictions []paradigmPrediction) error {
+	for i, pred := range predictions {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %.3f (like %s)\n", pred.Paradigm, pred.Confidence, strings.Join(pred.Analogies, ", "))
+		for _, section := range sortedKeys(pred.Forms) {
+			var forms []string
+			for _, f := range pred.Forms[section] {
+				forms = append(forms, strings.Join(append([]string{f.Form}, f.Variants...), " el. "))
+			}
+			if _, err := fmt.Fprintf(w, "  %s: %s\n", section, strings.Join(forms, ", ")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
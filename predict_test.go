@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPredictParadigms(t *testing.T) {
+	noun := func(sg, def, pl string) LexiconEntry {
+		return LexiconEntry{ID: sg, Headword: sg, Class: "substantiv", Forms: map[string][]Form{
+			"Nominativ": {{Form: sg, Label: "en"}, {Form: def, Label: "den"}, {Form: pl, Label: "flera"}},
+		}}
+	}
+	paradigms := clusterParadigms([]LexiconEntry{
+		noun("bil", "bilen", "bilar"),
+		noun("stol", "stolen", "stolar"),
+		noun("pojke", "pojken", "pojkar"),
+		noun("gubbe", "gubben", "gubbar"),
+		noun("sko", "skon", "skor"),
+		noun("ko", "kon", "kor"),
+	})
+
+	preds := predictParadigms(paradigms, "kanke", "substantiv", 0)
+	if len(preds) == 0 || preds[0].Paradigm != "substantiv_pojke" {
+		t.Fatalf("kanke: %+v, want substantiv_pojke first", preds)
+	}
+	want := map[string][]Form{"Nominativ": {{Form: "kanke", Label: "en"}, {Form: "kanken", Label: "den"}, {Form: "kankar", Label: "flera"}}}
+	if !reflect.DeepEqual(preds[0].Forms, want) {
+		t.Errorf("kanke forms %+v, want %+v", preds[0].Forms, want)
+	}
+	if preds[0].Analogies[0] != "pojke" {
+		t.Errorf("kanke analogies %q, want pojke first", preds[0].Analogies)
+	}
+	sum := 0.0
+	for _, p := range preds {
+		sum += p.Confidence
+	}
+	if sum < 0.99 || sum > 1.01 {
+		t.Errorf("confidences add up to %v", sum)
+	}
+
+	if preds := predictParadigms(paradigms, "spol", "substantiv", 1); len(preds) != 1 || preds[0].Paradigm != "substantiv_bil" || preds[0].Confidence != 1 {
+		t.Errorf("spol: %+v, want substantiv_bil only", preds)
+	}
+	if preds := predictParadigms(paradigms, "spol", "verb", 0); len(preds) != 0 {
+		t.Errorf("spol as a verb: %+v, want none", preds)
+	}
+
+	var buf bytes.Buffer
+	writePredictionsText(&buf, predictParadigms(paradigms, "spol", "substantiv", 1))
+	if got := buf.String(); !strings.Contains(got, "substantiv_bil 1.000 (like stol") || !strings.Contains(got, "  Nominativ: spol, spolen, spolar\n") {
+		t.Errorf("text:\n%s", got)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// stressMarks are the primary and secondary stress marks a headword may
+// carry, as in "kaˈfé".
+const stressMarks = "ˈˌ"
+
+// stripStress removes the stress marks from a headword.
+func stripStress(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(stressMarks, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// pronunciation returns the pronunciation hint of a lemma: the text of the
+// profile's pronunciation element without the brackets around it
+// ("[ʃiˈno:]" gives "ʃiˈno:"), failing that the headword when it carries
+// stress marks ("kaˈfé"), or "" when it has neither.
+func (p SelectorProfile) pronunciation(lemma *goquery.Selection) string {
+	if p.Pronunciation != "" {
+		if hint := strings.Trim(cellText(lemma.Find(p.Pronunciation).First()), "[]/ "); hint != "" {
+			return hint
+		}
+	}
+	grundform := lemma.Find(p.Headword).First().Clone()
+	grundform.Find("sup").Remove()
+	text := nfc(strings.TrimSpace(stripHyphenation(grundform.Text())))
+	if !strings.ContainsAny(text, stressMarks) {
+		return ""
+	}
+	return text
+}
+
+// ipaEnricher gives every entry a rough IPA transcription of its headword
+// by rule, see transcribeIPA.
+type ipaEnricher struct{}
+
+func (ipaEnricher) Name() string { return "ipa" }
+
+func (ipaEnricher) Check(ctx context.Context) error { return nil }
+
+func (ipaEnricher) Missing(e *LexiconEntry) bool { return e.IPA == "" }
+
+func (ipaEnricher) Enrich(ctx context.Context, e *LexiconEntry) error {
+	e.IPA = transcribeIPA(e.Headword)
+	return nil
+}
+
+// ipaClusters are the consonant spellings transcribed as one sound, longest
+// first. Those marked front only apply before a front vowel (e, i, y, ä,
+// ö, é), as in "kär" and "gärna"; "skjorta" and "stjärna" need no vowel.
+// Those marked initial only apply at the start of a word: the k in "rike"
+// and the g in "berget" stay hard.
+var ipaClusters = []struct {
+	spelling, ipa  string
+	front, initial bool
+}{
+	{"skj", "ɧ", false, false}, {"stj", "ɧ", false, false}, {"sch", "ɧ", false, false},
+	{"sj", "ɧ", false, false}, {"sk", "ɧ", true, true}, {"ch", "ɧ", false, false},
+	{"tj", "ɕ", false, false}, {"kj", "ɕ", false, false}, {"k", "ɕ", true, true},
+	{"dj", "j", false, false}, {"gj", "j", false, false}, {"hj", "j", false, false}, {"lj", "j", false, false}, {"g", "j", true, true},
+	{"ng", "ŋ", false, false}, {"ck", "k", false, false}, {"ph", "f", false, false}, {"qu", "kv", false, false},
+	{"rt", "ʈ", false, false}, {"rd", "ɖ", false, false}, {"rn", "ɳ", false, false}, {"rs", "ʂ", false, false}, {"rl", "ɭ", false, false},
+	{"x", "ks", false, false}, {"z", "s", false, false}, {"w", "v", false, false}, {"c", "s", true, false}, {"c", "k", false, false},
+}
+
+// ipaVowels gives the long and the short sound of each vowel.
+var ipaVowels = map[rune][2]string{
+	'a': {"ɑː", "a"}, 'e': {"eː", "ɛ"}, 'i': {"iː", "ɪ"}, 'o': {"uː", "ɔ"}, 'u': {"ʉː", "ɵ"},
+	'y': {"yː", "ʏ"}, 'å': {"oː", "ɔ"}, 'ä': {"ɛː", "ɛ"}, 'ö': {"øː", "œ"}, 'é': {"eː", "ɛ"},
+}
+
+func isFrontVowel(r rune) bool { return strings.ContainsRune("eiyäöé", r) }
+
+// transcribeIPA gives a rough IPA transcription of a Swedish word by
+// spelling rules: soft initial k, g and sk before front vowels, the sj- and
+// tj-sounds, retroflex r-clusters, and a stressed vowel long before at most
+// one consonant and short before two. Stress is put on the first syllable
+// and the vowels after it are short.
+// It is a guess for words without a pronunciation in the dictionary, not
+// a substitute for one: loanwords and compounds are often wrong.
+func transcribeIPA(word string) string {
+	runes := []rune(strings.ToLower(word))
+	var b strings.Builder
+	b.WriteString("ˈ")
+	stressed := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if v, ok := ipaVowels[r]; ok {
+			consonants := 0
+			for j := i + 1; j < len(runes); j++ {
+				if _, vowel := ipaVowels[runes[j]]; vowel || !isLetter(runes[j]) {
+					break
+				}
+				consonants++
+			}
+			if stressed && consonants < 2 {
+				b.WriteString(v[0])
+			} else {
+				b.WriteString(v[1])
+			}
+			stressed = false
+			i++
+			continue
+		}
+		if !isLetter(r) {
+			if r == ' ' || r == '-' {
+				b.WriteRune(' ')
+			}
+			i++
+			continue
+		}
+		matched := false
+		for _, c := range ipaClusters {
+			n := len([]rune(c.spelling))
+			if i+n > len(runes) || string(runes[i:i+n]) != c.spelling {
+				continue
+			}
+			if c.front && (i+n >= len(runes) || !isFrontVowel(runes[i+n])) {
+				continue
+			}
+			if c.initial && i > 0 && isLetter(runes[i-1]) {
+				continue
+			}
+			b.WriteString(c.ipa)
+			i += n
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+		b.WriteRune(r)
+		i++
+		for i < len(runes) && runes[i] == r {
+			i++ // a double consonant is one sound
+		}
+	}
+	return b.String()
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || strings.ContainsRune("åäöéü", r)
+}
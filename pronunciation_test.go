@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPronunciation(t *testing.T) {
+	tests := []struct {
+		name, html, headword, want string
+	}{
+		{"hint", `<span class="grundform">chinos</span><span class="uttal">[ʃiˈno:s]</span><span class="ordklass">substantiv</span>`, "chinos", "ʃiˈno:s"},
+		{"stress", `<span class="grundform">kaˈfé</span><span class="ordklass">substantiv</span>`, "kafé", "kaˈfé"},
+		{"none", `<span class="grundform">bil</span><span class="ordklass">substantiv</span>`, "bil", ""},
+	}
+	for _, tt := range tests {
+		entry, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: tt.html})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if entry.Headword != tt.headword || entry.Pronunciation != tt.want {
+			t.Errorf("%s: headword %q, pronunciation %q, want %q, %q", tt.name, entry.Headword, entry.Pronunciation, tt.headword, tt.want)
+		}
+	}
+}
+
+func TestTranscribeIPA(t *testing.T) {
+	tests := []struct{ word, want string }{
+		{"bil", "ˈbiːl"},
+		{"katt", "ˈkat"},
+		{"kär", "ˈɕɛːr"},
+		{"gäst", "ˈjɛst"},
+		{"skjorta", "ˈɧɔʈa"},
+		{"sked", "ˈɧeːd"},
+		{"ringa", "ˈrɪŋa"},
+		{"tjock", "ˈɕɔk"},
+		{"barn", "ˈbaɳ"},
+		{"Växjö", "ˈvɛksjœ"},
+		{"rike", "ˈriːkɛ"},
+		{"berget", "ˈbɛrgɛt"},
+		{"kafé", "ˈkɑːfɛ"},
+		{"god kväll", "ˈguːd kvɛl"},
+	}
+	for _, tt := range tests {
+		if got := transcribeIPA(tt.word); got != tt.want {
+			t.Errorf("transcribeIPA(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestIPAEnricher(t *testing.T) {
+	entries := []LexiconEntry{{Headword: "bil"}, {Headword: "kär", IPA: "ˈɕæːr"}}
+	degraded := enrichLexicon(context.Background(), entries, []Enricher{ipaEnricher{}}, true, 0)
+	if len(degraded) != 0 || entries[0].IPA != "ˈbiːl" || entries[1].IPA != "ˈɕæːr" {
+		t.Errorf("IPA %q, %q, degraded %v", entries[0].IPA, entries[1].IPA, degraded)
+	}
+}
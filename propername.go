@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// properNounClass is the word class SAOL gives names of countries,
+// places and the like.
+const properNounClass = "egennamn"
+
+// ProperNounEntry is the record written for a proper noun: the name, its
+// genitive and the forms its table gives, for gazetteers. GenitiveDerived
+// is set when the table had no genitive and it was formed by rule.
+type ProperNounEntry struct {
+	Name            string            `json:"name"`
+	Class           string            `json:"class"`
+	Genitive        string            `json:"genitive"`
+	GenitiveDerived bool              `json:"genitiveDerived,omitempty"`
+	Forms           map[string][]Form `json:"forms"`
+}
+
+// parseProperNoun reads a proper noun, whose table, if it has one, is laid
+// out like a pronoun's: a form per row under Nominativ and Genitiv.
+func parseProperNoun(ctx context.Context, doc *goquery.Document, p SelectorProfile) ProperNounEntry {
+	name, _ := p.headword(doc.Selection)
+	entry := ProperNounEntry{Name: name, Class: p.class(doc.Selection), Forms: groupForms(properNounClass, parseFeatureRows(ctx, doc, p))}
+	if genitives := entry.Forms["Genitiv"]; len(genitives) > 0 {
+		entry.Genitive = genitives[0].Form
+	} else {
+		entry.Genitive, entry.GenitiveDerived = genitiveOf(name), true
+	}
+	return entry
+}
+
+// genitiveOf forms the genitive of a name: an -s, except after s, x and z,
+// which take none (Paris, Linux, Schweiz).
+func genitiveOf(name string) string {
+	if name == "" || strings.ContainsAny(name[len(name)-1:], "sxzSXZ") {
+		return name
+	}
+	return name + "s"
+}
+
+// WriteProperNounsJSON writes proper noun records to w.
+func WriteProperNounsJSON(w io.Writer, entries []ProperNounEntry) error {
+	return writeVersionedJSON(w, entries)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestParseProperNoun(t *testing.T) {
+	sverige := `<span class="grundform">Sverige</span><span class="ordklass">egennamn</span><table class="tabell">` +
+		`<tr><th class="ordformth"><i>Nominativ</i></th></tr><tr><td class="ordform">Sverige</td></tr>` +
+		`<tr><th class="ordformth"><i>Genitiv</i></th></tr><tr><td class="ordform">Sveriges</td></tr></table>`
+	res, err := extractLemma(context.Background(), LemmaInput{HTML: sverige}, saolProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := res.properNoun; p == nil || p.Name != "Sverige" || p.Genitive != "Sveriges" || p.GenitiveDerived || len(p.Forms["Nominativ"]) != 1 {
+		t.Errorf("Sverige = %+v", p)
+	}
+
+	res, err = extractLemma(context.Background(), LemmaInput{HTML: `<span class="grundform">Paris</span><span class="ordklass">egennamn</span>`}, saolProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := res.properNoun; p == nil || p.Genitive != "Paris" || !p.GenitiveDerived {
+		t.Errorf("Paris = %+v", p)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteProperNounsJSON(&buf, []ProperNounEntry{*res.properNoun}); err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, "proper_nouns.json", buf.Bytes())
+}
+
+func TestGenitiveOf(t *testing.T) {
+	for name, want := range map[string]string{"Norge": "Norges", "Paris": "Paris", "Schweiz": "Schweiz", "Linux": "Linux", "": ""} {
+		if got := genitiveOf(name); got != want {
+			t.Errorf("genitiveOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
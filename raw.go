@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Modes of -keep-raw: the source table as HTML, or as text with a line
+// per row and the cells separated by tabs.
+const (
+	rawHTML = "html"
+	rawText = "text"
+)
+
+// checkRawMode validates a -keep-raw value; empty keeps nothing.
+func checkRawMode(mode string) error {
+	switch mode {
+	case "", rawHTML, rawText:
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q, want %s or %s", mode, rawHTML, rawText)
+}
+
+// rawTable returns the inflection tables the forms of a lemma were parsed
+// from, the inline inflection elements for profiles without tables, as
+// mode says. A lemma with several tables has all of them, one after the
+// other, in text mode with a blank line between. It is "" when the lemma
+// has none.
+func rawTable(doc *goquery.Document, p SelectorProfile, mode string) (string, error) {
+	var tables *goquery.Selection
+	if p.Inflection != "" {
+		tables = doc.Find(p.Inflection)
+	} else if p.TableRow != "" {
+		tables = doc.Find(p.TableRow).Closest("table")
+	}
+	if tables == nil || tables.Length() == 0 {
+		return "", nil
+	}
+	var parts []string
+	for i := range tables.Nodes {
+		table := tables.Eq(i)
+		if mode == rawHTML {
+			html, err := goquery.OuterHtml(table)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, html)
+			continue
+		}
+		parts = append(parts, rawTableText(table))
+	}
+	if mode == rawHTML {
+		return strings.Join(parts, "\n"), nil
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// rawTableText is a table as text: a line per row, the cells separated by
+// tabs, or the text of an element without rows.
+func rawTableText(table *goquery.Selection) string {
+	rows := table.Find("tr")
+	if rows.Length() == 0 {
+		return cellText(table)
+	}
+	var lines []string
+	rows.Each(func(_ int, tr *goquery.Selection) {
+		var cells []string
+		tr.Find("th, td").Each(func(_ int, c *goquery.Selection) { cells = append(cells, cellText(c)) })
+		lines = append(lines, strings.Join(cells, "\t"))
+	})
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRawTables(t *testing.T) {
+	in := LemmaInput{HTML: readFixture(t, "substantiv_bil")}
+
+	entry, _, err := newLexiconEntry(context.Background(), "1", in)
+	if err != nil || entry.Raw != "" {
+		t.Errorf("without WithRawTables, Raw = %q (%v)", entry.Raw, err)
+	}
+
+	entry, _, err = newLexiconEntry(context.Background(), "1", in, WithRawTables(rawHTML))
+	if err != nil || !strings.HasPrefix(entry.Raw, "<table") || !strings.Contains(entry.Raw, "bilarnas") {
+		t.Errorf("html: Raw = %q (%v)", entry.Raw, err)
+	}
+
+	entry, _, err = newLexiconEntry(context.Background(), "1", in, WithRawTables(rawText))
+	if err != nil || !strings.Contains(entry.Raw, "\nbil\ten\n") || strings.Contains(entry.Raw, "<") {
+		t.Errorf("text: Raw = %q (%v)", entry.Raw, err)
+	}
+
+	// A lemma with two tables keeps both.
+	two := LemmaInput{HTML: strings.Replace(in.HTML, "</table>", `</table><table class="tabell"><tr><td>bilar2</td></tr></table>`, 1)}
+	entry, _, err = newLexiconEntry(context.Background(), "1", two, WithRawTables(rawText))
+	if err != nil || !strings.Contains(entry.Raw, "bilarnas") || !strings.HasSuffix(entry.Raw, "\n\nbilar2") {
+		t.Errorf("two tables: Raw = %q (%v)", entry.Raw, err)
+	}
+
+	so, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: readFixture(t, "so_bil"), Source: "so"}, WithRawTables(rawText))
+	if err != nil || !strings.Contains(so.Raw, "bilen") {
+		t.Errorf("so: Raw = %q (%v)", so.Raw, err)
+	}
+
+	if err := checkRawMode("xml"); err == nil {
+		t.Error("checkRawMode(xml) = nil, want an error")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// The reference types, from the marker in front of a reference: "se"
+// sends the reader to another lemma, "jfr" asks to compare with one, and
+// "äv." or "variant av" makes the lemma a variant of the target.
+const (
+	referenceSeeAlso   = "seeAlso"
+	referenceCompare   = "compare"
+	referenceVariantOf = "variantOf"
+)
+
+// referenceMarkers maps the markers SAOL and SO put before a reference to
+// its type, longest first so "se även" wins over "även".
+var referenceMarkers = []struct{ marker, typ string }{
+	{"variant av", referenceVariantOf},
+	{"se även", referenceSeeAlso},
+	{"se äv.", referenceSeeAlso},
+	{"jämför", referenceCompare},
+	{"jfr.", referenceCompare},
+	{"jfr", referenceCompare},
+	{"även", referenceVariantOf},
+	{"äv.", referenceVariantOf},
+	{"el.", referenceVariantOf},
+	{"se", referenceSeeAlso},
+}
+
+// Reference is a cross-reference from a lemma to another, by the other's
+// lemma ID ("val_2"); Target is the headword as the reference gives it.
+type Reference struct {
+	Type      string `json:"type"`
+	Target    string `json:"target"`
+	Homograph int    `json:"homograph,omitempty"`
+}
+
+// references returns the cross-references in a lemma, found with the
+// profile's reference selector. The type comes from the marker right in
+// front of each, in text or an element of its own; a reference without one
+// is seeAlso.
+func (p SelectorProfile) references(lemma *goquery.Selection) []Reference {
+	if p.Reference == "" {
+		return nil
+	}
+	var refs []Reference
+	lemma.Find(p.Reference).Each(func(_ int, s *goquery.Selection) {
+		target := s.Clone()
+		var number string
+		if sup := target.Find("sup"); sup.Length() > 0 {
+			number = sup.First().Text()
+			sup.Remove()
+		}
+		headword := cellText(target)
+		if headword == "" {
+			return
+		}
+		homograph, _ := strconv.Atoi(strings.TrimSpace(number))
+		refs = append(refs, Reference{Type: referenceType(s), Target: headword, Homograph: homograph})
+	})
+	return refs
+}
+
+// referenceType reads the marker in the text or element just before s.
+func referenceType(s *goquery.Selection) string {
+	prev := s.Get(0).PrevSibling
+	for prev != nil && prev.Type == html.TextNode && strings.TrimSpace(prev.Data) == "" {
+		prev = prev.PrevSibling
+	}
+	if prev == nil {
+		return referenceSeeAlso
+	}
+	text := prev.Data
+	if prev.Type == html.ElementNode {
+		text = goquery.NewDocumentFromNode(prev).Text()
+	}
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, m := range referenceMarkers {
+		if text == m.marker || strings.HasSuffix(text, " "+m.marker) {
+			return m.typ
+		}
+	}
+	return referenceSeeAlso
+}
+
+// referenceEdge is a cross-reference in the reference graph. Missing is
+// set when the target is not in the lexicon, say a lemma of a word class
+// without a parser.
+type referenceEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Type    string `json:"type"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// referenceGraph resolves the references of entries to lemma IDs. A
+// reference without a homograph number to a headword with homographs
+// links to every one of them.
+func referenceGraph(entries []LexiconEntry) []referenceEdge {
+	ids := make(map[string]bool, len(entries))
+	byHeadword := make(map[string][]string)
+	for _, e := range entries {
+		ids[e.ID] = true
+		byHeadword[e.Headword] = append(byHeadword[e.Headword], e.ID)
+	}
+	edges := []referenceEdge{}
+	for _, e := range entries {
+		for _, r := range e.References {
+			targets := []string{lemmaID(r.Target, r.Homograph)}
+			if r.Homograph == 0 && len(byHeadword[r.Target]) > 0 {
+				targets = byHeadword[r.Target]
+			}
+			for _, to := range targets {
+				edges = append(edges, referenceEdge{From: e.ID, To: to, Type: r.Type, Missing: !ids[to]})
+			}
+		}
+	}
+	return edges
+}
+
+// writeReferences writes the reference graph of entries to filename as
+// versioned JSON.
+func writeReferences(entries []LexiconEntry, filename string) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, referenceGraph(entries)) })
+}
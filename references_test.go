@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestReferences(t *testing.T) {
+	html := `<span class="def">fordon, se <a class="hanvisning">automobil</a>; jfr <a class="hanvisning">vagn<sup>2</sup></a></span>` +
+		`<span class="hvtyp">även</span> <a class="hanvisning">bill</a> <a class="hanvisning">kärra</a> <a class="hanvisning"> </a>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Reference{
+		{Type: referenceSeeAlso, Target: "automobil"},
+		{Type: referenceCompare, Target: "vagn", Homograph: 2},
+		{Type: referenceVariantOf, Target: "bill"},
+		{Type: referenceSeeAlso, Target: "kärra"},
+	}
+	if got := selectorProfiles["saol"].references(doc.Selection); !reflect.DeepEqual(got, want) {
+		t.Errorf("references = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteReferences(t *testing.T) {
+	html := `<span class="grundform">bila</span><span class="ordklass">verb</span>jfr <a class="hanvisning">val</a> se <a class="hanvisning">bil</a>`
+	bila, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: html})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []LexiconEntry{
+		bila,
+		{ID: "val_1", Headword: "val", Homograph: 1},
+		{ID: "val_2", Headword: "val", Homograph: 2},
+	}
+	filename := filepath.Join(t.TempDir(), "references.json")
+	if err := writeReferences(entries, filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, filename, data)
+
+	var got []referenceEdge
+	decodeEntries(t, data, &got)
+	want := []referenceEdge{
+		{From: "bila", To: "val_1", Type: referenceCompare},
+		{From: "bila", To: "val_2", Type: referenceCompare},
+		{From: "bila", To: "bil", Type: referenceSeeAlso, Missing: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges %+v, want %+v", got, want)
+	}
+}
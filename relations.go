@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// lemmaFamily is one article of the dictionary as a graph: the lemma the
+// article is about, whose headword is the article's, and how every other
+// lemma of the article relates to it.
+type lemmaFamily struct {
+	FamilyID  int              `json:"familyID"`
+	Article   string           `json:"article"`
+	Head      string           `json:"head"`
+	Relations []familyRelation `json:"relations"`
+}
+
+// familyRelation links the head of a family to another of its lemmas.
+type familyRelation struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// The relation types, from the headwords alone: a variant is the same word
+// in another class, homograph or hyphenation (fort adverb and fort
+// adjektiv), a compound has the head as one of its parts (personbil,
+// bilverkstad), a derivation adds an affix to it (finhet, ofin) and
+// related is anything else SAOL put in the article.
+const (
+	relationVariant    = "variant"
+	relationCompound   = "compound"
+	relationDerivation = "derivation"
+	relationRelated    = "related"
+)
+
+// derivationalAffixes are the common Swedish suffixes and prefixes a
+// derivation adds; any other remainder of three letters or more makes the
+// lemma a compound.
+var derivationalAffixes = map[string]bool{
+	"a": true, "e": true, "are": true, "ande": true, "ende": true, "else": true,
+	"het": true, "ig": true, "lig": true, "isk": true, "ning": true, "ing": true,
+	"skap": true, "dom": true, "inna": true, "ska": true, "sam": true, "bar": true,
+	"o": true, "be": true, "för": true, "miss": true, "van": true, "an": true, "ut": true,
+}
+
+// lemmaFamilies groups entries by family in the order the families first
+// appear. The head of a family is its first lemma, as flatten splits an
+// article in page order.
+func lemmaFamilies(entries []LexiconEntry) []lemmaFamily {
+	families := []lemmaFamily{}
+	index := make(map[int]int)
+	for _, e := range entries {
+		i, ok := index[e.FamilyID]
+		if !ok {
+			index[e.FamilyID] = len(families)
+			families = append(families, lemmaFamily{FamilyID: e.FamilyID, Article: e.Headword, Head: e.ID, Relations: []familyRelation{}})
+			continue
+		}
+		families[i].Relations = append(families[i].Relations, familyRelation{
+			From: families[i].Head,
+			To:   e.ID,
+			Type: relationType(families[i].Article, e.Headword),
+		})
+	}
+	return families
+}
+
+// relationType tells how headword relates to the head of its family.
+func relationType(head, headword string) string {
+	h, w := normalizeHeadword(head), normalizeHeadword(headword)
+	if h == w {
+		return relationVariant
+	}
+	var rest string
+	switch {
+	case strings.HasPrefix(w, h):
+		rest = strings.TrimPrefix(w, h)
+	case strings.HasSuffix(w, h):
+		rest = strings.TrimSuffix(w, h)
+	default:
+		return relationRelated
+	}
+	if derivationalAffixes[rest] || len([]rune(rest)) < 3 {
+		return relationDerivation
+	}
+	return relationCompound
+}
+
+// normalizeHeadword lowercases a headword and drops the hyphens and spaces
+// SAOL writes some of them with.
+func normalizeHeadword(headword string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(headword))
+}
+
+// writeRelations writes the family graph of entries to filename as
+// versioned JSON.
+func writeRelations(entries []LexiconEntry, filename string) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, lemmaFamilies(entries)) })
+}
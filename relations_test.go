@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRelationType(t *testing.T) {
+	tests := []struct{ head, headword, want string }{
+		{"fort", "fort", relationVariant},
+		{"e-post", "epost", relationVariant},
+		{"bil", "bilverkstad", relationCompound},
+		{"bil", "personbil", relationCompound},
+		{"fin", "finhet", relationDerivation},
+		{"fin", "ofin", relationDerivation},
+		{"bil", "bila", relationDerivation},
+		{"gå", "gång", relationDerivation},
+		{"bil", "automobil", relationCompound},
+		{"bil", "vagn", relationRelated},
+	}
+	for _, tt := range tests {
+		if got := relationType(tt.head, tt.headword); got != tt.want {
+			t.Errorf("relationType(%q, %q) = %q, want %q", tt.head, tt.headword, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRelations(t *testing.T) {
+	entries := []LexiconEntry{
+		{ID: "bil", FamilyID: 1, Headword: "bil"},
+		{ID: "bila", FamilyID: 1, Headword: "bila"},
+		{ID: "fin", FamilyID: 2, Headword: "fin"},
+		{ID: "bilverkstad", FamilyID: 1, Headword: "bilverkstad"},
+	}
+	filename := filepath.Join(t.TempDir(), "relations.json")
+	if err := writeRelations(entries, filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, filename, data)
+
+	var got []lemmaFamily
+	decodeEntries(t, data, &got)
+	want := []lemmaFamily{
+		{FamilyID: 1, Article: "bil", Head: "bil", Relations: []familyRelation{
+			{"bil", "bila", relationDerivation},
+			{"bil", "bilverkstad", relationCompound},
+		}},
+		{FamilyID: 2, Article: "fin", Head: "fin", Relations: []familyRelation{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("families %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// sections returns the non-empty sections of e in the table order of
+// sectionConfig, under their names or, as englishEntry leaves them, in
+// English; sections a class does not normally have come last,
+// alphabetically.
+func (e LexiconEntry) sections() []string {
+	var out []string
+	known := make(map[string]bool)
+	for _, l := range sectionConfig.Sections[e.Class] {
+		for _, s := range []string{l.Name, l.English} {
+			if s == "" || known[s] {
+				continue
+			}
+			known[s] = true
+			if len(e.Forms[s]) > 0 {
+				out = append(out, s)
+			}
+		}
+	}
+	var rest []string
+	for s, forms := range e.Forms {
+		if !known[s] && len(forms) > 0 {
+			rest = append(rest, s)
+		}
+	}
+	sort.Strings(rest)
+	return append(out, rest...)
+}
+
+// entryHTML renders e as a small HTML article: headword, word class,
+// definition, English translations when there are any, and the inflection
+// table, for dictionary reader formats.
+func entryHTML(e LexiconEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s</b> <i>%s</i>", html.EscapeString(e.Headword), html.EscapeString(e.Class))
+	if e.Definition != "" {
+		fmt.Fprintf(&b, "<br>%s", html.EscapeString(e.Definition))
+	}
+	if len(e.Translations) > 0 {
+		fmt.Fprintf(&b, `<br><i lang="en">%s</i>`, html.EscapeString(strings.Join(e.Translations, ", ")))
+	}
+	sections := e.sections()
+	if len(sections) == 0 {
+		return b.String()
+	}
+	b.WriteString("<table>")
+	for _, s := range sections {
+		fmt.Fprintf(&b, `<tr><th colspan="2">%s</th></tr>`, html.EscapeString(s))
+		for _, f := range e.Forms[s] {
+			form := html.EscapeString(f.Form)
+			for _, v := range f.Variants {
+				form += " el. " + html.EscapeString(v)
+			}
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td></tr>", form, html.EscapeString(f.Label))
+		}
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+// entryText renders e as plain text for a terminal: the headword and word
+// class, the definition and translations, then each table section with its
+// forms and labels in two aligned columns.
+func entryText(e LexiconEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s\n", e.Headword, e.Class)
+	if e.Definition != "" {
+		fmt.Fprintf(&b, "%s\n", e.Definition)
+	}
+	if len(e.Translations) > 0 {
+		fmt.Fprintf(&b, "en: %s\n", strings.Join(e.Translations, ", "))
+	}
+	width := 0
+	for _, forms := range e.Forms {
+		for _, f := range forms {
+			width = max(width, utf8.RuneCountInString(formText(f)))
+		}
+	}
+	for _, s := range e.sections() {
+		fmt.Fprintf(&b, "\n%s\n", s)
+		for _, f := range e.Forms[s] {
+			text := formText(f)
+			if f.Label == "" {
+				fmt.Fprintf(&b, "  %s\n", text)
+				continue
+			}
+			fmt.Fprintf(&b, "  %s%s  %s\n", text, strings.Repeat(" ", width-utf8.RuneCountInString(text)), f.Label)
+		}
+	}
+	return b.String()
+}
+
+// formText is a form and its variants as SAOL writes them.
+func formText(f Form) string {
+	return strings.Join(append([]string{f.Form}, f.Variants...), " el. ")
+}
+
+// entryMarkdown renders e as Markdown: the headword and class as a
+// heading, the definition and translations, then a table per section.
+func entryMarkdown(e LexiconEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s)\n", e.Headword, e.Class)
+	if e.Definition != "" {
+		fmt.Fprintf(&b, "\n%s\n", e.Definition)
+	}
+	if len(e.Translations) > 0 {
+		fmt.Fprintf(&b, "\n*en:* %s\n", strings.Join(e.Translations, ", "))
+	}
+	escape := strings.NewReplacer("|", `\|`).Replace
+	for _, s := range e.sections() {
+		fmt.Fprintf(&b, "\n| %s | |\n|---|---|\n", escape(s))
+		for _, f := range e.Forms[s] {
+			fmt.Fprintf(&b, "| %s | %s |\n", escape(formText(f)), escape(f.Label))
+		}
+	}
+	return b.String()
+}
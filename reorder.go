@@ -0,0 +1,62 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+)
+
+// reorderBuffer puts the results of the flatten workers, which finish in
+// any order, back in job index order while holding at most window of them.
+// The dispatcher reserves a slot for every index before handing it out, so
+// it can never get more than window indexes ahead of the oldest result not
+// yet emitted, and a result leaves the buffer as soon as every index before
+// it has.
+type reorderBuffer struct {
+	next    int
+	pending resultHeap
+	slots   chan struct{}
+}
+
+func newReorderBuffer(window int) *reorderBuffer {
+	if window < 1 {
+		window = 1
+	}
+	return &reorderBuffer{slots: make(chan struct{}, window)}
+}
+
+// reserve blocks until there is room for one more index in the window, or
+// returns ctx's error once ctx is done.
+func (b *reorderBuffer) reserve(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// push adds res and calls emit with every result that is now next in
+// order. Every reserved index must be pushed exactly once, errors too, or
+// the results after it are held back for good.
+func (b *reorderBuffer) push(res Result, emit func(Result)) {
+	heap.Push(&b.pending, res)
+	for len(b.pending) > 0 && b.pending[0].Index == b.next {
+		emit(heap.Pop(&b.pending).(Result))
+		<-b.slots
+		b.next++
+	}
+}
+
+// resultHeap is a min-heap of results by index.
+type resultHeap []Result
+
+func (h resultHeap) Len() int            { return len(h) }
+func (h resultHeap) Less(i, j int) bool  { return h[i].Index < h[j].Index }
+func (h resultHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(x interface{}) { *h = append(*h, x.(Result)) }
+func (h *resultHeap) Pop() interface{} {
+	old := *h
+	res := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return res
+}
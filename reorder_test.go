@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReorderBuffer(t *testing.T) {
+	b := newReorderBuffer(3)
+	for i := 0; i < 3; i++ {
+		if err := b.reserve(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.reserve(ctx); err == nil {
+		t.Fatal("reserve past the window succeeded")
+	}
+
+	var order []int
+	emit := func(res Result) { order = append(order, res.Index) }
+	b.push(Result{Index: 2}, emit)
+	b.push(Result{Index: 1}, emit)
+	if len(order) != 0 {
+		t.Fatalf("emitted %v before index 0", order)
+	}
+	b.push(Result{Index: 0}, emit)
+	if !reflect.DeepEqual(order, []int{0, 1, 2}) {
+		t.Errorf("emitted %v", order)
+	}
+	if len(b.slots) != 0 {
+		t.Errorf("%d slots still held after emitting everything", len(b.slots))
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// reviewedLemma is a lemma of the review report: what the parsers made of
+// it next to the markup they read it from, with the table rows none of
+// its forms came from marked.
+type reviewedLemma struct {
+	Key     string
+	Entry   LexiconEntry
+	Source  string // lemma HTML, dropped rows with class "dropped"
+	Rows    int
+	Dropped int
+}
+
+// reviewLemma parses lemma and compares the forms to its table. A row is
+// dropped when none of the parsed forms is among its words; section header
+// rows and rows without cells are not counted. ok is false for lemmas of a
+// class without a parser.
+func reviewLemma(ctx context.Context, lemma Lemma) (r reviewedLemma, ok bool, err error) {
+	entry, ok, err := newLexiconEntry(ctx, lemma.Key, lemma.LemmaInput)
+	if err != nil || !ok {
+		return reviewedLemma{}, ok, err
+	}
+	profile, err := profileFor(lemma.Source)
+	if err != nil {
+		return reviewedLemma{}, false, err
+	}
+	doc, err := lemma.Document()
+	if err != nil {
+		return reviewedLemma{}, false, err
+	}
+
+	r = reviewedLemma{Key: lemma.Key, Entry: entry}
+	if profile.TableRow != "" {
+		var forms []string
+		for _, section := range entry.Forms {
+			for _, f := range section {
+				forms = append(forms, f.Form)
+				forms = append(forms, f.Variants...)
+			}
+		}
+		doc.Find(profile.TableRow).Each(func(_ int, row *goquery.Selection) {
+			if row.Find("td").Length() == 0 || (profile.SectionHeader != "" && row.Find(profile.SectionHeader).Length() > 0) {
+				return
+			}
+			r.Rows++
+			var cells []string
+			row.Find("td").Each(func(_ int, td *goquery.Selection) { cells = append(cells, cellText(td)) })
+			if !rowHasForm(strings.Join(cells, " "), forms) {
+				r.Dropped++
+				row.AddClass("dropped")
+			}
+		})
+	}
+	sanitizeSource(doc.Selection)
+	if r.Source, err = doc.Find("body").Html(); err != nil {
+		return reviewedLemma{}, false, err
+	}
+	return r, true, nil
+}
+
+// sanitizeSource strips what could run in the report from the scraped
+// markup shown in it: script, style and embedding elements, on* event
+// handler attributes and URLs other than relative, http, https and mailto
+// ones. The page is opened from disk, so a hostile or broken dump must not
+// get to run there.
+func sanitizeSource(doc *goquery.Selection) {
+	doc.Find("script, style, iframe, frame, object, embed, link, meta, base, form").Remove()
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		n := s.Get(0)
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			key := strings.ToLower(a.Key)
+			if strings.HasPrefix(key, "on") {
+				continue
+			}
+			if urlAttrs[key] && !safeURL(a.Val) {
+				continue
+			}
+			attrs = append(attrs, a)
+		}
+		n.Attr = attrs
+	})
+}
+
+// urlAttrs are the attributes sanitizeSource checks with safeURL.
+var urlAttrs = map[string]bool{
+	"href": true, "src": true, "action": true, "formaction": true,
+	"xlink:href": true, "poster": true, "background": true, "cite": true, "data": true,
+}
+
+// safeURL reports whether u is relative or has an http, https or mailto
+// scheme. Browsers skip ASCII whitespace and control characters inside a
+// scheme, "java\tscript:", so they are dropped before it is read.
+func safeURL(u string) bool {
+	u = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, u)
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return true
+	}
+	switch strings.ToLower(u[:i]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// rowHasForm reports whether one of forms appears in text as whole words.
+func rowHasForm(text string, forms []string) bool {
+	text = " " + text + " "
+	for _, f := range forms {
+		if f != "" && strings.Contains(text, " "+f+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// reportStyle is the stylesheet of the review report.
+const reportStyle = `body { font-family: sans-serif; margin: 2em; }
+.lemma { display: flex; gap: 2em; border-top: 1px solid #ccc; padding: 1em 0; }
+.lemma > div { flex: 1; overflow-x: auto; }
+.lemma h2 { font-size: 1em; color: #666; margin: 0 0 .5em; }
+.flagged h2 { color: #b00; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ddd; padding: 2px 6px; text-align: left; }
+tr.dropped, tr.dropped td { background: #fdd; }`
+
+// writeReviewHTML writes lemmas as a static HTML page: a summary, then
+// each lemma's parsed table on the left and its source on the right.
+func writeReviewHTML(w io.Writer, lemmas []reviewedLemma, in string) error {
+	bw := bufio.NewWriter(w)
+	rows, dropped, flagged := 0, 0, 0
+	for _, l := range lemmas {
+		rows += l.Rows
+		dropped += l.Dropped
+		if l.Dropped > 0 {
+			flagged++
+		}
+	}
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html lang=\"sv\">\n<head>\n<meta charset=\"utf-8\">\n<title>Parser review: %s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(in), reportStyle)
+	fmt.Fprintf(bw, "<h1>Parser review of %s</h1>\n", html.EscapeString(in))
+	fmt.Fprintf(bw, "<p>%d lemmas, %d table rows, %d dropped rows in %d lemmas. Dropped rows are highlighted in the source.</p>\n", len(lemmas), rows, dropped, flagged)
+	for _, l := range lemmas {
+		class := "lemma"
+		if l.Dropped > 0 {
+			class += " flagged"
+		}
+		fmt.Fprintf(bw, "<div class=\"%s\" id=\"key-%s\">\n", class, html.EscapeString(l.Key))
+		fmt.Fprintf(bw, "<div><h2>parsed: key %s, %s, %d of %d rows dropped</h2>\n%s\n</div>\n", html.EscapeString(l.Key), html.EscapeString(l.Entry.ID), l.Dropped, l.Rows, entryHTML(l.Entry))
+		fmt.Fprintf(bw, "<div><h2>source</h2>\n%s\n</div>\n</div>\n", l.Source)
+	}
+	fmt.Fprint(bw, "</body>\n</html>\n")
+	return bw.Flush()
+}
+
+// runReport writes an HTML page to review the parsers by: a sample of
+// lemmas, each parsed next to its source table with the rows the parser
+// dropped highlighted.
+func runReport(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas to review")
+	out := flags.String("html", "report.html", "HTML page to write")
+	classes := flags.String("class", "", "only review these word classes, comma separated, e.g. verb")
+	droppedOnly := flags.Bool("dropped", false, "only list the lemmas with dropped rows")
+	n := flags.Int("sample", 100, "review this many randomly chosen lemmas (0 for all)")
+	seed := flags.Int64("seed", 0, "random seed for -sample, to review the same lemmas again (0 for a new one each run)")
+	profiles := flags.String("profiles", "", "JSON file of extra selector profiles the lemmas were flattened with")
+	flags.Parse(args)
+	if *profiles != "" {
+		if err := loadSelectorProfiles(*profiles); err != nil {
+			fatal("could not load selector profiles", "file", *profiles, "err", err)
+		}
+	}
+	keepClass := make(map[string]bool)
+	for _, c := range strings.Split(*classes, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			keepClass[c] = true
+		}
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		fatal("could not open lemmas", "file", *in, "err", err)
+	}
+	var lemmas []Lemma
+	err = ForEachLemma(file, func(l Lemma) error {
+		if len(keepClass) > 0 {
+			if class, err := l.WordClass(); err != nil || !keepClass[class] {
+				return nil
+			}
+		}
+		lemmas = append(lemmas, l)
+		return nil
+	})
+	file.Close()
+	if err != nil {
+		fatal("could not read lemmas", "file", *in, "err", err)
+	}
+	if *n > 0 && *n < len(lemmas) {
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		slog.Info("sampling lemmas", "sample", *n, "of", len(lemmas), "seed", *seed)
+		lemmas = sampleOf(lemmas, *n, *seed)
+	}
+
+	var reviewed []reviewedLemma
+	for _, l := range lemmas {
+		r, ok, err := reviewLemma(context.Background(), l)
+		if err != nil {
+			slog.Warn("could not review lemma", "key", l.Key, "err", err)
+			continue
+		}
+		if ok && (!*droppedOnly || r.Dropped > 0) {
+			reviewed = append(reviewed, r)
+		}
+	}
+	if err := saveFile(*out, func(w io.Writer) error { return writeReviewHTML(w, reviewed, *in) }); err != nil {
+		fatal("could not write report", "file", *out, "err", err)
+	}
+	slog.Info("wrote review report", "lemmas", len(reviewed), "file", *out)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReviewLemma(t *testing.T) {
+	r, ok, err := reviewLemma(context.Background(), Lemma{Key: "1", LemmaInput: LemmaInput{HTML: readFixture(t, "substantiv_bil")}})
+	if err != nil || !ok {
+		t.Fatalf("reviewLemma(bil) = ok %v, err %v", ok, err)
+	}
+	if r.Rows == 0 || r.Dropped != 0 {
+		t.Errorf("bil: %d rows, %d dropped, want every row covered", r.Rows, r.Dropped)
+	}
+
+	html := strings.Replace(readFixture(t, "substantiv_bil"), "</table>", `<tr><td class="ledtext">bilarsk</td></tr></table>`, 1)
+	r, _, err = reviewLemma(context.Background(), Lemma{Key: "1", LemmaInput: LemmaInput{HTML: html}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Dropped != 1 || !strings.Contains(r.Source, `class="dropped"`) {
+		t.Errorf("extra row: %d dropped, want 1 marked in the source", r.Dropped)
+	}
+}
+
+func TestReviewLemmaSanitizesSource(t *testing.T) {
+	html := strings.Replace(readFixture(t, "substantiv_bil"), "</table>",
+		`</table><script>alert(1)</script><style>body{}</style><img src="x" onerror="alert(2)"><a href=" JavaScript:alert(3)">x</a>`+
+			`<a href="java&#9;script:alert(4)">y</a><a href="data:text/html,alert(5)">z</a><a href="/bil">bil</a>`, 1)
+	r, _, err := reviewLemma(context.Background(), Lemma{Key: "1", LemmaInput: LemmaInput{HTML: html}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"<script", "<style", "onerror", "alert"} {
+		if strings.Contains(r.Source, bad) {
+			t.Errorf("source keeps %q: %s", bad, r.Source)
+		}
+	}
+	if !strings.Contains(r.Source, `<img src="x"/>`) {
+		t.Errorf("source lost the image itself: %s", r.Source)
+	}
+	if !strings.Contains(r.Source, `<a href="/bil">`) {
+		t.Errorf("source lost a relative link: %s", r.Source)
+	}
+}
+
+func TestRowHasForm(t *testing.T) {
+	forms := []string{"bil", "bilen"}
+	for text, want := range map[string]bool{"en bil": true, "bilen": true, "bilar": false, "bilens": false} {
+		if got := rowHasForm(text, forms); got != want {
+			t.Errorf("rowHasForm(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestWriteReviewHTML(t *testing.T) {
+	r, _, err := reviewLemma(context.Background(), Lemma{Key: "1", LemmaInput: LemmaInput{HTML: readFixture(t, "substantiv_bil")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeReviewHTML(&buf, []reviewedLemma{r}, "lemmas.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<!DOCTYPE html>", "1 lemmas", `id="key-1"`, "bilen"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	for u, want := range map[string]bool{
+		"https://svenska.se/": true, "HTTP://x": true, "mailto:a@b.se": true,
+		"/tri/f_saol.php?id=1": true, "bil.html#x": true, "?q=a:b": true,
+		"javascript:alert(1)": false, " JavaScript:alert(1)": false, "java\tscript:alert(1)": false,
+		"java\x00script:x": false, "data:text/html,x": false, "vbscript:x": false,
+	} {
+		if got := safeURL(u); got != want {
+			t.Errorf("safeURL(%q) = %v, want %v", u, got, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+)
+
+// runRetry splits the articles flatten quarantined again, say after a
+// -profiles file for new markup was written or with -relaxed selectors,
+// and merges the ones that now split into flattened_lemmas.json at the
+// position of their article. Those that still fail stay in the quarantine.
+func runRetry(args []string) {
+	flags := flag.NewFlagSet("retry", flag.ExitOnError)
+	quarantineFile := flags.String("quarantine", "quarantined_entries.json", "articles flatten quarantined")
+	in := flags.String("in", outputFile, "flattened lemmas to merge the retried articles into")
+	dictionary := flags.String("dictionary", "auto", "selector profile to split with: saol, so, one from -profiles, or auto to detect it per article")
+	profilesFile := flags.String("profiles", "", "JSON file of extra selector profiles, for a redesigned site")
+	relaxed := flags.Bool("relaxed", false, "if no profile matches, also try them without the element names in their article and lemma selectors")
+	entryTimeout := flags.Duration("entry-timeout", 0, "give up on an article after this long (0 for no limit)")
+	stateFile := flags.String("state", "flatten_state.json", "flatten state to record the retried articles in, if it exists")
+	gapsFile := flags.String("gaps", "family_gaps.json", "family gap report to remove the retried articles from, if it exists")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *profilesFile != "" {
+		if err := loadSelectorProfiles(*profilesFile); err != nil {
+			fatal("could not load selector profiles", "file", *profilesFile, "err", err)
+		}
+	}
+	profiles := allProfiles()
+	if *dictionary != "auto" {
+		profile, err := profileFor(*dictionary)
+		if err != nil {
+			fatal("unknown -dictionary", "err", err)
+		}
+		profiles = []SelectorProfile{profile}
+	}
+	if *relaxed {
+		for _, p := range profiles {
+			profiles = append(profiles, p.relaxed())
+		}
+	}
+
+	quarantined, err := readArticleQuarantine(*quarantineFile)
+	if err != nil {
+		fatal("could not read quarantine", "file", *quarantineFile, "err", err)
+	}
+
+	var retried []Result
+	var remaining []quarantinedArticle
+	for _, q := range quarantined {
+		res, err := withEntryDeadline(ctx, *entryTimeout, func(ctx context.Context) (Result, error) {
+			return splitArticle(ctx, 0, profiles, Job{Index: q.Index, Data: q.InputEntry}), nil
+		})
+		if ctx.Err() != nil {
+			fatal("retry cancelled, nothing written", "err", ctx.Err())
+		}
+		if err == nil {
+			err = res.Error
+		}
+		if err != nil {
+			slog.Warn("article still fails", "index", q.Index, "err", err)
+			q.Reason = err.Error()
+			remaining = append(remaining, q)
+			continue
+		}
+		retried = append(retried, res)
+	}
+
+	if len(retried) > 0 {
+		if err := mergeRetried(*in, retried); err != nil {
+			fatal("could not merge the retried articles", "file", *in, "err", err)
+		}
+		if err := recordRetried(*stateFile, quarantined, retried); err != nil {
+			fatal("could not update flatten state", "file", *stateFile, "err", err)
+		}
+		if err := fillFamilyGaps(*gapsFile, retried); err != nil {
+			fatal("could not update family gaps", "file", *gapsFile, "err", err)
+		}
+	}
+	if remaining == nil {
+		remaining = []quarantinedArticle{}
+	}
+	if err := saveQuarantine(*quarantineFile, remaining); err != nil {
+		fatal("could not save quarantine", "file", *quarantineFile, "err", err)
+	}
+	slog.Info("retried quarantined articles", "split", len(retried), "remaining", len(remaining), "file", *in)
+}
+
+// readArticleQuarantine reads a flatten quarantine file.
+func readArticleQuarantine(filename string) ([]quarantinedArticle, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	_, entries, err := readVersionedEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding quarantine '%s': %w", filename, err)
+	}
+	var quarantined []quarantinedArticle
+	if err := json.Unmarshal(entries, &quarantined); err != nil {
+		return nil, fmt.Errorf("error decoding quarantine '%s': %w", filename, err)
+	}
+	return quarantined, nil
+}
+
+// mergeRetried inserts the lemmas of the retried articles into the
+// flattened lemma file filename, each before the first lemma of a later
+// article, and renumbers the keys and duplicateOf links of the lemmas
+// after them. The retried lemmas are not compared for -dedup.
+func mergeRetried(filename string, retried []Result) error {
+	sort.Slice(retried, func(i, j int) bool { return retried[i].Index < retried[j].Index })
+
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmpFile := filename + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+	defer f.Close()
+	out := newLemmaArrayWriter(f)
+
+	next := 0
+	insertBefore := func(familyID int) error {
+		for ; next < len(retried) && retried[next].Index+1 < familyID; next++ {
+			for _, entry := range retried[next].lemmas() {
+				if err := out.write(entry); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	keys := make(map[int]int)
+	err = ForEachLemma(in, func(l Lemma) error {
+		if err := insertBefore(l.FamilyID); err != nil {
+			return err
+		}
+		entry := LemmaOutput{
+			HTML:     l.HTML,
+			FamilyID: l.FamilyID,
+			Source:   l.Source,
+			Class:    l.Class,
+			Headword: l.Headword,
+		}
+		if l.DuplicateOf != 0 {
+			entry.DuplicateOf = keys[l.DuplicateOf]
+		}
+		if err := out.write(entry); err != nil {
+			return err
+		}
+		key, _ := strconv.Atoi(l.Key)
+		keys[key] = out.n
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := insertBefore(math.MaxInt); err != nil {
+		return err
+	}
+	if err := out.close(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Rename(tmpFile, filename)
+}
+
+// recordRetried adds the hashes of the retried articles to the flatten
+// state in filename, so flatten -incremental reuses them; without a state
+// file there is nothing to do.
+func recordRetried(filename string, quarantined []quarantinedArticle, retried []Result) error {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var hashes []string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		return fmt.Errorf("error decoding flatten state '%s': %w", filename, err)
+	}
+
+	html := make(map[int]string, len(quarantined))
+	for _, q := range quarantined {
+		html[q.Index] = q.HTML
+	}
+	for _, res := range retried {
+		if res.Index < len(hashes) {
+			hashes[res.Index] = hashArticle(html[res.Index])
+		}
+	}
+	data, err = json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorkerQuarantinesUnmatchedArticle(t *testing.T) {
+	res := runLayoutWorker(4, readFixture(t, "article_bil"), selectorProfiles["so"])
+	if res.Error == nil || res.Quarantined == nil {
+		t.Fatalf("error %v, quarantined %v; want both", res.Error, res.Quarantined)
+	}
+
+	// Retrying with the right profile splits it.
+	res = splitArticle(context.Background(), 0, allProfiles(), Job{Index: 4, Data: *res.Quarantined})
+	if res.Error != nil || len(res.LemmaHTMLs) != 2 {
+		t.Errorf("retry: %d lemmas, %v", len(res.LemmaHTMLs), res.Error)
+	}
+}
+
+func TestMergeRetried(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "flattened_lemmas.json")
+	lemmas := `[
+		{"key": 1, "html": "a", "familyID": 1},
+		{"key": 2, "html": "c", "familyID": 3},
+		{"key": 3, "html": "c", "familyID": 4, "duplicateOf": 2}
+	]`
+	if err := os.WriteFile(filename, []byte(lemmas), 0644); err != nil {
+		t.Fatal(err)
+	}
+	retried := []Result{
+		{Index: 5, Source: "saol", LemmaHTMLs: []string{"f"}, Classes: []string{"verb"}, Headwords: []string{"f"}},
+		{Index: 1, Source: "saol", LemmaHTMLs: []string{"b1", "b2"}, Classes: []string{"", ""}, Headwords: []string{"", ""}},
+	}
+	if err := mergeRetried(filename, retried); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []LemmaOutput
+	data, _ := os.ReadFile(filename)
+	decodeEntries(t, data, &got)
+	want := []LemmaOutput{
+		{Key: 1, HTML: "a", FamilyID: 1},
+		{Key: 2, HTML: "b1", FamilyID: 2, Source: "saol"},
+		{Key: 3, HTML: "b2", FamilyID: 2, Source: "saol"},
+		{Key: 4, HTML: "c", FamilyID: 3},
+		{Key: 5, HTML: "c", FamilyID: 4, DuplicateOf: 4},
+		{Key: 6, HTML: "f", FamilyID: 6, Source: "saol", Class: "verb", Headword: "f"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
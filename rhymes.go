@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// swedishVowels are the letters that make a syllable.
+const swedishVowels = "aeiouyåäöé"
+
+// syllables counts the syllables of word as its runs of vowels.
+func syllables(word string) int {
+	n, inVowel := 0, false
+	for _, r := range strings.ToLower(word) {
+		v := strings.ContainsRune(swedishVowels, r)
+		if v && !inVowel {
+			n++
+		}
+		inVowel = v
+	}
+	return n
+}
+
+// reverseForm is a form of the reverse index, under its letters reversed.
+type reverseForm struct {
+	key       string
+	form      string
+	lemma     string
+	class     string
+	syllables int
+}
+
+// reverseIndex lists every single-word form of a lexicon sorted by its
+// reversed spelling, so that the forms with a given ending are adjacent.
+type reverseIndex []reverseForm
+
+// newReverseIndex indexes the surface forms of entries.
+func newReverseIndex(entries []LexiconEntry) reverseIndex {
+	var idx reverseIndex
+	for _, e := range entries {
+		for _, f := range e.surfaceForms() {
+			if strings.ContainsAny(f, " -") {
+				continue
+			}
+			idx = append(idx, reverseForm{key: reverseString(strings.ToLower(f)), form: f, lemma: e.ID, class: e.Class, syllables: syllables(f)})
+		}
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return idx[i].key < idx[j].key })
+	return idx
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// withEnding returns the forms ending in the reversed ending key.
+func (idx reverseIndex) withEnding(key string) reverseIndex {
+	i := sort.Search(len(idx), func(i int) bool { return idx[i].key >= key })
+	j := i
+	for j < len(idx) && strings.HasPrefix(idx[j].key, key) {
+		j++
+	}
+	return idx[i:j]
+}
+
+// rhyme is a form sharing an ending with the word rhymes was asked about.
+type rhyme struct {
+	Form      string   `json:"form"`
+	Ending    string   `json:"ending"`
+	Syllables int      `json:"syllables"`
+	Lemmas    []string `json:"lemmas"`
+}
+
+// rhymeFilter narrows rhymes to a word class and a syllable count, each
+// ignored when empty or 0.
+type rhymeFilter struct {
+	class     string
+	syllables int
+}
+
+func (f rhymeFilter) keep(r reverseForm) bool {
+	return (f.class == "" || r.class == f.class) && (f.syllables == 0 || r.syllables == f.syllables)
+}
+
+// rhymes returns the forms sharing the longest ending with word, longest
+// first and alphabetically within one length, at most n of them (all when
+// n is 0). The shared ending takes in at least the last vowel of word, so
+// bil and stol, which only share the l, do not rhyme.
+func (idx reverseIndex) rhymes(word string, filter rhymeFilter, n int) []rhyme {
+	key := reverseString(strings.ToLower(word))
+	shortest := strings.IndexAny(key, swedishVowels)
+	if shortest < 0 {
+		return nil
+	}
+	shortest += len(string([]rune(key[shortest:])[0]))
+
+	var out []rhyme
+	byForm := make(map[string]int)
+	for l := len(key); l >= shortest; l-- {
+		if !isRuneStart(key, l) {
+			continue
+		}
+		start := len(out)
+		for _, r := range idx.withEnding(key[:l]) {
+			if r.key == key || !filter.keep(r) {
+				continue
+			}
+			if i, ok := byForm[r.form]; ok {
+				if i >= start {
+					out[i].Lemmas = append(out[i].Lemmas, r.lemma)
+				}
+				continue
+			}
+			byForm[r.form] = len(out)
+			out = append(out, rhyme{Form: r.form, Ending: reverseString(key[:l]), Syllables: r.syllables, Lemmas: []string{r.lemma}})
+		}
+		added := out[start:]
+		sort.SliceStable(added, func(i, j int) bool { return added[i].Form < added[j].Form })
+		for i, r := range added {
+			byForm[r.Form] = start + i
+		}
+		if n > 0 && len(out) >= n {
+			return out[:n]
+		}
+	}
+	return out
+}
+
+// isRuneStart reports whether i is a rune boundary of s.
+func isRuneStart(s string, i int) bool {
+	return i == len(s) || s[i]&0xc0 != 0x80
+}
+
+// runRhymes lists the forms of a lexicon rhyming with a word.
+func runRhymes(args []string) {
+	flags := flag.NewFlagSet("rhymes", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to find rhymes in")
+	class := flags.String("class", "", "only rhymes of this word class")
+	syl := flags.Int("syllables", 0, "only rhymes of this many syllables (0 for any)")
+	n := flags.Int("n", 20, "rhymes to list, longest shared ending first (0 for all)")
+	format := flags.String("format", "text", "output format: text, or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool rhymes [-in file] [-class c] [-syllables n] [-n 20] [-format text|json] <word>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	rhymes := newReverseIndex(entries).rhymes(flags.Arg(0), rhymeFilter{class: *class, syllables: *syl}, *n)
+	if rhymes == nil {
+		rhymes = []rhyme{}
+	}
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, rhymes)
+	} else {
+		err = writeRhymesText(os.Stdout, rhymes)
+	}
+	if err != nil {
+		fatal("could not write rhymes", "err", err)
+	}
+}
+
+// writeRhymesText writes a line per rhyme: the form, the shared ending and
+// the lemmas, separated by tabs.
+func writeRhymesText(w io.Writer, rhymes []rhyme) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range rhymes {
+		fmt.Fprintf(bw, "%s\t-%s\t%s\n", r.Form, r.Ending, strings.Join(r.Lemmas, " "))
+	}
+	return bw.Flush()
+}
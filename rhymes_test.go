@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSyllables(t *testing.T) {
+	for word, want := range map[string]int{"bil": 1, "katten": 2, "järnvägsstation": 4, "aula": 2, "hm": 0} {
+		if got := syllables(word); got != want {
+			t.Errorf("syllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestRhymes(t *testing.T) {
+	idx := newReverseIndex([]LexiconEntry{
+		{ID: "katt", Headword: "katt", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "katten"}}}},
+		{ID: "ratt", Headword: "ratt", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "ratten"}}}},
+		{ID: "matta", Headword: "matta", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "mattan"}}}},
+		{ID: "platt", Headword: "platt", Class: "adjektiv"},
+		{ID: "natt", Headword: "natt", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "natten"}}}},
+		{ID: "natten", Headword: "natten", Class: "adverb"},
+		{ID: "stol", Headword: "stol", Class: "substantiv"},
+		{ID: "vatten", Headword: "vatten", Class: "substantiv"},
+		{ID: "skäl", Headword: "skäl", Class: "substantiv"},
+		{ID: "mäl", Headword: "mäl", Class: "substantiv"},
+	})
+	forms := func(rs []rhyme) []string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Form)
+		}
+		return out
+	}
+
+	got := idx.rhymes("katten", rhymeFilter{}, 0)
+	if want := []string{"natten", "ratten", "vatten"}; !reflect.DeepEqual(forms(got), want) {
+		t.Errorf("rhymes(katten) = %q, want %q", forms(got), want)
+	}
+	if got[0].Ending != "atten" || !reflect.DeepEqual(got[0].Lemmas, []string{"natt", "natten"}) {
+		t.Errorf("rhymes(katten)[0] = %+v", got[0])
+	}
+	if got := idx.rhymes("katt", rhymeFilter{class: "adjektiv"}, 0); !reflect.DeepEqual(forms(got), []string{"platt"}) {
+		t.Errorf("adjektiv rhymes = %q", forms(got))
+	}
+	if got := idx.rhymes("katt", rhymeFilter{syllables: 1}, 2); !reflect.DeepEqual(forms(got), []string{"natt", "platt"}) {
+		t.Errorf("one-syllable rhymes = %q", forms(got))
+	}
+	if got := idx.rhymes("bil", rhymeFilter{}, 0); got != nil {
+		t.Errorf("rhymes(bil) = %q, want none: only the l is shared", forms(got))
+	}
+	if got := idx.rhymes("käl", rhymeFilter{}, 0); !reflect.DeepEqual(forms(got), []string{"skäl", "mäl"}) {
+		t.Errorf("rhymes(käl) = %q, want skäl sharing all of it first", forms(got))
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// saldoClasses maps SALDO part-of-speech codes to SAOL word classes.
+var saldoClasses = map[string]string{
+	"nn": "substantiv",
+	"vb": "verb",
+	"av": "adjektiv",
+	"ab": "adverb",
+	"pn": "pronomen",
+	"nl": "räkneord",
+	"pp": "preposition",
+	"kn": "konjunktion",
+	"sn": "subjunktion",
+	"in": "interjektion",
+}
+
+// saldoCompoundMSDs are the msd values of SALDO's compounding stems
+// ("bil-" in "bilverkstad"), which are not words of their own.
+var saldoCompoundMSDs = map[string]bool{"c": true, "ci": true, "cm": true, "sms": true}
+
+// saldoEntry is one lexical entry of SALDO's morphology (saldom.xml).
+type saldoEntry struct {
+	Baseform string
+	Class    string
+	Lemgram  string
+	Paradigm string
+	Forms    []string
+}
+
+type lmfFeat struct {
+	Att string `xml:"att,attr"`
+	Val string `xml:"val,attr"`
+}
+
+type lmfWordForm struct {
+	Feats []lmfFeat `xml:"feat"`
+}
+
+type lmfEntry struct {
+	Lemma     []lmfFeat     `xml:"Lemma>FormRepresentation>feat"`
+	WordForms []lmfWordForm `xml:"WordForm"`
+}
+
+func featValue(feats []lmfFeat, att string) string {
+	for _, f := range feats {
+		if f.Att == att {
+			return f.Val
+		}
+	}
+	return ""
+}
+
+// readSaldo streams the LexicalEntry elements of a SALDO morphology LMF
+// file. Entries whose part of speech has no SAOL counterpart are skipped.
+func readSaldo(r io.Reader) ([]saldoEntry, error) {
+	dec := xml.NewDecoder(r)
+	var entries []saldoEntry
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading SALDO XML: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "LexicalEntry" {
+			continue
+		}
+		var raw lmfEntry
+		if err := dec.DecodeElement(&raw, &start); err != nil {
+			return nil, fmt.Errorf("error reading SALDO entry: %w", err)
+		}
+		class, ok := saldoClasses[featValue(raw.Lemma, "partOfSpeech")]
+		if !ok {
+			continue
+		}
+		e := saldoEntry{
+			Baseform: featValue(raw.Lemma, "writtenForm"),
+			Class:    class,
+			Lemgram:  featValue(raw.Lemma, "lemgram"),
+			Paradigm: featValue(raw.Lemma, "paradigm"),
+		}
+		for _, wf := range raw.WordForms {
+			if form := featValue(wf.Feats, "writtenForm"); form != "" && !saldoCompoundMSDs[featValue(wf.Feats, "msd")] {
+				e.Forms = append(e.Forms, form)
+			}
+		}
+		entries = append(entries, e)
+	}
+}
+
+// saldoCrosswalk links a SAOL lemma to the SALDO entry it aligns with and
+// lists the forms only one side has. Lemgram is empty when SALDO has no
+// entry with the same baseform and class.
+type saldoCrosswalk struct {
+	ID            string   `json:"id"`
+	Headword      string   `json:"headword"`
+	Class         string   `json:"class"`
+	Lemgram       string   `json:"lemgram,omitempty"`
+	SaldoParadigm string   `json:"saldoParadigm,omitempty"`
+	OnlyInSAOL    []string `json:"onlyInSAOL,omitempty"`
+	OnlyInSALDO   []string `json:"onlyInSALDO,omitempty"`
+}
+
+// alignSaldo matches every lexicon entry to the SALDO entry with the same
+// baseform and class. Among homographs the one sharing the most forms wins.
+// Forms are compared as single words, so particle and reflexive verbs
+// ("komma ihåg") are compared on their verb forms.
+func alignSaldo(entries []LexiconEntry, saldo []saldoEntry) []saldoCrosswalk {
+	type key struct{ baseform, class string }
+	byKey := make(map[key][]saldoEntry)
+	for _, s := range saldo {
+		k := key{s.Baseform, s.Class}
+		byKey[k] = append(byKey[k], s)
+	}
+
+	out := make([]saldoCrosswalk, 0, len(entries))
+	for _, e := range entries {
+		cw := saldoCrosswalk{ID: e.ID, Headword: e.Headword, Class: e.Class}
+		candidates := byKey[key{e.Headword, e.Class}]
+		if len(candidates) == 0 {
+			out = append(out, cw)
+			continue
+		}
+
+		saolForms := make(map[string]bool)
+		for _, f := range e.surfaceForms() {
+			if !strings.Contains(f, " ") {
+				saolForms[f] = true
+			}
+		}
+
+		best, bestShared := -1, -1
+		var bestForms map[string]bool
+		for i, c := range candidates {
+			forms := make(map[string]bool)
+			for _, f := range c.Forms {
+				if e.Particle != "" || e.Reflexive {
+					w := strings.Fields(f)
+					if len(w) == 0 {
+						continue
+					}
+					f = w[0]
+				}
+				if !strings.Contains(f, " ") {
+					forms[f] = true
+				}
+			}
+			shared := 0
+			for f := range forms {
+				if saolForms[f] {
+					shared++
+				}
+			}
+			if shared > bestShared {
+				best, bestShared, bestForms = i, shared, forms
+			}
+		}
+
+		cw.Lemgram = candidates[best].Lemgram
+		cw.SaldoParadigm = candidates[best].Paradigm
+		cw.OnlyInSAOL = formDifference(saolForms, bestForms)
+		cw.OnlyInSALDO = formDifference(bestForms, saolForms)
+		out = append(out, cw)
+	}
+	return out
+}
+
+// formDifference returns the forms in a that are not in b, sorted.
+func formDifference(a, b map[string]bool) []string {
+	var diff []string
+	for f := range a {
+		if !b[f] {
+			diff = append(diff, f)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// runSaldo aligns the lexicon with SALDO and writes the crosswalk.
+func runSaldo(args []string) {
+	flags := flag.NewFlagSet("saldo", flag.ExitOnError)
+	saldoFile := flags.String("saldo", "saldom.xml", "SALDO morphology in LMF XML, from spraakbanken.gu.se")
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas to align")
+	out := flags.String("out", "saldo_crosswalk.json", "crosswalk to write")
+	flags.Parse(args)
+
+	f, err := os.Open(*saldoFile)
+	if err != nil {
+		fatal("could not open SALDO", "file", *saldoFile, "err", err)
+	}
+	saldo, err := readSaldo(f)
+	f.Close()
+	if err != nil {
+		fatal("could not read SALDO", "file", *saldoFile, "err", err)
+	}
+	entries, err := loadLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+
+	crosswalk := alignSaldo(entries, saldo)
+	unmatched, mismatched := 0, 0
+	for _, cw := range crosswalk {
+		switch {
+		case cw.Lemgram == "":
+			unmatched++
+		case len(cw.OnlyInSAOL) > 0 || len(cw.OnlyInSALDO) > 0:
+			mismatched++
+		}
+	}
+
+	if err := saveFile(*out, func(w io.Writer) error { return writeVersionedJSON(w, crosswalk) }); err != nil {
+		fatal("could not save crosswalk", "file", *out, "err", err)
+	}
+	slog.Info("aligned lexicon with SALDO", "lemmas", len(crosswalk), "saldoEntries", len(saldo),
+		"unmatched", unmatched, "mismatched", mismatched, "file", *out)
+}
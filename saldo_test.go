@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAlignSaldo(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "saldom_sample.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saldo, err := readSaldo(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saldo) != 2 {
+		t.Fatalf("read %d SALDO entries, want 2 (vbm has no SAOL class)", len(saldo))
+	}
+
+	var aligned []saldoCrosswalk
+	for _, cw := range alignSaldo(fixtureEntries(t), saldo) {
+		if cw.ID == "bil" || cw.ID == "simma" || cw.ID == "hus" {
+			aligned = append(aligned, cw)
+		}
+	}
+	checkGolden(t, "saldo_crosswalk", aligned)
+}
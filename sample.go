@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sampleFlags are the -sample and -seed flags of the stages that can run
+// on a random subset of their input, to try the pipeline on a huge dump in
+// seconds.
+type sampleFlags struct {
+	n    *int
+	seed *int64
+}
+
+func addSampleFlags(flags *flag.FlagSet) *sampleFlags {
+	return &sampleFlags{
+		n:    flags.Int("sample", 0, "only process this many randomly chosen entries (0 for all)"),
+		seed: flags.Int64("seed", 0, "random seed for -sample, to draw the same entries again (0 for a new one each run)"),
+	}
+}
+
+// applySample returns the random subset of items -sample asks for, in
+// their original order, or items when it asks for none. The seed is
+// logged, so that a run without -seed can be repeated.
+func applySample[T any](f *sampleFlags, items []T) []T {
+	if *f.n <= 0 || *f.n >= len(items) {
+		return items
+	}
+	seed := *f.seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	slog.Info("sampling entries", "sample", *f.n, "of", len(items), "seed", seed)
+	return sampleOf(items, *f.n, seed)
+}
+
+// sampleOf returns n items chosen at random with seed, in their order in
+// items.
+func sampleOf[T any](items []T, n int, seed int64) []T {
+	if n >= len(items) {
+		return items
+	}
+	idx := rand.New(rand.NewSource(seed)).Perm(len(items))[:n]
+	sort.Ints(idx)
+	out := make([]T, n)
+	for i, j := range idx {
+		out[i] = items[j]
+	}
+	return out
+}
+
+// runRandom prints random lemmas of the lexicon, say for a flashcard or
+// quiz generator.
+func runRandom(args []string) {
+	flags := flag.NewFlagSet("random", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to draw from")
+	classes := flags.String("class", "", "only draw lemmas of these word classes, comma separated, e.g. adjektiv")
+	where := flags.String("where", "", `only draw lemmas matching this filter, e.g. 'frequencyBand<=2'`)
+	n := flags.Int("n", 1, "lemmas to draw")
+	seed := flags.Int64("seed", 0, "random seed, to draw the same lemmas again (0 for a new one each run)")
+	format := flags.String("format", "text", "output format: text, a headword per line, or json with the full entries")
+	flags.Parse(args)
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+	var filter whereExpr
+	if *where != "" {
+		var err error
+		if filter, err = parseWhere(*where); err != nil {
+			fatal("invalid -where", "err", err)
+		}
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	if *classes != "" {
+		entries = filterByClass(entries, strings.Split(*classes, ","))
+	}
+	if filter != nil {
+		entries = filterWhere(entries, filter)
+	}
+	if len(entries) == 0 {
+		fatal("no lemmas to draw from", "class", *classes, "where", *where)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	drawn := drawLemmas(entries, *n, *seed)
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, drawn)
+	} else {
+		err = writeHeadwords(os.Stdout, drawn)
+	}
+	if err != nil {
+		fatal("could not write lemmas", "err", err)
+	}
+}
+
+// drawLemmas returns n lemmas of entries drawn at random with seed, in the
+// order drawn, or all of them shuffled when there are no more than n.
+func drawLemmas(entries []LexiconEntry, n int, seed int64) []LexiconEntry {
+	idx := rand.New(rand.NewSource(seed)).Perm(len(entries))
+	if n < len(idx) {
+		idx = idx[:n]
+	}
+	out := make([]LexiconEntry, len(idx))
+	for i, j := range idx {
+		out[i] = entries[j]
+	}
+	return out
+}
+
+// writeHeadwords writes the headword and class of each entry, tab
+// separated, one entry per line.
+func writeHeadwords(w io.Writer, entries []LexiconEntry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", e.Headword, e.Class); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSampleOf(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	got := sampleOf(items, 4, 1)
+	if len(got) != 4 || !sort.IntsAreSorted(got) {
+		t.Errorf("sampleOf = %v, want 4 items in input order", got)
+	}
+	if again := sampleOf(items, 4, 1); !reflect.DeepEqual(again, got) {
+		t.Errorf("same seed drew %v, then %v", got, again)
+	}
+	if all := sampleOf(items, 20, 1); !reflect.DeepEqual(all, items) {
+		t.Errorf("sampleOf more than all = %v", all)
+	}
+}
+
+func TestApplySample(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	sample := addSampleFlags(flags)
+	items := []string{"a", "b", "c"}
+	if got := applySample(sample, items); !reflect.DeepEqual(got, items) {
+		t.Errorf("without -sample = %v", got)
+	}
+	flags.Parse([]string{"-sample", "2", "-seed", "7"})
+	if got := applySample(sample, items); !reflect.DeepEqual(got, sampleOf(items, 2, 7)) {
+		t.Errorf("-sample 2 -seed 7 = %v", got)
+	}
+}
+
+func TestDrawLemmas(t *testing.T) {
+	entries := []LexiconEntry{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	got := drawLemmas(entries, 2, 3)
+	if len(got) != 2 || got[0].ID == got[1].ID {
+		t.Errorf("drawLemmas = %v, want 2 distinct lemmas", got)
+	}
+	if again := drawLemmas(entries, 2, 3); !reflect.DeepEqual(again, got) {
+		t.Errorf("same seed drew %v, then %v", got, again)
+	}
+	if all := drawLemmas(entries, 9, 3); len(all) != 4 {
+		t.Errorf("drawing more than all = %v", all)
+	}
+
+	var buf bytes.Buffer
+	writeHeadwords(&buf, []LexiconEntry{{Headword: "fin", Class: "adjektiv"}})
+	if got, want := buf.String(), "fin\tadjektiv\n"; got != want {
+		t.Errorf("writeHeadwords = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The JSON Schemas (draft 2020-12) of the files the pipeline writes, one
+// per output and class.schema.json for classes registered with
+// RegisterParser. They are published in schema/ and built into saoltool
+// for validate.
+//
+//go:embed schema/*.schema.json
+var schemaFiles embed.FS
+
+// jsonSchema is the part of JSON Schema the published schemas use: type,
+// const, enum, minimum, required, properties, additionalProperties, items
+// and $ref to their own $defs or to another of the published schemas.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+	Type                 string                 `json:"type"`
+	Const                json.RawMessage        `json:"const"`
+	Enum                 []json.RawMessage      `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+
+	never bool // the boolean schema false
+}
+
+// UnmarshalJSON also accepts the boolean schemas true and false.
+func (s *jsonSchema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = jsonSchema{}
+		return nil
+	case "false":
+		*s = jsonSchema{never: true}
+		return nil
+	}
+	type plain jsonSchema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// loadSchema reads an embedded schema, name without .schema.json.
+func loadSchema(name string) (*jsonSchema, error) {
+	data, err := schemaFiles.ReadFile("schema/" + name + ".schema.json")
+	if err != nil {
+		return nil, err
+	}
+	return parseSchema(data)
+}
+
+func parseSchema(data []byte) (*jsonSchema, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error decoding schema: %w", err)
+	}
+	return &s, nil
+}
+
+// schemaFor picks the embedded schema of a file the pipeline writes by its
+// base name: flattened_lemmas.json, nouns.json, verbs.json and so on, and
+// <class>.json for the other classes with a parser.
+func schemaFor(filename string) (*jsonSchema, error) {
+	name := strings.TrimSuffix(filepath.Base(filename), ".json")
+	if _, err := schemaFiles.Open("schema/" + name + ".schema.json"); err == nil && name != "class" {
+		return loadSchema(name)
+	}
+	for _, class := range parsedClasses() {
+		if outputFor(class).file == name+".json" {
+			return loadSchema("class")
+		}
+	}
+	return nil, fmt.Errorf("no schema for %s, name one with -schema", filepath.Base(filename))
+}
+
+// schemaError is one place a document does not match its schema; Path is
+// a JSON Pointer to it.
+type schemaError struct {
+	Path    string
+	Message string
+}
+
+func (e schemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// validateJSON checks the document in r against s and returns where it
+// does not match. Objects and arrays are read a member at a time down to
+// the elements of arrays, which are decoded one by one, so a
+// flattened_lemmas.json of any size fits in memory.
+func validateJSON(r io.Reader, s *jsonSchema) ([]schemaError, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	errs, err := s.validateStream(dec, s, "")
+	if err != nil {
+		return errs, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errs, errors.New("data after the top-level value")
+	}
+	return errs, nil
+}
+
+// validateStream checks the next value of dec against s at path. A value
+// of the wrong type is reported without reading it further.
+func (root *jsonSchema) validateStream(dec *json.Decoder, s *jsonSchema, path string) ([]schemaError, error) {
+	s, root, err := root.resolve(s)
+	if err != nil {
+		return []schemaError{{path, err.Error()}}, nil
+	}
+	if s.Type != "array" && s.Type != "object" {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		return root.validate(s, v, path), nil
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if want := json.Delim(map[string]rune{"array": '[', "object": '{'}[s.Type]); tok != want {
+		return []schemaError{{path, fmt.Sprintf("want %s, got %s", s.Type, jsonTypeOfToken(tok))}}, skipValue(dec, tok)
+	}
+
+	var errs []schemaError
+	if s.Type == "array" {
+		for i := 0; dec.More(); i++ {
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return errs, err
+			}
+			if s.Items != nil {
+				errs = append(errs, root.validate(s.Items, v, path+"/"+strconv.Itoa(i))...)
+			}
+		}
+	} else {
+		seen := make(map[string]bool)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return errs, err
+			}
+			name := tok.(string)
+			seen[name] = true
+			sub, ok := s.Properties[name]
+			if !ok {
+				sub = s.AdditionalProperties
+			}
+			if sub == nil {
+				sub = &jsonSchema{}
+			}
+			if sub.never && !ok {
+				errs = append(errs, schemaError{path, "unknown property " + strconv.Quote(name)})
+				sub = &jsonSchema{}
+			}
+			subErrs, err := root.validateStream(dec, sub, path+"/"+jsonPointerEscape(name))
+			errs = append(errs, subErrs...)
+			if err != nil {
+				return errs, err
+			}
+		}
+		for _, name := range s.Required {
+			if !seen[name] {
+				errs = append(errs, schemaError{path, "missing required property " + strconv.Quote(name)})
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return errs, err
+	}
+	return errs, nil
+}
+
+// resolve follows the $ref of s, if it has one, to the schema it points
+// to: a JSON Pointer into root ("#/$defs/form") or into another of the
+// published schemas ("verbs.schema.json#/properties/entries"). It also
+// returns the root the target is in.
+func (root *jsonSchema) resolve(s *jsonSchema) (*jsonSchema, *jsonSchema, error) {
+	for s.Ref != "" {
+		ref := s.Ref
+		file, pointer := ref, ""
+		if i := strings.Index(ref, "#"); i >= 0 {
+			file, pointer = ref[:i], ref[i+1:]
+		}
+		if file != "" {
+			other, err := loadSchema(strings.TrimSuffix(file, ".schema.json"))
+			if err != nil {
+				return nil, nil, fmt.Errorf("schema has unresolvable $ref %s", ref)
+			}
+			root = other
+		}
+		target, ok := root.lookup(pointer)
+		if !ok {
+			return nil, nil, fmt.Errorf("schema has unresolvable $ref %s", ref)
+		}
+		s = target
+	}
+	return s, root, nil
+}
+
+// lookup finds the subschema at a JSON Pointer in s.
+func (s *jsonSchema) lookup(pointer string) (*jsonSchema, bool) {
+	if pointer == "" {
+		return s, true
+	}
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i := 0; i < len(segments) && s != nil; i++ {
+		switch segments[i] {
+		case "items":
+			s = s.Items
+		case "additionalProperties":
+			s = s.AdditionalProperties
+		case "$defs", "properties":
+			if i+1 == len(segments) {
+				return nil, false
+			}
+			name := strings.NewReplacer("~1", "/", "~0", "~").Replace(segments[i+1])
+			if segments[i] == "$defs" {
+				s = s.Defs[name]
+			} else {
+				s = s.Properties[name]
+			}
+			i++
+		default:
+			return nil, false
+		}
+	}
+	return s, s != nil
+}
+
+// validate checks v, decoded with UseNumber, against s at path.
+func (root *jsonSchema) validate(s *jsonSchema, v interface{}, path string) []schemaError {
+	s, root, err := root.resolve(s)
+	if err != nil {
+		return []schemaError{{path, err.Error()}}
+	}
+	if s.never {
+		return []schemaError{{path, "not allowed here"}}
+	}
+
+	if s.Type != "" && !hasJSONType(v, s.Type) {
+		return []schemaError{{path, fmt.Sprintf("want %s, got %s", s.Type, jsonTypeOf(v))}}
+	}
+	if s.Const != nil && !jsonEqual(v, s.Const) {
+		return []schemaError{{path, fmt.Sprintf("want %s", s.Const)}}
+	}
+	if s.Enum != nil {
+		found := false
+		for _, e := range s.Enum {
+			found = found || jsonEqual(v, e)
+		}
+		if !found {
+			return []schemaError{{path, "not one of the allowed values"}}
+		}
+	}
+
+	var errs []schemaError
+	switch v := v.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil && s.Minimum != nil && f < *s.Minimum {
+			errs = append(errs, schemaError{path, fmt.Sprintf("%s is less than %g", v, *s.Minimum)})
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, elem := range v {
+				errs = append(errs, root.validate(s.Items, elem, path+"/"+strconv.Itoa(i))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, schemaError{path, "missing required property " + strconv.Quote(name)})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := s.Properties[name]
+			if !ok {
+				sub = s.AdditionalProperties
+			}
+			if sub == nil {
+				continue
+			}
+			if sub.never && !ok {
+				errs = append(errs, schemaError{path, "unknown property " + strconv.Quote(name)})
+				continue
+			}
+			errs = append(errs, root.validate(sub, v[name], path+"/"+jsonPointerEscape(name))...)
+		}
+	}
+	return errs
+}
+
+// hasJSONType reports whether v is of the JSON Schema type typ.
+func hasJSONType(v interface{}, typ string) bool {
+	if typ == "integer" {
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	if typ == "number" {
+		_, ok := v.(json.Number)
+		return ok
+	}
+	return jsonTypeOf(v) == typ
+}
+
+// jsonTypeOf names the JSON type of v, decoded with UseNumber.
+func jsonTypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func jsonTypeOfToken(tok json.Token) string {
+	switch tok {
+	case json.Delim('{'):
+		return "object"
+	case json.Delim('['):
+		return "array"
+	}
+	return jsonTypeOf(tok)
+}
+
+// skipValue reads the rest of the value dec started with tok.
+func skipValue(dec *json.Decoder, tok json.Token) error {
+	depth := 0
+	for {
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+	}
+}
+
+// jsonEqual reports whether v is the JSON value raw.
+func jsonEqual(v interface{}, raw json.RawMessage) bool {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var want interface{}
+	if err := dec.Decode(&want); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(v, want)
+}
+
+// jsonPointerEscape escapes a property name for a JSON Pointer.
+func jsonPointerEscape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// runValidate checks output files, or hand-edited ones, against the schema
+// of what they are, picked by file name, and exits 1 if any does not match.
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	schemaFile := flags.String("schema", "", "JSON Schema file to check against instead of the built-in one picked by file name")
+	maxErrors := flags.Int("max-errors", 20, "errors to print per file (0 for all)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool validate [-schema file] [-max-errors n] <file>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var override *jsonSchema
+	if *schemaFile != "" {
+		data, err := ioutil.ReadFile(*schemaFile)
+		if err != nil {
+			fatal("could not read schema", "file", *schemaFile, "err", err)
+		}
+		if override, err = parseSchema(data); err != nil {
+			fatal("could not read schema", "file", *schemaFile, "err", err)
+		}
+	}
+
+	failed := false
+	for _, filename := range flags.Args() {
+		errs, err := validateFile(filename, override)
+		if err != nil {
+			fatal("could not validate", "file", filename, "err", err)
+		}
+		if version, err := fileSchemaVersion(filename); err == nil && version < outputSchemaVersion && len(errs) > 0 {
+			fmt.Printf("%s: written at schema version %d, upgrade it with saoltool migrate\n", filename, version)
+		}
+		for i, e := range errs {
+			if *maxErrors > 0 && i == *maxErrors {
+				fmt.Printf("%s: ... and %d more\n", filename, len(errs)-i)
+				break
+			}
+			fmt.Printf("%s: %v\n", filename, e)
+		}
+		if len(errs) > 0 {
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: ok\n", filename)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// validateFile checks filename against s, or against the schema of its
+// name if s is nil.
+func validateFile(filename string, s *jsonSchema) ([]schemaError, error) {
+	if s == nil {
+		var err error
+		if s, err = schemaFor(filename); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	errs, err := validateJSON(f, s)
+	if err != nil {
+		return errs, fmt.Errorf("error decoding '%s': %w", filename, err)
+	}
+	return errs, nil
+}
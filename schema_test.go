@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSchemasMatchOutput writes every fixture lemma the way extract does,
+// with and without -ud, and checks the file against the schema validate
+// would pick for it.
+func TestSchemasMatchOutput(t *testing.T) {
+	for _, name := range fixtureNames(t, "") {
+		doc := loadFixture(t, name)
+		class := saolProfile.class(doc.Selection)
+		parse, ok := parserFor(class)
+		if !ok || strings.HasPrefix(name, "article_") || strings.HasPrefix(name, "so_") {
+			continue
+		}
+		out := outputFor(class)
+		for _, ud := range []bool{false, true} {
+			var buf bytes.Buffer
+			if err := out.write(&buf, [][]string{parse(context.Background(), doc, saolProfile)}, ud); err != nil {
+				t.Fatal(err)
+			}
+			checkValid(t, out.file, buf.Bytes())
+		}
+	}
+
+	var buf bytes.Buffer
+	WriteUninflectedJSON(&buf, []UninflectedEntry{parseUninflected(loadFixture(t, "preposition_pa"), saolProfile)})
+	checkValid(t, "uninflected.json", buf.Bytes())
+
+	buf.Reset()
+	w := newLemmaArrayWriter(&buf)
+	w.write(LemmaOutput{HTML: readFixture(t, "substantiv_bil"), FamilyID: 1, Source: "saol", Class: "substantiv", Headword: "bil"})
+	w.write(LemmaOutput{HTML: readFixture(t, "substantiv_bil"), FamilyID: 2, DuplicateOf: 1})
+	w.close()
+	checkValid(t, outputFile, buf.Bytes())
+
+	for _, file := range []string{"verbs.json", "nouns.json", outputFile} {
+		checkValid(t, file, []byte(`{"schemaVersion": 2, "entries": []}`))
+	}
+}
+
+func checkValid(t *testing.T, file string, data []byte) {
+	t.Helper()
+	s, err := schemaFor(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := validateJSON(bytes.NewReader(data), s)
+	if err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+	for _, e := range errs {
+		t.Errorf("%s: %v", file, e)
+	}
+}
+
+// TestSchemasMatchReports writes the side files of flatten, extract, saldo
+// and enrich and checks them against their schemas.
+func TestSchemasMatchReports(t *testing.T) {
+	for file, entries := range map[string]interface{}{
+		"quarantined_entries.json": []quarantinedArticle{{Index: 3, Reason: "deadline exceeded", InputEntry: InputEntry{HTML: "<div></div>"}}},
+		"quarantined_lemmas.json":  []LemmaInput{{HTML: "<div></div>", FamilyID: 4, Class: "verb", Headword: "bila"}},
+		"duplicate_lemmas.json":    []duplicateLemma{{Key: 5, FamilyID: 2, DuplicateOf: 1, Hash: "ab"}, {FamilyID: 3, DuplicateOf: 1, Hash: "ab"}},
+		"saldo_crosswalk.json":     []saldoCrosswalk{{ID: "bil", Headword: "bil", Class: "substantiv", Lemgram: "bil..nn.1", OnlyInSAOL: []string{"bilarnas"}}},
+		"encoding_report.json":     []encodingIssue{{Index: 0, Headwords: []string{"bil"}, Problems: []string{"not NFC"}}, {Index: 3, Problems: []string{"invalid UTF-8"}}},
+		"family_gaps.json":         []familyGap{{FamilyID: 2, Reason: gapFailed, Error: "no lemmas"}, {FamilyID: 5, Reason: gapEmpty}},
+		enrichManifestFile:         Manifest{Generated: time.Now().UTC(), Entries: 2, Degraded: []Degradation{{Source: "folkets", Reason: "unavailable", Skipped: 2}}},
+	} {
+		var buf bytes.Buffer
+		if err := writeVersionedJSON(&buf, entries); err != nil {
+			t.Fatal(err)
+		}
+		checkValid(t, file, buf.Bytes())
+	}
+}
+
+func TestValidateReportsErrors(t *testing.T) {
+	tests := []struct {
+		file, doc string
+		want      []string
+	}{
+		{"verbs.json", `{}`, []string{`/: missing required property "schemaVersion"`, `/: missing required property "entries"`}},
+		{"verbs.json", `null`, []string{"/: want object, got null"}},
+		{"verbs.json", `[]`, []string{"/: want object, got array"}},
+		{"verbs.json", `{"schemaVersion": 1, "entries": {"class": "verb"}}`, []string{"/schemaVersion: want 2", "/entries: want array, got object"}},
+		{"verbs.json", `{"schemaVersion": 2, "entries": [{"class": "verb", "forms": {"Finita former": [], "Infinita former": [], "Presens particip": [], "Perfekt particip": [{"form": 1}]}}]}`,
+			[]string{"/entries/0/forms/Perfekt particip/0/form: want string, got number"}},
+		{"verbs.json", `{"schemaVersion": 2, "entries": [{"class": "verb", "forms": {}}]}`, []string{
+			`/entries/0/forms: missing required property "Finita former"`,
+			`/entries/0/forms: missing required property "Infinita former"`,
+			`/entries/0/forms: missing required property "Presens particip"`,
+			`/entries/0/forms: missing required property "Perfekt particip"`,
+		}},
+		{"adjectives.json", `{"schemaVersion": 2, "entries": [{"class": "verb", "forms": {"Positiv": [], "Komparativ": [], "Superlativ": []}}]}`,
+			[]string{`/entries/0/class: want "adjektiv"`}},
+		{"nouns.json", `{"schemaVersion": 2, "entries": [{"class": "substantiv", "forms": [{"form": "bil", "case": "Nominativ", "kasus": "x"}]}]}`,
+			[]string{`/entries/0/forms/0: unknown property "kasus"`}},
+		{outputFile, `{"schemaVersion": 2, "entries": [{"key": 1, "html": "", "familyID": 0}, {"key": 2.5, "html": ""}]}`, []string{
+			"/entries/0/familyID: 0 is less than 1",
+			`/entries/1: missing required property "familyID"`,
+			"/entries/1/key: want integer, got number",
+		}},
+		{"pronouns.json", `{"schemaVersion": 2, "entries": [{"class": "pronomen", "forms": {"Nominativ": [{"form": "jag", "frequency": -1}]}}]}`,
+			[]string{"/entries/0/forms/Nominativ/0/frequency: -1 is less than 0"}},
+	}
+	for _, tt := range tests {
+		s, err := schemaFor(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs, err := validateJSON(strings.NewReader(tt.doc), s)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.file, tt.doc, err)
+			continue
+		}
+		var got []string
+		for _, e := range errs {
+			got = append(got, e.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s %s:\ngot  %q\nwant %q", tt.file, tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRejectsBadJSON(t *testing.T) {
+	s, _ := schemaFor("verbs.json")
+	for _, doc := range []string{`{"schemaVersion": 2, "entries": [{"class": "verb"`, `{"schemaVersion": 2, "entries": []} {}`, ``} {
+		if _, err := validateJSON(strings.NewReader(doc), s); err == nil {
+			t.Errorf("%q: want a decoding error", doc)
+		}
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	if _, err := schemaFor("out/lexicon.json"); err == nil {
+		t.Error("lexicon.json: want an error, there is no schema for it")
+	}
+	if _, err := schemaFor("artikel.json"); err == nil {
+		t.Error("artikel.json: want an error before artikel has a parser")
+	}
+	withParser(t, "artikel", parseAdjektiv)
+	s, err := schemaFor("artikel.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := validateJSON(strings.NewReader(`{"schemaVersion": 2, "entries": [{"class": "artikel", "forms": {"Positiv": [{"form": "den"}]}}]}`), s)
+	if err != nil || len(errs) > 0 {
+		t.Errorf("artikel.json: %v %v", errs, err)
+	}
+}
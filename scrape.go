@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	scrapeSite       = "https://svenska.se/tri/f_"
+	scrapeUserAgent  = "saoltool (+https://github.com/PantaKoda/misc)"
+	maxScrapeRetries = 5
+)
+
+// scraper fetches SAOL pages politely: at most one request per delay,
+// backing off when the server asks it to, and never fetching the same
+// page twice, since every response is kept in cacheDir. A rerun after an
+// interruption replays the cache and carries on where it stopped.
+type scraper struct {
+	client   *http.Client
+	base     string
+	cacheDir string
+	article  string // selector of an article on a result page, from the -dictionary profile
+	delay    time.Duration
+	last     time.Time
+	fetched  int
+}
+
+// get returns the body of the page at query string q, from the cache when
+// possible.
+func (s *scraper) get(q url.Values) ([]byte, error) {
+	u := s.base + "?" + q.Encode()
+	sum := sha1.Sum([]byte(u))
+	cached := filepath.Join(s.cacheDir, hex.EncodeToString(sum[:])+".html")
+	if data, err := ioutil.ReadFile(cached); err == nil {
+		return data, nil
+	}
+
+	backoff := s.delay
+	for attempt := 1; ; attempt++ {
+		if wait := s.delay - time.Since(s.last); wait > 0 {
+			time.Sleep(wait)
+		}
+		s.last = time.Now()
+
+		data, retryAfter, err := s.fetch(u)
+		if err == nil {
+			s.fetched++
+			if err := os.MkdirAll(s.cacheDir, 0755); err != nil {
+				return nil, err
+			}
+			return data, ioutil.WriteFile(cached, data, 0644)
+		}
+		if retryAfter < 0 || attempt == maxScrapeRetries {
+			return nil, err
+		}
+		if retryAfter == 0 {
+			backoff *= 2
+			if backoff < time.Second {
+				backoff = time.Second
+			}
+			retryAfter = backoff
+		}
+		slog.Warn("request failed, retrying", "err", err, "retryAfter", retryAfter)
+		time.Sleep(retryAfter)
+	}
+}
+
+// fetch does one request. retryAfter is negative when retrying cannot
+// help, zero when the server gave no hint and positive when it did.
+func (s *scraper) fetch(u string) (data []byte, retryAfter time.Duration, err error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("User-Agent", scrapeUserAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		return data, 0, err
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, retryAfter, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return nil, -1, fmt.Errorf("GET %s: %s", u, resp.Status)
+}
+
+// entryLinks returns the ids of the SAOL entries a page links to: the hit
+// list of an ambiguous search, and with crawl also cross references
+// inside the articles.
+func entryLinks(doc *goquery.Document, crawl bool) []string {
+	sel := "a.slank"
+	if crawl {
+		sel = "a[href]"
+	}
+	var ids []string
+	doc.Find(sel).Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		u, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		if id := u.Query().Get("id"); id != "" {
+			ids = append(ids, id)
+		}
+	})
+	return ids
+}
+
+// scrapeEntries looks up every word on SAOL and returns the articles found,
+// each once. With crawl the entries linked from those articles are
+// followed too, up to maxEntries articles in total (0 for no limit).
+func (s *scraper) scrapeEntries(words []string, crawl bool, maxEntries int) ([]InputEntry, error) {
+	var entries []InputEntry
+	seenArticle := make(map[string]bool)
+	seenID := make(map[string]bool)
+	var queue []url.Values
+	for _, w := range words {
+		queue = append(queue, url.Values{"sok": {w}})
+	}
+
+	for len(queue) > 0 {
+		if maxEntries > 0 && len(entries) >= maxEntries {
+			break
+		}
+		q := queue[0]
+		queue = queue[1:]
+
+		page, err := s.get(q)
+		if err != nil {
+			return entries, err
+		}
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(page)))
+		if err != nil {
+			slog.Warn("could not parse page", "query", q.Encode(), "err", err)
+			continue
+		}
+
+		doc.Find(s.article).Each(func(_ int, article *goquery.Selection) {
+			html, err := goquery.OuterHtml(article)
+			if err != nil || seenArticle[html] {
+				return
+			}
+			seenArticle[html] = true
+			entries = append(entries, InputEntry{HTML: html})
+		})
+		for _, id := range entryLinks(doc, crawl) {
+			if !seenID[id] {
+				seenID[id] = true
+				queue = append(queue, url.Values{"id": {id}})
+			}
+		}
+	}
+	if maxEntries > 0 && len(entries) > maxEntries {
+		entries = entries[:maxEntries]
+	}
+	return entries, nil
+}
+
+// runScrape builds saol_entries.json from svenska.se. Articles scraped with
+// -dictionary so are flattened the same way; flatten detects their layout.
+func runScrape(args []string) {
+	flags := flag.NewFlagSet("scrape", flag.ExitOnError)
+	wordsFile := flags.String("words", "", "wordlist to look up, one word per line (required)")
+	out := flags.String("out", inputFile, "where to write the scraped articles")
+	cacheDir := flags.String("cache", ".saol-cache", "directory responses are cached in; rerunning with the same cache resumes")
+	delay := flags.Duration("delay", time.Second, "minimum time between requests")
+	crawl := flags.Bool("crawl", false, "also follow links from the scraped articles to other entries")
+	maxEntries := flags.Int("max", 0, "stop after this many articles (0 for no limit)")
+	dictionary := flags.String("dictionary", "saol", "dictionary to scrape: saol or so (Svensk ordbok)")
+	base := flags.String("base", "", "search endpoint (default svenska.se's for -dictionary)")
+	flags.Parse(args)
+
+	profile, err := profileFor(*dictionary)
+	if err != nil {
+		fatal("unknown -dictionary", "err", err)
+	}
+	if *base == "" {
+		*base = scrapeSite + *dictionary + ".php"
+	}
+
+	if *wordsFile == "" {
+		fatal("scrape needs -words")
+	}
+	words, err := readHeadwordList(*wordsFile)
+	if err != nil {
+		fatal("could not read wordlist", "file", *wordsFile, "err", err)
+	}
+
+	s := &scraper{client: &http.Client{Timeout: 30 * time.Second}, base: *base, cacheDir: *cacheDir, article: profile.Article, delay: *delay}
+	entries, err := s.scrapeEntries(words, *crawl, *maxEntries)
+	if err != nil {
+		// Keep what was scraped; the cache makes the rerun cheap.
+		slog.Error("scraping stopped early", "err", err)
+	}
+
+	data, merr := json.MarshalIndent(entries, "", "  ")
+	if merr != nil {
+		fatal("could not encode articles", "err", merr)
+	}
+	if werr := ioutil.WriteFile(*out, data, 0644); werr != nil {
+		fatal("could not save articles", "file", *out, "err", werr)
+	}
+	slog.Info("wrote articles", "articles", len(entries), "file", *out, "fetched", s.fetched, "cache", *cacheDir)
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScrapeEntries(t *testing.T) {
+	var requests, throttled int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("User-Agent") != scrapeUserAgent {
+			t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		q := r.URL.Query()
+		switch {
+		case q.Get("sok") == "val":
+			// Ambiguous search: a hit list instead of an article.
+			fmt.Fprint(w, `<a class="slank" href="/tri/f_saol.php?id=1">val</a><a class="slank" href="/tri/f_saol.php?id=2">val</a>`)
+		case q.Get("sok") == "bil":
+			if atomic.AddInt32(&throttled, 1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `<div class="article"><div class="lemma">bil, se <a href="/tri/f_saol.php?id=3">bilen</a></div></div>`)
+		case q.Get("id") != "":
+			fmt.Fprintf(w, `<div class="article"><div class="lemma">val %s</div></div>`, q.Get("id"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cache := t.TempDir()
+	s := &scraper{client: srv.Client(), base: srv.URL + "/tri/f_saol.php", cacheDir: cache, article: saolProfile.Article}
+	entries, err := s.scrapeEntries([]string{"val", "bil", "val"}, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d articles, want val 1, val 2 and bil: %v", len(entries), entries)
+	}
+	if n := atomic.LoadInt32(&requests); n != 5 {
+		t.Errorf("%d requests, want 5 (one of them retried)", n)
+	}
+
+	// A second run is served from the cache, and -crawl follows the link in bil.
+	s = &scraper{client: srv.Client(), base: srv.URL + "/tri/f_saol.php", cacheDir: cache, article: saolProfile.Article}
+	entries, err = s.scrapeEntries([]string{"val", "bil"}, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || s.fetched != 1 {
+		t.Errorf("crawl: got %d articles with %d fetched, want 4 with 1", len(entries), s.fetched)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The fields search looks in.
+const (
+	searchHeadword   = "headword"
+	searchForm       = "form"
+	searchDefinition = "definition"
+)
+
+var searchFields = []string{searchHeadword, searchForm, searchDefinition}
+
+// searchHit is a lemma search matched and what in it matched.
+type searchHit struct {
+	ID       string        `json:"id"`
+	Headword string        `json:"headword"`
+	Class    string        `json:"class"`
+	Matches  []searchMatch `json:"matches"`
+}
+
+// searchMatch is a headword, form or definition a search matched.
+type searchMatch struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+}
+
+// lemmaSearch matches lemmas against a pattern. A headword or form must
+// match as a whole, so kn.*sätta finds knäsätta but not knäsättande; a
+// definition matches if any of it does.
+type lemmaSearch struct {
+	word       *regexp.Regexp
+	definition *regexp.Regexp
+	fields     map[string]bool
+	classes    map[string]bool
+}
+
+// newLemmaSearch compiles a regular expression, or a glob with * and ?
+// when glob is set, to look for in fields (all of them when empty) of the
+// lemmas of classes (any when empty).
+func newLemmaSearch(pattern string, glob, foldCase bool, fields, classes []string) (*lemmaSearch, error) {
+	if pattern == "" {
+		return nil, errors.New("empty pattern")
+	}
+	if glob {
+		pattern = globRegexp(pattern)
+	}
+	if foldCase {
+		pattern = "(?i)" + pattern
+	}
+	definition, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s := &lemmaSearch{
+		word:       regexp.MustCompile("^(?:" + pattern + ")$"),
+		definition: definition,
+		fields:     make(map[string]bool),
+		classes:    make(map[string]bool),
+	}
+	if len(fields) == 0 {
+		fields = searchFields
+	}
+	for _, f := range fields {
+		if !isSearchField(f) {
+			return nil, fmt.Errorf("unknown field %q, want %s", f, strings.Join(searchFields, ", "))
+		}
+		s.fields[f] = true
+	}
+	for _, c := range classes {
+		s.classes[c] = true
+	}
+	return s, nil
+}
+
+func isSearchField(field string) bool {
+	for _, f := range searchFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp turns a glob into a regular expression: * is any run of
+// letters, ? any one letter and [...] a letter class; everything else
+// stands for itself.
+func globRegexp(glob string) string {
+	var re strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			re.WriteString(".*")
+		case '?':
+			re.WriteString(".")
+		case '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end > 0 {
+				class := glob[i+1 : i+1+end]
+				if class[0] == '!' {
+					class = "^" + class[1:]
+				}
+				re.WriteString("[" + class + "]")
+				i += end + 1
+				continue
+			}
+			re.WriteString(`\[`)
+		default:
+			n := strings.IndexAny(glob[i:], "*?[")
+			if n < 0 {
+				n = len(glob) - i
+			}
+			re.WriteString(regexp.QuoteMeta(glob[i : i+n]))
+			i += n - 1
+		}
+	}
+	return re.String()
+}
+
+// match returns what of e the search matches, headword first, then the
+// forms in alphabetical order, then the definition.
+func (s *lemmaSearch) match(e LexiconEntry) (searchHit, bool) {
+	if len(s.classes) > 0 && !s.classes[e.Class] {
+		return searchHit{}, false
+	}
+	hit := searchHit{ID: e.ID, Headword: e.Headword, Class: e.Class}
+	if s.fields[searchHeadword] && s.word.MatchString(e.Headword) {
+		hit.Matches = append(hit.Matches, searchMatch{searchHeadword, e.Headword})
+	}
+	if s.fields[searchForm] {
+		forms := e.surfaceForms()[1:]
+		sort.Strings(forms)
+		for _, f := range forms {
+			if s.word.MatchString(f) {
+				hit.Matches = append(hit.Matches, searchMatch{searchForm, f})
+			}
+		}
+	}
+	if s.fields[searchDefinition] && e.Definition != "" && s.definition.MatchString(e.Definition) {
+		hit.Matches = append(hit.Matches, searchMatch{searchDefinition, e.Definition})
+	}
+	return hit, len(hit.Matches) > 0
+}
+
+// writeSearchHits writes a JSON line to w for each of entries s matches,
+// as it finds it, at most limit of them (all when limit is 0), and returns
+// how many it wrote.
+func writeSearchHits(w io.Writer, entries []LexiconEntry, s *lemmaSearch, limit int) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	n := 0
+	for _, e := range entries {
+		if limit > 0 && n == limit {
+			break
+		}
+		hit, ok := s.match(e)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(hit); err != nil {
+			return n, err
+		}
+		n++
+		if bw.Buffered() > 32<<10 {
+			if err := bw.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, bw.Flush()
+}
+
+// runSearch streams the lemmas whose headword, forms or definition match a
+// pattern as NDJSON.
+func runSearch(args []string) {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to search")
+	pattern := flags.String("pattern", "", "regular expression a headword or form matches as a whole, e.g. 'kn.*sätta'")
+	glob := flags.String("glob", "", "wildcard pattern instead of -pattern: * any letters, ? one letter, [abc] one of them")
+	fields := flags.String("field", "", "only search these fields, comma separated: headword, form, definition")
+	classes := flags.String("class", "", "only search these word classes, comma separated, e.g. substantiv,verb")
+	where := flags.String("where", "", `only search the lemmas matching this filter, e.g. 'class=verb AND frequencyBand<=2'`)
+	foldCase := flags.Bool("i", false, "ignore case")
+	limit := flags.Int("limit", 0, "stop after this many lemmas (0 for all)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool search [-in file] (-pattern re | -glob pattern) [-field f,...] [-class c,...] [-where expr] [-i] [-limit n]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if (*pattern == "") == (*glob == "") || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	var fieldList, classList []string
+	if *fields != "" {
+		fieldList = strings.Split(*fields, ",")
+	}
+	if *classes != "" {
+		classList = strings.Split(*classes, ",")
+	}
+	s, err := newLemmaSearch(*pattern+*glob, *glob != "", *foldCase, fieldList, classList)
+	if err != nil {
+		fatal("invalid search", "err", err)
+	}
+	var filter whereExpr
+	if *where != "" {
+		if filter, err = parseWhere(*where); err != nil {
+			fatal("invalid -where", "err", err)
+		}
+	}
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	if filter != nil {
+		entries = filterWhere(entries, filter)
+	}
+	n, err := writeSearchHits(os.Stdout, entries, s, *limit)
+	if err != nil {
+		fatal("could not write search results", "err", err)
+	}
+	slog.Info("searched lexicon", "lemmas", len(entries), "matched", n)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct{ glob, want string }{
+		{"bil*", "bil.*"},
+		{"b?l", "b.l"},
+		{"[bs]il", "[bs]il"},
+		{"[!b]il", "[^b]il"},
+		{"a.b[", `a\.b\[`},
+	}
+	for _, tt := range tests {
+		if got := globRegexp(tt.glob); got != tt.want {
+			t.Errorf("globRegexp(%q) = %q, want %q", tt.glob, got, tt.want)
+		}
+	}
+}
+
+func searchEntries() []LexiconEntry {
+	return []LexiconEntry{
+		{ID: "knäsätta", Headword: "knäsätta", Class: "verb", Forms: map[string][]Form{"Aktiv": {{Form: "knäsätter"}, {Form: "knäsatte"}}}},
+		{ID: "sätta", Headword: "sätta", Class: "verb", Definition: "placera något", Forms: map[string][]Form{"Aktiv": {{Form: "sätter"}}}},
+		{ID: "bil", Headword: "bil", Class: "substantiv", Definition: "motorfordon", Forms: map[string][]Form{"Nominativ": {{Form: "bilen"}, {Form: "bilar"}}}},
+	}
+}
+
+func TestLemmaSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		glob    bool
+		fold    bool
+		fields  []string
+		classes []string
+		want    map[string][]searchMatch
+	}{
+		{"regexp", "kn.*sätta", false, false, nil, nil, map[string][]searchMatch{
+			"knäsätta": {{searchHeadword, "knäsätta"}},
+		}},
+		{"forms whole", "kn.*", false, false, []string{searchForm}, nil, map[string][]searchMatch{
+			"knäsätta": {{searchForm, "knäsatte"}, {searchForm, "knäsätter"}},
+		}},
+		{"glob", "bil*", true, false, nil, nil, map[string][]searchMatch{
+			"bil": {{searchHeadword, "bil"}, {searchForm, "bilar"}, {searchForm, "bilen"}},
+		}},
+		{"class", "*ätt*", true, false, []string{searchHeadword}, []string{"substantiv"}, map[string][]searchMatch{}},
+		{"definition anywhere", "FORDON", false, true, []string{searchDefinition}, nil, map[string][]searchMatch{
+			"bil": {{searchDefinition, "motorfordon"}},
+		}},
+	}
+	for _, tt := range tests {
+		s, err := newLemmaSearch(tt.pattern, tt.glob, tt.fold, tt.fields, tt.classes)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := make(map[string][]searchMatch)
+		for _, e := range searchEntries() {
+			if hit, ok := s.match(e); ok {
+				got[hit.ID] = hit.Matches
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewLemmaSearchErrors(t *testing.T) {
+	if _, err := newLemmaSearch("(", false, false, nil, nil); err == nil {
+		t.Error("bad regexp: want an error")
+	}
+	if _, err := newLemmaSearch("bil", false, false, []string{"gender"}, nil); err == nil {
+		t.Error("unknown field: want an error")
+	}
+	if _, err := newLemmaSearch("", false, false, nil, nil); err == nil {
+		t.Error("empty pattern: want an error")
+	}
+}
+
+func TestWriteSearchHits(t *testing.T) {
+	s, err := newLemmaSearch(".*", false, false, []string{searchHeadword}, []string{"verb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := writeSearchHits(&buf, searchEntries(), s, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"knäsätta","headword":"knäsätta","class":"verb","matches":[{"field":"headword","text":"knäsätta"}]}` + "\n"
+	if n != 1 || buf.String() != want {
+		t.Errorf("wrote %d lines %q, want 1 line %q", n, buf.String(), want)
+	}
+	buf.Reset()
+	if n, _ := writeSearchHits(&buf, searchEntries(), s, 0); n != 2 || strings.Count(buf.String(), "\n") != 2 {
+		t.Errorf("wrote %d lines %q, want 2", n, buf.String())
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// SectionLabel is one section of a word class's inflection table: the
+// name forms are filed under, other headings the site has used for it,
+// and an English name for display.
+type SectionLabel struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	English string   `json:"english,omitempty"`
+}
+
+// SectionConfig is the section labels of each word class, in table order,
+// and English translations of the words form labels are made of. The
+// sections of verbs and adjectives are a closed set: forms under any other
+// heading are left out of verbs.json and adjectives.json.
+type SectionConfig struct {
+	Sections map[string][]SectionLabel `json:"sections"`
+	Labels   map[string]string         `json:"labels,omitempty"`
+}
+
+// sectionConfig is in use, the built-in labels with any loaded with
+// loadSectionConfig on top.
+var sectionConfig = SectionConfig{
+	Sections: map[string][]SectionLabel{
+		"substantiv": {
+			{Name: "Nominativ", English: "nominative"},
+			{Name: "Genitiv", English: "genitive"},
+		},
+		"verb": {
+			{Name: "Finita former", English: "finite forms"},
+			{Name: "Infinita former", English: "non-finite forms"},
+			{Name: "Presens particip", English: "present participle"},
+			{Name: "Perfekt particip", English: "past participle"},
+		},
+		"adjektiv": {
+			{Name: "Positiv", English: "positive"},
+			{Name: "Komparativ", English: "comparative"},
+			{Name: "Superlativ", English: "superlative"},
+		},
+		"pronomen": {
+			{Name: "Subjektsform", English: "subject form"},
+			{Name: "Objektsform", English: "object form"},
+			{Name: "Possessiv", English: "possessive"},
+		},
+		"räkneord": {
+			{Name: "Grundtal", English: "cardinal"},
+			{Name: "Ordningstal", English: "ordinal"},
+		},
+		"adverb": {
+			{Name: "Positiv", English: "positive"},
+			{Name: "Komparativ", English: "comparative"},
+			{Name: "Superlativ", English: "superlative"},
+		},
+	},
+	Labels: map[string]string{
+		"singular": "singular", "plural": "plural",
+		"obestämd": "indefinite", "bestämd": "definite",
+		"utrum": "common", "neutrum": "neuter", "maskulinum": "masculine",
+		"presens": "present", "preteritum": "past", "imperativ": "imperative",
+		"infinitiv": "infinitive", "supinum": "supine",
+		"aktiv": "active", "passiv": "passive",
+		"flera": "plural", "en": "common singular", "ett": "neuter singular",
+		"den": "definite", "det": "definite", "de": "definite plural", "den/det/de": "definite",
+	},
+}
+
+// closedSectionClasses are the classes whose writers drop unknown sections.
+var closedSectionClasses = map[string]bool{"verb": true, "adjektiv": true}
+
+// loadSectionConfig reads a SectionConfig from filename. The sections it
+// gives for a class replace the built-in ones; its labels are added.
+func loadSectionConfig(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var config SectionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("error decoding section labels from '%s': %w", filename, err)
+	}
+	for class, labels := range config.Sections {
+		if err := validateSections(class, labels); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	for class, labels := range config.Sections {
+		sectionConfig.Sections[class] = labels
+	}
+	for word, english := range config.Labels {
+		sectionConfig.Labels[word] = english
+	}
+	return nil
+}
+
+// validateSections checks that every section of class has a name and that
+// no name or alias stands for two sections.
+func validateSections(class string, labels []SectionLabel) error {
+	seen := make(map[string]string)
+	for _, l := range labels {
+		if l.Name == "" {
+			return fmt.Errorf("section of %s without a name", class)
+		}
+		for _, name := range append([]string{l.Name}, l.Aliases...) {
+			if other, ok := seen[name]; ok && other != l.Name {
+				return fmt.Errorf("%s: %q is both %s and %s", class, name, other, l.Name)
+			}
+			seen[name] = l.Name
+		}
+	}
+	return nil
+}
+
+// findSection returns the configured section of class that heading names
+// or is an alias of.
+func findSection(class, heading string) (SectionLabel, bool) {
+	for _, l := range sectionConfig.Sections[class] {
+		if l.Name == heading {
+			return l, true
+		}
+		for _, a := range l.Aliases {
+			if a == heading {
+				return l, true
+			}
+		}
+	}
+	return SectionLabel{}, false
+}
+
+// canonicalSection returns the name forms under heading are filed under in
+// class, and whether the section is one the class is configured with.
+func canonicalSection(class, heading string) (string, bool) {
+	if l, ok := findSection(class, heading); ok {
+		return l.Name, true
+	}
+	return heading, false
+}
+
+// knownSection is canonicalSection for the writers of the classes with a
+// closed set of sections. A heading it does not know is logged, once.
+func knownSection(class, heading string) (string, bool) {
+	name, ok := canonicalSection(class, heading)
+	if !ok && closedSectionClasses[class] {
+		if _, seen := unknownSections.LoadOrStore(class+"\x00"+heading, true); !seen {
+			slog.Warn("leaving out forms under an unknown section; add it to a -sections file", "class", class, "section", heading)
+		}
+	}
+	return name, ok
+}
+
+var unknownSections sync.Map
+
+// emptySections returns the configured sections of class, each without
+// forms yet, for the writers that list every section.
+func emptySections(class string) map[string][]Form {
+	forms := make(map[string][]Form)
+	for _, l := range sectionConfig.Sections[class] {
+		forms[l.Name] = []Form{}
+	}
+	return forms
+}
+
+// englishSection returns the English name of a section of class, or the
+// section itself when it has none.
+func englishSection(class, section string) string {
+	if l, ok := findSection(class, section); ok && l.English != "" {
+		return l.English
+	}
+	return section
+}
+
+// englishLabel translates a form label word by word, keeping the words
+// without a translation.
+func englishLabel(label string) string {
+	words := strings.Fields(label)
+	for i, w := range words {
+		if english, ok := sectionConfig.Labels[w]; ok {
+			words[i] = english
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// englishEntry returns a copy of e with its sections and form labels in
+// English.
+func englishEntry(e LexiconEntry) LexiconEntry {
+	out := e
+	out.Forms = make(map[string][]Form, len(e.Forms))
+	for s, forms := range e.Forms {
+		english := englishSection(e.Class, s)
+		for _, f := range forms {
+			f.Label = englishLabel(f.Label)
+			out.Forms[english] = append(out.Forms[english], f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withSectionConfig restores the built-in section labels after a test
+// loads its own.
+func withSectionConfig(t *testing.T) {
+	t.Helper()
+	saved := SectionConfig{Sections: make(map[string][]SectionLabel), Labels: make(map[string]string)}
+	for k, v := range sectionConfig.Sections {
+		saved.Sections[k] = v
+	}
+	for k, v := range sectionConfig.Labels {
+		saved.Labels[k] = v
+	}
+	t.Cleanup(func() { sectionConfig = saved })
+}
+
+func TestLoadSectionConfig(t *testing.T) {
+	withSectionConfig(t)
+	file := filepath.Join(t.TempDir(), "sections.json")
+	os.WriteFile(file, []byte(`{
+		"sections": {"adjektiv": [
+			{"name": "Positiv", "aliases": ["Grundform"], "english": "positive"},
+			{"name": "Komparativ"},
+			{"name": "Superlativ"},
+			{"name": "Elativ", "english": "elative"}
+		]},
+		"labels": {"ett": "neuter"}
+	}`), 0644)
+	if err := loadSectionConfig(file); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	raw := []string{"fin-en-Grundform", "finare-Komparativ", "finast-Elativ", "finaste-Okänd"}
+	if err := WriteAdjectivesJSON(&buf, [][]string{raw}, false); err != nil {
+		t.Fatal(err)
+	}
+	var entries []AdjectiveEntry
+	decodeEntries(t, []byte(buf.String()), &entries)
+	got := make(map[string]int)
+	for s, forms := range entries[0].Forms {
+		got[s] = len(forms)
+	}
+	want := map[string]int{"Positiv": 1, "Komparativ": 1, "Superlativ": 0, "Elativ": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("adjective sections = %v, want %v", got, want)
+	}
+
+	if name, ok := canonicalSection("verb", "Finita former"); !ok || name != "Finita former" {
+		t.Errorf("verb sections changed by an adjektiv config: %q, %v", name, ok)
+	}
+	if got := englishLabel("ett"); got != "neuter" {
+		t.Errorf("englishLabel(ett) = %q, want the loaded neuter", got)
+	}
+}
+
+func TestLoadSectionConfigErrors(t *testing.T) {
+	withSectionConfig(t)
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"syntax":    `{"sections": `,
+		"unnamed":   `{"sections": {"verb": [{"english": "finite"}]}}`,
+		"ambiguous": `{"sections": {"verb": [{"name": "A", "aliases": ["X"]}, {"name": "B", "aliases": ["X"]}]}}`,
+	} {
+		file := filepath.Join(dir, name+".json")
+		os.WriteFile(file, []byte(data), 0644)
+		if err := loadSectionConfig(file); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+	if _, ok := canonicalSection("verb", "A"); ok {
+		t.Error("a rejected config was applied")
+	}
+}
+
+func TestEnglishEntry(t *testing.T) {
+	e := englishEntry(LexiconEntry{Class: "verb", Forms: map[string][]Form{
+		"Perfekt particip": {{Form: "skriven", Label: "en"}},
+		"Finita former":    {{Form: "skriver", Label: "presens aktiv"}},
+	}})
+	if got, want := e.sections(), []string{"finite forms", "past participle"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+	if got := e.Forms["finite forms"][0].Label; got != "present active" {
+		t.Errorf("label = %q, want present active", got)
+	}
+}
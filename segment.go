@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// linkingMorphemes are the fogemorfem a Swedish compound may join its
+// parts with: järnväg+s+station, gäst+a+bud, kyrk+o+gård.
+var linkingMorphemes = []string{"s", "e", "a", "o", "u"}
+
+// truncatedVowels are the final vowels a first part may drop: flick(a)+vän,
+// skol(a)+bok, läs(a)+bok.
+var truncatedVowels = []string{"a", "e"}
+
+// minPartLength is the shortest lemma segment cuts a compound into, in
+// letters, short enough for ko and bo but not for every one-letter word.
+const minPartLength = 2
+
+// compoundPart is one part of a segmented compound: a lemma, as Form
+// appears in the compound, or a linking morpheme between two of them.
+type compoundPart struct {
+	Form    string `json:"form"`
+	Lemma   string `json:"lemma,omitempty"`
+	Linking bool   `json:"linking,omitempty"`
+}
+
+// compoundSegmentation is one way to read a compound.
+type compoundSegmentation struct {
+	Parts []compoundPart `json:"parts"`
+}
+
+func (s compoundSegmentation) String() string {
+	forms := make([]string, len(s.Parts))
+	for i, p := range s.Parts {
+		forms[i] = p.Form
+	}
+	return strings.Join(forms, "+")
+}
+
+// cost ranks segmentations, lowest first: fewer lemmas, then fewer linking
+// morphemes, then fewer truncated parts.
+func (s compoundSegmentation) cost() int {
+	lemmas, links, truncated := 0, 0, 0
+	for _, p := range s.Parts {
+		switch {
+		case p.Linking:
+			links++
+		case p.Form != p.Lemma && strings.HasPrefix(p.Lemma, p.Form) && len(p.Lemma) == len(p.Form)+1:
+			truncated++
+			lemmas++
+		default:
+			lemmas++
+		}
+	}
+	return lemmas*100 + links*10 + truncated
+}
+
+// compoundSegmenter splits compounds into the lemmas of a lexicon. Every
+// part but the last is a headword, possibly without its final vowel and
+// followed by a linking morpheme; the last is any form of a lemma, so an
+// inflected compound splits too (järnvägsstationen).
+type compoundSegmenter struct {
+	headwords map[string]bool
+	forms     map[string]string // surface form -> its lemma's headword
+}
+
+// newCompoundSegmenter indexes the headwords and forms of entries.
+func newCompoundSegmenter(entries []LexiconEntry) *compoundSegmenter {
+	s := &compoundSegmenter{headwords: make(map[string]bool), forms: make(map[string]string)}
+	for _, e := range entries {
+		headword := strings.ToLower(e.Headword)
+		if strings.ContainsAny(headword, " -") {
+			continue
+		}
+		s.headwords[headword] = true
+		for _, f := range e.surfaceForms() {
+			f = strings.ToLower(f)
+			if _, ok := s.forms[f]; !ok {
+				s.forms[f] = headword
+			}
+		}
+	}
+	return s
+}
+
+// segment returns the segmentations of word into two or more lemmas, best
+// first, at most max of them (all when max is 0).
+func (s *compoundSegmenter) segment(word string, max int) []compoundSegmentation {
+	word = strings.ToLower(word)
+	memo := make(map[int][][]compoundPart)
+	var tails func(i int) [][]compoundPart
+	tails = func(i int) [][]compoundPart {
+		if parts, ok := memo[i]; ok {
+			return parts
+		}
+		var out [][]compoundPart
+		rest := word[i:]
+		if lemma, ok := s.forms[rest]; ok && i > 0 {
+			out = append(out, []compoundPart{{Form: rest, Lemma: lemma}})
+		}
+		for j := i + 1; j < len(word); j++ {
+			form := word[i:j]
+			if len([]rune(form)) < minPartLength {
+				continue
+			}
+			for _, lemma := range s.firstPartLemmas(form) {
+				head := compoundPart{Form: form, Lemma: lemma}
+				for _, tail := range tails(j) {
+					out = append(out, append([]compoundPart{head}, tail...))
+				}
+				for _, link := range linkingMorphemes {
+					if !strings.HasPrefix(word[j:], link) || j+len(link) >= len(word) {
+						continue
+					}
+					for _, tail := range tails(j + len(link)) {
+						out = append(out, append([]compoundPart{head, {Form: link, Linking: true}}, tail...))
+					}
+				}
+			}
+		}
+		memo[i] = out
+		return out
+	}
+
+	var all []compoundSegmentation
+	for _, parts := range tails(0) {
+		all = append(all, compoundSegmentation{Parts: parts})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].cost() < all[j].cost() })
+	if max > 0 && len(all) > max {
+		all = all[:max]
+	}
+	return all
+}
+
+// firstPartLemmas returns the headwords form can stand for before another
+// part: itself, or a headword it is missing the final vowel of.
+func (s *compoundSegmenter) firstPartLemmas(form string) []string {
+	var lemmas []string
+	if s.headwords[form] {
+		lemmas = append(lemmas, form)
+	}
+	for _, v := range truncatedVowels {
+		if s.headwords[form+v] {
+			lemmas = append(lemmas, form+v)
+		}
+	}
+	return lemmas
+}
+
+// segmentResult is the output of segment for one word.
+type segmentResult struct {
+	Word          string                 `json:"word"`
+	Segmentations []compoundSegmentation `json:"segmentations"`
+}
+
+// runSegment splits the words given as arguments, or listed in -words,
+// into the lemmas of a lexicon.
+func runSegment(args []string) {
+	flags := flag.NewFlagSet("segment", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "lemma inventory: flattened lemmas or an enriched lexicon.json")
+	n := flags.Int("n", 3, "segmentations to list per word, best first (0 for all)")
+	format := flags.String("format", "text", "output format: text, or json")
+	wordsFile := flags.String("words", "", "file of words to split, one per line, instead of the arguments")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool segment [-in file] [-n 3] [-format text|json] -words file | word...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	words := flags.Args()
+	if *wordsFile != "" {
+		var err error
+		if words, err = readHeadwordList(*wordsFile); err != nil {
+			fatal("could not read words", "file", *wordsFile, "err", err)
+		}
+	}
+	if len(words) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	s := newCompoundSegmenter(entries)
+	slog.Info("indexed lemmas", "headwords", len(s.headwords), "forms", len(s.forms))
+
+	results := make([]segmentResult, 0, len(words))
+	for _, w := range words {
+		segs := s.segment(w, *n)
+		if segs == nil {
+			segs = []compoundSegmentation{}
+		}
+		results = append(results, segmentResult{Word: w, Segmentations: segs})
+	}
+
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, results)
+	} else {
+		err = writeSegmentText(os.Stdout, results)
+	}
+	if err != nil {
+		fatal("could not write segmentations", "err", err)
+	}
+}
+
+// writeSegmentText writes a line per word, the word and its segmentations
+// separated by tabs.
+func writeSegmentText(w io.Writer, results []segmentResult) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range results {
+		fields := []string{r.Word}
+		for _, s := range r.Segmentations {
+			fields = append(fields, s.String())
+		}
+		fmt.Fprintln(bw, strings.Join(fields, "\t"))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSegmentCompounds(t *testing.T) {
+	entries := []LexiconEntry{
+		{Headword: "järn", Class: "substantiv"},
+		{Headword: "väg", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "vägen"}}}},
+		{Headword: "järnväg", Class: "substantiv"},
+		{Headword: "station", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "stationen"}}}},
+		{Headword: "flicka", Class: "substantiv"},
+		{Headword: "vän", Class: "substantiv"},
+		{Headword: "kyrka", Class: "substantiv"},
+		{Headword: "gård", Class: "substantiv"},
+		{Headword: "på", Class: "preposition"},
+	}
+	s := newCompoundSegmenter(entries)
+
+	tests := []struct {
+		word string
+		max  int
+		want []string
+	}{
+		{"järnvägsstation", 0, []string{"järnväg+s+station", "järn+väg+s+station"}},
+		{"Järnvägsstationen", 1, []string{"järnväg+s+stationen"}},
+		{"flickvän", 0, []string{"flick+vän"}},
+		{"kyrkogård", 0, []string{"kyrk+o+gård"}},
+		{"järnväg", 0, []string{"järn+väg"}},
+		{"station", 0, nil},
+		{"bilväg", 0, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, seg := range s.segment(tt.word, tt.max) {
+			got = append(got, seg.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("segment(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+
+	seg := s.segment("flickvän", 1)[0]
+	want := []compoundPart{{Form: "flick", Lemma: "flicka"}, {Form: "vän", Lemma: "vän"}}
+	if !reflect.DeepEqual(seg.Parts, want) {
+		t.Errorf("flickvän parts %+v, want %+v", seg.Parts, want)
+	}
+	seg = s.segment("järnvägsstationen", 1)[0]
+	if link, last := seg.Parts[1], seg.Parts[2]; !link.Linking || last.Lemma != "station" {
+		t.Errorf("järnvägsstationen parts %+v", seg.Parts)
+	}
+}
+
+func TestWriteSegmentText(t *testing.T) {
+	var buf bytes.Buffer
+	writeSegmentText(&buf, []segmentResult{
+		{Word: "flickvän", Segmentations: []compoundSegmentation{{Parts: []compoundPart{{Form: "flick"}, {Form: "vän"}}}}},
+		{Word: "bil", Segmentations: []compoundSegmentation{}},
+	})
+	if got, want := buf.String(), "flickvän\tflick+vän\nbil\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Sense is one meaning of a lemma: its definition, the usage examples the
+// dictionary gives for it, its usage labels, such as "vardagligt", and the
+// subject fields, such as "medicin", it belongs to.
+type Sense struct {
+	Definition string   `json:"definition,omitempty"`
+	Examples   []string `json:"examples,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+	Domains    []string `json:"domains,omitempty"`
+}
+
+// senses returns the senses of a lemma, found with the profile's sense
+// selector. A lemma without sense blocks has a single sense made of its
+// first definition and every example and label, or none if it has
+// neither definition nor examples.
+func (p SelectorProfile) senses(lemma *goquery.Selection) []Sense {
+	var senses []Sense
+	if p.Sense != "" {
+		lemma.Find(p.Sense).Each(func(_ int, s *goquery.Selection) {
+			senses = append(senses, p.sense(s))
+		})
+	}
+	if len(senses) > 0 {
+		return senses
+	}
+	if sense := p.sense(lemma); sense.Definition != "" || len(sense.Examples) > 0 {
+		return []Sense{sense}
+	}
+	return nil
+}
+
+// sense reads the definition and examples inside s.
+func (p SelectorProfile) sense(s *goquery.Selection) Sense {
+	var sense Sense
+	if p.Definition != "" {
+		sense.Definition = cellText(s.Find(p.Definition).First())
+	}
+	if p.Example != "" {
+		s.Find(p.Example).Each(func(_ int, ex *goquery.Selection) {
+			if text := cellText(ex); text != "" {
+				sense.Examples = append(sense.Examples, text)
+			}
+		})
+	}
+	sense.Labels = p.usageLabelsIn(s)
+	sense.Domains = p.domainsIn(s)
+	return sense
+}
+
+// SenseRecord is one sense of a lemma as a record of its own, for
+// consumers that work sense by sense, such as flashcards or word sense
+// disambiguation data: the sense with its 1-based SenseIndex, the lemma
+// it belongs to and the inflection all senses of the lemma share.
+type SenseRecord struct {
+	ID         string            `json:"id"`
+	LemmaID    string            `json:"lemmaID"`
+	SenseIndex int               `json:"senseIndex"`
+	Senses     int               `json:"senses"`
+	Headword   string            `json:"headword"`
+	Homograph  int               `json:"homograph,omitempty"`
+	Class      string            `json:"class"`
+	FamilyID   int               `json:"familyID"`
+	Definition string            `json:"definition,omitempty"`
+	Examples   []string          `json:"examples,omitempty"`
+	Labels     []string          `json:"labels,omitempty"`
+	Domains    []string          `json:"domains,omitempty"`
+	Forms      map[string][]Form `json:"forms"`
+}
+
+// senseRecords splits entries into a record per sense, IDs "bil#2" for
+// the second sense of bil. Entries without senses get one record with
+// their definition, labels and domains, so every lemma is kept.
+func senseRecords(entries []LexiconEntry) []SenseRecord {
+	records := []SenseRecord{}
+	for _, e := range entries {
+		senses := e.Senses
+		if len(senses) == 0 {
+			senses = []Sense{{Definition: e.Definition, Labels: e.Labels, Domains: e.Domains}}
+		}
+		for i, s := range senses {
+			records = append(records, SenseRecord{
+				ID:         e.ID + "#" + strconv.Itoa(i+1),
+				LemmaID:    e.ID,
+				SenseIndex: i + 1,
+				Senses:     len(senses),
+				Headword:   e.Headword,
+				Homograph:  e.Homograph,
+				Class:      e.Class,
+				FamilyID:   e.FamilyID,
+				Definition: s.Definition,
+				Examples:   s.Examples,
+				Labels:     s.Labels,
+				Domains:    s.Domains,
+				Forms:      e.Forms,
+			})
+		}
+	}
+	return records
+}
+
+// writeSenses writes a record per sense of entries to filename as
+// versioned JSON.
+func writeSenses(entries []LexiconEntry, filename string) error {
+	return saveFile(filename, func(w io.Writer) error { return writeVersionedJSON(w, senseRecords(entries)) })
+}
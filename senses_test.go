@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSenses(t *testing.T) {
+	tests := []struct {
+		html string
+		want []Sense
+	}{
+		{`<span class="def">stort havslevande däggdjur</span>`, []Sense{{Definition: "stort havslevande däggdjur"}}},
+		{`<span class="grundform">bil</span>`, nil},
+		{`<div class="kbetydelse"><span class="def">motordrivet fordon</span><span class="syntex">köra bil</span><span class="syntex">bilen startar</span></div>` +
+			`<div class="kbetydelse"><span class="def">bil i ett spel</span></div>`,
+			[]Sense{{Definition: "motordrivet fordon", Examples: []string{"köra bil", "bilen startar"}}, {Definition: "bil i ett spel"}}},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := selectorProfiles["so"].senses(doc.Selection); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("senses(%s) = %+v, want %+v", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSenses(t *testing.T) {
+	forms := map[string][]Form{"Nominativ": {{Form: "bil", Label: "en"}}}
+	entries := []LexiconEntry{
+		{ID: "bil", FamilyID: 1, Headword: "bil", Class: "substantiv", Forms: forms,
+			Senses: []Sense{{Definition: "motordrivet fordon", Examples: []string{"köra bil"}}, {Definition: "bil i ett spel"}}},
+		{ID: "på", FamilyID: 2, Headword: "på", Class: "preposition", Definition: "anger läge", Forms: map[string][]Form{}},
+	}
+	filename := filepath.Join(t.TempDir(), "senses.json")
+	if err := writeSenses(entries, filename); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValid(t, filename, data)
+
+	var got []SenseRecord
+	decodeEntries(t, data, &got)
+	if len(got) != 3 {
+		t.Fatalf("got %d records, want 3", len(got))
+	}
+	if r := got[1]; r.ID != "bil#2" || r.LemmaID != "bil" || r.SenseIndex != 2 || r.Senses != 2 || r.Definition != "bil i ett spel" || !reflect.DeepEqual(r.Forms, forms) {
+		t.Errorf("second sense of bil = %+v", r)
+	}
+	if r := got[2]; r.ID != "på#1" || r.Senses != 1 || r.Definition != "anger läge" {
+		t.Errorf("på = %+v", r)
+	}
+}
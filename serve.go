@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// exportPageSize is how many entries handleExport reads from the store
+// between flushes. Only one page is held in memory at a time, and a slow
+// client blocks the writes, so the store is never read ahead of it.
+const exportPageSize = 500
+
+// handleExport serves GET /export?class=verb&format=ndjson, streaming one
+// JSON lexicon entry per line. Without class every word class is exported.
+func handleExport(store Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		if format := query.Get("format"); format != "" && format != "ndjson" {
+			http.Error(w, "unsupported format, only ndjson is available", http.StatusBadRequest)
+			return
+		}
+
+		classes := lexiconClasses()
+		if class := query.Get("class"); class != "" {
+			classes = []string{class}
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher, _ := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+		exported := 0
+
+		for _, class := range classes {
+			for offset := 0; ; offset += exportPageSize {
+				if err := r.Context().Err(); err != nil {
+					slog.Warn("export aborted", "class", class, "exported", exported, "err", err)
+					return
+				}
+
+				page, err := store.ListByClass(class, offset, exportPageSize)
+				if err != nil {
+					// Headers are already out once anything was written, so
+					// the truncated stream is all the client will see.
+					slog.Error("could not read class for export", "class", class, "offset", offset, "err", err)
+					if exported == 0 {
+						http.Error(w, "error reading lexicon", http.StatusInternalServerError)
+					}
+					return
+				}
+
+				for _, entry := range page {
+					if err := encoder.Encode(entry); err != nil {
+						slog.Warn("export aborted", "exported", exported, "err", err)
+						return
+					}
+					exported++
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+				if len(page) < exportPageSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// newServeMux routes the REST API over store. Lemma and form lookups are
+// counted in stats, which may be nil.
+func newServeMux(store Store, stats *lookupStats) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /lemma/{word}", func(w http.ResponseWriter, r *http.Request) {
+		entries, err := store.GetLemma(r.PathValue("word"))
+		writeEntries(w, entries, err, stats)
+	})
+	mux.HandleFunc("GET /form/{form}", func(w http.ResponseWriter, r *http.Request) {
+		entries, err := store.SearchForm(r.PathValue("form"))
+		writeEntries(w, entries, err, stats)
+	})
+	mux.HandleFunc("GET /paradigm/{id}", func(w http.ResponseWriter, r *http.Request) {
+		entry, ok, err := store.GetByID(r.PathValue("id"))
+		if err != nil {
+			slog.Error("could not look up lemma", "id", r.PathValue("id"), "err", err)
+			http.Error(w, "error reading lexicon", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "no such lemma", http.StatusNotFound)
+			return
+		}
+		stats.Record(entry.Headword)
+		writeJSON(w, entry)
+	})
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		if q == "" {
+			http.Error(w, "q is required", http.StatusBadRequest)
+			return
+		}
+		limit := defaultSearchLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil || parsed < 1 || parsed > maxSearchLimit {
+				http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit), http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		entries, err := store.SearchPrefix(q, limit)
+		if err != nil {
+			slog.Error("could not search", "query", q, "err", err)
+			http.Error(w, "error reading lexicon", http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []LexiconEntry{}
+		}
+		writeJSON(w, entries)
+	})
+	mux.Handle("GET /export", handleExport(store))
+	mux.Handle("GET /generate/{lemma}", handleGenerate(store))
+	mux.HandleFunc("GET /stats/top", stats.handleTop)
+	return mux
+}
+
+// writeEntries answers a lookup: 404 when nothing matched, otherwise the
+// entries as a JSON array.
+func writeEntries(w http.ResponseWriter, entries []LexiconEntry, err error, stats *lookupStats) {
+	if err != nil {
+		slog.Error("could not read lexicon", "err", err)
+		http.Error(w, "error reading lexicon", http.StatusInternalServerError)
+		return
+	}
+	if len(entries) == 0 {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	for _, e := range entries {
+		stats.Record(e.Headword)
+	}
+	writeJSON(w, entries)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		slog.Warn("could not write response", "err", err)
+	}
+}
+
+// runServe loads the lexicon into the chosen store and serves the REST API
+// until interrupted.
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	storeKind := flags.String("store", "memory", "lexicon backend: memory, sqlite, postgres or index")
+	dsn := flags.String("dsn", "lexicon.db", "database for -store sqlite or postgres, index file for -store index")
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas to load (into an empty database for sqlite/postgres)")
+	statsFile := flags.String("stats", "", "file to persist lookup counts to; empty disables GET /stats/top")
+	grpcAddr := flags.String("grpc-addr", "", "also serve the gRPC Lexicon service (proto/lexicon.proto) on this address, e.g. :9090")
+	statsInterval := flags.Duration("stats-interval", time.Minute, "how often lookup counts are written to -stats")
+	ui := flags.Bool("ui", true, "serve the browsing UI on / (search, inflection tables, article families)")
+	flags.Parse(args)
+
+	store, err := openStore(*storeKind, *dsn, *in)
+	if err != nil {
+		fatal("could not open store", "store", *storeKind, "err", err)
+	}
+	defer store.Close()
+
+	var stats *lookupStats
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	if *statsFile != "" {
+		if stats, err = newLookupStats(*statsFile); err != nil {
+			fatal("could not load lookup stats", "err", err)
+		}
+		go func() {
+			stats.persistEvery(*statsInterval, stop)
+			close(done)
+		}()
+	} else {
+		close(done)
+	}
+
+	var grpcSrv *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			fatal("could not listen", "addr", *grpcAddr, "err", err)
+		}
+		grpcSrv = newGRPCServer(store)
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				slog.Error("gRPC server failed", "err", err)
+			}
+		}()
+		slog.Info("serving gRPC", "addr", *grpcAddr)
+	}
+
+	mux := newServeMux(store, stats)
+	if *ui {
+		addUIRoutes(mux, store)
+	}
+	srv := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if grpcSrv != nil {
+			grpcSrv.GracefulStop()
+		}
+		srv.Shutdown(ctx)
+	}()
+
+	slog.Info("serving the lexicon", "store", *storeKind, "addr", *addr, "ui", *ui)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fatal("server failed", "err", err)
+	}
+	close(stop)
+	<-done
+}
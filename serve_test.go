@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleExport(t *testing.T) {
+	handler := handleExport(newMemStore(fixtureEntries(t)))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/export?class=substantiv&format=ndjson", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	var headwords []string
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var entry LexiconEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		headwords = append(headwords, entry.Headword)
+	}
+	if len(headwords) != 4 || headwords[0] != "bil" || headwords[3] != "val" {
+		t.Errorf("exported %v, want bil, hus, man, val", headwords)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/export?format=csv", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("format=csv: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestServeMux(t *testing.T) {
+	mux := newServeMux(newMemStore(fixtureEntries(t)), nil)
+
+	tests := []struct {
+		path     string
+		code     int
+		headword string
+	}{
+		{"/lemma/man", http.StatusOK, "man"},
+		{"/lemma/nej", http.StatusNotFound, ""},
+		{"/form/bilarna", http.StatusOK, "bil"},
+		{"/paradigm/val_2", http.StatusOK, "val"},
+		{"/paradigm/val_9", http.StatusNotFound, ""},
+		{"/search?q=kn", http.StatusOK, "knäsätta"},
+		{"/search", http.StatusBadRequest, ""},
+		{"/search?q=b&limit=500", http.StatusBadRequest, ""},
+		{"/stats/top", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.code)
+			continue
+		}
+		if tt.headword == "" {
+			continue
+		}
+		var entries []LexiconEntry
+		body := rec.Body.Bytes()
+		if body[0] == '{' {
+			entries = make([]LexiconEntry, 1)
+			err := json.Unmarshal(body, &entries[0])
+			if err != nil {
+				t.Fatalf("%s: %v", tt.path, err)
+			}
+		} else if err := json.Unmarshal(body, &entries); err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if len(entries) == 0 || entries[0].Headword != tt.headword {
+			t.Errorf("%s: got %v, want %s", tt.path, entries, tt.headword)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// shardManifest is shard.json in every shard directory split writes: which
+// shard of how many it is and which articles of the full input it holds.
+type shardManifest struct {
+	Shard        int `json:"shard"`
+	Of           int `json:"of"`
+	FirstArticle int `json:"firstArticle"`
+	Articles     int `json:"articles"`
+}
+
+// shardDir is the directory of shard i under dir.
+func shardDir(dir string, i int) string {
+	return filepath.Join(dir, fmt.Sprintf("shard-%03d", i))
+}
+
+// runSplit partitions saol_entries.json into -n shards of consecutive
+// articles, each a directory with its own saol_entries.json and a
+// shard.json, so flatten and extract can run in each on another machine.
+func runSplit(args []string) {
+	flags := flag.NewFlagSet("split", flag.ExitOnError)
+	n := flags.Int("n", 2, "number of shards")
+	in := flags.String("in", inputFile, "articles to split")
+	dir := flags.String("dir", "shards", "directory to write the shard directories to")
+	flags.Parse(args)
+
+	if *n < 1 {
+		fatal("-n must be at least 1", "n", *n)
+	}
+	total, err := countArticles(*in)
+	if err != nil {
+		fatal("could not read articles", "file", *in, "err", err)
+	}
+	if err := splitArticles(*in, *dir, *n, total); err != nil {
+		fatal("could not split articles", "dir", *dir, "err", err)
+	}
+	slog.Info("split articles into shards", "articles", total, "shards", *n, "dir", *dir)
+}
+
+// countArticles counts the articles of a saol_entries.json.
+func countArticles(filename string) (int, error) {
+	count := 0
+	err := eachArticle(filename, func(json.RawMessage) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// eachArticle calls fn with every article of a saol_entries.json as it is
+// in the file, an array or an object of articles.
+func eachArticle(filename string, fn func(json.RawMessage) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	input, err := newArticleReader(bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	for input.More() {
+		raw, err := input.Next()
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitArticles writes the total articles of filename to n shards under
+// dir, the first total%n shards one article larger than the rest.
+func splitArticles(filename, dir string, n, total int) error {
+	var (
+		shard    = -1
+		manifest shardManifest
+		out      *os.File
+		w        *bufio.Writer
+	)
+	closeShard := func() error {
+		if out == nil {
+			return nil
+		}
+		w.WriteString("\n]\n")
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(shardDir(dir, shard), "shard.json"), data, 0644)
+	}
+	openShard := func(first int) error {
+		if err := closeShard(); err != nil {
+			return err
+		}
+		shard++
+		manifest = shardManifest{Shard: shard, Of: n, FirstArticle: first, Articles: total / n}
+		if shard < total%n {
+			manifest.Articles++
+		}
+		if err := os.MkdirAll(shardDir(dir, shard), 0755); err != nil {
+			return err
+		}
+		var err error
+		if out, err = os.Create(filepath.Join(shardDir(dir, shard), inputFile)); err != nil {
+			return err
+		}
+		w = bufio.NewWriter(out)
+		w.WriteString("[")
+		return nil
+	}
+
+	if err := openShard(0); err != nil {
+		return err
+	}
+	written, index := 0, 0
+	err := eachArticle(filename, func(raw json.RawMessage) error {
+		if written == manifest.Articles {
+			if err := openShard(index); err != nil {
+				return err
+			}
+			written = 0
+		}
+		if written > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString("\n  ")
+		w.Write(raw)
+		written++
+		index++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for shard < n-1 {
+		if err := openShard(index); err != nil {
+			return err
+		}
+	}
+	return closeShard()
+}
+
+// runMerge combines the outputs of the shards split wrote into one set, as
+// if the full input had been run in one go: flattened_lemmas.json with the
+// keys, family IDs and duplicateOf links renumbered, and the per-class
+// files of extract and the quarantine files one after the other. -dedup
+// only ever compared lemmas within a shard.
+func runMerge(args []string) {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	dir := flags.String("dir", "shards", "directory with the shard directories")
+	outDir := flags.String("out-dir", ".", "directory to write the merged files to")
+	flags.Parse(args)
+
+	shards, err := readShardManifests(*dir)
+	if err != nil {
+		fatal("could not read shards", "dir", *dir, "err", err)
+	}
+	if err := mergeFlattened(*dir, shards, filepath.Join(*outDir, outputFile)); err != nil {
+		fatal("could not merge", "file", outputFile, "err", err)
+	}
+
+	if err := mergeQuarantines(*dir, shards, filepath.Join(*outDir, "quarantined_entries.json")); err != nil {
+		fatal("could not merge", "file", "quarantined_entries.json", "err", err)
+	}
+	if err := mergeFamilyGaps(*dir, shards, filepath.Join(*outDir, "family_gaps.json")); err != nil {
+		fatal("could not merge", "file", "family_gaps.json", "err", err)
+	}
+	if err := mergeLemmaQuarantines(*dir, shards, filepath.Join(*outDir, "quarantined_lemmas.json")); err != nil {
+		fatal("could not merge", "file", "quarantined_lemmas.json", "err", err)
+	}
+	files := []string{"uninflected.json"}
+	for _, class := range parsedClasses() {
+		files = append(files, outputFor(class).file)
+	}
+	for _, name := range files {
+		merged, err := mergeJSONArrays(*dir, shards, name, filepath.Join(*outDir, name))
+		if err != nil {
+			fatal("could not merge", "file", name, "err", err)
+		}
+		if merged {
+			slog.Info("merged", "file", name)
+		}
+	}
+}
+
+// readShardManifests reads the shard.json of every shard under dir and
+// checks that they are all there and cover the input without gaps.
+func readShardManifests(dir string) ([]shardManifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "shard-*", "shard.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no shards in %s", dir)
+	}
+	shards := make([]shardManifest, 0, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var m shardManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		shards = append(shards, m)
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i].Shard < shards[j].Shard })
+
+	next := 0
+	for i, m := range shards {
+		if m.Shard != i || m.Of != len(shards) {
+			return nil, fmt.Errorf("%s has shard %d of %d, want shard %d of %d", dir, m.Shard, m.Of, i, len(shards))
+		}
+		if m.FirstArticle != next {
+			return nil, fmt.Errorf("shard %d starts at article %d, want %d", i, m.FirstArticle, next)
+		}
+		next += m.Articles
+	}
+	return shards, nil
+}
+
+// mergeFlattened concatenates the flattened lemmas of the shards, in
+// shard order, into filename.
+func mergeFlattened(dir string, shards []shardManifest, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	out := newLemmaArrayWriter(f)
+
+	for _, m := range shards {
+		in, err := os.Open(filepath.Join(shardDir(dir, m.Shard), outputFile))
+		if err != nil {
+			return err
+		}
+		keyOffset := out.n
+		err = ForEachLemma(in, func(l Lemma) error {
+			entry := LemmaOutput{
+				HTML:     l.HTML,
+				FamilyID: l.FamilyID + m.FirstArticle,
+				Source:   l.Source,
+				Class:    l.Class,
+				Headword: l.Headword,
+			}
+			if l.DuplicateOf != 0 {
+				entry.DuplicateOf = l.DuplicateOf + keyOffset
+			}
+			return out.write(entry)
+		})
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+	}
+	if err := out.close(); err != nil {
+		return err
+	}
+	slog.Info("merged flattened lemmas", "lemmas", out.n, "shards", len(shards), "file", filename)
+	return f.Close()
+}
+
+// mergeQuarantines concatenates the flatten quarantines of the shards into
+// filename, with the article indexes of the full input, if any shard has one.
+func mergeQuarantines(dir string, shards []shardManifest, filename string) error {
+	var all []quarantinedArticle
+	for _, m := range shards {
+		quarantined, err := readArticleQuarantine(filepath.Join(shardDir(dir, m.Shard), "quarantined_entries.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		for _, q := range quarantined {
+			q.Index += m.FirstArticle
+			all = append(all, q)
+		}
+	}
+	if all == nil {
+		return nil
+	}
+	return saveQuarantine(filename, all)
+}
+
+// mergeFamilyGaps merges the gap reports of the shards into filename,
+// with their family IDs offset to the merged input.
+func mergeFamilyGaps(dir string, shards []shardManifest, filename string) error {
+	var all []familyGap
+	merged := false
+	for _, m := range shards {
+		gaps, err := readFamilyGaps(filepath.Join(shardDir(dir, m.Shard), "family_gaps.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		for _, g := range gaps {
+			g.FamilyID += m.FirstArticle
+			all = append(all, g)
+		}
+		merged = true
+	}
+	if !merged {
+		return nil
+	}
+	return saveFamilyGaps(filename, all)
+}
+
+// mergeLemmaQuarantines merges the lemmas extract quarantined in the
+// shards into filename, with their family IDs offset to the merged input.
+func mergeLemmaQuarantines(dir string, shards []shardManifest, filename string) error {
+	var all []LemmaInput
+	for _, m := range shards {
+		data, err := ioutil.ReadFile(filepath.Join(shardDir(dir, m.Shard), "quarantined_lemmas.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		_, entries, err := readVersionedEntries(data)
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		var quarantined []LemmaInput
+		if err := json.Unmarshal(entries, &quarantined); err != nil {
+			return fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		for _, l := range quarantined {
+			l.FamilyID += m.FirstArticle
+			all = append(all, l)
+		}
+	}
+	if all == nil {
+		return nil
+	}
+	return saveQuarantine(filename, all)
+}
+
+// mergeJSONArrays concatenates the entries of the output file name of
+// every shard into filename, in the envelope if any shard's file has one.
+// Shards without the file add nothing; merged is false when none has it.
+func mergeJSONArrays(dir string, shards []shardManifest, name, filename string) (merged bool, err error) {
+	all := []json.RawMessage{}
+	versioned := false
+	for _, m := range shards {
+		data, err := ioutil.ReadFile(filepath.Join(shardDir(dir, m.Shard), name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		version, entries, err := readVersionedEntries(data)
+		if err != nil {
+			return false, fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(entries, &elems); err != nil {
+			return false, fmt.Errorf("shard %d: %w", m.Shard, err)
+		}
+		all = append(all, elems...)
+		versioned = versioned || version > 1
+		merged = true
+	}
+	if !merged {
+		return false, nil
+	}
+	return true, saveFile(filename, func(w io.Writer) error {
+		if versioned {
+			return writeVersionedJSON(w, all)
+		}
+		return writeIndentedJSON(w, all)
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitAndMerge(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.json")
+	if err := os.WriteFile(in, []byte(`[{"html":"a"},{"html":"b"},{"html":"c"},{"html":"d"},{"html":"e"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shards := filepath.Join(dir, "shards")
+	if err := splitArticles(in, shards, 3, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err := readShardManifests(shards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantManifests := []shardManifest{{0, 3, 0, 2}, {1, 3, 2, 2}, {2, 3, 4, 1}}
+	if !reflect.DeepEqual(manifests, wantManifests) {
+		t.Errorf("manifests %+v, want %+v", manifests, wantManifests)
+	}
+	var articles []InputEntry
+	for i := range manifests {
+		data, err := os.ReadFile(filepath.Join(shardDir(shards, i), inputFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var shard []InputEntry
+		if err := json.Unmarshal(data, &shard); err != nil {
+			t.Fatalf("shard %d: %v", i, err)
+		}
+		articles = append(articles, shard...)
+	}
+	if len(articles) != 5 || articles[0].HTML != "a" || articles[4].HTML != "e" {
+		t.Errorf("shards hold %+v", articles)
+	}
+
+	// Flatten output of the shards: each shard numbers its own lemmas and articles.
+	flattened := []string{
+		`[{"key":1,"html":"a","familyID":1},{"key":2,"html":"b","familyID":2}]`,
+		`[{"key":1,"html":"c","familyID":1},{"key":2,"html":"c","familyID":2,"duplicateOf":1}]`,
+		`[]`,
+	}
+	for i, lemmas := range flattened {
+		if err := os.WriteFile(filepath.Join(shardDir(shards, i), outputFile), []byte(lemmas), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(shardDir(shards, 0), "nouns.json"), []byte(`[{"n":1}]`), 0644)
+	os.WriteFile(filepath.Join(shardDir(shards, 2), "nouns.json"), []byte(`[{"n":2}]`), 0644)
+
+	out := filepath.Join(dir, outputFile)
+	if err := mergeFlattened(shards, manifests, out); err != nil {
+		t.Fatal(err)
+	}
+	var got []LemmaOutput
+	data, _ := os.ReadFile(out)
+	decodeEntries(t, data, &got)
+	want := []LemmaOutput{
+		{Key: 1, HTML: "a", FamilyID: 1},
+		{Key: 2, HTML: "b", FamilyID: 2},
+		{Key: 3, HTML: "c", FamilyID: 3},
+		{Key: 4, HTML: "c", FamilyID: 4, DuplicateOf: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged %+v, want %+v", got, want)
+	}
+
+	nouns := filepath.Join(dir, "nouns.json")
+	if merged, err := mergeJSONArrays(shards, manifests, "nouns.json", nouns); !merged || err != nil {
+		t.Fatalf("merging nouns.json: %v, %v", merged, err)
+	}
+	var gotNouns []map[string]int
+	data, _ = os.ReadFile(nouns)
+	json.Unmarshal(data, &gotNouns)
+	if !reflect.DeepEqual(gotNouns, []map[string]int{{"n": 1}, {"n": 2}}) {
+		t.Errorf("merged nouns %v", gotNouns)
+	}
+	if merged, _ := mergeJSONArrays(shards, manifests, "verbs.json", filepath.Join(dir, "verbs.json")); merged {
+		t.Error("merged verbs.json, which no shard has")
+	}
+
+	os.WriteFile(filepath.Join(shardDir(shards, 1), "quarantined_lemmas.json"), []byte(`{"schemaVersion":2,"entries":[{"html":"c","familyID":1}]}`), 0644)
+	quarantined := filepath.Join(dir, "quarantined_lemmas.json")
+	if err := mergeLemmaQuarantines(shards, manifests, quarantined); err != nil {
+		t.Fatal(err)
+	}
+	var gotQuarantined []LemmaInput
+	data, _ = os.ReadFile(quarantined)
+	decodeEntries(t, data, &gotQuarantined)
+	if !reflect.DeepEqual(gotQuarantined, []LemmaInput{{HTML: "c", FamilyID: 3}}) {
+		t.Errorf("merged quarantined lemmas %+v, want c under family 3", gotQuarantined)
+	}
+
+	if err := saveFamilyGaps(filepath.Join(shardDir(shards, 0), "family_gaps.json"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveFamilyGaps(filepath.Join(shardDir(shards, 2), "family_gaps.json"), []familyGap{{FamilyID: 1, Reason: gapFailed, Error: "boom"}}); err != nil {
+		t.Fatal(err)
+	}
+	gaps := filepath.Join(dir, "family_gaps.json")
+	if err := mergeFamilyGaps(shards, manifests, gaps); err != nil {
+		t.Fatal(err)
+	}
+	gotGaps, err := readFamilyGaps(gaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []familyGap{{FamilyID: 5, Reason: gapFailed, Error: "boom"}}; !reflect.DeepEqual(gotGaps, want) {
+		t.Errorf("merged family gaps %+v, want %+v", gotGaps, want)
+	}
+
+	os.Remove(filepath.Join(shardDir(shards, 1), "shard.json"))
+	if _, err := readShardManifests(shards); err == nil {
+		t.Error("a missing shard went unnoticed")
+	}
+}
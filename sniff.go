@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// voidElements never have an end tag, so they do not nest.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// sniffClassText returns the text of the first element in r with the CSS
+// class selector, the same as goquery's Find(selector).First().Text(), but
+// with the html tokenizer, which stops at the end of that element instead
+// of building a tree of the whole lemma. ok is false for selectors other
+// than a single class (".ordklass"), which the caller has to run through
+// goquery instead.
+func sniffClassText(r io.Reader, selector string) (text string, ok bool) {
+	name := strings.TrimPrefix(selector, ".")
+	if name == selector || name == "" || strings.ContainsAny(name, " .#[:>+~,") {
+		return "", false
+	}
+
+	z := html.NewTokenizer(r)
+	depth := 0
+	var b strings.Builder
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return b.String(), true
+		case html.TextToken:
+			if depth > 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken:
+			tag, hasAttr := z.TagName()
+			if voidElements[string(tag)] {
+				continue
+			}
+			if depth > 0 {
+				depth++
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) == "class" && hasClass(string(val), name) {
+					depth = 1
+					break
+				}
+			}
+		case html.EndTagToken:
+			if depth > 0 {
+				depth--
+				if depth == 0 {
+					return b.String(), true
+				}
+			}
+		}
+	}
+}
+
+// hasClass reports whether the class attribute value attr includes name.
+func hasClass(attr, name string) bool {
+	for _, c := range strings.Fields(attr) {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSniffClassTextMatchesGoquery(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		for _, selector := range []string{".ordklass", ".grundform", ".bojningsklass"} {
+			html := readFixture(t, name)
+			got, ok := sniffClassText(strings.NewReader(html), selector)
+			want := loadFixture(t, name).Find(selector).First().Text()
+			if !ok || got != want {
+				t.Errorf("%s %s: got %q, %v; goquery has %q", name, selector, got, ok, want)
+			}
+		}
+	}
+
+	for _, selector := range []string{"ordklass", "div.ordklass", ".a .b", "#id"} {
+		if _, ok := sniffClassText(strings.NewReader(""), selector); ok {
+			t.Errorf("sniffClassText accepted %q", selector)
+		}
+	}
+
+	text, _ := sniffClassText(strings.NewReader(`<p class="x ordklass y">verb<br>a<b>b</b></p><p class="ordklass">nej</p>`), ".ordklass")
+	if text != "verbab" {
+		t.Errorf("nested text = %q", text)
+	}
+}
+
+func BenchmarkWordClass(b *testing.B) {
+	html := readFixture(b, "substantiv_bil")
+	b.Run("sniff", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sniffClassText(strings.NewReader(html), ".ordklass")
+		}
+	})
+	b.Run("goquery", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+			doc.Find(".ordklass").First().Text()
+		}
+	})
+}
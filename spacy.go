@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// spacyPOS maps SAOL word classes to the part-of-speech keys of spaCy's
+// lemma_rules table.
+var spacyPOS = map[string]string{
+	"substantiv": "noun",
+	"verb":       "verb",
+	"adjektiv":   "adj",
+	"pronomen":   "pron",
+	"räkneord":   "num",
+}
+
+// spacyLookups builds spaCy's lemma_lookup table (form -> lemma) and
+// lemma_rules table (POS -> [form suffix, lemma suffix] pairs, most
+// frequent first). Multiword headwords such as "komma ihåg" cannot be the
+// lemma of a single token and are left out. A form shared by several
+// lemmas maps to the first of them in lexicon order.
+func spacyLookups(entries []LexiconEntry) (lookup map[string]string, rules map[string][][2]string) {
+	lookup = make(map[string]string)
+	counts := make(map[string]map[[2]string]int)
+
+	for _, e := range entries {
+		pos, ok := spacyPOS[e.Class]
+		if !ok || e.Headword == "" || strings.Contains(e.Headword, " ") {
+			continue
+		}
+		for _, form := range e.surfaceForms() {
+			if form == e.Headword || strings.Contains(form, " ") {
+				continue
+			}
+			if _, taken := lookup[form]; !taken {
+				lookup[form] = e.Headword
+			}
+			if counts[pos] == nil {
+				counts[pos] = make(map[[2]string]int)
+			}
+			counts[pos][suffixRule(form, e.Headword)]++
+		}
+	}
+
+	rules = make(map[string][][2]string, len(counts))
+	for pos, byRule := range counts {
+		list := make([][2]string, 0, len(byRule))
+		for rule := range byRule {
+			list = append(list, rule)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if byRule[list[i]] != byRule[list[j]] {
+				return byRule[list[i]] > byRule[list[j]]
+			}
+			return list[i][0] < list[j][0] || list[i][0] == list[j][0] && list[i][1] < list[j][1]
+		})
+		rules[pos] = list
+	}
+	return lookup, rules
+}
+
+// suffixRule returns the suffix replacement that turns form into lemma
+// after their longest common prefix: ("bilarna", "bil") gives ["arna", ""].
+func suffixRule(form, lemma string) [2]string {
+	f, l := []rune(form), []rune(lemma)
+	n := 0
+	for n < len(f) && n < len(l) && f[n] == l[n] {
+		n++
+	}
+	return [2]string{string(f[n:]), string(l[n:])}
+}
+
+// writeSpacyLookups writes lemma_lookup.json and lemma_rules.json into dir,
+// in the layout of spacy-lookups-data.
+func writeSpacyLookups(entries []LexiconEntry, dir string) error {
+	if err := createOutputDir(dir); err != nil {
+		return err
+	}
+	lookup, rules := spacyLookups(entries)
+
+	tables := map[string]interface{}{
+		"lemma_lookup.json": lookup,
+		"lemma_rules.json":  rules,
+	}
+	for name, table := range tables {
+		data, err := json.MarshalIndent(table, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuffixRule(t *testing.T) {
+	tests := []struct {
+		form, lemma string
+		want        [2]string
+	}{
+		{"bilarna", "bil", [2]string{"arna", ""}},
+		{"män", "man", [2]string{"än", "an"}},
+		{"knäsatte", "knäsätta", [2]string{"atte", "ätta"}},
+	}
+	for _, tt := range tests {
+		if got := suffixRule(tt.form, tt.lemma); got != tt.want {
+			t.Errorf("suffixRule(%q, %q) = %q, want %q", tt.form, tt.lemma, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSpacyLookups(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeSpacyLookups(fixtureEntries(t), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var lookup map[string]string
+	data, err := os.ReadFile(filepath.Join(dir, "lemma_lookup.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &lookup); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "spacy_lemma_lookup", lookup)
+
+	var rules map[string][][2]string
+	data, err = os.ReadFile(filepath.Join(dir, "lemma_rules.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "spacy_lemma_rules", rules)
+}
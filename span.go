@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// spanFlags are the -offset, -limit and -index-range flags of flatten and
+// extract, which process only a stretch of their input: for a quick smoke
+// test, or to go straight to the entries that misbehave.
+type spanFlags struct {
+	offset     *int
+	limit      *int
+	indexRange *string
+}
+
+func addSpanFlags(flags *flag.FlagSet) *spanFlags {
+	return &spanFlags{
+		offset:     flags.Int("offset", 0, "skip this many entries at the start of the input"),
+		limit:      flags.Int("limit", 0, "process at most this many entries (0 for no limit)"),
+		indexRange: flags.String("index-range", "", `only process the entries with 0-based index a to b, b excluded, e.g. "1200:1300"; a or b may be left out`),
+	}
+}
+
+// bounds returns the first index to process and the one after the last,
+// or -1 for no end.
+func (f *spanFlags) bounds() (start, end int, err error) {
+	if *f.indexRange != "" && (*f.offset != 0 || *f.limit != 0) {
+		return 0, 0, errors.New("-index-range cannot be combined with -offset or -limit")
+	}
+	if *f.indexRange != "" {
+		return parseIndexRange(*f.indexRange)
+	}
+	if *f.offset < 0 || *f.limit < 0 {
+		return 0, 0, errors.New("-offset and -limit must not be negative")
+	}
+	end = -1
+	if *f.limit > 0 {
+		end = *f.offset + *f.limit
+	}
+	return *f.offset, end, nil
+}
+
+// set reports whether any of the flags was given.
+func (f *spanFlags) set() bool {
+	return *f.offset != 0 || *f.limit != 0 || *f.indexRange != ""
+}
+
+// parseIndexRange parses "a:b", "a:" or ":b".
+func parseIndexRange(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("index range %q, want a:b", s)
+	}
+	end = -1
+	if from != "" {
+		if start, err = strconv.Atoi(from); err != nil || start < 0 {
+			return 0, 0, fmt.Errorf("index range %q: bad start", s)
+		}
+	}
+	if to != "" {
+		if end, err = strconv.Atoi(to); err != nil || end < start {
+			return 0, 0, fmt.Errorf("index range %q: bad end", s)
+		}
+	}
+	return start, end, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSpanBounds(t *testing.T) {
+	tests := []struct {
+		args       []string
+		start, end int
+		wantErr    bool
+	}{
+		{nil, 0, -1, false},
+		{[]string{"-limit", "10"}, 0, 10, false},
+		{[]string{"-offset", "5"}, 5, -1, false},
+		{[]string{"-offset", "5", "-limit", "10"}, 5, 15, false},
+		{[]string{"-index-range", "1200:1300"}, 1200, 1300, false},
+		{[]string{"-index-range", ":30"}, 0, 30, false},
+		{[]string{"-index-range", "30:"}, 30, -1, false},
+		{[]string{"-index-range", "30"}, 0, 0, true},
+		{[]string{"-index-range", "30:20"}, 0, 0, true},
+		{[]string{"-index-range", "a:b"}, 0, 0, true},
+		{[]string{"-index-range", "1:2", "-limit", "1"}, 0, 0, true},
+		{[]string{"-offset", "-1"}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		span := addSpanFlags(flags)
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		start, end, err := span.bounds()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (start != tt.start || end != tt.end) {
+			t.Errorf("%q: bounds = %d, %d, want %d, %d", tt.args, start, end, tt.start, tt.end)
+		}
+		if got := span.set(); got != (len(tt.args) > 0) {
+			t.Errorf("%q: set = %v", tt.args, got)
+		}
+	}
+}
+
+func TestFilterLemmasWithRange(t *testing.T) {
+	input := `[
+		{"key": 1, "html": "", "familyID": 1, "class": "substantiv", "headword": "bil"},
+		{"key": 2, "html": "", "familyID": 2, "class": "substantiv", "headword": "hus"},
+		{"key": 3, "html": "", "familyID": 3, "class": "förkortning", "headword": "t.ex."},
+		{"key": 4, "html": "", "familyID": 4, "class": "substantiv", "headword": "katt"}
+	]`
+	for _, tt := range []struct {
+		start, end int
+		want       string
+	}{
+		{0, -1, "1 2 4"},
+		{1, 3, "2"},
+		{2, -1, "4"},
+		{0, 0, ""},
+	} {
+		lemmas, err := FilterLemmas(strings.NewReader(input), WithRange(tt.start, tt.end))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, l := range lemmas {
+			ids = append(ids, strconv.Itoa(l.FamilyID))
+		}
+		if got := strings.Join(ids, " "); got != tt.want {
+			t.Errorf("range %d:%d = family IDs %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestModernizeSpelling(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"hvad", "vad"},
+		{"Hvilken", "Vilken"},
+		{"lefva", "leva"},
+		{"hufvud", "huvud"},
+		{"qvinna", "kvinna"},
+		{"godt", "gott"},
+		{"hårdt", "hårt"},
+		{"af", "av"},
+		{"godtycke", "godtycke"},
+		{"skrifv", "skriv"},
+		{"golfväska", "golfväska"},
+		{"straffvärd", "straffvärd"},
+		{"hvad godt, hvad ondt!", "vad gott, vad ont!"},
+	}
+	for _, tt := range tests {
+		if got := ModernizeSpelling(tt.in); got != tt.want {
+			t.Errorf("ModernizeSpelling(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHistoricizeSpelling(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"vad", "hvad"},
+		{"Över", "Öfver"},
+		{"gott", "godt"},
+		{"bil", "bil"},
+		{"knäsätter-presens aktiv-Finita former", "knäsätter-presens aktiv-Finita former"},
+	}
+	for _, tt := range tests {
+		if got := HistoricizeSpelling(tt.in); got != tt.want {
+			t.Errorf("HistoricizeSpelling(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stardictName is the base name of the files of the StarDict export.
+const stardictName = "saol"
+
+// stardictLess is StarDict's index order: ASCII case-insensitive first,
+// byte order to break ties.
+func stardictLess(a, b string) bool {
+	la, lb := asciiLower(a), asciiLower(b)
+	if la != lb {
+		return la < lb
+	}
+	return a < b
+}
+
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+// writeStarDict writes entries as a StarDict 2.4.2 dictionary into dir:
+// saol.ifo, saol.idx and saol.dict with one HTML article per lemma, and
+// saol.syn so inflected forms look up their lemma. The audio files of the
+// entries are copied into res, the resource directory, and played from
+// their articles.
+func writeStarDict(entries []LexiconEntry, dir string) error {
+	if err := createOutputDir(dir); err != nil {
+		return err
+	}
+
+	var articles []LexiconEntry
+	for _, e := range entries {
+		if e.Headword != "" {
+			articles = append(articles, e)
+		}
+	}
+	sort.SliceStable(articles, func(i, j int) bool { return stardictLess(articles[i].Headword, articles[j].Headword) })
+
+	var dict, idx bytes.Buffer
+	type synonym struct {
+		word  string
+		index uint32
+	}
+	var syns []synonym
+	for i, e := range articles {
+		article := entryHTML(e)
+		if e.Audio != "" {
+			if err := copyResource(e.Audio, filepath.Join(dir, "res")); err != nil {
+				return err
+			}
+			article += fmt.Sprintf(`<audio controls src="%s"></audio>`, html.EscapeString(filepath.Base(e.Audio)))
+		}
+		idx.WriteString(e.Headword)
+		idx.WriteByte(0)
+		binary.Write(&idx, binary.BigEndian, uint32(dict.Len()))
+		binary.Write(&idx, binary.BigEndian, uint32(len(article)))
+		dict.WriteString(article)
+
+		for _, f := range e.surfaceForms() {
+			if f != e.Headword {
+				syns = append(syns, synonym{f, uint32(i)})
+			}
+		}
+	}
+	sort.SliceStable(syns, func(i, j int) bool { return stardictLess(syns[i].word, syns[j].word) })
+
+	var syn bytes.Buffer
+	for _, s := range syns {
+		syn.WriteString(s.word)
+		syn.WriteByte(0)
+		binary.Write(&syn, binary.BigEndian, s.index)
+	}
+
+	ifo := fmt.Sprintf("StarDict's dict ifo file\nversion=2.4.2\nbookname=SAOL\nwordcount=%d\nsynwordcount=%d\nidxfilesize=%d\nsametypesequence=h\ndescription=Svenska Akademiens ordlista with inflection tables\n",
+		len(articles), len(syns), idx.Len())
+
+	files := map[string][]byte{
+		".ifo":  []byte(ifo),
+		".idx":  idx.Bytes(),
+		".dict": dict.Bytes(),
+		".syn":  syn.Bytes(),
+	}
+	for ext, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, stardictName+ext), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyResource copies the file at path into dir.
+func copyResource(path, dir string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := createOutputDir(dir); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, filepath.Base(path)), data, 0644)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readStarDictIndex decodes a .idx or .syn file into words and their
+// trailing numbers (offset and size, or the synonym's article index).
+func readStarDictIndex(t *testing.T, path string, numbers int) (words []string, values [][]uint32) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 || len(data) < end+1+4*numbers {
+			t.Fatalf("truncated index %s", path)
+		}
+		words = append(words, string(data[:end]))
+		data = data[end+1:]
+		v := make([]uint32, numbers)
+		for i := range v {
+			v[i] = binary.BigEndian.Uint32(data)
+			data = data[4:]
+		}
+		values = append(values, v)
+	}
+	return words, values
+}
+
+func TestWriteStarDict(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeStarDict(fixtureEntries(t), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	words, spans := readStarDictIndex(t, filepath.Join(dir, "saol.idx"), 2)
+	for i := 1; i < len(words); i++ {
+		if stardictLess(words[i], words[i-1]) {
+			t.Errorf("index out of order: %q before %q", words[i-1], words[i])
+		}
+	}
+	dict, err := os.ReadFile(filepath.Join(dir, "saol.dict"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syns, targets := readStarDictIndex(t, filepath.Join(dir, "saol.syn"), 1)
+	found := false
+	for i, s := range syns {
+		if s != "bilarna" {
+			continue
+		}
+		found = true
+		span := spans[targets[i][0]]
+		article := string(dict[span[0] : span[0]+span[1]])
+		if !strings.HasPrefix(article, "<b>bil</b> <i>substantiv</i>") || !strings.Contains(article, "<td>bilarna</td><td>de</td>") {
+			t.Errorf("bilarna resolves to %q", article)
+		}
+	}
+	if !found {
+		t.Error("bilarna missing from saol.syn")
+	}
+
+	ifo, err := os.ReadFile(filepath.Join(dir, "saol.ifo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(ifo), "StarDict's dict ifo file\nversion=2.4.2\n") {
+		t.Errorf("malformed ifo:\n%s", ifo)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultTopLemmas = 20
+
+// lemmaCount is one row of GET /stats/top.
+type lemmaCount struct {
+	Lemma string `json:"lemma"`
+	Count int64  `json:"count"`
+}
+
+// lookupStats counts lookups per lemma in serve mode and persists the
+// counts to a JSON file so they survive restarts. A nil *lookupStats is
+// valid and records nothing, which is how tracking is switched off.
+type lookupStats struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	path   string
+	dirty  bool
+}
+
+// newLookupStats loads previously persisted counts from path, if any.
+func newLookupStats(path string) (*lookupStats, error) {
+	s := &lookupStats{counts: make(map[string]int64), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.counts); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Record counts one lookup of lemma.
+func (s *lookupStats) Record(lemma string) {
+	if s == nil || lemma == "" {
+		return
+	}
+	s.mu.Lock()
+	s.counts[lemma]++
+	s.dirty = true
+	s.mu.Unlock()
+}
+
+// Top returns the n most looked-up lemmas, most popular first.
+func (s *lookupStats) Top(n int) []lemmaCount {
+	s.mu.Lock()
+	top := make([]lemmaCount, 0, len(s.counts))
+	for lemma, count := range s.counts {
+		top = append(top, lemmaCount{Lemma: lemma, Count: count})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Lemma < top[j].Lemma
+	})
+	if n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
+
+// Save writes the counts to disk if they changed since the last save. The
+// file is replaced atomically so a crash never leaves it half written.
+func (s *lookupStats) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(s.counts)
+	s.dirty = false
+	s.mu.Unlock()
+	if err == nil {
+		err = s.write(data)
+	}
+	if err != nil {
+		// Leave the counts dirty so the next Save tries again.
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+	}
+	return err
+}
+
+func (s *lookupStats) write(data []byte) error {
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// persistEvery saves the counts every interval until stop is closed, then
+// saves one last time.
+func (s *lookupStats) persistEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Save(); err != nil {
+				slog.Warn("could not persist lookup stats", "file", s.path, "err", err)
+			}
+		case <-stop:
+			if err := s.Save(); err != nil {
+				slog.Warn("could not persist lookup stats", "file", s.path, "err", err)
+			}
+			return
+		}
+	}
+}
+
+// handleTop serves GET /stats/top?n=20.
+func (s *lookupStats) handleTop(w http.ResponseWriter, r *http.Request) {
+	if s == nil {
+		http.Error(w, "lookup statistics are not enabled", http.StatusNotFound)
+		return
+	}
+
+	n := defaultTopLemmas
+	if v := r.URL.Query().Get("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Top(n))
+}
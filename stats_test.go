@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLookupStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	s, err := newLookupStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lemma := range []string{"bil", "hus", "bil", "fin", "bil", "hus"} {
+		s.Record(lemma)
+	}
+	want := []lemmaCount{{"bil", 3}, {"hus", 2}}
+	if got := s.Top(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Top(2) = %v, want %v", got, want)
+	}
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := newLookupStats(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Top(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("after reload Top(2) = %v, want %v", got, want)
+	}
+
+	var disabled *lookupStats
+	disabled.Record("bil")
+}
+
+func TestLookupStatsSaveRetries(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newLookupStats(filepath.Join(dir, "missing", "stats.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Record("bil")
+	if err := s.Save(); err == nil {
+		t.Fatal("Save into a missing directory succeeded")
+	}
+	if err := os.Mkdir(filepath.Join(dir, "missing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing", "stats.json")); err != nil {
+		t.Errorf("the counts of a failed Save were not saved by the next: %v", err)
+	}
+}
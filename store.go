@@ -0,0 +1,350 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// Store is the lexicon backend behind the HTTP API. Every lookup returns
+// whole entries; results are ordered by headword and then ID.
+type Store interface {
+	// GetLemma returns the entries whose headword is exactly headword.
+	GetLemma(headword string) ([]LexiconEntry, error)
+	// GetByID returns the entry with the given ID; ok is false if there is none.
+	GetByID(id string) (entry LexiconEntry, ok bool, err error)
+	// SearchForm returns the entries that have form as one of their
+	// inflected forms (or as headword).
+	SearchForm(form string) ([]LexiconEntry, error)
+	// SearchPrefix returns up to limit entries whose headword starts with prefix.
+	SearchPrefix(prefix string, limit int) ([]LexiconEntry, error)
+	// ListByClass pages through the entries of one word class.
+	ListByClass(class string, offset, limit int) ([]LexiconEntry, error)
+	Close() error
+}
+
+// openStore opens the backend named by kind: "memory" keeps the parsed
+// lexicon in RAM, "sqlite" and "postgres" use the database at dsn and
+// "index" maps the index file at dsn. When lexiconFile is set and a
+// database is still empty, or the index file missing, the flattened
+// lemmas in it are parsed and imported first.
+func openStore(kind, dsn, lexiconFile string) (Store, error) {
+	switch kind {
+	case "memory":
+		entries, err := loadLexicon(lexiconFile)
+		if err != nil {
+			return nil, err
+		}
+		return newMemStore(entries), nil
+	case "sqlite":
+		return openSQLStore("sqlite", dsn, lexiconFile)
+	case "postgres":
+		return openSQLStore("postgres", dsn, lexiconFile)
+	case "index":
+		return openIndexStore(dsn, lexiconFile)
+	}
+	return nil, fmt.Errorf("unknown store %q, want memory, sqlite, postgres or index", kind)
+}
+
+// memStore indexes an in-memory lexicon by headword, form, class and
+// article family.
+type memStore struct {
+	entries    []LexiconEntry
+	byID       map[string]int
+	byHeadword map[string][]int
+	byForm     map[string][]int
+	byClass    map[string][]int
+	byFamily   map[int][]int
+	headwords  []string // sorted, for prefix search
+}
+
+func newMemStore(entries []LexiconEntry) *memStore {
+	sorted := append([]LexiconEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return entryLess(sorted[i], sorted[j])
+	})
+
+	s := &memStore{
+		entries:    sorted,
+		byID:       make(map[string]int),
+		byHeadword: make(map[string][]int),
+		byForm:     make(map[string][]int),
+		byClass:    make(map[string][]int),
+		byFamily:   make(map[int][]int),
+	}
+	for i, e := range sorted {
+		s.byID[e.ID] = i
+		if len(s.byHeadword[e.Headword]) == 0 {
+			s.headwords = append(s.headwords, e.Headword)
+		}
+		s.byHeadword[e.Headword] = append(s.byHeadword[e.Headword], i)
+		s.byClass[e.Class] = append(s.byClass[e.Class], i)
+		s.byFamily[e.FamilyID] = append(s.byFamily[e.FamilyID], i)
+		for _, f := range e.surfaceForms() {
+			s.byForm[f] = append(s.byForm[f], i)
+		}
+	}
+	return s
+}
+
+func (s *memStore) pick(idx []int) []LexiconEntry {
+	out := make([]LexiconEntry, len(idx))
+	for i, n := range idx {
+		out[i] = s.entries[n]
+	}
+	return out
+}
+
+func (s *memStore) GetLemma(headword string) ([]LexiconEntry, error) {
+	return s.pick(s.byHeadword[headword]), nil
+}
+
+func (s *memStore) GetByID(id string) (LexiconEntry, bool, error) {
+	i, ok := s.byID[id]
+	if !ok {
+		return LexiconEntry{}, false, nil
+	}
+	return s.entries[i], true, nil
+}
+
+func (s *memStore) SearchForm(form string) ([]LexiconEntry, error) {
+	return s.pick(s.byForm[form]), nil
+}
+
+func (s *memStore) SearchPrefix(prefix string, limit int) ([]LexiconEntry, error) {
+	var out []LexiconEntry
+	i := sort.SearchStrings(s.headwords, prefix)
+	for ; i < len(s.headwords) && strings.HasPrefix(s.headwords[i], prefix); i++ {
+		for _, e := range s.pick(s.byHeadword[s.headwords[i]]) {
+			if len(out) == limit {
+				return out, nil
+			}
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Family returns the entries split out of article familyID.
+func (s *memStore) Family(familyID int) ([]LexiconEntry, error) {
+	return s.pick(s.byFamily[familyID]), nil
+}
+
+func (s *memStore) ListByClass(class string, offset, limit int) ([]LexiconEntry, error) {
+	idx := s.byClass[class]
+	if offset >= len(idx) {
+		return nil, nil
+	}
+	idx = idx[offset:]
+	if limit < len(idx) {
+		idx = idx[:limit]
+	}
+	return s.pick(idx), nil
+}
+
+func (s *memStore) Close() error { return nil }
+
+// entryLess orders entries by headword, then by homograph number and ID.
+func entryLess(a, b LexiconEntry) bool {
+	if a.Headword != b.Headword {
+		return a.Headword < b.Headword
+	}
+	if a.Homograph != b.Homograph {
+		return a.Homograph < b.Homograph
+	}
+	return a.ID < b.ID
+}
+
+// sqlStore keeps the lexicon in SQLite or PostgreSQL. Each entry is stored
+// whole as JSON, next to the columns lookups filter on, and once more per
+// surface form for form lookups.
+type sqlStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// headwordCollation is the collation lemmas.headword is declared with,
+// by driver. SearchPrefix needs headwords compared byte by byte, as the
+// memory and index stores sort them: under a locale collation its range
+// and ORDER BY disagree with theirs, and the \U0010FFFF upper bound may
+// even sort first. SQLite compares bytes unless told otherwise.
+var headwordCollation = map[string]string{"postgres": ` COLLATE "C"`}
+
+// sqlSchema returns the statements creating the tables of the store on
+// driver.
+func sqlSchema(driver string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS lemmas (
+			id        TEXT PRIMARY KEY,
+			family_id INTEGER NOT NULL,
+			headword  TEXT NOT NULL` + headwordCollation[driver] + `,
+			class     TEXT NOT NULL,
+			entry     TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS lemma_forms (
+			lemma_id TEXT NOT NULL REFERENCES lemmas(id),
+			form     TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS lemmas_headword ON lemmas(headword)`,
+		`CREATE INDEX IF NOT EXISTS lemmas_class ON lemmas(class)`,
+		`CREATE INDEX IF NOT EXISTS lemma_forms_form ON lemma_forms(form)`,
+	}
+}
+
+func openSQLStore(driver, dsn, lexiconFile string) (*sqlStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s database: %w", driver, err)
+	}
+	s := &sqlStore{db: db, driver: driver}
+
+	for _, stmt := range sqlSchema(driver) {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error creating schema: %w", err)
+		}
+	}
+
+	if lexiconFile != "" {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM lemmas`).Scan(&n); err != nil {
+			db.Close()
+			return nil, err
+		}
+		if n == 0 {
+			entries, err := loadLexicon(lexiconFile)
+			if err != nil {
+				db.Close()
+				return nil, err
+			}
+			if err := s.Import(entries); err != nil {
+				db.Close()
+				return nil, err
+			}
+			slog.Info("imported lexicon into the store", "entries", len(entries), "store", driver)
+		}
+	}
+	return s, nil
+}
+
+// rebind rewrites ? placeholders to $1, $2, … for PostgreSQL.
+func (s *sqlStore) rebind(query string) string {
+	if s.driver != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Import inserts entries in a single transaction.
+func (s *sqlStore) Import(entries []LexiconEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertLemma, err := tx.Prepare(s.rebind(`INSERT INTO lemmas (id, family_id, headword, class, entry) VALUES (?, ?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer insertLemma.Close()
+	insertForm, err := tx.Prepare(s.rebind(`INSERT INTO lemma_forms (lemma_id, form) VALUES (?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer insertForm.Close()
+
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := insertLemma.Exec(e.ID, e.FamilyID, e.Headword, e.Class, string(data)); err != nil {
+			return fmt.Errorf("error inserting lemma %s: %w", e.ID, err)
+		}
+		for _, f := range e.surfaceForms() {
+			if _, err := insertForm.Exec(e.ID, f); err != nil {
+				return fmt.Errorf("error inserting form of lemma %s: %w", e.ID, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+const lemmaColumns = `l.entry`
+
+func (s *sqlStore) query(query string, args ...interface{}) ([]LexiconEntry, error) {
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []LexiconEntry
+	for rows.Next() {
+		var e LexiconEntry
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, fmt.Errorf("malformed lexicon entry in database: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return entryLess(out[i], out[j]) })
+	return out, nil
+}
+
+func (s *sqlStore) GetLemma(headword string) ([]LexiconEntry, error) {
+	return s.query(`SELECT `+lemmaColumns+` FROM lemmas l WHERE l.headword = ?`, headword)
+}
+
+func (s *sqlStore) GetByID(id string) (LexiconEntry, bool, error) {
+	entries, err := s.query(`SELECT `+lemmaColumns+` FROM lemmas l WHERE l.id = ?`, id)
+	if err != nil || len(entries) == 0 {
+		return LexiconEntry{}, false, err
+	}
+	return entries[0], true, nil
+}
+
+func (s *sqlStore) SearchForm(form string) ([]LexiconEntry, error) {
+	return s.query(`SELECT `+lemmaColumns+` FROM lemmas l
+		WHERE l.id IN (SELECT lemma_id FROM lemma_forms WHERE form = ?)`, form)
+}
+
+func (s *sqlStore) SearchPrefix(prefix string, limit int) ([]LexiconEntry, error) {
+	// Range instead of LIKE so the headword index is used and %/_ in the
+	// prefix need no escaping; see headwordCollation.
+	return s.query(`SELECT `+lemmaColumns+` FROM lemmas l
+		WHERE l.headword >= ? AND l.headword < ?
+		ORDER BY l.headword LIMIT ?`, prefix, prefix+"\U0010FFFF", limit)
+}
+
+func (s *sqlStore) ListByClass(class string, offset, limit int) ([]LexiconEntry, error) {
+	return s.query(`SELECT `+lemmaColumns+` FROM lemmas l
+		WHERE l.class = ?
+		ORDER BY l.headword, l.id LIMIT ? OFFSET ?`, class, limit, offset)
+}
+
+func (s *sqlStore) Close() error { return s.db.Close() }
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fixtureEntries parses every SAOL lemma fixture into a lexicon entry.
+func fixtureEntries(t testing.TB) []LexiconEntry {
+	t.Helper()
+	var entries []LexiconEntry
+	for i, name := range fixtureNames(t, "") {
+		if strings.HasPrefix(name, "article_") || strings.HasPrefix(name, "so_") {
+			continue
+		}
+		entry, ok, err := newLexiconEntry(context.Background(), strconv.Itoa(i+1), LemmaInput{HTML: readFixture(t, name), FamilyID: i + 1})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestStores(t *testing.T) {
+	entries := fixtureEntries(t)
+
+	sqlite, err := openSQLStore("sqlite", filepath.Join(t.TempDir(), "lexicon.db"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+	if err := sqlite.Import(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := openIndexStore(writeTestIndex(t, entries), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	stores := map[string]Store{
+		"memory": newMemStore(entries),
+		"sqlite": sqlite,
+		"index":  index,
+	}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			got, err := s.GetLemma("man")
+			if err != nil || len(got) != 1 || !reflect.DeepEqual(got[0].Forms["Nominativ"][2], Form{Form: "män", Label: "flera"}) {
+				t.Errorf("GetLemma(man) = %v, %v", got, err)
+			}
+
+			entry, ok, err := s.GetByID("val_2")
+			if err != nil || !ok || entry.Homograph != 2 {
+				t.Errorf("GetByID(val_2) = %v, %v, %v", entry, ok, err)
+			}
+			if _, ok, err := s.GetByID("val_9"); err != nil || ok {
+				t.Errorf("GetByID(val_9) = %v, %v", ok, err)
+			}
+
+			got, err = s.SearchForm("knäsatte")
+			if err != nil || len(got) != 1 || got[0].Headword != "knäsätta" {
+				t.Errorf("SearchForm(knäsatte) = %v, %v", got, err)
+			}
+
+			got, err = s.SearchPrefix("h", 10)
+			if err != nil || len(got) != 2 || got[0].Headword != "hoppas" || got[1].Headword != "hus" {
+				t.Errorf("SearchPrefix(h) = %v, %v", got, err)
+			}
+
+			got, err = s.ListByClass("substantiv", 1, 1)
+			if err != nil || len(got) != 1 || got[0].Headword != "hus" {
+				t.Errorf("ListByClass(substantiv, 1, 1) = %v, %v", got, err)
+			}
+		})
+	}
+}
+
+// TestPostgresSearchPrefix checks that the postgres store pages prefix
+// matches in the same byte order as the memory store, whatever the
+// database's locale. It takes the scratch database TestBulkLoadPostgres
+// uses, SAOL_TEST_POSTGRES.
+func TestPostgresSearchPrefix(t *testing.T) {
+	dsn := os.Getenv("SAOL_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("SAOL_TEST_POSTGRES not set")
+	}
+	entries := fixtureEntries(t)
+	// Punctuation and å sort differently in a locale collation.
+	collated := []LexiconEntry{
+		{ID: "h-s", FamilyID: 1, Headword: "h-s", Class: "substantiv"},
+		{ID: "hå", FamilyID: 1, Headword: "hå", Class: "interjektion"},
+		{ID: "Hz", FamilyID: 1, Headword: "Hz", Class: "förkortning"},
+	}
+
+	pg, err := openSQLStore("postgres", dsn, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	if _, ok, err := pg.GetByID(entries[0].ID); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		if err := pg.Import(entries); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok, err := pg.GetByID("h-s"); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		if err := pg.Import(collated); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mem := newMemStore(append(entries, collated...))
+	for _, prefix := range []string{"", "h", "h-", "hå", "H"} {
+		want, _ := mem.SearchPrefix(prefix, 4)
+		got, err := pg.SearchPrefix(prefix, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(headwords(got), headwords(want)) {
+			t.Errorf("SearchPrefix(%q) = %q, want %q", prefix, headwords(got), headwords(want))
+		}
+	}
+}
+
+func headwords(entries []LexiconEntry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Headword)
+	}
+	return out
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// keyboardRows is the Swedish QWERTY layout, each row shifted a little
+// further right than the one above it.
+var keyboardRows = []string{"1234567890+", "qwertyuiopå", "asdfghjklöä", "zxcvbnm,.-"}
+
+// keyPositions gives each key its row and column on keyboardRows.
+var keyPositions = func() map[rune][2]int {
+	pos := make(map[rune][2]int)
+	for r, row := range keyboardRows {
+		for c, key := range []rune(row) {
+			pos[key] = [2]int{r, c}
+		}
+	}
+	return pos
+}()
+
+// diacriticGroups are letters that are easily typed for each other, by
+// leaving out or misplacing the diacritic.
+var diacriticGroups = []string{"aåä", "oö", "eé", "uü"}
+
+// adjacentKeys reports whether a and b are neighbours on the keyboard:
+// beside each other on a row, or touching on the row above or below.
+func adjacentKeys(a, b rune) bool {
+	pa, ok := keyPositions[a]
+	pb, okb := keyPositions[b]
+	if !ok || !okb {
+		return false
+	}
+	dr, dc := pb[0]-pa[0], pb[1]-pa[1]
+	switch dr {
+	case 0:
+		return dc == 1 || dc == -1
+	case -1:
+		return dc == 0 || dc == 1
+	case 1:
+		return dc == 0 || dc == -1
+	}
+	return false
+}
+
+// substitutionCost is what typing b for a costs: a diacritic slip is
+// cheaper than hitting a neighbouring key, which is cheaper than any other
+// letter.
+func substitutionCost(a, b rune) float64 {
+	switch {
+	case a == b:
+		return 0
+	case sameDiacriticGroup(a, b):
+		return 0.3
+	case adjacentKeys(a, b):
+		return 0.5
+	}
+	return 1
+}
+
+func sameDiacriticGroup(a, b rune) bool {
+	for _, g := range diacriticGroups {
+		if strings.ContainsRune(g, a) && strings.ContainsRune(g, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// typingDistance is damerauLevenshtein with substitutions weighted by
+// substitutionCost, so the corrections a typist is likely to need come
+// out closer than other words at the same number of edits.
+func typingDistance(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	prev2 := make([]float64, len(t)+1)
+	prev := make([]float64, len(t)+1)
+	cur := make([]float64, len(t)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = float64(i)
+		for j := 1; j <= len(t); j++ {
+			cur[j] = math.Min(math.Min(prev[j]+1, cur[j-1]+1), prev[j-1]+substitutionCost(s[i-1], t[j-1]))
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = math.Min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
+
+// Suggestion is a correction of a misspelling: a form of the lexicon, its
+// typing distance from the misspelling, its corpus count and the lemmas it
+// is a form of. Score ranks the suggestions, lowest first.
+type Suggestion struct {
+	Form      string   `json:"form"`
+	Distance  float64  `json:"distance"`
+	Frequency int      `json:"frequency,omitempty"`
+	Score     float64  `json:"score"`
+	Lemmas    []string `json:"lemmas"`
+}
+
+// frequencyWeight is how much a tenfold corpus count makes up for in
+// typing distance.
+const frequencyWeight = 0.25
+
+// formCounts returns the corpus count of every form of the lexicon in s,
+// from the frequency annotations of enrich -counts: those of its slots,
+// and for a headword without one the lemma's.
+func formCounts(s *memStore) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range s.entries {
+		for _, slots := range e.Forms {
+			for _, f := range slots {
+				if w := wordForm(f.Form); f.Frequency > counts[w] {
+					counts[w] = f.Frequency
+				}
+			}
+		}
+		if counts[e.Headword] == 0 {
+			counts[e.Headword] = e.Frequency
+		}
+	}
+	return counts
+}
+
+// suggestCorrections returns up to n forms of the lexicon in s within
+// maxEdits edits of word (all of them when n is 0), ranked by typing
+// distance less a bonus for frequent forms. Counts are looked up in
+// counts. A correctly spelt word is its own first suggestion. Case is
+// ignored.
+func suggestCorrections(s *memStore, counts map[string]int, word string, maxEdits, n int) []Suggestion {
+	word = strings.ToLower(word)
+	candidates := lookupForms(s, word, lookupQuery{Fuzzy: maxEdits}, 0)
+	suggestions := make([]Suggestion, 0, len(candidates))
+	for _, c := range candidates {
+		d := typingDistance(word, strings.ToLower(c.Form))
+		count := counts[c.Form]
+		suggestions = append(suggestions, Suggestion{
+			Form: c.Form, Distance: d, Frequency: count, Lemmas: c.Lemmas,
+			Score: d - frequencyWeight*math.Log10(float64(count)+1),
+		})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if (a.Distance == 0) != (b.Distance == 0) {
+			return a.Distance == 0
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Form < b.Form
+	})
+	if n > 0 && len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+// runSuggest prints ranked corrections of a misspelt word.
+func runSuggest(args []string) {
+	flags := flag.NewFlagSet("suggest", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json, whose frequency annotations rank the suggestions")
+	counts := flags.String("counts", "", "frequency list, word<TAB>count per line, to rank by instead of the lexicon's annotations")
+	maxEdits := flags.Int("max-edits", 2, "consider the forms within this many edits of the word")
+	n := flags.Int("n", 10, "suggestions to list, best first (0 for all)")
+	format := flags.String("format", "text", "output format: text, or json")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool suggest [-in file] [-counts freq.tsv] [-max-edits 2] [-n 10] [-format text|json] <misspelling>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *maxEdits < 0 {
+		fatal("-max-edits must not be negative", "max-edits", *maxEdits)
+	}
+	if *format != "text" && *format != "json" {
+		fatal("unknown -format, want text or json", "format", *format)
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	store := newMemStore(entries)
+	formCount := formCounts(store)
+	if *counts != "" {
+		list, err := readFrequencyList(*counts, 1)
+		if err != nil {
+			fatal("could not read frequency list", "file", *counts, "err", err)
+		}
+		formCount = list.counts
+	}
+
+	suggestions := suggestCorrections(store, formCount, flags.Arg(0), *maxEdits, *n)
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, suggestions)
+	} else {
+		err = writeSuggestionsText(os.Stdout, suggestions)
+	}
+	if err != nil {
+		fatal("could not write suggestions", "err", err)
+	}
+}
+
+// writeSuggestionsText writes a line per suggestion: the form, its typing
+// distance, its count and its lemmas, separated by tabs.
+func writeSuggestionsText(w io.Writer, suggestions []Suggestion) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range suggestions {
+		fmt.Fprintf(bw, "%s\t%.1f\t%d\t%s\n", s.Form, s.Distance, s.Frequency, strings.Join(s.Lemmas, " "))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTypingDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"bil", "bil", 0},
+		{"bul", "bil", 0.5}, // u is next to i
+		{"bal", "bil", 1},   // a is not
+		{"hys", "hus", 0.5}, // y is next to u
+		{"pa", "på", 0.3},   // the ring left out
+		{"snlö", "snöl", 1}, // a swap
+		{"hus", "huset", 2}, // two letters missing
+		{"mannen", "männen", 0.3},
+	}
+	for _, tt := range tests {
+		if got := typingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("typingDistance(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if !adjacentKeys('g', 't') || !adjacentKeys('g', 'b') || adjacentKeys('g', 'n') || !adjacentKeys('ö', 'ä') {
+		t.Error("wrong keyboard adjacency")
+	}
+}
+
+func TestSuggestCorrections(t *testing.T) {
+	store := newMemStore(fixtureEntries(t))
+
+	got := suggestCorrections(store, nil, "bul", 1, 3)
+	if len(got) == 0 || got[0].Form != "bil" || got[0].Lemmas[0] != "bil" {
+		t.Fatalf("suggestions for bul: %+v", got)
+	}
+
+	got = suggestCorrections(store, nil, "Hus", 1, 0)
+	if len(got) == 0 || got[0].Form != "hus" || got[0].Distance != 0 {
+		t.Errorf("a correct word is not its own first suggestion: %+v", got)
+	}
+
+	// man is closer to mam (n is next to m), but män is far more frequent.
+	got = suggestCorrections(store, nil, "mam", 2, 2)
+	if len(got) != 2 || got[0].Form != "man" {
+		t.Errorf("suggestions for mam without counts: %+v", got)
+	}
+	counts := map[string]int{"män": 100000, "man": 1}
+	got = suggestCorrections(store, counts, "mam", 2, 2)
+	if len(got) != 2 || got[0].Form != "män" || got[0].Frequency != 100000 {
+		t.Errorf("suggestions for mam: %+v", got)
+	}
+	if got := suggestCorrections(store, nil, "xyzzy", 1, 0); len(got) != 0 {
+		t.Errorf("suggestions for xyzzy: %+v", got)
+	}
+}
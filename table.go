@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tableRenderers render a lemma's inflection table in the formats the
+// conjugate and decline commands print.
+var tableRenderers = map[string]func(LexiconEntry) string{
+	"text":     entryText,
+	"markdown": entryMarkdown,
+	"html":     func(e LexiconEntry) string { return entryHTML(e) + "\n" },
+}
+
+// lemmaTables returns the tables of the lemmas with headword word, or of
+// the lemma with that ID, rendered by render and separated by blank lines.
+func lemmaTables(store Store, word string, render func(LexiconEntry) string) (string, error) {
+	entries, err := store.GetLemma(word)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		e, ok, err := store.GetByID(word)
+		if err != nil || !ok {
+			return "", err
+		}
+		entries = []LexiconEntry{e}
+	}
+	tables := make([]string, len(entries))
+	for i, e := range entries {
+		tables[i] = render(e)
+	}
+	return strings.Join(tables, "\n"), nil
+}
+
+// runTable prints the full inflection table of a lemma, as conjugate or
+// decline: both do the same for any word class.
+func runTable(name string, args []string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to read the table from")
+	format := flags.String("format", "text", "output format: text, markdown or html")
+	english := flags.Bool("english", false, "name the sections and label the forms in English")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: saoltool %s [-in file] [-format text|markdown|html] [-english] <lemma or id>\n", name)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	render, ok := tableRenderers[*format]
+	if !ok {
+		fatal("unknown -format, want text, markdown or html", "format", *format)
+	}
+	if *english {
+		swedish := render
+		render = func(e LexiconEntry) string { return swedish(englishEntry(e)) }
+	}
+
+	entries, err := readDiffLexicon(*in)
+	if err != nil {
+		fatal("could not read lexicon", "file", *in, "err", err)
+	}
+	tables, err := lemmaTables(newMemStore(entries), flags.Arg(0), render)
+	if err != nil {
+		fatal("could not look up lemma", "lemma", flags.Arg(0), "err", err)
+	}
+	if tables == "" {
+		fatal("no such lemma", "lemma", flags.Arg(0))
+	}
+	fmt.Print(tables)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEntryMarkdown(t *testing.T) {
+	want := `## bil (substantiv)
+
+motorfordon
+
+| Nominativ | |
+|---|---|
+| en bil | singular obestämd |
+| bilen | singular bestämd |
+
+| Genitiv | |
+|---|---|
+| bils | singular obestämd |
+`
+	if got := entryMarkdown(browseEntries()[0]); got != want {
+		t.Errorf("entryMarkdown:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLemmaTables(t *testing.T) {
+	store := newMemStore([]LexiconEntry{
+		{ID: "val_1", Headword: "val", Homograph: 1, Class: "substantiv"},
+		{ID: "val_2", Headword: "val", Homograph: 2, Class: "substantiv"},
+	})
+	id := func(e LexiconEntry) string { return e.ID + "\n" }
+	for word, want := range map[string]string{"val": "val_1\n\nval_2\n", "val_2": "val_2\n", "vals": ""} {
+		got, err := lemmaTables(store, word, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("lemmaTables(%q) = %q, want %q", word, got, want)
+		}
+	}
+	for format, render := range tableRenderers {
+		if out := render(browseEntries()[0]); !strings.Contains(out, "bilen") {
+			t.Errorf("%s: table lacks bilen:\n%s", format, out)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// udPOS maps SAOL word classes to Universal Dependencies part-of-speech
+// tags; a class without one is tagged X.
+var udPOS = map[string]string{
+	"substantiv":   "NOUN",
+	"verb":         "VERB",
+	"adjektiv":     "ADJ",
+	"adverb":       "ADV",
+	"pronomen":     "PRON",
+	"räkneord":     "NUM",
+	"preposition":  "ADP",
+	"konjunktion":  "CCONJ",
+	"subjunktion":  "SCONJ",
+	"interjektion": "INTJ",
+	"egennamn":     "PROPN",
+	"artikel":      "DET",
+}
+
+// tagToken matches a word (with inner hyphens, apostrophes or dots, as in
+// "e-post" and "t.ex"), a number, or any other single character.
+var tagToken = regexp.MustCompile(`[\pL\pM]+(?:[-'’.][\pL\pM]+)*|\pN+(?:[.,:]\pN+)*|[^\s\pL\pN]`)
+
+// Analysis is one reading of a token: the lemma it is a form of and the
+// features of the slot it fills there.
+type Analysis struct {
+	ID    string `json:"id"`
+	Lemma string `json:"lemma"`
+	Class string `json:"class"`
+	UPOS  string `json:"upos"`
+	Feats string `json:"feats,omitempty"`
+}
+
+// TaggedToken is a token of the text and its readings, all of them when
+// the form is ambiguous and none when the lexicon does not know it.
+type TaggedToken struct {
+	Form       string     `json:"form"`
+	SpaceAfter bool       `json:"spaceAfter"`
+	Analyses   []Analysis `json:"analyses"`
+}
+
+// TaggedSentence is a sentence of the text and its tokens.
+type TaggedSentence struct {
+	Text   string        `json:"text"`
+	Tokens []TaggedToken `json:"tokens"`
+}
+
+// analyze returns the readings of a word form in store: every slot of
+// every lemma it is a form of, the headword itself counting as one, with
+// genitive readings, rarer in running text, last. A form that is not
+// found is looked up again in lower case, for words capitalized at the
+// start of a sentence.
+func analyze(store Store, form string) ([]Analysis, error) {
+	entries, err := store.SearchForm(form)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		if lower := strings.ToLower(form); lower != form {
+			form = lower
+			if entries, err = store.SearchForm(form); err != nil {
+				return nil, err
+			}
+		}
+	}
+	analyses := []Analysis{}
+	seen := make(map[Analysis]bool)
+	add := func(a Analysis) {
+		if !seen[a] {
+			seen[a] = true
+			analyses = append(analyses, a)
+		}
+	}
+	for _, e := range entries {
+		reading := Analysis{ID: e.ID, Lemma: e.Headword, Class: e.Class, UPOS: udPOS[e.Class]}
+		if reading.UPOS == "" {
+			reading.UPOS = "X"
+		}
+		found := false
+		for _, section := range sortedKeys(e.Forms) {
+			for _, f := range e.Forms[section] {
+				for _, w := range append([]string{f.Form}, f.Variants...) {
+					if wordForm(w) == form {
+						a := reading
+						a.Feats = slotFeats(e, section, f)
+						add(a)
+						found = true
+						break
+					}
+				}
+			}
+		}
+		if !found {
+			add(reading)
+		}
+	}
+	sort.SliceStable(analyses, func(i, j int) bool {
+		return !strings.Contains(analyses[i].Feats, "Case=Gen") && strings.Contains(analyses[j].Feats, "Case=Gen")
+	})
+	return analyses, nil
+}
+
+// tagText splits text into sentences at sentence-final punctuation and
+// blank lines, tokenizes them and looks every token up in store. A word
+// followed by a period is taken together with it when the lexicon knows
+// the abbreviation ("t.ex.").
+func tagText(store Store, text string) ([]TaggedSentence, error) {
+	var sentences []TaggedSentence
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		para = strings.Join(strings.Fields(para), " ")
+		spans := tagToken.FindAllStringIndex(para, -1)
+		start := -1
+		var tokens []TaggedToken
+		flush := func(end int) {
+			if len(tokens) > 0 {
+				sentences = append(sentences, TaggedSentence{Text: para[start:end], Tokens: tokens})
+			}
+			start, tokens = -1, nil
+		}
+		for i := 0; i < len(spans); i++ {
+			from, to := spans[i][0], spans[i][1]
+			if start < 0 {
+				start = from
+			}
+			form := para[from:to]
+			analyses, err := analyze(store, form)
+			if err != nil {
+				return nil, err
+			}
+			if i+1 < len(spans) && spans[i+1][0] == to && para[to:spans[i+1][1]] == "." && unicode.IsLetter([]rune(form)[0]) {
+				abbr, err := analyze(store, form+".")
+				if err != nil {
+					return nil, err
+				}
+				if len(abbr) > 0 {
+					form, analyses, to = form+".", abbr, to+1
+					i++
+				}
+			}
+			tokens = append(tokens, TaggedToken{Form: form, SpaceAfter: to == len(para) || para[to] == ' ', Analyses: analyses})
+			if form == "." || form == "!" || form == "?" {
+				flush(to)
+			}
+		}
+		flush(len(para))
+	}
+	return sentences, nil
+}
+
+// untaggedPOS tags a token the lexicon does not know: punctuation,
+// numbers and, failing those, X.
+func untaggedPOS(form string) string {
+	r := []rune(form)[0]
+	switch {
+	case unicode.IsPunct(r) || unicode.IsSymbol(r):
+		return "PUNCT"
+	case unicode.IsDigit(r):
+		return "NUM"
+	}
+	return "X"
+}
+
+// writeCoNLLU writes sentences in CoNLL-U: a "# text" comment and a line
+// per token with the first reading's lemma, UPOS, SAOL class as XPOS and
+// features, head and relations left empty. Further readings go into MISC
+// as Alt=lemma/UPOS/feats, separated by ";" and with the features joined
+// by ",", so one line holds the whole ambiguity list.
+func writeCoNLLU(w io.Writer, sentences []TaggedSentence) error {
+	bw := bufio.NewWriter(w)
+	for n, s := range sentences {
+		fmt.Fprintf(bw, "# sent_id = %d\n# text = %s\n", n+1, s.Text)
+		for i, t := range s.Tokens {
+			lemma, upos, xpos, feats := "_", untaggedPOS(t.Form), "_", "_"
+			var misc []string
+			if len(t.Analyses) > 0 {
+				a := t.Analyses[0]
+				lemma, upos, xpos = a.Lemma, a.UPOS, a.Class
+				if a.Feats != "" {
+					feats = a.Feats
+				}
+			}
+			if len(t.Analyses) > 1 {
+				alt := make([]string, 0, len(t.Analyses)-1)
+				for _, a := range t.Analyses[1:] {
+					alt = append(alt, a.Lemma+"/"+a.UPOS+"/"+strings.ReplaceAll(a.Feats, "|", ","))
+				}
+				misc = append(misc, "Alt="+strings.Join(alt, ";"))
+			}
+			if !t.SpaceAfter {
+				misc = append(misc, "SpaceAfter=No")
+			}
+			miscField := "_"
+			if len(misc) > 0 {
+				miscField = strings.Join(misc, "|")
+			}
+			fmt.Fprintf(bw, "%d\t%s\t%s\t%s\t%s\t%s\t_\t_\t_\t%s\n", i+1, t.Form, lemma, upos, xpos, feats, miscField)
+		}
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// runTag tags running Swedish text, from files or standard input, with the
+// lemma, word class and features of every token.
+func runTag(args []string) {
+	flags := flag.NewFlagSet("tag", flag.ExitOnError)
+	in := flags.String("in", "flattened_lemmas.json", "flattened lemmas or an enriched lexicon.json to tag with")
+	index := flags.String("index", "", "look forms up in this index file (see saoltool index) instead of parsing -in, which builds it if missing")
+	format := flags.String("format", "conllu", "output format: conllu, or json with every reading of every token")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool tag [-in file | -index file] [-format conllu|json] [text files...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *format != "conllu" && *format != "json" {
+		fatal("unknown -format, want conllu or json", "format", *format)
+	}
+
+	var store Store
+	if *index != "" {
+		s, err := openIndexStore(*index, *in)
+		if err != nil {
+			fatal("could not open index", "file", *index, "err", err)
+		}
+		store = s
+	} else {
+		entries, err := readDiffLexicon(*in)
+		if err != nil {
+			fatal("could not read lexicon", "file", *in, "err", err)
+		}
+		store = newMemStore(entries)
+	}
+	defer store.Close()
+
+	var text strings.Builder
+	if flags.NArg() == 0 {
+		if _, err := io.Copy(&text, os.Stdin); err != nil {
+			fatal("could not read text", "err", err)
+		}
+	}
+	for _, name := range flags.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			fatal("could not read text", "file", name, "err", err)
+		}
+		text.Write(data)
+		text.WriteString("\n\n")
+	}
+
+	sentences, err := tagText(store, text.String())
+	if err != nil {
+		fatal("could not tag text", "err", err)
+	}
+	if *format == "json" {
+		err = writeIndentedJSON(os.Stdout, sentences)
+	} else {
+		err = writeCoNLLU(os.Stdout, sentences)
+	}
+	if err != nil {
+		fatal("could not write tagged text", "err", err)
+	}
+}
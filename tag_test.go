@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTagText(t *testing.T) {
+	store := newMemStore(fixtureEntries(t))
+	sentences, err := tagText(store, "Männen har en fin bil, 12 hus.\n\nJag\nknäsätter det!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sentences) != 2 || sentences[1].Text != "Jag knäsätter det!" {
+		t.Fatalf("sentences %+v", sentences)
+	}
+
+	var buf bytes.Buffer
+	if err := writeCoNLLU(&buf, sentences); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	for _, want := range []string{
+		"# text = Männen har en fin bil, 12 hus.",
+		"1\tMännen\tman\tNOUN\tsubstantiv\tCase=Nom|Definite=Def|Gender=Com|Number=Plur\t_\t_\t_\t_",
+		"2\thar\t_\tX\t_\t_\t_\t_\t_\t_",
+		"5\tbil\tbil\tNOUN\tsubstantiv\tCase=Nom|Definite=Ind|Gender=Com|Number=Sing\t_\t_\t_\tSpaceAfter=No",
+		"6\t,\t_\tPUNCT\t_\t_\t_\t_\t_\t_",
+		"7\t12\t_\tNUM\t_\t_\t_\t_\t_\t_",
+		"2\tknäsätter\tknäsätta\tVERB\tverb\tMood=Ind|Tense=Pres|VerbForm=Fin|Voice=Act\t_\t_\t_\t_",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing line %q in:\n%s", want, buf.String())
+		}
+	}
+
+	hus := sentences[0].Tokens[7]
+	if len(hus.Analyses) != 4 || hus.Analyses[0].Feats != "Case=Nom|Definite=Ind|Gender=Neut|Number=Sing" || !strings.Contains(hus.Analyses[3].Feats, "Case=Gen") {
+		t.Errorf("hus readings %+v", hus.Analyses)
+	}
+}
+
+func TestTagAbbreviation(t *testing.T) {
+	store := newMemStore([]LexiconEntry{{ID: "t.ex.", Headword: "t.ex.", Class: "förkortning"}})
+	sentences, err := tagText(store, "Bilar, t.ex. Volvo. Slut.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sentences) != 2 {
+		t.Fatalf("got %d sentences, want 2: %+v", len(sentences), sentences)
+	}
+	tok := sentences[0].Tokens[2]
+	if tok.Form != "t.ex." || len(tok.Analyses) != 1 || tok.Analyses[0].UPOS != "X" {
+		t.Errorf("abbreviation token %+v", tok)
+	}
+}
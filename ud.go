@@ -0,0 +1,125 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// udWordFeatures maps the words SAOL uses in section headers and form
+// labels to Universal Dependencies features.
+var udWordFeatures = map[string][]string{
+	"nominativ":    {"Case=Nom"},
+	"genitiv":      {"Case=Gen"},
+	"subjektsform": {"Case=Nom"},
+	"objektsform":  {"Case=Acc"},
+	"possessiv":    {"Poss=Yes"},
+	"utrum":        {"Gender=Com"},
+	"neutrum":      {"Gender=Neut"},
+	"maskulinum":   {"Gender=Masc"},
+	"singular":     {"Number=Sing"},
+	"plural":       {"Number=Plur"},
+	"obestämd":     {"Definite=Ind"},
+	"bestämd":      {"Definite=Def"},
+	"positiv":      {"Degree=Pos"},
+	"komparativ":   {"Degree=Cmp"},
+	"superlativ":   {"Degree=Sup"},
+	"grundtal":     {"NumType=Card"},
+	"ordningstal":  {"NumType=Ord"},
+	"finita":       {"VerbForm=Fin"},
+	"presens":      {"Tense=Pres"},
+	"preteritum":   {"Tense=Past"},
+	"perfekt":      {"Tense=Past"},
+	"imperativ":    {"Mood=Imp"},
+	"infinitiv":    {"VerbForm=Inf"},
+	"supinum":      {"VerbForm=Sup"},
+	"particip":     {"VerbForm=Part"},
+	"aktiv":        {"Voice=Act"},
+	"passiv":       {"Voice=Pass"},
+}
+
+// attributiveLed maps the article an adjective or participle form is shown
+// with ("en fin + substantiv") to the features it implies.
+var attributiveLed = map[string]string{
+	"en":         "utrum singular obestämd",
+	"ett":        "neutrum singular obestämd",
+	"den/det/de": "bestämd",
+}
+
+// udFeats converts Swedish grammatical labels, such as "Finita former" and
+// "presens aktiv", into a UD feature bundle ("Mood=Ind|Tense=Pres|VerbForm=Fin|Voice=Act")
+// with features in alphabetical order. Unknown words are ignored.
+func udFeats(labels ...string) string {
+	feats := make(map[string]string)
+	for _, label := range labels {
+		for _, word := range strings.Fields(strings.ToLower(label)) {
+			for _, f := range udWordFeatures[word] {
+				kv := strings.SplitN(f, "=", 2)
+				feats[kv[0]] = kv[1]
+			}
+		}
+	}
+	if feats["VerbForm"] == "Fin" && feats["Mood"] == "" {
+		feats["Mood"] = "Ind"
+	}
+
+	bundle := make([]string, 0, len(feats))
+	for name, value := range feats {
+		bundle = append(bundle, name+"="+value)
+	}
+	sort.Strings(bundle)
+	return strings.Join(bundle, "|")
+}
+
+// formFeats returns the UD features of one form slot of class in section.
+func formFeats(class, section string, f Form) string {
+	labels := []string{section, f.Label}
+	if class == "adjektiv" || section == "Perfekt particip" {
+		if words := strings.Fields(f.Form); len(words) > 0 {
+			labels = append(labels, attributiveLed[words[0]])
+		}
+	}
+	return udFeats(labels...)
+}
+
+// addUDFeats sets Feats on every form slot in forms.
+func addUDFeats(class string, forms map[string][]Form) {
+	for section, slots := range forms {
+		for i := range slots {
+			slots[i].Feats = formFeats(class, section, slots[i])
+		}
+	}
+}
+
+// nounFeats returns the UD features of one noun form.
+func nounFeats(f NounForm) string {
+	return udFeats(f.Case, f.Number, f.Gender, f.Definiteness)
+}
+
+// slotFeats returns the UD features of one form slot of a lexicon entry.
+// Noun slots carry their led word as label, so their features come from
+// ledFeatures, with the entry's gender standing in for plural forms.
+func slotFeats(e LexiconEntry, section string, f Form) string {
+	if e.Class != "substantiv" {
+		return formFeats(e.Class, section, f)
+	}
+	nf := ledFeatures[f.Label]
+	nf.Case = section
+	if nf.Gender == "" {
+		nf.Gender = e.Gender
+	}
+	return nounFeats(nf)
+}
+
+// parseFeats splits a UD feature bundle into a map from feature to value.
+func parseFeats(feats string) map[string]string {
+	m := make(map[string]string)
+	if feats == "" {
+		return m
+	}
+	for _, f := range strings.Split(feats, "|") {
+		if kv := strings.SplitN(f, "=", 2); len(kv) == 2 {
+			m[kv[0]] = kv[1]
+		}
+	}
+	return m
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestFormFeats(t *testing.T) {
+	tests := []struct {
+		class, section string
+		form           Form
+		want           string
+	}{
+		{"verb", "Finita former", Form{Form: "knäsätter", Label: "presens aktiv"}, "Mood=Ind|Tense=Pres|VerbForm=Fin|Voice=Act"},
+		{"verb", "Finita former", Form{Form: "knäsätt", Label: "imperativ aktiv"}, "Mood=Imp|VerbForm=Fin|Voice=Act"},
+		{"verb", "Infinita former", Form{Form: "knäsatts", Label: "supinum passiv"}, "VerbForm=Sup|Voice=Pass"},
+		{"verb", "Presens particip", Form{Form: "knäsättande"}, "Tense=Pres|VerbForm=Part"},
+		{"verb", "Perfekt particip", Form{Form: "ett knäsatt + substantiv"}, "Definite=Ind|Gender=Neut|Number=Sing|Tense=Past|VerbForm=Part"},
+		{"adjektiv", "Positiv", Form{Form: "en fin + substantiv"}, "Definite=Ind|Degree=Pos|Gender=Com|Number=Sing"},
+		{"adjektiv", "Komparativ", Form{Form: "finare + substantiv"}, "Degree=Cmp"},
+		{"adjektiv", "Superlativ", Form{Form: "den/det/de finaste + substantiv"}, "Definite=Def|Degree=Sup"},
+		{"pronomen", "Possessiv", Form{Form: "mitt", Label: "neutrum singular"}, "Gender=Neut|Number=Sing|Poss=Yes"},
+		{"räkneord", "Ordningstal", Form{Form: "första"}, "NumType=Ord"},
+		{"pronomen", "Okänd", Form{Form: "x"}, ""},
+	}
+	for _, tt := range tests {
+		if got := formFeats(tt.class, tt.section, tt.form); got != tt.want {
+			t.Errorf("formFeats(%q, %q, %q) = %q, want %q", tt.class, tt.section, tt.form.Form, got, tt.want)
+		}
+	}
+}
+
+func TestNounFeats(t *testing.T) {
+	f := NounForm{Case: "Genitiv", Number: "plural", Gender: "utrum", Definiteness: "bestämd"}
+	if got, want := nounFeats(f), "Case=Gen|Definite=Def|Gender=Com|Number=Plur"; got != want {
+		t.Errorf("nounFeats = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"embed"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// The browsing UI of serve: one page that searches through the REST API
+// and shows entries as rendered by entryHTML, so the tables keep their
+// section order.
+//
+//go:embed ui/index.html
+var uiFiles embed.FS
+
+// familyStore is implemented by stores that index entries by article
+// family; the others are scanned class by class.
+type familyStore interface {
+	Family(familyID int) ([]LexiconEntry, error)
+}
+
+// familyEntries returns the entries split out of article familyID.
+func familyEntries(store Store, familyID int) ([]LexiconEntry, error) {
+	if fs, ok := store.(familyStore); ok {
+		return fs.Family(familyID)
+	}
+	var out []LexiconEntry
+	for _, class := range lexiconClasses() {
+		for offset := 0; ; offset += exportPageSize {
+			page, err := store.ListByClass(class, offset, exportPageSize)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range page {
+				if e.FamilyID == familyID {
+					out = append(out, e)
+				}
+			}
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// addUIRoutes serves the UI on / with the two routes only it uses:
+// GET /family/{id}, the entries of an article as JSON, and
+// GET /ui/entry/{id}, an entry as an HTML fragment.
+func addUIRoutes(mux *http.ServeMux, store Store) {
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, uiFiles, "ui/index.html")
+	})
+	mux.HandleFunc("GET /family/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id < 1 {
+			http.Error(w, "family ID must be a positive number", http.StatusBadRequest)
+			return
+		}
+		entries, err := familyEntries(store, id)
+		writeEntries(w, entries, err, nil)
+	})
+	mux.HandleFunc("GET /ui/entry/{id}", func(w http.ResponseWriter, r *http.Request) {
+		entry, ok, err := store.GetByID(r.PathValue("id"))
+		if err != nil {
+			slog.Error("could not look up lemma", "id", r.PathValue("id"), "err", err)
+			http.Error(w, "error reading lexicon", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "no such lemma", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(entryHTML(entry)))
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scanStore hides memStore's family index, for the scanning fallback.
+type scanStore struct{ Store }
+
+func TestFamilyEntries(t *testing.T) {
+	entries := fixtureEntries(t)
+	entries[1].FamilyID = entries[0].FamilyID
+	mem := newMemStore(entries)
+	for _, store := range []Store{mem, scanStore{mem}} {
+		family, err := familyEntries(store, entries[0].FamilyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(family) != 2 {
+			t.Errorf("%T: family %d has %d entries, want 2", store, entries[0].FamilyID, len(family))
+		}
+	}
+}
+
+func TestUIRoutes(t *testing.T) {
+	store := newMemStore(fixtureEntries(t))
+	mux := newServeMux(store, nil)
+	addUIRoutes(mux, store)
+
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/", http.StatusOK, "<title>saoltool</title>"},
+		{"/ui/entry/bil", http.StatusOK, "<td>bilarna</td>"},
+		{"/ui/entry/nej", http.StatusNotFound, ""},
+		{"/family/x", http.StatusBadRequest, ""},
+		{"/family/999", http.StatusNotFound, ""},
+		{"/nothing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.code)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: body lacks %q", tt.path, tt.want)
+		}
+	}
+
+	bil, _, _ := store.GetByID("bil")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/family/"+strconv.Itoa(bil.FamilyID), nil))
+	var family []LexiconEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &family); err != nil || len(family) != 1 || family[0].ID != "bil" {
+		t.Errorf("/family of bil = %v (%v), want bil", family, err)
+	}
+}
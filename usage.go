@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// usageLabels maps the usage and register labels SAOL and SO give, written
+// out or abbreviated, to the label an entry records.
+var usageLabels = map[string]string{
+	"vard.": "vardagligt", "vardagl.": "vardagligt", "vardagligt": "vardagligt", "vardagligen": "vardagligt",
+	"åld.": "ålderdomligt", "ålderdomligt": "ålderdomligt", "åldr.": "åldrat", "åldrat": "åldrat",
+	"prov.": "provinsiellt", "provinsiellt": "provinsiellt", "dial.": "dialektalt", "dialektalt": "dialektalt",
+	"slang": "slang", "skämts.": "skämtsamt", "skämtsamt": "skämtsamt",
+	"neds.": "nedsättande", "nedsättande": "nedsättande", "stötande": "stötande",
+	"högtidl.": "högtidligt", "högtidligt": "högtidligt", "formellt": "formellt", "form.": "formellt",
+	"talspr.": "talspråkligt", "talspråkligt": "talspråkligt", "skriftspr.": "skriftspråkligt", "skriftspråkligt": "skriftspråkligt",
+	"fackspr.": "fackspråkligt", "fackspråkligt": "fackspråkligt", "sällsynt": "sällsynt",
+}
+
+// usageModifiers are the words that can precede a label, as in "ngt åld."
+// (somewhat dated), and are dropped.
+var usageModifiers = map[string]bool{"ngt": true, "något": true, "mest": true, "äv.": true, "även": true, "numera": true}
+
+// usageLabelsIn returns the labels in the text of the profile's usage
+// elements inside s, in order and without repeats. Words that are not a
+// known label are left out.
+func (p SelectorProfile) usageLabelsIn(s *goquery.Selection) []string {
+	if p.Usage == "" {
+		return nil
+	}
+	var labels []string
+	seen := make(map[string]bool)
+	s.Find(p.Usage).Each(func(_ int, u *goquery.Selection) {
+		for _, word := range labelWords(cellText(u)) {
+			if usageModifiers[word] {
+				continue
+			}
+			if label, ok := usageLabels[word]; ok && !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+		}
+	})
+	return labels
+}
+
+// labelWords splits the text of a label element into lower-cased words,
+// dropping the commas, semicolons and parentheses around them.
+func labelWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '(' || r == ')'
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestUsageLabels(t *testing.T) {
+	tests := []struct {
+		html string
+		want []string
+	}{
+		{`<span class="stilruta">vard.</span>`, []string{"vardagligt"}},
+		{`<span class="stilruta">ngt åld., skämts.</span><span class="stilruta">vard. slang</span>`, []string{"ålderdomligt", "skämtsamt", "vardagligt", "slang"}},
+		{`<span class="stilruta">(Vardagligt)</span>`, []string{"vardagligt"}},
+		{`<span class="stilruta">i bildlig mening</span>`, nil},
+		{`<span class="def">vard.</span>`, nil},
+	}
+	for _, tt := range tests {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := selectorProfiles["saol"].usageLabelsIn(doc.Selection); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("usageLabelsIn(%s) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
+
+func TestEntryUsageLabels(t *testing.T) {
+	html := `<span class="orto">kåk</span><span class="ordklass">substantiv</span><span class="bojning">kåken kåkar</span>` +
+		`<div class="kbetydelse"><span class="def">hus</span><span class="stilruta">vard.</span></div>` +
+		`<div class="kbetydelse"><span class="def">fängelse</span><span class="stilruta">slang</span></div>`
+	entry, _, err := newLexiconEntry(context.Background(), "1", LemmaInput{HTML: html, Source: "so"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entry.Labels, []string{"vardagligt", "slang"}) || !reflect.DeepEqual(entry.Senses[1].Labels, []string{"slang"}) {
+		t.Errorf("labels %q, senses %+v", entry.Labels, entry.Senses)
+	}
+
+	filter, err := parseWhere("NOT usage=slang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter(entry) {
+		t.Error("NOT usage=slang kept a slang lemma")
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// outputSchemaVersion is the version of the output formats described by
+// schema/, written into every output and the extract manifest. Bump it
+// when a format changes incompatibly and teach migrate the step.
+//
+//	1  bare JSON arrays; before that flattened_lemmas.json was an object
+//	   keyed by the decimal key, and the forms of verbs.json and
+//	   adjectives.json "form-label" strings
+//	2  {"schemaVersion": 2, "entries": [...]}, forms as objects
+const outputSchemaVersion = 2
+
+// versionedOutput is the envelope of every output file.
+type versionedOutput struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	Entries       interface{} `json:"entries"`
+}
+
+// writeVersionedJSON writes entries to w in the envelope of the current
+// schema version.
+func writeVersionedJSON(w io.Writer, entries interface{}) error {
+	return writeIndentedJSON(w, versionedOutput{SchemaVersion: outputSchemaVersion, Entries: entries})
+}
+
+// checkSchemaVersion refuses files written by a newer saoltool.
+func checkSchemaVersion(version int) error {
+	if version > outputSchemaVersion {
+		return fmt.Errorf("schema version %d is newer than this saoltool's %d", version, outputSchemaVersion)
+	}
+	return nil
+}
+
+// readVersionedEntries returns the entries of an output file and its
+// schema version; a bare array is version 1.
+func readVersionedEntries(data []byte) (version int, entries json.RawMessage, err error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		return 1, data, nil
+	}
+	var v struct {
+		SchemaVersion int             `json:"schemaVersion"`
+		Entries       json.RawMessage `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return 0, nil, err
+	}
+	if v.SchemaVersion == 0 || v.Entries == nil {
+		return 0, nil, fmt.Errorf("want an array or an object with schemaVersion and entries")
+	}
+	return v.SchemaVersion, v.Entries, checkSchemaVersion(v.SchemaVersion)
+}
+
+// fileSchemaVersion reads the schema version of an output file without
+// decoding its entries: the schemaVersion of an object if it has one,
+// else 1.
+func fileSchemaVersion(filename string) (int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, err
+	}
+	if tok != json.Delim('{') {
+		return 1, nil
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return 0, err
+		}
+		if _, err := strconv.Atoi(fmt.Sprint(key)); err == nil {
+			return 1, nil // flattened lemmas keyed by the decimal key
+		}
+		if key == "schemaVersion" {
+			var version int
+			if err := dec.Decode(&version); err != nil {
+				return 0, fmt.Errorf("malformed schemaVersion: %w", err)
+			}
+			return version, nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return 0, err
+		}
+	}
+	return 1, nil
+}
+
+// runMigrate upgrades output files written by an older saoltool to the
+// current schema version, in place or to -out.
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	out := flags.String("out", "", "write the migrated file here instead of replacing it (one file only)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: saoltool migrate [-out file] <file>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 || (*out != "" && flags.NArg() > 1) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	for _, filename := range flags.Args() {
+		target := filename
+		if *out != "" {
+			target = *out
+		}
+		from, err := migrateFile(filename, target)
+		if err != nil {
+			fatal("could not migrate", "file", filename, "err", err)
+		}
+		if from == outputSchemaVersion {
+			slog.Info("already at the current schema version", "file", filename, "version", from)
+			continue
+		}
+		slog.Info("migrated", "file", target, "from", from, "to", outputSchemaVersion)
+	}
+}
+
+// migrateFile writes filename, upgraded to the current schema version, to
+// target and returns the version it had. A file that is already current
+// is left alone.
+func migrateFile(filename, target string) (from int, err error) {
+	if from, err = fileSchemaVersion(filename); err != nil {
+		return 0, err
+	}
+	if err := checkSchemaVersion(from); err != nil || from == outputSchemaVersion {
+		return from, err
+	}
+
+	in, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	tmpFile := target + ".tmp"
+	defer os.Remove(tmpFile)
+	err = saveFile(tmpFile, func(w io.Writer) error {
+		switch filepath.Base(filename) {
+		case outputFile:
+			return migrateFlattened(in, w)
+		case combinedFile:
+			return migrateCombined(in, w)
+		case enrichManifestFile:
+			return migrateManifest(in, w)
+		default:
+			return migrateEntries(in, w)
+		}
+	})
+	if err != nil {
+		return from, err
+	}
+	in.Close()
+	return from, os.Rename(tmpFile, target)
+}
+
+// migrateFlattened rewrites flattened lemmas of any version, renumbering
+// the keys of the old keyed object from 1 as the array has them.
+func migrateFlattened(r io.Reader, w io.Writer) error {
+	out := newLemmaArrayWriter(w)
+	keys := make(map[int]int)
+	err := ForEachLemma(r, func(l Lemma) error {
+		entry := LemmaOutput{
+			HTML:     l.HTML,
+			FamilyID: l.FamilyID,
+			Source:   l.Source,
+			Class:    l.Class,
+			Headword: l.Headword,
+		}
+		if l.DuplicateOf != 0 {
+			entry.DuplicateOf = keys[l.DuplicateOf]
+		}
+		if err := out.write(entry); err != nil {
+			return err
+		}
+		key, _ := strconv.Atoi(l.Key)
+		keys[key] = out.n
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return out.close()
+}
+
+// migrateEntries rewrites a version 1 class file, uninflected.json,
+// lexicon.json or report (the quarantines, the dedup report, the SALDO
+// crosswalk) in the envelope.
+func migrateEntries(r io.Reader, w io.Writer) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, entries, err := readVersionedEntries(data)
+	if err != nil {
+		return err
+	}
+	upgraded, err := upgradeEntries(entries)
+	if err != nil {
+		return err
+	}
+	return writeVersionedJSON(w, upgraded)
+}
+
+// migrateManifest rewrites a version 1 enrich manifest, a bare object, in
+// the envelope.
+func migrateManifest(r io.Reader, w io.Writer) error {
+	var manifest json.RawMessage
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return err
+	}
+	return writeVersionedJSON(w, manifest)
+}
+
+// migrateCombined rewrites a version 1 classes.json, an object of the
+// entries of every class, in the envelope.
+func migrateCombined(r io.Reader, w io.Writer) error {
+	var classes map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&classes); err != nil {
+		return err
+	}
+	for class, entries := range classes {
+		upgraded, err := upgradeEntries(entries)
+		if err != nil {
+			return fmt.Errorf("%s: %w", class, err)
+		}
+		if classes[class], err = json.Marshal(upgraded); err != nil {
+			return err
+		}
+	}
+	return writeIndentedJSON(w, combinedOutput{SchemaVersion: outputSchemaVersion, Classes: classes})
+}
+
+// upgradeEntries turns the "form-label" strings of entries whose forms
+// are still grouped that way into Form objects, as newForm reads them off
+// a parser result; every other entry is kept as it is.
+func upgradeEntries(entries json.RawMessage) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	if err := json.Unmarshal(entries, &all); err != nil {
+		return nil, err
+	}
+	for i, raw := range all {
+		var old struct {
+			Class string              `json:"class"`
+			Forms map[string][]string `json:"forms"`
+		}
+		if json.Unmarshal(raw, &old) != nil || old.Forms == nil {
+			continue
+		}
+		forms := make(map[string][]Form, len(old.Forms))
+		for section, values := range old.Forms {
+			forms[section] = []Form{}
+			for _, v := range values {
+				forms[section] = append(forms[section], newForm(old.Class, v))
+			}
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		var err error
+		if fields["forms"], err = json.Marshal(forms); err != nil {
+			return nil, err
+		}
+		if all[i], err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+	return all, nil
+}
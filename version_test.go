@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadVersionedEntries(t *testing.T) {
+	for doc, want := range map[string]int{
+		` [{"class": "verb"}]`:                        1,
+		`{"schemaVersion": 2, "entries": [{"a": 1}]}`: 2,
+	} {
+		version, entries, err := readVersionedEntries([]byte(doc))
+		if err != nil || version != want || entries[0] != '[' {
+			t.Errorf("%s: version %d, entries %s, %v; want version %d", doc, version, entries, err, want)
+		}
+	}
+	for _, doc := range []string{`{"schemaVersion": 3, "entries": []}`, `{"entries": []}`, `{"schemaVersion": 2}`, `"x"`} {
+		if _, _, err := readVersionedEntries([]byte(doc)); err == nil {
+			t.Errorf("%s: want an error", doc)
+		}
+	}
+}
+
+func TestMigrateClassFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "verbs.json")
+	// verbs.json as saoltool wrote it before forms were objects.
+	old := `[{"class": "verb", "forms": {
+		"Finita former": ["simmar-presens aktiv", "simmade el. sam-preteritum aktiv"],
+		"Infinita former": [], "Presens particip": [], "Perfekt particip": []}}]`
+	os.WriteFile(filename, []byte(old), 0644)
+
+	from, err := migrateFile(filename, filename)
+	if err != nil || from != 1 {
+		t.Fatalf("migrateFile: from %d, %v", from, err)
+	}
+	data, _ := os.ReadFile(filename)
+	checkValid(t, "verbs.json", data)
+	var got []struct {
+		Forms map[string][]Form `json:"forms"`
+	}
+	decodeEntries(t, data, &got)
+	want := []Form{
+		{Form: "simmar", Label: "presens aktiv"},
+		{Form: "simmade", Label: "preteritum aktiv", Variants: []string{"sam"}},
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Forms["Finita former"], want) {
+		t.Errorf("migrated forms %+v, want %+v", got, want)
+	}
+
+	// A current file is left alone.
+	if from, err := migrateFile(filename, filename); err != nil || from != outputSchemaVersion {
+		t.Errorf("second migrateFile: from %d, %v", from, err)
+	}
+	if again, _ := os.ReadFile(filename); string(again) != string(data) {
+		t.Error("migrating a current file changed it")
+	}
+}
+
+func TestMigrateFlattened(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, outputFile)
+	os.WriteFile(filename, []byte(`{
+		"3": {"html": "a", "familyID": 1},
+		"7": {"html": "a", "familyID": 2, "duplicateOf": 3}
+	}`), 0644)
+	target := filepath.Join(dir, "migrated.json")
+	if from, err := migrateFile(filename, target); err != nil || from != 1 {
+		t.Fatalf("migrateFile: from %d, %v", from, err)
+	}
+
+	data, _ := os.ReadFile(target)
+	checkValid(t, outputFile, data)
+	var got []LemmaOutput
+	decodeEntries(t, data, &got)
+	want := []LemmaOutput{
+		{Key: 1, HTML: "a", FamilyID: 1},
+		{Key: 2, HTML: "a", FamilyID: 2, DuplicateOf: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("migrated %+v, want %+v", got, want)
+	}
+}
+
+func TestMigrateCombined(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, combinedFile)
+	os.WriteFile(filename, []byte(`{"adjektiv": [{"class": "adjektiv", "forms": {"Positiv": ["fin"], "Komparativ": ["finare"], "Superlativ": []}}]}`), 0644)
+	if _, err := migrateFile(filename, filename); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filename)
+	checkValid(t, combinedFile, data)
+	var got combinedOutput
+	json.Unmarshal(data, &got)
+	var adjectives []AdjectiveEntry
+	json.Unmarshal(got.Classes["adjektiv"], &adjectives)
+	if len(adjectives) != 1 || !reflect.DeepEqual(adjectives[0].Forms["Komparativ"], []Form{{Form: "finare"}}) {
+		t.Errorf("migrated %s", data)
+	}
+
+	newer := filepath.Join(dir, "nouns.json")
+	os.WriteFile(newer, []byte(`{"schemaVersion": 99, "entries": []}`), 0644)
+	if _, err := migrateFile(newer, newer); err == nil {
+		t.Error("want an error for a file from a newer saoltool")
+	}
+}
+
+func TestMigrateReports(t *testing.T) {
+	dir := t.TempDir()
+	for file, old := range map[string]string{
+		"duplicate_lemmas.json": `[{"key": 5, "familyID": 2, "duplicateOf": 1, "hash": "ab"}]`,
+		enrichManifestFile:      `{"generated": "2024-03-01T12:00:00Z", "entries": 2}`,
+	} {
+		filename := filepath.Join(dir, file)
+		os.WriteFile(filename, []byte(old), 0644)
+		if from, err := migrateFile(filename, filename); err != nil || from != 1 {
+			t.Fatalf("%s: migrated from %d, %v", file, from, err)
+		}
+		data, _ := os.ReadFile(filename)
+		checkValid(t, file, data)
+	}
+}
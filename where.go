@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A where expression filters lexicon entries, for the -where flag of
+// export and search:
+//
+//	class=verb AND section="Perfekt particip"
+//	headword~"^för" AND NOT (class=substantiv OR frequencyBand>2)
+//
+// A comparison is a field, an operator and a value, quoted when it holds
+// anything but letters, digits, "_", "." and "-". = and != compare
+// strings, ~ and !~ match a regular expression anywhere in the value, and
+// < <= > >= compare numbers. A field with several values (form, section,
+// label, translation, usage, domain) compares true when any of its values
+// does; != and !~ are the negations of = and ~. AND binds tighter than OR,
+// and NOT tighter than both; keywords are case-insensitive.
+type whereExpr func(LexiconEntry) bool
+
+// whereFields gives the values of each field of an entry.
+var whereFields = map[string]func(LexiconEntry) []string{
+	"id":            func(e LexiconEntry) []string { return []string{e.ID} },
+	"headword":      func(e LexiconEntry) []string { return []string{e.Headword} },
+	"class":         func(e LexiconEntry) []string { return []string{e.Class} },
+	"paradigm":      func(e LexiconEntry) []string { return []string{e.Paradigm} },
+	"definition":    func(e LexiconEntry) []string { return []string{e.Definition} },
+	"gender":        func(e LexiconEntry) []string { return []string{e.Gender} },
+	"particle":      func(e LexiconEntry) []string { return []string{e.Particle} },
+	"source":        func(e LexiconEntry) []string { return []string{e.Source} },
+	"reflexive":     func(e LexiconEntry) []string { return []string{strconv.FormatBool(e.Reflexive)} },
+	"homograph":     func(e LexiconEntry) []string { return []string{strconv.Itoa(e.Homograph)} },
+	"familyID":      func(e LexiconEntry) []string { return []string{strconv.Itoa(e.FamilyID)} },
+	"frequency":     func(e LexiconEntry) []string { return []string{strconv.Itoa(e.Frequency)} },
+	"frequencyBand": func(e LexiconEntry) []string { return []string{strconv.Itoa(e.FrequencyBand)} },
+	"translation":   func(e LexiconEntry) []string { return e.Translations },
+	"usage":         func(e LexiconEntry) []string { return e.Labels },
+	"domain":        func(e LexiconEntry) []string { return e.Domains },
+	"form":          func(e LexiconEntry) []string { return e.surfaceForms() },
+	"section":       func(e LexiconEntry) []string { return e.sections() },
+	"label": func(e LexiconEntry) []string {
+		var labels []string
+		for _, forms := range e.Forms {
+			for _, f := range forms {
+				labels = append(labels, f.Label)
+			}
+		}
+		return labels
+	},
+}
+
+// parseWhere parses a where expression.
+func parseWhere(s string) (whereExpr, error) {
+	tokens, err := lexWhere(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &whereParser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %s", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// filterWhere keeps the entries expr holds for.
+func filterWhere(entries []LexiconEntry, expr whereExpr) []LexiconEntry {
+	var out []LexiconEntry
+	for _, e := range entries {
+		if expr(e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// whereToken is a token of a where expression; quoted tells a string
+// value from a word.
+type whereToken struct {
+	text   string
+	quoted bool
+}
+
+func (t whereToken) String() string {
+	if t.quoted {
+		return strconv.Quote(t.text)
+	}
+	return t.text
+}
+
+// isSymbol reports whether t is a parenthesis or an operator.
+func (t whereToken) isSymbol() bool {
+	return !t.quoted && strings.IndexByte("()!=~<>", t.text[0]) >= 0
+}
+
+// whereOperators are the comparison operators, longest first.
+var whereOperators = []string{"!=", "!~", "<=", ">=", "=", "~", "<", ">"}
+
+func lexWhere(s string) ([]whereToken, error) {
+	var tokens []whereToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, whereToken{text: s[i : i+1]})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(s) && s[end] != '"'; end++ {
+				if s[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			text, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("bad string at %d: %w", i, err)
+			}
+			tokens = append(tokens, whereToken{text: text, quoted: true})
+			i = end + 1
+		case strings.IndexByte("!=~<>", c) >= 0:
+			op := ""
+			for _, o := range whereOperators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, whereToken{text: op})
+			i += len(op)
+		default:
+			end := strings.IndexFunc(s[i:], func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' && r != '-'
+			})
+			if end == 0 {
+				return nil, fmt.Errorf("unexpected %q at %d", s[i:i+1], i)
+			}
+			if end < 0 {
+				end = len(s) - i
+			}
+			tokens = append(tokens, whereToken{text: s[i : i+end]})
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+// keyword reports whether the next token is the keyword kw, consuming it
+// if so.
+func (p *whereParser) keyword(kw string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) next() (whereToken, error) {
+	if p.pos == len(p.tokens) {
+		return whereToken{}, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *whereParser) or() (whereExpr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e LexiconEntry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) and() (whereExpr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e LexiconEntry) bool { return l(e) && right(e) }
+	}
+	return left, nil
+}
+
+func (p *whereParser) not() (whereExpr, error) {
+	if p.keyword("NOT") {
+		expr, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return func(e LexiconEntry) bool { return !expr(e) }, nil
+	}
+	if p.keyword("(") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+func (p *whereParser) comparison() (whereExpr, error) {
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	values, ok := whereFields[field.text]
+	if !ok || field.quoted {
+		return nil, fmt.Errorf("unknown field %s", field)
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if value.isSymbol() {
+		return nil, fmt.Errorf("missing value after %s %s", field, op)
+	}
+
+	var test func(string) bool
+	negate := false
+	switch op.text {
+	case "=", "!=":
+		test = func(v string) bool { return v == value.text }
+		negate = op.text == "!="
+	case "~", "!~":
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", field, op, err)
+		}
+		test = re.MatchString
+		negate = op.text == "!~"
+	case "<", "<=", ">", ">=":
+		want, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s wants a number, not %s", field, op, value)
+		}
+		test = func(v string) bool {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return false
+			}
+			switch op.text {
+			case "<":
+				return n < want
+			case "<=":
+				return n <= want
+			case ">":
+				return n > want
+			}
+			return n >= want
+		}
+	default:
+		return nil, fmt.Errorf("want an operator after %s, not %s", field, op)
+	}
+	return func(e LexiconEntry) bool {
+		for _, v := range values(e) {
+			if test(v) {
+				return !negate
+			}
+		}
+		return negate
+	}, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func whereEntries() []LexiconEntry {
+	return []LexiconEntry{
+		{ID: "förstå", Headword: "förstå", Class: "verb", FrequencyBand: 1, Forms: map[string][]Form{
+			"Perfekt particip": {{Form: "förstådd", Label: "en"}},
+		}},
+		{ID: "springa", Headword: "springa", Class: "verb", FrequencyBand: 3, Forms: map[string][]Form{
+			"Finita former": {{Form: "springer", Label: "presens"}},
+		}},
+		{ID: "förslag", Headword: "förslag", Class: "substantiv", Gender: "neutrum", Translations: []string{"proposal", "suggestion"}},
+		{ID: "bil", Headword: "bil", Class: "substantiv"},
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`class=verb AND section="Perfekt particip"`, []string{"förstå"}},
+		{`headword~"^för"`, []string{"förstå", "förslag"}},
+		{`headword ~ "^för" and not class = verb`, []string{"förslag"}},
+		{`class=verb OR gender=neutrum AND headword!=förslag`, []string{"förstå", "springa"}},
+		{`(class=verb OR gender=neutrum) AND headword!=förslag`, []string{"förstå", "springa"}},
+		{`frequencyBand>=1 AND frequencyBand<3`, []string{"förstå"}},
+		{`translation=suggestion`, []string{"förslag"}},
+		{`form=springer OR label="en"`, []string{"förstå", "springa"}},
+		{`form!~"er$"`, []string{"förstå", "förslag", "bil"}},
+		{`id="bil"`, []string{"bil"}},
+	}
+	for _, tt := range tests {
+		expr, err := parseWhere(tt.expr)
+		if err != nil {
+			t.Errorf("parseWhere(%q): %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, e := range filterWhere(whereEntries(), expr) {
+			got = append(got, e.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseWhereErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`class`,
+		`class=`,
+		`class=(`,
+		`colour=red`,
+		`class=verb AND`,
+		`(class=verb`,
+		`class=verb)`,
+		`headword~"("`,
+		`frequency>many`,
+		`class verb`,
+		`class="verb`,
+		`class=verb; drop`,
+	} {
+		if _, err := parseWhere(expr); err == nil {
+			t.Errorf("parseWhere(%q): want an error", expr)
+		}
+	}
+}
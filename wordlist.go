@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordlist returns every distinct surface form of entries, NFC-normalized
+// and in Swedish alphabetical order (å, ä and ö after z).
+func wordlist(entries []LexiconEntry) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, e := range entries {
+		for _, f := range e.surfaceForms() {
+			f = norm.NFC.String(f)
+			if f != "" && !seen[f] {
+				seen[f] = true
+				words = append(words, f)
+			}
+		}
+	}
+	collate.New(language.Swedish).SortStrings(words)
+	return words
+}
+
+// writeWordlist writes the wordlist of entries to filename, one form per line.
+func writeWordlist(entries []LexiconEntry, filename string) error {
+	words := wordlist(entries)
+	if len(words) == 0 {
+		return ioutil.WriteFile(filename, nil, 0644)
+	}
+	return ioutil.WriteFile(filename, []byte(strings.Join(words, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	entries := []LexiconEntry{
+		{Headword: "ö", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "öar"}}}},
+		{Headword: "år", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "året"}}}},
+		{Headword: "zon", Class: "substantiv"},
+		// "ä" as a decomposed a + combining diaeresis.
+		{Headword: "a\u0308ng", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "ängen"}}}},
+		{Headword: "bil", Class: "substantiv", Forms: map[string][]Form{"Nominativ": {{Form: "bil"}, {Form: "bilen"}}}},
+	}
+	want := []string{"bil", "bilen", "zon", "år", "året", "äng", "ängen", "ö", "öar"}
+	if got := wordlist(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("wordlist = %q, want %q", got, want)
+	}
+}